@@ -128,6 +128,38 @@ WINDOWS_EXE="$BUILD_DIR/DashboardAgent.exe"
 )
 echo "    DashboardAgent.exe created at $WINDOWS_EXE"
 
+# Native build for Windows on ARM (Surface Pro X, Snapdragon laptops). The
+# amd64 build runs there under emulation, but reads no CPU temperature and
+# costs more CPU; installed amd64 agents switch over on their next update.
+echo "==> Building Windows Agent (arm64)..."
+WINDOWS_ARM64_EXE="$BUILD_DIR/arm64/DashboardAgent.exe"
+mkdir -p "$BUILD_DIR/arm64"
+(
+    cd "$PROJECT_DIR/agent-go"
+    GOOS=windows GOARCH=arm64 go build \
+        -ldflags="-H windowsgui -X main.version=$APP_VERSION" \
+        -o "$WINDOWS_ARM64_EXE" \
+        .
+)
+echo "    DashboardAgent.exe created at $WINDOWS_ARM64_EXE"
+
+# --- Build Windows Installers (needs the WiX Toolset: dotnet tool install --global wix) ---
+if command -v wix >/dev/null 2>&1; then
+    echo "==> Building Windows Installers..."
+    wix extension add -g WixToolset.Util.wixext >/dev/null
+    wix build -arch x64 -ext WixToolset.Util.wixext \
+        -d Version="$APP_VERSION" -d ExePath="$WINDOWS_EXE" \
+        -o "$BUILD_DIR/DashboardAgent-v${APP_VERSION}-windows-amd64.msi" \
+        "$PROJECT_DIR/agent-go/installer/DashboardAgent.wxs"
+    wix build -arch arm64 -ext WixToolset.Util.wixext \
+        -d Version="$APP_VERSION" -d ExePath="$WINDOWS_ARM64_EXE" \
+        -o "$BUILD_DIR/DashboardAgent-v${APP_VERSION}-windows-arm64.msi" \
+        "$PROJECT_DIR/agent-go/installer/DashboardAgent.wxs"
+    echo "    MSIs created in $BUILD_DIR"
+else
+    echo "==> Skipping Windows Installer (wix not found)"
+fi
+
 # --- Build Linux Agent ---
 echo "==> Building Linux Agent..."
 LINUX_BIN="$BUILD_DIR/dashboard-agent"
@@ -141,6 +173,27 @@ LINUX_BIN="$BUILD_DIR/dashboard-agent"
 )
 echo "    dashboard-agent created at $LINUX_BIN"
 
+# --- Build lite agents (BUILD_LITE=1) ---
+# For low-spec machines (32 GB eMMC kiosks): no metrics export, SNMP, GPU
+# probe, or styled HTML report, and stripped of debug info. Lite agents only
+# update from the "-lite" archives.
+if [ "${BUILD_LITE:-0}" = "1" ]; then
+    echo "==> Building lite agents..."
+    mkdir -p "$BUILD_DIR/lite"
+    (
+        cd "$PROJECT_DIR/agent-go"
+        GOOS=windows GOARCH=amd64 go build -tags lite -trimpath \
+            -ldflags="-s -w -H windowsgui -X main.version=$APP_VERSION" \
+            -o "$BUILD_DIR/lite/DashboardAgent.exe" \
+            .
+        GOOS=linux GOARCH=amd64 CGO_ENABLED=0 go build -tags lite -trimpath \
+            -ldflags="-s -w -X main.version=$APP_VERSION" \
+            -o "$BUILD_DIR/lite/dashboard-agent" \
+            .
+    )
+    echo "    Lite agents created in $BUILD_DIR/lite"
+fi
+
 # --- Create release archives ---
 echo "==> Creating release archives..."
 # Use ditto (not zip -r) to preserve symlinks inside Sparkle.framework.
@@ -149,7 +202,12 @@ echo "==> Creating release archives..."
 (cd "$BUILD_DIR" && ditto -c -k --keepParent Dashboard.app "Dashboard-v${APP_VERSION}-universal.zip")
 (cd "$BUILD_DIR" && ditto -c -k --keepParent DashboardAgent.app "DashboardAgent-v${APP_VERSION}-universal.zip")
 (cd "$BUILD_DIR" && zip -j "DashboardAgent-v${APP_VERSION}-windows-amd64.zip" DashboardAgent.exe)
+(cd "$BUILD_DIR/arm64" && zip -j "../DashboardAgent-v${APP_VERSION}-windows-arm64.zip" DashboardAgent.exe)
 (cd "$BUILD_DIR" && zip -j "DashboardAgent-v${APP_VERSION}-linux-amd64.zip" dashboard-agent)
+if [ "${BUILD_LITE:-0}" = "1" ]; then
+    (cd "$BUILD_DIR/lite" && zip -j "../DashboardAgent-lite-v${APP_VERSION}-windows-amd64.zip" DashboardAgent.exe)
+    (cd "$BUILD_DIR/lite" && zip -j "../DashboardAgent-lite-v${APP_VERSION}-linux-amd64.zip" dashboard-agent)
+fi
 
 echo ""
 echo "==> Build complete!"
@@ -0,0 +1,650 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/alerts"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/backup"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/budget"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/clonecheck"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/diag"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/display"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/events"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/files"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/firewall"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/fleet"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/history"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/i18n"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/identity"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/incident"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/instances"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/intercom"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/keepawake"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/kiosk"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/maintenance"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/mdns"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/metrics"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/netready"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/obs"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/pairing"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/peerclock"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/powerevents"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/recordcheck"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/report"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/routines"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/server"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/snapshot"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/startup"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/state"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/update"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/watchdog"
+)
+
+// version is injected at build time via -ldflags "-X main.version=..."
+var version = "dev"
+
+// options are the command-line flags. The Windows watchdog passes them
+// through to the supervised child.
+type options struct {
+	headless   bool
+	configPath string
+	port       uint
+	logLevel   string
+	once       bool
+	simulate   string
+	args       []string // subcommand, e.g. ["config", "validate"]
+}
+
+func parseFlags() options {
+	var o options
+	flag.BoolVar(&o.headless, "headless", false, "run without the tray icon (Windows Server Core, automated tests); Linux is always headless")
+	flag.StringVar(&o.configPath, "config", config.DefaultPath(), "local config file")
+	flag.UintVar(&o.port, "port", 0, "listen on exactly this port instead of the first free one from 49990")
+	flag.StringVar(&o.logLevel, "log-level", "info", "lowest level written to stderr: debug, info, warn, or error")
+	flag.BoolVar(&o.once, "once", false, "print one status snapshot as JSON and exit")
+	flag.StringVar(&o.simulate, "simulate", "", "report scripted fake metrics and faults instead of real ones: "+strings.Join(metrics.Scenarios(), ", "))
+	flag.Usage = usage
+	flag.Parse()
+	o.args = flag.Args()
+	return o
+}
+
+// setup parses flags, configures logging, and loads the config. Bad flags
+// exit with status 2, like the flag package does. Event outputs are left to
+// the caller so subcommands don't write to the event log.
+func setup() (options, *config.Config) {
+	diag.CaptureLog()
+	opts := parseFlags()
+
+	level, err := diag.ParseLevel(opts.logLevel)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	diag.SetLevel(level)
+	if opts.port > 65535 {
+		fmt.Fprintf(os.Stderr, "invalid port %d\n", opts.port)
+		os.Exit(2)
+	}
+	if opts.simulate != "" {
+		if _, err := metrics.NewSimulation(opts.simulate); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
+
+	cfg, err := config.LoadLayered(config.PackPath(), config.FleetPath(), opts.configPath)
+	if err != nil {
+		log.Printf("Config error, using defaults: %v", err)
+	}
+	return opts, cfg
+}
+
+// State keys owned by main.
+const (
+	lastPortKey       = "lastPort"       // port bound last run
+	firewallPromptKey = "firewallPrompt" // port the user was last asked to allow
+)
+
+func statePath() string {
+	return filepath.Join(config.DataDir(), "state.json")
+}
+
+// pinnedPort returns the port that must be bound exactly: --port, else
+// the config's port, else 0.
+func pinnedPort(opts options, cfg *config.Config) uint16 {
+	if opts.port != 0 {
+		return uint16(opts.port)
+	}
+	if cfg.Port > 0 && cfg.Port <= 65535 {
+		return uint16(cfg.Port)
+	}
+	return 0
+}
+
+// displayName labels notifications with the site, since two campuses can
+// both have a "Lyrics PC".
+func displayName(hostname string, site config.SiteConfig) string {
+	switch {
+	case site.Name != "":
+		return hostname + " (" + site.Name + ")"
+	case site.ID != "":
+		return hostname + " (" + site.ID + ")"
+	}
+	return hostname
+}
+
+// agent holds the subsystems shared by the tray and headless modes.
+type agent struct {
+	cfg          *config.Config
+	hostname     string
+	store        *state.Store
+	collector    *metrics.Collector
+	maint        *maintenance.Mode
+	updater      *update.Updater
+	availability *history.Availability
+	history      *history.Recorder
+	alerts       *alerts.Engine
+	pairer       *pairing.Manager
+	server       *server.Server
+	identity     *identity.Monitor
+	instances    []*instances.Instance
+
+	// ctx is cancelled by shutdown; running counts the loops that stop
+	// with it.
+	ctx     context.Context
+	stop    context.CancelFunc
+	running sync.WaitGroup
+
+	// online is closed once the network is usable or the startup wait
+	// runs out; networkUp only once it is usable.
+	online    chan struct{}
+	networkUp chan struct{}
+}
+
+const (
+	// shutdownGrace bounds how long shutdown waits for the loops to stop.
+	shutdownGrace = 2 * time.Second
+	// defaultNetworkWait is how long startup waits for a usable network
+	// before advertising anyway.
+	defaultNetworkWait = 2 * time.Minute
+)
+
+// newAgent creates the subsystems and starts their background loops. The
+// server is not started until serve, so callers can hook the updater first.
+func newAgent(cfg *config.Config, opts options) *agent {
+	hostname, _ := os.Hostname()
+	log.Printf("AVL Dashboard Agent v%s starting on %s", version, hostname)
+
+	store := state.Open(statePath())
+	events.Record(events.Lifecycle, events.Info, "Agent v%s start #%d", version, store.Incr("agentStarts"))
+
+	a := &agent{
+		cfg:       cfg,
+		hostname:  hostname,
+		store:     store,
+		online:    make(chan struct{}),
+		networkUp: make(chan struct{}),
+	}
+	a.ctx, a.stop = context.WithCancel(context.Background())
+	a.collector = metrics.NewCollector(version, cfg)
+	if opts.simulate != "" {
+		sim, _ := metrics.NewSimulation(opts.simulate) // checked in setup
+		a.collector.Simulate(sim)
+		log.Printf("Simulating %q: metrics are fake", opts.simulate)
+	}
+	a.maint = maintenance.New(store)
+	a.collector.SetMaintenance(a.maint)
+	a.spawn(a.collector.Start)
+
+	if guard := budget.New(cfg.Budget, a.collector); guard != nil {
+		go guard.Run()
+	}
+
+	peers := peerclock.New(cfg.PeerClock, cfg.Site.ID, a.collector.CurrentStatus().HardwareUUID)
+	a.collector.SetPeerClock(peers)
+	go peers.Run()
+
+	if awake := keepawake.New(cfg.KeepAwake); awake != nil {
+		a.collector.SetKeepAwake(awake)
+		go awake.Run()
+	}
+
+	player := kiosk.New(cfg.Kiosk)
+	if player != nil {
+		a.collector.SetKiosk(player)
+		go player.Run()
+	}
+
+	if scheduler := routines.New(cfg.Routines, store); scheduler != nil {
+		a.collector.SetRoutines(scheduler)
+		go scheduler.Run()
+	}
+
+	if apps := startup.New(cfg.StartupApps); apps != nil {
+		a.collector.SetStartup(apps)
+		go apps.Run()
+	}
+
+	if studio := obs.New(cfg.OBS); studio != nil {
+		a.collector.SetOBS(studio)
+		go studio.Run()
+	}
+
+	backups := backup.New(cfg.Backup, filepath.Join(config.DataDir(), "backups"), store)
+	if backups != nil {
+		a.collector.SetBackup(backups)
+		go backups.Run()
+	}
+
+	if checker := recordcheck.New(cfg.Recording, a.collector.ServiceContext, store); checker != nil {
+		a.collector.SetRecordCheck(checker)
+		go checker.Run()
+	}
+
+	settings := snapshot.New(cfg.Snapshot, a.collector, store)
+	if settings != nil {
+		go settings.Run()
+	}
+
+	a.identity = identity.New(store)
+	a.collector.SetIdentity(a.identity)
+
+	displays := display.New(cfg.Displays, store)
+	if displays != nil {
+		a.collector.SetDisplays(displays)
+		go displays.Run()
+	}
+
+	a.updater = update.NewUpdater(version)
+	a.updater.SetRollout(cfg.Updates, a.collector.CurrentStatus().HardwareUUID)
+	a.updater.SetReporting(cfg.Updates)
+	a.updater.Resume(func() bool { return a.collector.Health().Healthy })
+	a.collector.SetUpdater(a.updater)
+
+	a.availability = history.NewAvailability(store)
+	go a.availability.Run()
+
+	recorder := history.NewRecorder(filepath.Join(config.DataDir(), "history"), cfg.History)
+	a.history = recorder
+	update.BeforeExit(recorder.Flush)
+	go recorder.Run(a.collector)
+	go recorder.WatchCooling(a.collector)
+
+	a.alerts = alerts.NewEngine(cfg.Alerts, store)
+	a.alerts.AddConfiguredNotifiers(displayName(hostname, cfg.Site))
+	go a.alerts.Run(a.collector)
+
+	incidents := incident.New(cfg.FlightRecorder, filepath.Join(config.DataDir(), "incidents"))
+	if incidents != nil {
+		incidents.Attach(a.alerts)
+		go incidents.Run()
+	}
+
+	a.startIntegrations(map[string]string{"host": hostname, "site": cfg.Site.ID})
+
+	reporter := report.New(report.Deps{
+		Config:       cfg.Report,
+		Email:        cfg.Alerts.Email,
+		Name:         displayName(hostname, cfg.Site),
+		Store:        store,
+		Collector:    a.collector,
+		Availability: a.availability,
+		Alerts:       a.alerts,
+		Updater:      a.updater,
+	})
+	go reporter.Run()
+
+	fleetConfig := fleet.New(cfg.Fleet, config.FleetPath(), store)
+
+	if !cfg.Pairing.Disabled {
+		a.pairer = pairing.New(store, cfg.Pairing.Scope)
+	}
+
+	restart := func() error {
+		a.availability.Heartbeat()
+		return update.Restart()
+	}
+	// renew gives the machine a new hardware UUID after a clone is found;
+	// the restart makes every subsystem and advertisement pick it up.
+	renew := func(reason string) error {
+		uuid, err := metrics.RegenerateHardwareUUID()
+		if err != nil {
+			return err
+		}
+		events.Record(events.Lifecycle, events.Warning, "Hardware UUID is now %s: %s", uuid, reason)
+		return restart()
+	}
+	// A new asset pack's messages apply at once; its config layer, like
+	// the rest of the config, at the next start.
+	a.updater.OnAssets(func(configChanged bool) {
+		if err := i18n.LoadOverrides(filepath.Join(config.AssetsDir(), "i18n")); err != nil {
+			log.Printf("Asset pack messages: %v", err)
+		}
+		if configChanged {
+			events.Record(events.Lifecycle, events.Info, "Restarting for the asset pack's config")
+			if err := restart(); err != nil {
+				events.Record(events.Lifecycle, events.Error, "Restart failed: %v", err)
+			}
+		}
+	})
+	// A new fleet document, like the asset pack's config, applies at the
+	// next start. The restart waits a moment so POST /config can answer.
+	fleetConfig.OnChange(func() {
+		events.Record(events.Lifecycle, events.Info, "Restarting for the fleet config")
+		go func() {
+			time.Sleep(time.Second)
+			if err := restart(); err != nil {
+				events.Record(events.Lifecycle, events.Error, "Restart failed: %v", err)
+			}
+		}()
+	})
+	go fleetConfig.Run()
+	if clones := clonecheck.New(cfg.CloneCheck, a.collector.CurrentStatus().HardwareUUID, renew); clones != nil {
+		go clones.Run()
+	}
+
+	var lastPort uint16
+	store.Get(lastPortKey, &lastPort)
+
+	a.server = server.New(server.Deps{
+		Port:          pinnedPort(opts, cfg),
+		PreferredPort: lastPort,
+		Collector:     a.collector,
+		Updater:       a.updater,
+		Config:        cfg,
+		Availability:  a.availability,
+		History:       recorder,
+		Alerts:        a.alerts,
+		Pairing:       a.pairer,
+		Fleet:         fleetConfig,
+		Maintenance:   a.maint,
+		Report:        reporter,
+		Displays:      displays,
+		Kiosk:         player,
+		Intercom:      intercom.New(),
+		Incidents:     incidents,
+		Backup:        backups,
+		Snapshot:      settings,
+		Files:         files.New(cfg.SharedFolders),
+		Restart:       restart,
+		RenewIdentity: renew,
+	})
+	a.instances = instances.New(cfg.Instances, a.collector.CurrentStatus().HardwareUUID)
+	return a
+}
+
+// serve starts the server and update checks. Once the port is bound it
+// calls ready, which may be nil, and starts mDNS as soon as the network is
+// usable. mDNS and update checks wait for the network (see awaitNetwork).
+func (a *agent) serve(ready func(port uint16)) {
+	a.spawn(func(ctx context.Context) {
+		if err := a.server.ListenAndServe(ctx); err != nil {
+			events.Record(events.Lifecycle, events.Error, "Server stopped: %v", err)
+		}
+	})
+	a.spawn(a.awaitNetwork)
+
+	var instanceServers []*server.Server
+	for _, inst := range a.instances {
+		srv := server.NewInstance(inst, a.cfg, a.pairer)
+		instanceServers = append(instanceServers, srv)
+		a.spawn(func(ctx context.Context) {
+			if err := srv.ListenAndServe(ctx); err != nil {
+				events.Record(events.Lifecycle, events.Error, "Instance %s: server stopped: %v", inst.Name, err)
+			}
+		})
+	}
+
+	a.spawn(func(ctx context.Context) {
+		port := a.server.Port() // blocks until ready
+		if port == 0 {
+			return
+		}
+		log.Printf("Server ready on port %d", port)
+		a.store.Set(lastPortKey, port)
+		watchdog.ReportPort(port)
+		if !a.cfg.Firewall.Disabled {
+			go a.ensureFirewall(port)
+		}
+		if ready != nil {
+			ready(port)
+		}
+
+		select {
+		case <-a.online:
+		case <-ctx.Done():
+			return
+		}
+		advertiser := mdns.Advertise(ctx, a.hostname, port, mdns.Identity{
+			HardwareUUID: a.collector.CurrentStatus().HardwareUUID,
+			Version:      version,
+			SiteID:       a.cfg.Site.ID,
+			SiteName:     a.cfg.Site.Name,
+			Ring:         a.updater.Ring(),
+			Channel:      a.updater.Channel(),
+		})
+		advertisers := append([]*mdns.Advertiser{advertiser}, a.advertiseInstances(ctx, instanceServers)...)
+		a.watchPower(advertisers...)
+		// A new address needs announcing; a new hostname, a new name.
+		a.identity.OnChange(advertiser.Rename)
+		a.updater.OnChannel(advertiser.SetChannel)
+		if a.waitingForNetwork() {
+			// The startup wait ran out: announce again with the addresses
+			// the network brings.
+			go func() {
+				select {
+				case <-a.networkUp:
+					for _, adv := range advertisers {
+						adv.Announce()
+					}
+				case <-ctx.Done():
+				}
+			}()
+		}
+
+		// Advertise withdraws on cancel too; waiting here holds shutdown
+		// until the goodbye is sent.
+		<-ctx.Done()
+		for _, adv := range advertisers {
+			adv.Withdraw()
+		}
+	})
+
+	a.spawn(func(ctx context.Context) {
+		select {
+		case <-a.online:
+			a.updater.StartPeriodicChecks(ctx)
+		case <-ctx.Done():
+		}
+	})
+	a.spawn(func(ctx context.Context) {
+		select {
+		case <-a.online:
+			a.identity.Run(ctx)
+		case <-ctx.Done():
+		}
+	})
+}
+
+// advertiseInstances advertises each instance whose port was bound. The
+// first status fetch is tried before advertising, so the record carries
+// the machine's own hardware UUID when its agent answers.
+func (a *agent) advertiseInstances(ctx context.Context, servers []*server.Server) []*mdns.Advertiser {
+	var advertisers []*mdns.Advertiser
+	for i, srv := range servers {
+		inst := a.instances[i]
+		port := srv.Port()
+		if port == 0 {
+			continue
+		}
+		if _, err := inst.Status(ctx); err != nil {
+			events.Record(events.Lifecycle, events.Warning, "Instance %s: status unavailable: %v", inst.Name, err)
+		}
+		advertisers = append(advertisers, mdns.Advertise(ctx, inst.Name, port, mdns.Identity{
+			HardwareUUID: inst.UUID(),
+			Version:      version,
+			SiteID:       a.cfg.Site.ID,
+			SiteName:     a.cfg.Site.Name,
+			Proxy:        true,
+		}))
+	}
+	return advertisers
+}
+
+// awaitNetwork waits for a usable IPv4 address, up to the configured
+// startup wait. An agent launched at boot before DHCP finishes would
+// otherwise advertise an mDNS record with no reachable address and fail
+// its first update check. When the wait runs out it closes online anyway,
+// so a machine with an unusual network still starts, and keeps waiting
+// for networkUp.
+func (a *agent) awaitNetwork(ctx context.Context) {
+	wait := time.Duration(a.cfg.Network.StartupWaitSeconds) * time.Second
+	if wait == 0 {
+		wait = defaultNetworkWait
+	}
+	if wait < 0 || netready.Usable() {
+		close(a.online)
+		close(a.networkUp)
+		return
+	}
+
+	log.Printf("Network: waiting up to %v for a usable address", wait)
+	start := time.Now()
+	ready := netready.Wait(ctx, wait)
+	close(a.online)
+	if !ready {
+		if ctx.Err() != nil {
+			return
+		}
+		events.Record(events.Lifecycle, events.Warning, "Network: no usable address after %v; starting without one", wait)
+		if !netready.Wait(ctx, 0) {
+			return
+		}
+	}
+	events.Record(events.Lifecycle, events.Info, "Network: usable after %v", time.Since(start).Round(time.Second))
+	close(a.networkUp)
+}
+
+// waitingForNetwork reports whether the machine has yet to get a usable
+// network address.
+func (a *agent) waitingForNetwork() bool {
+	select {
+	case <-a.networkUp:
+		return false
+	default:
+		return true
+	}
+}
+
+// spawn runs loop in the background until shutdown.
+func (a *agent) spawn(loop func(ctx context.Context)) {
+	a.running.Add(1)
+	go func() {
+		defer a.running.Done()
+		loop(a.ctx)
+	}()
+}
+
+// shutdown records the last heartbeat and writes the queued history,
+// then stops the server, collector, updater, and mDNS record and waits
+// briefly for them to finish.
+func (a *agent) shutdown() {
+	a.availability.Heartbeat()
+	a.history.Flush()
+	a.stop()
+	done := make(chan struct{})
+	go func() {
+		a.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(shutdownGrace):
+		log.Printf("Shutdown: gave up waiting after %v", shutdownGrace)
+	}
+}
+
+// watchPower withdraws the mDNS records before sleep and, on resume,
+// re-announces them and collects fresh metrics so the dashboard never shows
+// pre-sleep numbers as current.
+func (a *agent) watchPower(advertisers ...*mdns.Advertiser) {
+	err := powerevents.Watch(
+		func() {
+			events.Record(events.Lifecycle, events.Info, "Power: suspending")
+			for _, adv := range advertisers {
+				adv.Withdraw()
+			}
+		},
+		func() {
+			events.Record(events.Lifecycle, events.Info, "Power: resumed")
+			a.collector.Refresh()
+			for _, adv := range advertisers {
+				adv.Announce()
+			}
+		},
+	)
+	if err != nil {
+		log.Printf("Power: sleep notifications unavailable: %v", err)
+	}
+}
+
+// ensureFirewall opens the firewall for port. An agent that isn't elevated
+// prompts for rights once per port, so a declined prompt doesn't come back
+// every start.
+func (a *agent) ensureFirewall(port uint16) {
+	ok, err := firewall.Check(port)
+	if err != nil {
+		log.Printf("Firewall: check failed: %v", err)
+		return
+	}
+	if ok {
+		return
+	}
+	var prompted uint16
+	a.store.Get(firewallPromptKey, &prompted)
+	prompt := prompted != port
+	if prompt {
+		a.store.Set(firewallPromptKey, port)
+	}
+	if err := firewall.Ensure(port, prompt); err != nil {
+		events.Record(events.Lifecycle, events.Warning, "Firewall: rules for port %d not created: %v", port, err)
+		return
+	}
+	events.Record(events.Lifecycle, events.Info, "Firewall: allowed inbound TCP %d and mDNS", port)
+}
+
+// openPairing opens a pairing window at startup if nothing is paired yet.
+// Headless agents have no tray to show a code, so it goes to the console.
+// The log only notes the window: log files get shared in support bundles.
+func (a *agent) openPairing() {
+	if a.pairer != nil && len(a.pairer.List()) == 0 {
+		code := a.pairer.Open(10 * time.Minute)
+		_, expires := a.pairer.Code()
+		log.Printf("Pairing: window open until %s", expires.Format("15:04"))
+		fmt.Printf("Pairing code: %s (valid 10 minutes)\n", code)
+	}
+}
+
+// runHeadless blocks until SIGINT or SIGTERM (systemd sends SIGTERM on
+// stop).
+func (a *agent) runHeadless() {
+	a.openPairing()
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	received := <-sig
+	events.Record(events.Lifecycle, events.Info, "Received %s, shutting down", received)
+	a.shutdown()
+}
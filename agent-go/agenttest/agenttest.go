@@ -0,0 +1,153 @@
+// Package agenttest runs an in-process agent for end-to-end tests: the real
+// HTTP server, alert engine, and maintenance mode on an ephemeral localhost
+// port, with metrics supplied by the test instead of read from the machine.
+//
+//	a, err := agenttest.New(nil)
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	defer a.Close()
+//
+//	status := agenttest.Status()
+//	status.CPUTempCelsius = 99
+//	a.SetStatus(status)
+//	resp, err := a.Get("/alerts")
+package agenttest
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/alerts"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/maintenance"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/metrics"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/server"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/state"
+)
+
+const (
+	// Version is the agent version the test agent reports.
+	Version = "0.0.0-test"
+	// Token is the admin token of an agent started with the default
+	// config, so remote actions work.
+	Token = "agenttest-token"
+)
+
+// Agent is a running in-process agent.
+type Agent struct {
+	// URL is the server's base URL, e.g. http://127.0.0.1:53211.
+	URL string
+
+	Config      *config.Config
+	Collector   *metrics.Collector
+	Alerts      *alerts.Engine
+	Maintenance *maintenance.Mode
+	Store       *state.Store
+
+	dir    string
+	server *server.Server
+
+	mu     sync.Mutex
+	status metrics.MachineStatus
+}
+
+// Status returns a healthy snapshot to start from and modify.
+func Status() metrics.MachineStatus {
+	return metrics.MachineStatus{
+		HardwareUUID:    "00000000-0000-0000-0000-000000000001",
+		Hostname:        "agenttest",
+		CPUTempCelsius:  50,
+		CPUUsagePercent: 10,
+		RAMUsagePercent: 40,
+		RAMTotalGB:      16,
+		UptimeSeconds:   3600,
+		OSVersion:       "agenttest",
+		ChipType:        "agenttest",
+		Networks: []metrics.NetworkInfo{
+			{InterfaceName: "Ethernet", IPAddress: "192.0.2.10", MACAddress: "00:00:5e:00:53:01", InterfaceType: "ethernet"},
+		},
+		Volumes: []metrics.VolumeUsage{
+			{Mount: "C:\\", TotalBytes: 500e9, FreeBytes: 250e9, UsedPercent: 50},
+		},
+	}
+}
+
+// New starts an agent serving cfg (defaults with AuthToken set to Token if
+// nil) and reporting Status() until SetStatus. State is kept in a temporary
+// directory removed by Close.
+func New(cfg *config.Config) (*Agent, error) {
+	if cfg == nil {
+		cfg = &config.Config{AuthToken: Token}
+	}
+	dir, err := os.MkdirTemp("", "agenttest-")
+	if err != nil {
+		return nil, err
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	a := &Agent{Config: cfg, dir: dir, status: Status()}
+	a.Store = state.Open(filepath.Join(dir, "state.json"))
+	a.Collector = metrics.NewCollectorFrom(Version, cfg, a.current)
+	a.Maintenance = maintenance.New(a.Store)
+	a.Collector.SetMaintenance(a.Maintenance)
+	a.Alerts = alerts.NewEngine(cfg.Alerts, a.Store)
+
+	a.server = server.NewWithListener(server.Deps{
+		Collector:   a.Collector,
+		Config:      cfg,
+		Alerts:      a.Alerts,
+		Maintenance: a.Maintenance,
+	}, l)
+	go a.server.ListenAndServe(context.Background())
+	a.URL = "http://" + l.Addr().String()
+	return a, nil
+}
+
+func (a *Agent) current() metrics.MachineStatus {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.status
+}
+
+// SetStatus makes s the reported snapshot and evaluates alerts against it,
+// so the next request sees both.
+func (a *Agent) SetStatus(s metrics.MachineStatus) {
+	a.mu.Lock()
+	a.status = s
+	a.mu.Unlock()
+	a.Collector.Refresh()
+	a.Alerts.Check(a.Collector.CurrentStatus())
+}
+
+// Get requests path from the agent, with the config's admin token if set.
+func (a *Agent) Get(path string) (*http.Response, error) {
+	return a.Do("GET", path, nil)
+}
+
+// Do sends a request to the agent, with the config's admin token if set.
+func (a *Agent) Do(method, path string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(method, a.URL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if a.Config.AuthToken != "" {
+		req.Header.Set("Authorization", "Bearer "+a.Config.AuthToken)
+	}
+	return http.DefaultClient.Do(req)
+}
+
+// Close stops the server and removes the agent's state.
+func (a *Agent) Close() {
+	a.server.Close()
+	os.RemoveAll(a.dir)
+}
@@ -0,0 +1,110 @@
+package agenttest_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/agenttest"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/alerts"
+)
+
+func getJSON(t *testing.T, a *agenttest.Agent, path string, v any) {
+	t.Helper()
+	resp, err := a.Get(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET %s: status %d", path, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatalf("GET %s: %v", path, err)
+	}
+}
+
+func TestStatusReportsInjectedMetrics(t *testing.T) {
+	a, err := agenttest.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	status := agenttest.Status()
+	status.CPUUsagePercent = 73
+	a.SetStatus(status)
+
+	var got struct {
+		Hostname        string  `json:"hostname"`
+		CPUUsagePercent float64 `json:"cpuUsagePercent"`
+	}
+	getJSON(t, a, "/status", &got)
+	if got.Hostname != status.Hostname || got.CPUUsagePercent != 73 {
+		t.Errorf("GET /status = %+v, want hostname %q and cpuUsagePercent 73", got, status.Hostname)
+	}
+}
+
+func TestOverheatingRaisesAndResolvesAlert(t *testing.T) {
+	a, err := agenttest.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	status := agenttest.Status()
+	status.CPUTempCelsius = 99
+	a.SetStatus(status)
+
+	var list []alerts.Alert
+	getJSON(t, a, "/alerts", &list)
+	if !hasActive(list, "temp:cpu") {
+		t.Fatalf("GET /alerts = %+v, want an active temp:cpu alert", list)
+	}
+
+	a.SetStatus(agenttest.Status())
+	list = nil
+	getJSON(t, a, "/alerts", &list)
+	if hasActive(list, "temp:cpu") {
+		t.Errorf("GET /alerts = %+v, want temp:cpu resolved", list)
+	}
+}
+
+func TestMaintenanceNeedsToken(t *testing.T) {
+	a, err := agenttest.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	resp, err := http.Post(a.URL+"/maintenance", "application/json", strings.NewReader(`{"minutes": 30}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("POST /maintenance without a token: status %d, want 401", resp.StatusCode)
+	}
+
+	resp, err = a.Do("POST", "/maintenance", strings.NewReader(`{"minutes": 30, "reason": "rebuild"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("POST /maintenance: status %d", resp.StatusCode)
+	}
+	if w := a.Maintenance.Current(); w == nil || w.Reason != "rebuild" {
+		t.Errorf("maintenance window = %+v, want one for \"rebuild\"", w)
+	}
+}
+
+func hasActive(list []alerts.Alert, key string) bool {
+	for _, alert := range list {
+		if alert.Key == key && alert.Active {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,370 @@
+// Package alerts turns problems found in the status snapshot into alerts
+// and decides when to notify about them: quiet hours, escalation,
+// de-duplication, and acknowledgement.
+package alerts
+
+import (
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/events"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/metrics"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/planningcenter"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/state"
+)
+
+// Severity levels, lowest to highest.
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+const (
+	stateKey              = "alerts"
+	evaluateInterval      = 5 * time.Second
+	defaultEscalateAfter  = 30 * time.Minute
+	defaultRepeatInterval = time.Hour
+)
+
+// Condition is a problem found in one evaluation pass. Key identifies the
+// problem across passes (e.g. "check:Sunday playlist").
+type Condition struct {
+	Key      string
+	Severity string
+	Message  string
+}
+
+// Alert is a condition's lifecycle. Alerts are sticky: a resolved alert
+// stays listed until acknowledged, so a problem that flapped overnight is
+// still visible in the morning.
+type Alert struct {
+	Key          string     `json:"key"`
+	Severity     string     `json:"severity"` // effective severity after escalation
+	BaseSeverity string     `json:"baseSeverity"`
+	Message      string     `json:"message"`
+	Active       bool       `json:"active"`
+	Occurrences  int        `json:"occurrences"`
+	FirstSeen    time.Time  `json:"firstSeen"`
+	LastSeen     time.Time  `json:"lastSeen"`
+	ResolvedAt   *time.Time `json:"resolvedAt,omitempty"`
+	Acknowledged bool       `json:"acknowledged"`
+	AckedAt      *time.Time `json:"ackedAt,omitempty"`
+	LastNotified *time.Time `json:"lastNotified,omitempty"`
+}
+
+// Notifier delivers alerts to people. Resolved alerts are delivered once
+// with Active false.
+type Notifier interface {
+	Notify(a Alert) error
+}
+
+// Engine tracks alerts and applies the routing policy. Safe for concurrent use.
+type Engine struct {
+	cfg   config.AlertsConfig
+	store *state.Store
+
+	mu        sync.Mutex
+	alerts    map[string]*Alert
+	notifiers []Notifier
+	onRaise   []func(Alert)
+}
+
+// NewEngine creates an engine, restoring alerts (and their acknowledgements)
+// from the state store.
+func NewEngine(cfg config.AlertsConfig, store *state.Store) *Engine {
+	e := &Engine{cfg: cfg, store: store, alerts: make(map[string]*Alert)}
+	var saved []Alert
+	if store.Get(stateKey, &saved) {
+		for i := range saved {
+			e.alerts[saved[i].Key] = &saved[i]
+		}
+	}
+	return e
+}
+
+// AddNotifier registers a delivery channel.
+func (e *Engine) AddNotifier(n Notifier) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.notifiers = append(e.notifiers, n)
+}
+
+// OnRaise registers a callback run (under the engine lock, so it must not
+// call back into the engine) each time an alert is raised or re-raised.
+func (e *Engine) OnRaise(fn func(Alert)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.onRaise = append(e.onRaise, fn)
+}
+
+// Run evaluates the collector's snapshot every 5 seconds. Evaluation is
+// paused during maintenance: alerts neither raise nor resolve until the
+// window closes. Blocks forever.
+func (e *Engine) Run(collector *metrics.Collector) {
+	ticker := time.NewTicker(evaluateInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		e.Check(collector.CurrentStatus())
+	}
+}
+
+// Check evaluates one status snapshot now, as each tick of Run does. It
+// does nothing during maintenance.
+func (e *Engine) Check(status metrics.MachineStatus) {
+	if status.Maintenance != nil {
+		return
+	}
+	e.Update(Evaluate(status), phaseOf(status), time.Now())
+}
+
+// List returns all alerts, active first, then newest first.
+func (e *Engine) List() []Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	out := make([]Alert, 0, len(e.alerts))
+	for _, a := range e.alerts {
+		out = append(out, *a)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Active != out[j].Active {
+			return out[i].Active
+		}
+		return out[i].LastSeen.After(out[j].LastSeen)
+	})
+	return out
+}
+
+// Ack acknowledges the alert with the given key. Returns false if there is
+// no such alert.
+func (e *Engine) Ack(key string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	a, ok := e.alerts[key]
+	if !ok {
+		return false
+	}
+	e.ack(a, time.Now())
+	e.save()
+	return true
+}
+
+// AckAll acknowledges every alert and returns how many were acknowledged.
+func (e *Engine) AckAll() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := time.Now()
+	n := 0
+	for _, a := range e.alerts {
+		if !a.Acknowledged {
+			e.ack(a, now)
+			n++
+		}
+	}
+	e.save()
+	return n
+}
+
+// ack marks a acknowledged; resolved alerts are dropped. Caller holds e.mu.
+func (e *Engine) ack(a *Alert, now time.Time) {
+	events.Record(events.Alert, events.Info, "Alert acknowledged: %s", a.Key)
+	if !a.Active {
+		delete(e.alerts, a.Key)
+		return
+	}
+	a.Acknowledged = true
+	a.AckedAt = &now
+}
+
+// Update reconciles alerts with the conditions found in one pass and sends
+// whatever notifications the policy allows. phase is the Planning Center
+// service phase ("" when the integration is off).
+func (e *Engine) Update(conds []Condition, phase string, now time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	changed := false
+	seen := make(map[string]bool, len(conds))
+	for _, c := range conds {
+		seen[c.Key] = true
+		a, ok := e.alerts[c.Key]
+		if !ok {
+			a = &Alert{Key: c.Key, FirstSeen: now}
+			e.alerts[c.Key] = a
+		}
+		raised := !a.Active
+		if raised {
+			// New, or a resolved alert firing again: a fresh occurrence
+			// needs a fresh acknowledgement.
+			a.Active = true
+			a.Occurrences++
+			a.ResolvedAt = nil
+			a.Acknowledged, a.AckedAt = false, nil
+			a.LastNotified = nil
+			events.Record(events.Alert, eventLevel(c.Severity), "Alert raised: %s: %s", c.Key, c.Message)
+			changed = true
+		}
+		a.BaseSeverity, a.Message, a.LastSeen = c.Severity, c.Message, now
+		if raised {
+			for _, fn := range e.onRaise {
+				fn(*a)
+			}
+		}
+		if sev := e.effectiveSeverity(a, phase, now); sev != a.Severity {
+			if sev == SeverityCritical {
+				a.LastNotified = nil // escalation re-notifies
+				if a.Severity != "" {
+					events.Record(events.Alert, events.Error, "Alert escalated: %s", a.Key)
+				}
+			}
+			a.Severity = sev
+			changed = true
+		}
+		if e.shouldNotify(a, phase, now) {
+			e.notify(a, now)
+			changed = true
+		}
+	}
+
+	for key, a := range e.alerts {
+		if seen[key] || !a.Active {
+			continue
+		}
+		a.Active = false
+		a.ResolvedAt = &now
+		events.Record(events.Alert, events.Info, "Alert resolved: %s", key)
+		if a.LastNotified != nil {
+			e.notify(a, now)
+		}
+		if a.Acknowledged {
+			delete(e.alerts, key)
+		}
+		changed = true
+	}
+
+	if changed {
+		e.save()
+	}
+}
+
+// effectiveSeverity escalates unacknowledged warnings to critical once they
+// have been active long enough, or immediately around a service.
+func (e *Engine) effectiveSeverity(a *Alert, phase string, now time.Time) string {
+	if a.BaseSeverity != SeverityWarning || a.Acknowledged {
+		return a.BaseSeverity
+	}
+	if phase == planningcenter.PhasePreService || phase == planningcenter.PhaseInService {
+		return SeverityCritical
+	}
+	escalateAfter := defaultEscalateAfter
+	if e.cfg.EscalateAfterMinutes > 0 {
+		escalateAfter = time.Duration(e.cfg.EscalateAfterMinutes) * time.Minute
+	}
+	if now.Sub(a.FirstSeen) >= escalateAfter {
+		return SeverityCritical
+	}
+	return SeverityWarning
+}
+
+// shouldNotify applies acknowledgement, de-duplication, and quiet hours.
+// Info alerts are recorded but never pushed.
+func (e *Engine) shouldNotify(a *Alert, phase string, now time.Time) bool {
+	if a.Acknowledged || a.Severity == SeverityInfo {
+		return false
+	}
+	if a.LastNotified != nil {
+		repeat := defaultRepeatInterval
+		if e.cfg.RepeatMinutes > 0 {
+			repeat = time.Duration(e.cfg.RepeatMinutes) * time.Minute
+		}
+		if now.Sub(*a.LastNotified) < repeat {
+			return false
+		}
+	}
+	// Quiet hours hold back warnings (they go out when quiet hours end)
+	// but never apply around a service.
+	if a.Severity != SeverityCritical && phase != planningcenter.PhasePreService &&
+		phase != planningcenter.PhaseInService && inQuietHours(e.cfg.QuietHours, now) {
+		return false
+	}
+	return true
+}
+
+// notify sends a to every notifier in the background so a slow transport
+// can't stall evaluation. Caller holds e.mu.
+func (e *Engine) notify(a *Alert, now time.Time) {
+	a.LastNotified = &now
+	for _, n := range e.notifiers {
+		go func(n Notifier, a Alert) {
+			if err := n.Notify(a); err != nil {
+				log.Printf("Alert notify failed (%T): %v", n, err)
+			}
+		}(n, *a)
+	}
+}
+
+// save persists alerts. Caller holds e.mu.
+func (e *Engine) save() {
+	list := make([]Alert, 0, len(e.alerts))
+	for _, a := range e.alerts {
+		list = append(list, *a)
+	}
+	if err := e.store.Set(stateKey, list); err != nil {
+		log.Printf("State: save alerts failed: %v", err)
+	}
+}
+
+// inQuietHours reports whether now falls in the configured local-time
+// window, which may span midnight.
+func inQuietHours(q *config.QuietHours, now time.Time) bool {
+	if q == nil {
+		return false
+	}
+	start, ok1 := parseClock(q.Start)
+	end, ok2 := parseClock(q.End)
+	if !ok1 || !ok2 || start == end {
+		return false
+	}
+	minute := now.Hour()*60 + now.Minute()
+	if start < end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end
+}
+
+// parseClock parses "HH:MM" into minutes after midnight.
+func parseClock(s string) (int, bool) {
+	h, m, ok := strings.Cut(s, ":")
+	if !ok {
+		return 0, false
+	}
+	hour, err1 := strconv.Atoi(h)
+	min, err2 := strconv.Atoi(m)
+	if err1 != nil || err2 != nil || hour < 0 || hour > 23 || min < 0 || min > 59 {
+		return 0, false
+	}
+	return hour*60 + min, true
+}
+
+// eventLevel maps alert severity to an event log level.
+func eventLevel(severity string) events.Level {
+	switch severity {
+	case SeverityCritical:
+		return events.Error
+	case SeverityWarning:
+		return events.Warning
+	}
+	return events.Info
+}
+
+func phaseOf(status metrics.MachineStatus) string {
+	if status.Service == nil {
+		return ""
+	}
+	return status.Service.Phase
+}
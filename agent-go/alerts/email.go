@@ -0,0 +1,108 @@
+//go:build !lite
+
+package alerts
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+)
+
+// Email sends alerts over SMTP. Port 465 uses implicit TLS; other ports
+// upgrade with STARTTLS when the server offers it.
+type Email struct {
+	cfg      config.EmailConfig
+	hostname string
+}
+
+// NewEmail creates an SMTP notifier.
+func NewEmail(cfg config.EmailConfig, hostname string) *Email {
+	if cfg.Port == 0 {
+		cfg.Port = 587
+	}
+	return &Email{cfg: cfg, hostname: hostname}
+}
+
+// Notify mails a to every recipient whose severity filter it passes.
+func (m *Email) Notify(a Alert) error {
+	to := recipientsFor(m.cfg.Recipients, a.Severity)
+	if len(to) == 0 {
+		return nil
+	}
+
+	body := fmt.Sprintf("%s\r\n\r\nMachine: %s\r\nAlert: %s\r\nSeverity: %s\r\nFirst seen: %s\r\n",
+		a.Message, m.hostname, a.Key, a.Severity, a.FirstSeen.Local().Format(time.RFC1123))
+	return m.send(to, summary(m.hostname, a), "text/plain", body)
+}
+
+// SendHTML mails an HTML document, such as the weekly report, to the given
+// addresses using the alert SMTP settings.
+func (m *Email) SendHTML(to []string, subject, body string) error {
+	return m.send(to, subject, "text/html", body)
+}
+
+func (m *Email) send(to []string, subject, contentType, body string) error {
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\nContent-Type: %s; charset=utf-8\r\n\r\n%s",
+		m.cfg.From, strings.Join(to, ", "), subject, time.Now().Format(time.RFC1123Z), contentType, body)
+
+	addr := net.JoinHostPort(m.cfg.Host, strconv.Itoa(m.cfg.Port))
+	var auth smtp.Auth
+	if m.cfg.Username != "" {
+		auth = smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+	}
+	if m.cfg.Port != 465 {
+		return smtp.SendMail(addr, auth, m.cfg.From, to, []byte(msg))
+	}
+
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 15 * time.Second}, "tcp", addr, &tls.Config{ServerName: m.cfg.Host})
+	if err != nil {
+		return err
+	}
+	client, err := smtp.NewClient(conn, m.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+	if auth != nil {
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(m.cfg.From); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := client.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write([]byte(msg)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// recipientsFor returns the addresses whose minimum severity a meets.
+func recipientsFor(recipients []config.Recipient, severity string) []string {
+	var out []string
+	for _, r := range recipients {
+		if severityAtLeast(severity, r.MinSeverity) {
+			out = append(out, r.Address)
+		}
+	}
+	return out
+}
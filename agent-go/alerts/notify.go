@@ -0,0 +1,30 @@
+package alerts
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AddConfiguredNotifiers registers every notification transport in the
+// alerts config. A transport with a bad configuration is logged and skipped.
+func (e *Engine) AddConfiguredNotifiers(hostname string) {
+	e.addTransports(hostname)
+	if !e.cfg.DisableToasts {
+		e.AddNotifier(Toast{hostname: hostname})
+	}
+}
+
+// summary is the one-line text used by chat formats and other transports.
+func summary(hostname string, a Alert) string {
+	if !a.Active {
+		return fmt.Sprintf("[RESOLVED] %s: %s", hostname, a.Message)
+	}
+	return fmt.Sprintf("[%s] %s: %s", strings.ToUpper(a.Severity), hostname, a.Message)
+}
+
+// severityAtLeast reports whether severity meets min. An empty min passes
+// everything.
+func severityAtLeast(severity, min string) bool {
+	rank := map[string]int{SeverityInfo: 0, SeverityWarning: 1, SeverityCritical: 2}
+	return rank[strings.ToLower(severity)] >= rank[strings.ToLower(min)]
+}
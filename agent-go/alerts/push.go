@@ -0,0 +1,108 @@
+//go:build !lite
+
+package alerts
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+)
+
+const (
+	defaultNtfyServer = "https://ntfy.sh"
+	pushoverEndpoint  = "https://api.pushover.net/1/messages.json"
+)
+
+// Ntfy publishes alerts to an ntfy topic.
+type Ntfy struct {
+	cfg      config.NtfyConfig
+	hostname string
+	client   *http.Client
+}
+
+// NewNtfy creates an ntfy notifier. The server defaults to ntfy.sh.
+func NewNtfy(cfg config.NtfyConfig, hostname string) *Ntfy {
+	if cfg.Server == "" {
+		cfg.Server = defaultNtfyServer
+	}
+	return &Ntfy{cfg: cfg, hostname: hostname, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Notify publishes a if it meets the minimum severity.
+func (n *Ntfy) Notify(a Alert) error {
+	if !severityAtLeast(a.Severity, n.cfg.MinSeverity) {
+		return nil
+	}
+	endpoint := strings.TrimRight(n.cfg.Server, "/") + "/" + url.PathEscape(n.cfg.Topic)
+	req, _ := http.NewRequest("POST", endpoint, strings.NewReader(a.Message))
+	req.Header.Set("Title", fmt.Sprintf("%s: %s", n.hostname, a.Key))
+
+	// ntfy priorities: 3 default, 4 high, 5 urgent (bypasses Do Not Disturb)
+	switch {
+	case !a.Active:
+		req.Header.Set("Priority", "3")
+		req.Header.Set("Tags", "white_check_mark")
+	case a.Severity == SeverityCritical:
+		req.Header.Set("Priority", "5")
+		req.Header.Set("Tags", "rotating_light")
+	default:
+		req.Header.Set("Priority", "4")
+		req.Header.Set("Tags", "warning")
+	}
+	if n.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.cfg.Token)
+	}
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("ntfy returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Pushover sends alerts through the Pushover API.
+type Pushover struct {
+	cfg      config.PushoverConfig
+	hostname string
+	client   *http.Client
+}
+
+// NewPushover creates a Pushover notifier.
+func NewPushover(cfg config.PushoverConfig, hostname string) *Pushover {
+	return &Pushover{cfg: cfg, hostname: hostname, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Notify sends a if it meets the minimum severity.
+func (p *Pushover) Notify(a Alert) error {
+	if !severityAtLeast(a.Severity, p.cfg.MinSeverity) {
+		return nil
+	}
+	// Pushover priorities: 0 normal, 1 high (bypasses quiet hours)
+	priority := "0"
+	if a.Active && a.Severity == SeverityCritical {
+		priority = "1"
+	}
+	form := url.Values{
+		"token":    {p.cfg.AppToken},
+		"user":     {p.cfg.UserKey},
+		"title":    {fmt.Sprintf("%s: %s", p.hostname, a.Key)},
+		"message":  {summary(p.hostname, a)},
+		"priority": {priority},
+	}
+	resp, err := p.client.PostForm(pushoverEndpoint, form)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("Pushover returned %d", resp.StatusCode)
+	}
+	return nil
+}
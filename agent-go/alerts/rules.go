@@ -0,0 +1,233 @@
+package alerts
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/metrics"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/snapshot"
+)
+
+// Thresholds for capacity and temperature alerts.
+const (
+	recordingFullWarnHours     = 24
+	recordingFullCriticalHours = 4
+	volumeWarnPercent          = 90
+	volumeCriticalPercent      = 97
+	cpuTempCriticalCelsius     = 95
+	certCriticalDays           = 7
+	licenseCriticalDays        = 7
+)
+
+// Evaluate derives the current conditions from a status snapshot.
+func Evaluate(s metrics.MachineStatus) []Condition {
+	var conds []Condition
+	add := func(key, severity, format string, args ...any) {
+		conds = append(conds, Condition{Key: key, Severity: severity, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if s.AgentHealth != nil && !s.AgentHealth.Healthy {
+		add("agent:stalled", SeverityCritical, "Metrics collection stalled %.0fs ago", s.AgentHealth.CollectionLagSeconds)
+	}
+
+	if s.CPUTempCelsius >= cpuTempCriticalCelsius {
+		add("temp:cpu", SeverityCritical, "CPU temperature is %.0f°C", s.CPUTempCelsius)
+	}
+
+	for _, v := range s.Volumes {
+		switch {
+		case v.UsedPercent >= volumeCriticalPercent:
+			add("disk:"+v.Mount, SeverityCritical, "Disk %s is %.0f%% full", v.Mount, v.UsedPercent)
+		case v.UsedPercent >= volumeWarnPercent:
+			add("disk:"+v.Mount, SeverityWarning, "Disk %s is %.0f%% full", v.Mount, v.UsedPercent)
+		}
+	}
+
+	for _, check := range s.CustomChecks {
+		if !check.OK {
+			add("check:"+check.Name, SeverityWarning, "%s: %s", check.Name, check.Detail)
+		}
+	}
+
+	for _, cert := range s.Certificates {
+		switch {
+		case cert.Error != "":
+			add("cert:"+cert.Name, SeverityWarning, "Couldn't check the certificate of %s: %s", cert.Name, cert.Error)
+		case cert.Expires == nil:
+		case cert.Expires.Before(time.Now()):
+			add("cert:"+cert.Name, SeverityCritical, "Certificate of %s expired on %s", cert.Name, cert.Expires.Format("2006-01-02"))
+		case cert.DaysLeft < certCriticalDays:
+			add("cert:"+cert.Name, SeverityCritical, "Certificate of %s expires in %d days", cert.Name, cert.DaysLeft)
+		case cert.DaysLeft < cert.WarnDays:
+			add("cert:"+cert.Name, SeverityWarning, "Certificate of %s expires in %d days", cert.Name, cert.DaysLeft)
+		}
+	}
+
+	for _, l := range s.Licenses {
+		switch {
+		case slices.Contains(l.Missing, "usbDevice"):
+			add("license:"+l.Name, SeverityCritical, "%s license dongle is not plugged in", l.Name)
+		case slices.Contains(l.Missing, "file"):
+			add("license:"+l.Name, SeverityCritical, "%s license file is missing", l.Name)
+		case l.Expires == nil:
+		case l.Expires.Before(time.Now()):
+			add("license:"+l.Name, SeverityCritical, "%s license expired on %s", l.Name, l.Expires.Format("2006-01-02"))
+		case *l.DaysLeft < licenseCriticalDays:
+			add("license:"+l.Name, SeverityCritical, "%s license expires in %d days", l.Name, *l.DaysLeft)
+		case *l.DaysLeft < l.WarnDays:
+			add("license:"+l.Name, SeverityWarning, "%s license expires in %d days", l.Name, *l.DaysLeft)
+		}
+	}
+
+	for _, folder := range s.Recording {
+		switch {
+		case !folder.Exists:
+			add("recording:"+folder.Path, SeverityCritical, "Recording folder %s is missing", folder.Path)
+		case folder.HoursUntilFull != nil && *folder.HoursUntilFull < recordingFullCriticalHours:
+			add("recording:"+folder.Path, SeverityCritical, "Recording volume for %s full in %.1f hours", folder.Path, *folder.HoursUntilFull)
+		case folder.HoursUntilFull != nil && *folder.HoursUntilFull < recordingFullWarnHours:
+			add("recording:"+folder.Path, SeverityWarning, "Recording volume for %s full in %.0f hours", folder.Path, *folder.HoursUntilFull)
+		}
+	}
+
+	for _, link := range s.PCIeLinks {
+		if link.Degraded {
+			add("link:"+link.Location, SeverityWarning, "%s link degraded to %.1f x%d", link.Device, link.CurrentSpeed, link.CurrentWidth)
+		}
+	}
+
+	if s.Cooling != nil && s.Cooling.Suspect {
+		add("cooling", SeverityWarning, "Possible cooling failure: CPU runs %.0f°C hotter than usual at similar load", s.Cooling.DeviationCelsius)
+	}
+
+	if s.TimeSync != nil && !s.TimeSync.Synchronized {
+		add("timesync", SeverityWarning, "Clock is not synchronized")
+	}
+	if s.PeerClock.Skewed() {
+		add("timesync:peers", SeverityWarning, "Clock is %.0f ms off from other agents", s.PeerClock.MaxSkewMs)
+	}
+
+	for _, d := range s.Displays {
+		if len(d.Drift) > 0 {
+			add("display:"+d.Name, SeverityWarning, "%s: %s", d.Name, strings.Join(d.Drift, "; "))
+		}
+	}
+
+	if s.Kiosk != nil && len(s.Kiosk.Problems) > 0 {
+		add("kiosk", SeverityWarning, "Signage: %s", strings.Join(s.Kiosk.Problems, "; "))
+	}
+
+	for _, r := range s.Routines {
+		if r.Failed() {
+			add("routine:"+r.Label(), SeverityWarning, "Routine %s failed: %s", r.Label(), r.LastResult)
+		}
+	}
+
+	for _, app := range s.StartupApps {
+		if problem := app.Problem(); problem != "" {
+			add("startup:"+app.Process, SeverityWarning, "Startup: %s", problem)
+		}
+	}
+
+	if o := s.OBS; o != nil {
+		if len(o.Problems) > 0 {
+			add("obs:profile", SeverityCritical, "OBS %s", strings.Join(o.Problems, "; "))
+		}
+		if st := o.Stream; st != nil && st.Problem != "" {
+			add("obs:stream", SeverityCritical, "OBS %s", st.Problem)
+		}
+		for _, src := range o.Sources {
+			if src.Problem != "" {
+				add("obs:source:"+src.Name, SeverityWarning, "OBS source %s: %s", src.Name, src.Problem)
+			}
+		}
+		if o.Error != "" {
+			add("obs", SeverityWarning, "Couldn't check OBS: %s", o.Error)
+		}
+	}
+
+	for _, vm := range s.VMs {
+		if vm.HeartbeatLost() {
+			add("vm:"+vm.Name, SeverityWarning, "VM %s is running but its heartbeat is %s", vm.Name, vm.Heartbeat)
+		}
+	}
+
+	if d := s.ConfigDrift; d != nil && len(d.Drift) > 0 {
+		add("config:drift", SeverityWarning, "%d settings differ from the baseline of %s: %s",
+			len(d.Drift), d.BaselineAt.Local().Format("Jan 2"), snapshot.Summary(d.Drift))
+	}
+
+	if s.Backup != nil {
+		if failed := s.Backup.Failed(); len(failed) > 0 {
+			add("backup", SeverityWarning, "Backup incomplete: %s", strings.Join(failed, "; "))
+		}
+	}
+
+	if c := s.RecordingCheck; c != nil && !c.OK() {
+		add("recording:check", SeverityWarning, "Recording check after %s: %s", c.Trigger, strings.Join(c.Problems, "; "))
+	}
+
+	for _, d := range s.Bluetooth {
+		if problem := d.Problem(); problem != "" {
+			add("bluetooth:"+d.Name, SeverityWarning, "Bluetooth %s: %s", d.Name, problem)
+		}
+	}
+
+	for _, b := range s.Batteries {
+		switch {
+		case b.Low && b.Percent != nil:
+			add("battery:"+b.ID, SeverityWarning, "%s battery at %d%%", b.Name, *b.Percent)
+		case b.Low:
+			add("battery:"+b.ID, SeverityWarning, "%s battery %s", b.Name, b.Level)
+		}
+	}
+
+	for _, ct := range s.Containers {
+		if problem := ct.Problem(); problem != "" {
+			add("container:"+ct.Name, SeverityWarning, "Container %s: %s", ct.Name, problem)
+		}
+	}
+
+	if s.Multicast != nil {
+		for _, iface := range s.Multicast.Interfaces {
+			if len(iface.MissingGroups) > 0 {
+				add("multicast:"+iface.InterfaceName, SeverityWarning, "%s has not joined %s", iface.InterfaceName, strings.Join(iface.MissingGroups, ", "))
+			}
+		}
+	}
+
+	for _, n := range s.Networks {
+		switch {
+		case !n.IPConfig.WrongMethod():
+		case n.IPConfig.Method == metrics.AddressAutoconfig:
+			add("ipconfig:"+n.InterfaceName, SeverityCritical, "%s should be static but has a self-assigned address (%s)", n.InterfaceName, n.IPAddress)
+		default:
+			add("ipconfig:"+n.InterfaceName, SeverityWarning, "%s should be static but got %s from DHCP", n.InterfaceName, n.IPAddress)
+		}
+	}
+
+	for _, n := range s.Networks {
+		switch {
+		case n.Subnet == nil || n.Subnet.OK:
+		case n.Subnet.FoundRole != "":
+			add("subnet:"+n.InterfaceName, SeverityWarning, "%s is on the %s network (%s), expected %s", n.InterfaceName, n.Subnet.FoundRole, n.IPAddress, strings.Join(n.Subnet.Expected, ", "))
+		default:
+			add("subnet:"+n.InterfaceName, SeverityWarning, "%s is on an unexpected network (%s), expected %s", n.InterfaceName, n.IPAddress, strings.Join(n.Subnet.Expected, ", "))
+		}
+	}
+
+	if s.Power != nil && len(s.Power.Drift) > 0 {
+		add("power:drift", SeverityWarning, "Power settings drifted: %s", strings.Join(s.Power.Drift, "; "))
+	}
+
+	if s.Defender != nil && s.Defender.HighImpact {
+		add("defender:scan", SeverityWarning, "Defender scan is using heavy CPU/disk")
+	}
+
+	if s.WindowsUpdate != nil && s.WindowsUpdate.RebootRequired {
+		add("windowsupdate:reboot", SeverityInfo, "Windows Update is waiting to restart")
+	}
+	return conds
+}
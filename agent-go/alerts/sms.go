@@ -0,0 +1,52 @@
+//go:build !lite
+
+package alerts
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+)
+
+// SMS sends alerts as text messages through Twilio.
+type SMS struct {
+	cfg      config.TwilioConfig
+	hostname string
+	client   *http.Client
+}
+
+// NewSMS creates a Twilio notifier.
+func NewSMS(cfg config.TwilioConfig, hostname string) *SMS {
+	return &SMS{cfg: cfg, hostname: hostname, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Notify texts a to every recipient whose severity filter it passes.
+// Every recipient is attempted; the first failure is returned.
+func (s *SMS) Notify(a Alert) error {
+	endpoint := fmt.Sprintf("https://api.twilio.com/2010-04-01/Accounts/%s/Messages.json", url.PathEscape(s.cfg.AccountSID))
+	text := summary(s.hostname, a)
+
+	var firstErr error
+	for _, to := range recipientsFor(s.cfg.Recipients, a.Severity) {
+		form := url.Values{"To": {to}, "From": {s.cfg.From}, "Body": {text}}
+		req, _ := http.NewRequest("POST", endpoint, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth(s.cfg.AccountSID, s.cfg.AuthToken)
+
+		resp, err := s.client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode >= 300 {
+				err = fmt.Errorf("Twilio returned %d for %s", resp.StatusCode, to)
+			}
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
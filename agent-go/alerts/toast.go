@@ -0,0 +1,33 @@
+package alerts
+
+import (
+	"strings"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/i18n"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/toast"
+)
+
+// localPrefixes are alert keys the person at the machine can act on.
+var localPrefixes = []string{"disk:", "recording:", "temp:"}
+
+// Toast shows locally relevant alerts as desktop notifications.
+type Toast struct {
+	hostname string
+}
+
+// Notify shows a toast for active local alerts and ignores the rest.
+func (t Toast) Notify(a Alert) error {
+	if !a.Active || !isLocal(a.Key) {
+		return nil
+	}
+	return toast.Show(i18n.T("toast.alert.title", i18n.T("severity."+a.Severity), t.hostname), a.Message)
+}
+
+func isLocal(key string) bool {
+	for _, prefix := range localPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
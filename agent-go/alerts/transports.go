@@ -0,0 +1,30 @@
+//go:build !lite
+
+package alerts
+
+import "log"
+
+// addTransports registers the remote transports: webhooks, email, SMS,
+// ntfy, and Pushover. Lite builds leave them out.
+func (e *Engine) addTransports(hostname string) {
+	for _, hook := range e.cfg.Webhooks {
+		w, err := NewWebhook(hook, hostname)
+		if err != nil {
+			log.Printf("Alerts: skipping webhook %s: %v", hook.URL, err)
+			continue
+		}
+		e.AddNotifier(w)
+	}
+	if email := e.cfg.Email; email != nil && email.Host != "" {
+		e.AddNotifier(NewEmail(*email, hostname))
+	}
+	if sms := e.cfg.SMS; sms != nil && sms.AccountSID != "" {
+		e.AddNotifier(NewSMS(*sms, hostname))
+	}
+	for _, topic := range e.cfg.Ntfy {
+		e.AddNotifier(NewNtfy(topic, hostname))
+	}
+	for _, user := range e.cfg.Pushover {
+		e.AddNotifier(NewPushover(user, hostname))
+	}
+}
@@ -0,0 +1,15 @@
+//go:build lite
+
+package alerts
+
+import "log"
+
+// addTransports only warns in lite builds: webhooks, email, SMS, ntfy, and
+// Pushover aren't compiled in. The dashboard and the tray's toasts still
+// show alerts.
+func (e *Engine) addTransports(string) {
+	c := e.cfg
+	if len(c.Webhooks) > 0 || c.Email != nil || c.SMS != nil || len(c.Ntfy) > 0 || len(c.Pushover) > 0 {
+		log.Printf("Lite build: alert transports are configured but not included; ignoring them")
+	}
+}
@@ -0,0 +1,115 @@
+//go:build !lite
+
+package alerts
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+)
+
+// Webhook payload formats.
+const (
+	FormatGeneric = "generic"
+	FormatSlack   = "slack"
+	FormatDiscord = "discord"
+	FormatTeams   = "teams"
+)
+
+// teamsColors are MessageCard theme colors by severity.
+var teamsColors = map[string]string{
+	SeverityInfo:     "0078D7",
+	SeverityWarning:  "FFA500",
+	SeverityCritical: "D13438",
+}
+
+// Webhook POSTs alerts to a chat service or any HTTP endpoint.
+type Webhook struct {
+	cfg      config.WebhookConfig
+	hostname string
+	tmpl     *template.Template
+	client   *http.Client
+}
+
+// Event is the data available to custom webhook templates and the generic
+// JSON payload.
+type Event struct {
+	Host string `json:"host"`
+	Alert
+}
+
+// NewWebhook creates a webhook notifier. A custom template, if set, is
+// parsed up front so a typo shows up at startup rather than mid-incident.
+func NewWebhook(cfg config.WebhookConfig, hostname string) (*Webhook, error) {
+	w := &Webhook{cfg: cfg, hostname: hostname, client: &http.Client{Timeout: 10 * time.Second}}
+	if cfg.Template != "" {
+		tmpl, err := template.New("webhook").Funcs(template.FuncMap{"json": jsonString}).Parse(cfg.Template)
+		if err != nil {
+			return nil, fmt.Errorf("webhook template: %w", err)
+		}
+		w.tmpl = tmpl
+	}
+	return w, nil
+}
+
+// Notify sends a if it meets the webhook's minimum severity.
+func (w *Webhook) Notify(a Alert) error {
+	if !severityAtLeast(a.Severity, w.cfg.MinSeverity) {
+		return nil
+	}
+	body, err := w.payload(a)
+	if err != nil {
+		return err
+	}
+	resp, err := w.client.Post(w.cfg.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (w *Webhook) payload(a Alert) ([]byte, error) {
+	event := Event{Host: w.hostname, Alert: a}
+	if w.tmpl != nil {
+		var buf bytes.Buffer
+		if err := w.tmpl.Execute(&buf, event); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	text := summary(w.hostname, a)
+	switch strings.ToLower(w.cfg.Format) {
+	case FormatSlack:
+		return json.Marshal(map[string]string{"text": text})
+	case FormatDiscord:
+		return json.Marshal(map[string]string{"content": text})
+	case FormatTeams:
+		return json.Marshal(map[string]string{
+			"@type":      "MessageCard",
+			"@context":   "https://schema.org/extensions",
+			"themeColor": teamsColors[a.Severity],
+			"summary":    text,
+			"title":      fmt.Sprintf("%s: %s", w.hostname, a.Key),
+			"text":       a.Message,
+		})
+	default:
+		return json.Marshal(event)
+	}
+}
+
+// jsonString quotes s for embedding in a JSON template.
+func jsonString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
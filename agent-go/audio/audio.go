@@ -0,0 +1,75 @@
+// Package audio reads and sets the system output volume and mute, so a
+// lobby PC left muted (or blasting) can be fixed from the dashboard.
+package audio
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/events"
+)
+
+// State is the default output device's volume.
+type State struct {
+	VolumePercent int  `json:"volumePercent"` // 0-100
+	Muted         bool `json:"muted"`
+}
+
+// Change is a requested change; nil fields are left alone. VolumePercent
+// must be 0-100.
+type Change struct {
+	VolumePercent *int  `json:"volumePercent"`
+	Muted         *bool `json:"muted"`
+}
+
+// mu serializes changes, which take several calls.
+var mu sync.Mutex
+
+// Get returns the default output device's volume.
+func Get() (State, error) {
+	return read()
+}
+
+// DefaultDevice returns the name of the default output device.
+func DefaultDevice() (string, error) {
+	return defaultDevice()
+}
+
+// Set applies change and returns the resulting state. The change is
+// recorded as an event naming by.
+func Set(change Change, by string) (State, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	before, err := read()
+	if err != nil {
+		return State{}, err
+	}
+	if change.VolumePercent != nil && *change.VolumePercent != before.VolumePercent {
+		if err := setVolume(*change.VolumePercent); err != nil {
+			return State{}, err
+		}
+	}
+	if change.Muted != nil && *change.Muted != before.Muted {
+		if err := setMute(*change.Muted); err != nil {
+			return State{}, err
+		}
+	}
+	after, err := read()
+	if err != nil {
+		return State{}, err
+	}
+
+	var changed []string
+	if after.VolumePercent != before.VolumePercent {
+		changed = append(changed, fmt.Sprintf("volume %d%% -> %d%%", before.VolumePercent, after.VolumePercent))
+	}
+	if after.Muted != before.Muted {
+		changed = append(changed, map[bool]string{true: "muted", false: "unmuted"}[after.Muted])
+	}
+	if len(changed) > 0 {
+		events.Record(events.RemoteAction, events.Info, "Audio: %s by %s", strings.Join(changed, ", "), by)
+	}
+	return after, nil
+}
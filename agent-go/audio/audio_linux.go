@@ -0,0 +1,54 @@
+//go:build linux && !lite
+
+package audio
+
+import (
+	"errors"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// control is the ALSA mixer control for the main output.
+const control = "Master"
+
+// amixerLevel matches a channel line of `amixer get Master`, e.g.
+// "Front Left: Playback 49151 [75%] [-7.50dB] [on]".
+var amixerLevel = regexp.MustCompile(`\[(\d+)%\](?:.*\[(on|off)\])?`)
+
+func read() (State, error) {
+	out, err := exec.Command("amixer", "get", control).Output()
+	if err != nil {
+		return State{}, err
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		if m := amixerLevel.FindStringSubmatch(line); m != nil {
+			percent, _ := strconv.Atoi(m[1])
+			return State{VolumePercent: percent, Muted: m[2] == "off"}, nil
+		}
+	}
+	return State{}, errors.New("amixer reported no volume for " + control)
+}
+
+func setVolume(percent int) error {
+	return exec.Command("amixer", "-q", "set", control, strconv.Itoa(percent)+"%").Run()
+}
+
+func setMute(muted bool) error {
+	state := "unmute"
+	if muted {
+		state = "mute"
+	}
+	return exec.Command("amixer", "-q", "set", control, state).Run()
+}
+
+// defaultDevice names the default sink when PulseAudio or PipeWire runs;
+// plain ALSA has no default device to report.
+func defaultDevice() (string, error) {
+	out, err := exec.Command("pactl", "get-default-sink").Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,13 @@
+//go:build lite
+
+package audio
+
+import "errors"
+
+// errLite is returned by everything: lite builds leave out audio control.
+var errLite = errors.New("audio control is not included in lite builds")
+
+func read() (State, error)           { return State{}, errLite }
+func setVolume(percent int) error    { return errLite }
+func setMute(muted bool) error       { return errLite }
+func defaultDevice() (string, error) { return "", errLite }
@@ -0,0 +1,214 @@
+//go:build windows && !lite
+
+package audio
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+var (
+	procCoCreateInstance = windows.NewLazySystemDLL("ole32.dll").NewProc("CoCreateInstance")
+
+	clsidMMDeviceEnumerator = windows.GUID{Data1: 0xBCDE0395, Data2: 0xE52F, Data3: 0x467C, Data4: [8]byte{0x8E, 0x3D, 0xC4, 0x57, 0x92, 0x91, 0x69, 0x2E}}
+	iidIMMDeviceEnumerator  = windows.GUID{Data1: 0xA95664D2, Data2: 0x9614, Data3: 0x4F35, Data4: [8]byte{0xA7, 0x46, 0xDE, 0x8D, 0xB6, 0x36, 0x17, 0xE6}}
+	iidIAudioEndpointVolume = windows.GUID{Data1: 0x5CDF2C82, Data2: 0x841E, Data3: 0x4546, Data4: [8]byte{0x97, 0x22, 0x0C, 0xF7, 0x40, 0x78, 0x22, 0x9A}}
+)
+
+const (
+	sFalse    = syscall.Errno(1)
+	clsctxAll = 0x17
+	eRender   = 0
+	eConsole  = 0
+)
+
+// Vtable slots used, after IUnknown's QueryInterface, AddRef, Release.
+const (
+	methodRelease = 2
+
+	// IMMDeviceEnumerator
+	methodGetDefaultAudioEndpoint = 4
+	// IMMDevice
+	methodActivate = 3
+	methodGetId    = 5
+	// IAudioEndpointVolume
+	methodGetMasterVolumeLevelScalar = 9
+	methodSetMute                    = 14
+	methodGetMute                    = 15
+	methodGetVolumeStepInfo          = 16
+	methodVolumeStepUp               = 17
+	methodVolumeStepDown             = 18
+)
+
+// comObject is a COM interface pointer's target: a pointer to its vtable.
+type comObject struct {
+	vtbl *[32]uintptr
+}
+
+func (o *comObject) call(method int, args ...uintptr) error {
+	hr, _, _ := syscall.SyscallN(o.vtbl[method], append([]uintptr{uintptr(unsafe.Pointer(o))}, args...)...)
+	if int32(hr) < 0 {
+		return fmt.Errorf("Core Audio: %w", windows.Errno(hr))
+	}
+	return nil
+}
+
+func (o *comObject) release() {
+	o.call(methodRelease)
+}
+
+// withDevice runs fn with the default output device's IMMDevice. COM is
+// initialized on a locked thread for the duration.
+func withDevice(fn func(device *comObject) error) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	// S_FALSE means COM was already initialized on the thread; it still
+	// needs the matching CoUninitialize.
+	if err := windows.CoInitializeEx(0, windows.COINIT_MULTITHREADED); err != nil && err != sFalse {
+		return err
+	}
+	defer windows.CoUninitialize()
+
+	var enumerator *comObject
+	if hr, _, _ := procCoCreateInstance.Call(uintptr(unsafe.Pointer(&clsidMMDeviceEnumerator)), 0, clsctxAll,
+		uintptr(unsafe.Pointer(&iidIMMDeviceEnumerator)), uintptr(unsafe.Pointer(&enumerator))); int32(hr) < 0 {
+		return fmt.Errorf("Core Audio: %w", windows.Errno(hr))
+	}
+	defer enumerator.release()
+
+	var device *comObject
+	if err := enumerator.call(methodGetDefaultAudioEndpoint, eRender, eConsole, uintptr(unsafe.Pointer(&device))); err != nil {
+		return fmt.Errorf("no default output device: %w", err)
+	}
+	defer device.release()
+	return fn(device)
+}
+
+// withEndpoint runs fn with the default output device's
+// IAudioEndpointVolume.
+func withEndpoint(fn func(volume *comObject) error) error {
+	return withDevice(func(device *comObject) error {
+		var volume *comObject
+		if err := device.call(methodActivate, uintptr(unsafe.Pointer(&iidIAudioEndpointVolume)), clsctxAll, 0, uintptr(unsafe.Pointer(&volume))); err != nil {
+			return err
+		}
+		defer volume.release()
+		return fn(volume)
+	})
+}
+
+// Endpoint properties in the MMDevices registry key, read there rather
+// than through IPropertyStore, whose PROPVARIANTs syscalls can't unpack.
+const (
+	mmDevicesRender   = `SOFTWARE\Microsoft\Windows\CurrentVersion\MMDevices\Audio\Render\`
+	propDeviceDesc    = "{a45c254e-df1c-4efd-8020-67d146a850e0},2" // "Speakers"
+	propInterfaceName = "{b3f8fa53-0004-438e-9003-51a46e139bfc},6" // "Realtek(R) Audio"
+)
+
+// defaultDevice names the default output device the way Sound settings
+// do, e.g. "Speakers (Realtek(R) Audio)".
+func defaultDevice() (string, error) {
+	var id string
+	err := withDevice(func(device *comObject) error {
+		var p *uint16
+		if err := device.call(methodGetId, uintptr(unsafe.Pointer(&p))); err != nil {
+			return err
+		}
+		defer windows.CoTaskMemFree(unsafe.Pointer(p))
+		id = windows.UTF16PtrToString(p)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	// The ID is "{0.0.0.00000000}.{endpoint GUID}"; the GUID names the key.
+	guid := id[strings.LastIndex(id, ".")+1:]
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, mmDevicesRender+guid+`\Properties`, registry.QUERY_VALUE)
+	if err != nil {
+		return id, nil
+	}
+	defer k.Close()
+	desc, _, _ := k.GetStringValue(propDeviceDesc)
+	iface, _, _ := k.GetStringValue(propInterfaceName)
+	switch {
+	case desc != "" && iface != "":
+		return desc + " (" + iface + ")", nil
+	case desc != "":
+		return desc, nil
+	}
+	return id, nil
+}
+
+func read() (State, error) {
+	var state State
+	err := withEndpoint(func(volume *comObject) error {
+		var err error
+		state, err = readEndpoint(volume)
+		return err
+	})
+	return state, err
+}
+
+func readEndpoint(volume *comObject) (State, error) {
+	var level float32
+	var muted int32
+	if err := volume.call(methodGetMasterVolumeLevelScalar, uintptr(unsafe.Pointer(&level))); err != nil {
+		return State{}, err
+	}
+	if err := volume.call(methodGetMute, uintptr(unsafe.Pointer(&muted))); err != nil {
+		return State{}, err
+	}
+	return State{VolumePercent: int(math.Round(float64(level) * 100)), Muted: muted != 0}, nil
+}
+
+// setVolume steps the volume to percent. SetMasterVolumeLevelScalar would
+// be one call, but it takes a float argument, which syscalls can't pass on
+// ARM64; the steps are the ones the volume keys take.
+func setVolume(percent int) error {
+	return withEndpoint(func(volume *comObject) error {
+		var step, steps uint32
+		if err := volume.call(methodGetVolumeStepInfo, uintptr(unsafe.Pointer(&step)), uintptr(unsafe.Pointer(&steps))); err != nil {
+			return err
+		}
+		if steps < 2 {
+			return fmt.Errorf("output device has %d volume steps", steps)
+		}
+		halfStep := 50.0 / float64(steps-1)
+		for i := uint32(0); i < steps; i++ {
+			state, err := readEndpoint(volume)
+			if err != nil {
+				return err
+			}
+			diff := float64(percent - state.VolumePercent)
+			switch {
+			case math.Abs(diff) <= halfStep:
+				return nil
+			case diff > 0:
+				err = volume.call(methodVolumeStepUp, 0)
+			default:
+				err = volume.call(methodVolumeStepDown, 0)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func setMute(muted bool) error {
+	var value uintptr
+	if muted {
+		value = 1
+	}
+	return withEndpoint(func(volume *comObject) error {
+		return volume.call(methodSetMute, value, 0)
+	})
+}
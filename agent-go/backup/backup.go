@@ -0,0 +1,368 @@
+//go:build !lite
+
+package backup
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/events"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/state"
+)
+
+const (
+	stateKey      = "backup"
+	checkInterval = time.Minute
+	fetchTimeout  = 30 * time.Second
+	uploadTimeout = 5 * time.Minute
+	// A run that failed outright (the zip couldn't be written) is retried
+	// after retryMin, doubling up to retryMax.
+	retryMin    = 5 * time.Minute
+	retryMax    = 4 * time.Hour
+	defaultKeep = 14
+	// maxItemSize bounds one item, so a path pointed at a media folder
+	// doesn't fill the disk with copies.
+	maxItemSize = 200 << 20
+)
+
+// ErrTooLarge is reported for an item over maxItemSize.
+var ErrTooLarge = fmt.Errorf("larger than %d MB", maxItemSize>>20)
+
+// Manager runs scheduled backups. Safe for concurrent use.
+type Manager struct {
+	cfg   config.BackupConfig
+	items []config.BackupItem
+	dir   string
+	store *state.Store
+
+	run     sync.Mutex // one backup at a time
+	mu      sync.Mutex
+	last    *Result
+	retryAt time.Time // after a failed run
+	retries int
+}
+
+// New creates a manager storing zips in dir. It returns nil when nothing
+// is configured to be backed up.
+func New(cfg config.BackupConfig, dir string, store *state.Store) *Manager {
+	if len(cfg.Items) == 0 {
+		return nil
+	}
+	m := &Manager{cfg: cfg, dir: dir, store: store}
+	for _, item := range cfg.Items {
+		m.items = append(m.items, item.Resolved())
+	}
+	var last Result
+	if store.Get(stateKey, &last) {
+		m.last = &last
+	}
+	return m
+}
+
+// Last returns the latest run, or nil before the first.
+func (m *Manager) Last() *Result {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.last == nil {
+		return nil
+	}
+	last := *m.last
+	return &last
+}
+
+// Run backs up daily at the configured time. A run missed while the
+// machine was off is caught up at the next start. Blocks forever.
+func (m *Manager) Run() {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+	for {
+		now := time.Now()
+		if m.due(now) {
+			m.Backup()
+		}
+		<-ticker.C
+	}
+}
+
+// due reports whether a scheduled run or a retry is due.
+func (m *Manager) due(now time.Time) bool {
+	last := m.Last()
+	if last == nil || last.Time.Before(m.lastScheduled(now)) {
+		return true
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return last.Error != "" && !now.Before(m.retryAt)
+}
+
+// Backup runs a backup now and returns its result. Items that fail are
+// recorded in the result; the others are still saved.
+func (m *Manager) Backup() (Result, error) {
+	m.run.Lock()
+	defer m.run.Unlock()
+
+	now := time.Now()
+	hostname, _ := os.Hostname()
+	result := Result{
+		File: fmt.Sprintf("%s-%s.zip", safeName(hostname), now.Format("20060102-150405")),
+		Time: now,
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, item := range m.items {
+		n, err := m.add(zw, item)
+		res := ItemResult{Name: item.Name, Bytes: n}
+		if err != nil {
+			res.Error = err.Error()
+		}
+		result.Items = append(result.Items, res)
+	}
+	if err := zw.Close(); err != nil {
+		return m.finish(result, err)
+	}
+	result.Bytes = int64(buf.Len())
+
+	if err := os.MkdirAll(m.dir, 0o700); err != nil {
+		return m.finish(result, err)
+	}
+	if err := os.WriteFile(filepath.Join(m.dir, result.File), buf.Bytes(), 0o600); err != nil {
+		return m.finish(result, err)
+	}
+	m.prune()
+
+	if m.cfg.Upload.URL != "" {
+		if err := m.upload(result.File, buf.Bytes()); err != nil {
+			result.UploadError = err.Error()
+		} else {
+			result.Uploaded = true
+		}
+	}
+
+	return m.finish(result, nil)
+}
+
+// finish records a run's result, err when it failed outright, which
+// schedules a retry and, like a failed item, raises the backup alert.
+func (m *Manager) finish(result Result, err error) (Result, error) {
+	m.mu.Lock()
+	if err != nil {
+		result.Error = err.Error()
+		delay := retryMin << min(m.retries, 6)
+		m.retryAt = result.Time.Add(min(delay, retryMax))
+		m.retries++
+	} else {
+		m.retries = 0
+	}
+	m.last = &result
+	m.mu.Unlock()
+
+	if failed := result.Failed(); len(failed) > 0 {
+		events.Record(events.Lifecycle, events.Warning, "Backup %s: %s", result.File, strings.Join(failed, "; "))
+	} else {
+		events.Record(events.Lifecycle, events.Info, "Backup %s: %d items, %d KB", result.File, len(result.Items), result.Bytes>>10)
+	}
+	if err := m.store.Set(stateKey, result); err != nil {
+		log.Printf("State: save backup failed: %v", err)
+	}
+	return result, err
+}
+
+// List returns the stored backups, newest first.
+func (m *Manager) List() []string {
+	entries, _ := os.ReadDir(m.dir)
+	var files []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".zip") {
+			files = append(files, e.Name())
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(files)))
+	return files
+}
+
+// Open returns a stored backup's contents.
+func (m *Manager) Open(name string) ([]byte, error) {
+	for _, file := range m.List() {
+		if file == name {
+			return os.ReadFile(filepath.Join(m.dir, file))
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// add writes one item to the zip and returns its uncompressed size.
+func (m *Manager) add(zw *zip.Writer, item config.BackupItem) (int64, error) {
+	if item.URL != "" {
+		return addURL(zw, item)
+	}
+	return addPath(zw, item)
+}
+
+func addURL(zw *zip.Writer, item config.BackupItem) (int64, error) {
+	req, err := http.NewRequest("GET", item.URL, nil)
+	if err != nil {
+		return 0, err
+	}
+	if item.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+item.Token)
+	}
+	resp, err := (&http.Client{Timeout: fetchTimeout}).Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return 0, fmt.Errorf("%s answered %s", item.URL, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxItemSize+1))
+	if err != nil {
+		return 0, err
+	}
+	if len(body) > maxItemSize {
+		return 0, ErrTooLarge
+	}
+	w, err := zw.Create(safeName(item.Name) + urlExt(item.URL, resp.Header.Get("Content-Type")))
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(body)
+	return int64(n), err
+}
+
+// addPath copies a file, or a folder's files under the item's name.
+func addPath(zw *zip.Writer, item config.BackupItem) (int64, error) {
+	root := filepath.Clean(item.Path)
+	info, err := os.Stat(root)
+	if err != nil {
+		return 0, err
+	}
+	if !info.IsDir() {
+		if info.Size() > maxItemSize {
+			return 0, ErrTooLarge
+		}
+		return addFile(zw, safeName(item.Name)+"/"+info.Name(), root)
+	}
+
+	var total int64
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if total += info.Size(); total > maxItemSize {
+			return ErrTooLarge
+		}
+		rel, _ := filepath.Rel(root, p)
+		_, err = addFile(zw, path.Join(safeName(item.Name), filepath.ToSlash(rel)), p)
+		return err
+	})
+	return total, err
+}
+
+func addFile(zw *zip.Writer, name, src string) (int64, error) {
+	f, err := os.Open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	w, err := zw.Create(name)
+	if err != nil {
+		return 0, err
+	}
+	return io.Copy(w, f)
+}
+
+// upload POSTs a zip to the configured server.
+func (m *Manager) upload(name string, body []byte) error {
+	req, err := http.NewRequest("POST", m.cfg.Upload.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/zip")
+	req.Header.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	if m.cfg.Upload.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+m.cfg.Upload.Token)
+	}
+	resp, err := (&http.Client{Timeout: uploadTimeout}).Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("server answered %s", resp.Status)
+	}
+	return nil
+}
+
+// prune deletes all but the newest Keep zips.
+func (m *Manager) prune() {
+	keep := m.cfg.Keep
+	if keep <= 0 {
+		keep = defaultKeep
+	}
+	files := m.List()
+	for i := keep; i < len(files); i++ {
+		if err := os.Remove(filepath.Join(m.dir, files[i])); err != nil {
+			log.Printf("Backup: removing %s: %v", files[i], err)
+		}
+	}
+}
+
+// lastScheduled returns the most recent scheduled run at or before now.
+func (m *Manager) lastScheduled(now time.Time) time.Time {
+	hour, minute := 3, 0
+	if t, err := time.Parse("15:04", m.cfg.Time); err == nil {
+		hour, minute = t.Hour(), t.Minute()
+	}
+	t := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+	if t.After(now) {
+		t = t.AddDate(0, 0, -1)
+	}
+	return t
+}
+
+// urlExt picks a file extension for a fetched item.
+func urlExt(rawURL, contentType string) string {
+	switch {
+	case strings.Contains(contentType, "json"):
+		return ".json"
+	case strings.Contains(contentType, "zip"):
+		return ".zip"
+	}
+	if i := strings.IndexAny(rawURL, "?#"); i >= 0 {
+		rawURL = rawURL[:i]
+	}
+	if ext := path.Ext(rawURL); ext != "" && len(ext) <= 16 {
+		return ext
+	}
+	return ".dat"
+}
+
+// safeName makes a name usable in a file name.
+func safeName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`<>:"/\|?* `, r) {
+			return '-'
+		}
+		return r
+	}, s)
+}
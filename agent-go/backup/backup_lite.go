@@ -0,0 +1,28 @@
+//go:build lite
+
+package backup
+
+import (
+	"log"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/state"
+)
+
+// Manager is a stub: lite builds leave backups out, and New never
+// returns one.
+type Manager struct{}
+
+// New only warns in lite builds when backups are configured.
+func New(cfg config.BackupConfig, dir string, store *state.Store) *Manager {
+	if len(cfg.Items) > 0 {
+		log.Printf("Lite build: backup is configured but not included; ignoring it")
+	}
+	return nil
+}
+
+func (m *Manager) Last() *Result                    { return nil }
+func (m *Manager) Run()                             {}
+func (m *Manager) Backup() (Result, error)          { return Result{}, nil }
+func (m *Manager) List() []string                   { return nil }
+func (m *Manager) Open(name string) ([]byte, error) { return nil, ErrNotFound }
@@ -0,0 +1,49 @@
+// Package backup pulls application data on a schedule: Companion's config
+// export, Node-RED flows, OBS scene collections, or any file, folder, or
+// URL. Each run is one zip in the agent's data directory, optionally
+// POSTed to a server, so rebuilding a dead show-control machine doesn't
+// start from memory.
+package backup
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned by Open for an unknown backup.
+var ErrNotFound = errors.New("no such backup")
+
+// Result is one backup run.
+type Result struct {
+	File        string       `json:"file"` // zip name, for GET /backups/<file>
+	Time        time.Time    `json:"time"`
+	Bytes       int64        `json:"bytes"`
+	Items       []ItemResult `json:"items"`
+	Uploaded    bool         `json:"uploaded,omitempty"`
+	UploadError string       `json:"uploadError,omitempty"`
+	Error       string       `json:"error,omitempty"` // the run failed and saved nothing
+}
+
+// ItemResult is one item of a run.
+type ItemResult struct {
+	Name  string `json:"name"`
+	Bytes int64  `json:"bytes"`
+	Error string `json:"error,omitempty"`
+}
+
+// Failed lists what went wrong in the run, or nil.
+func (r Result) Failed() []string {
+	var failed []string
+	if r.Error != "" {
+		failed = append(failed, r.Error)
+	}
+	for _, item := range r.Items {
+		if item.Error != "" {
+			failed = append(failed, item.Name+": "+item.Error)
+		}
+	}
+	if r.UploadError != "" {
+		failed = append(failed, "upload: "+r.UploadError)
+	}
+	return failed
+}
@@ -0,0 +1,129 @@
+// Package budget keeps the agent's own CPU and memory use within limits.
+// A monitoring agent competing with ProPresenter for cycles defeats its
+// purpose, so over budget the agent sheds work one level at a time:
+// first it collects less often, then it skips the optional probes. It
+// restores them once it has stayed under budget for a while.
+package budget
+
+import (
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/process"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/events"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/metrics"
+)
+
+const (
+	checkInterval     = 30 * time.Second
+	defaultCPUPercent = 5
+	defaultMemoryMB   = 250
+	overToShed        = 2  // consecutive checks over budget before shedding
+	underToRestore    = 10 // consecutive checks under budget before restoring
+)
+
+// Guard samples the agent's footprint and throttles the collector.
+type Guard struct {
+	cpuPercent float64
+	memoryMB   float64
+	collector  *metrics.Collector
+	proc       *process.Process
+
+	level, over, under int
+}
+
+// New creates a guard for collector. It returns nil when the budget is
+// disabled or the agent can't inspect its own process.
+func New(cfg config.BudgetConfig, collector *metrics.Collector) *Guard {
+	if cfg.Disabled {
+		return nil
+	}
+	proc, err := process.NewProcess(int32(os.Getpid()))
+	if err != nil {
+		return nil
+	}
+	g := &Guard{
+		cpuPercent: cfg.CPUPercent,
+		memoryMB:   float64(cfg.MemoryMB),
+		collector:  collector,
+		proc:       proc,
+	}
+	if g.cpuPercent <= 0 {
+		g.cpuPercent = defaultCPUPercent
+	}
+	if g.memoryMB <= 0 {
+		g.memoryMB = defaultMemoryMB
+	}
+	return g
+}
+
+// Run checks the footprint every 30 seconds. Blocks forever.
+func (g *Guard) Run() {
+	prevCPU, prevAt := g.cpuSeconds(), time.Now()
+	for range time.Tick(checkInterval) {
+		cpuSeconds, now := g.cpuSeconds(), time.Now()
+		// Percent of the whole machine, like Task Manager shows.
+		cpu := (cpuSeconds - prevCPU) / now.Sub(prevAt).Seconds() / float64(runtime.NumCPU()) * 100
+		prevCPU, prevAt = cpuSeconds, now
+		g.check(cpu, g.residentMB())
+	}
+}
+
+func (g *Guard) check(cpu, memMB float64) {
+	overCPU, overMem := cpu > g.cpuPercent, memMB > g.memoryMB
+	if overMem {
+		debug.FreeOSMemory() // cheap first resort: the heap may just not have been returned yet
+	}
+	if !overCPU && !overMem {
+		g.over = 0
+		g.under++
+		if g.level > metrics.ThrottleNone && g.under >= underToRestore {
+			restored := strings.Join(newlyShed(g.level-1, g.level), ", ")
+			g.setLevel(g.level - 1)
+			events.Record(events.Lifecycle, events.Info, "Budget: back under budget (CPU %.1f%%, %.0f MB); restored %s", cpu, memMB, restored)
+		}
+		return
+	}
+
+	g.under = 0
+	g.over++
+	if g.level < metrics.ThrottleEssential && g.over >= overToShed {
+		shed := strings.Join(newlyShed(g.level, g.level+1), ", ")
+		g.setLevel(g.level + 1)
+		events.Record(events.Lifecycle, events.Warning, "Budget: agent using %.1f%% CPU and %.0f MB (budget %.1f%%, %.0f MB); shed %s",
+			cpu, memMB, g.cpuPercent, g.memoryMB, shed)
+	}
+}
+
+func (g *Guard) setLevel(level int) {
+	g.level, g.over, g.under = level, 0, 0
+	g.collector.SetThrottle(level)
+}
+
+// newlyShed returns what level to gives up beyond level from.
+func newlyShed(from, to int) []string {
+	return metrics.Shed(to)[len(metrics.Shed(from)):]
+}
+
+// cpuSeconds returns the agent's total user and system CPU time.
+func (g *Guard) cpuSeconds() float64 {
+	times, err := g.proc.Times()
+	if err != nil {
+		return 0
+	}
+	return times.User + times.System
+}
+
+// residentMB returns the agent's resident memory.
+func (g *Guard) residentMB() float64 {
+	mem, err := g.proc.MemoryInfo()
+	if err != nil {
+		return 0
+	}
+	return float64(mem.RSS) / (1 << 20)
+}
@@ -0,0 +1,137 @@
+// Package clonecheck notices another agent advertising this machine's
+// hardware UUID. Machines cloned from one Windows image can carry the same
+// SMBIOS data, and the dashboard, which keys machines by UUID, merges them
+// into one machine whose readings flip between the two. One of the pair
+// takes a new identity; the other keeps its own.
+package clonecheck
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/events"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/mdns"
+)
+
+const (
+	checkInterval = 2 * time.Minute
+	browseTimeout = 3 * time.Second
+)
+
+// Monitor browses for agents sharing this machine's UUID. Safe for
+// concurrent use.
+type Monitor struct {
+	selfUUID string
+	// renew gives this machine a new identity; it restarts the agent and
+	// doesn't return on success.
+	renew func(reason string) error
+
+	mu      sync.Mutex
+	suspect map[string]bool // addresses seen sharing the UUID last check
+	kept    map[string]bool // addresses already reported as the ones to yield
+}
+
+// New creates a monitor for selfUUID. It returns nil when disabled.
+func New(cfg config.CloneCheckConfig, selfUUID string, renew func(reason string) error) *Monitor {
+	if cfg.Disabled || selfUUID == "" {
+		return nil
+	}
+	return &Monitor{selfUUID: selfUUID, renew: renew, kept: map[string]bool{}}
+}
+
+// Run checks every two minutes. Blocks forever.
+func (m *Monitor) Run() {
+	for {
+		m.check()
+		time.Sleep(checkInterval)
+	}
+}
+
+// check acts on agents found sharing the UUID in two checks in a row, so
+// one stray answer doesn't cost a machine its identity. Of each pair, the
+// machine whose address on their shared network is higher takes a new
+// UUID; both apply the same rule, so exactly one does.
+func (m *Monitor) check() {
+	found, err := mdns.Browse(browseTimeout)
+	if err != nil {
+		log.Printf("CloneCheck: %v", err)
+		return
+	}
+	local := localIPs()
+	seen := map[string]bool{}
+	for _, p := range found {
+		host, _, err := net.SplitHostPort(p.Address)
+		peer := net.ParseIP(host).To4()
+		if err != nil || peer == nil || p.Text["uuid"] != m.selfUUID || local[peer.String()] {
+			continue
+		}
+		// A host advertising this machine as one of its instances
+		// carries its UUID by design.
+		if p.Text["proxy"] != "" {
+			continue
+		}
+		seen[p.Address] = true
+
+		m.mu.Lock()
+		confirmed := m.suspect[p.Address]
+		m.mu.Unlock()
+		if !confirmed {
+			continue
+		}
+		ours := outboundIP(p.Address)
+		if ours == nil || bytes.Compare(ours, peer) < 0 {
+			m.keep(p)
+			continue
+		}
+		reason := fmt.Sprintf("%s (%s) advertises the same hardware UUID %s", p.Instance, p.Address, m.selfUUID)
+		events.Record(events.Lifecycle, events.Warning, "Clone check: %s; taking a new identity", reason)
+		if err := m.renew(reason); err != nil {
+			events.Record(events.Lifecycle, events.Error, "Clone check: new identity failed: %v", err)
+		}
+		return
+	}
+	m.mu.Lock()
+	m.suspect = seen
+	m.mu.Unlock()
+}
+
+// keep records, once per peer, that the peer is the one to change.
+func (m *Monitor) keep(p mdns.Peer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.kept[p.Address] {
+		return
+	}
+	m.kept[p.Address] = true
+	events.Record(events.Lifecycle, events.Warning, "Clone check: %s (%s) advertises the same hardware UUID %s; it should take a new identity",
+		p.Instance, p.Address, m.selfUUID)
+}
+
+// localIPs returns this machine's IPv4 addresses, so its own
+// advertisement isn't mistaken for a clone.
+func localIPs() map[string]bool {
+	ips := map[string]bool{}
+	addrs, _ := net.InterfaceAddrs()
+	for _, addr := range addrs {
+		if ipnet, ok := addr.(*net.IPNet); ok && ipnet.IP.To4() != nil {
+			ips[ipnet.IP.To4().String()] = true
+		}
+	}
+	return ips
+}
+
+// outboundIP returns the local IPv4 address used to reach addr. Dialing
+// UDP sends nothing.
+func outboundIP(addr string) net.IP {
+	conn, err := net.Dial("udp4", addr)
+	if err != nil {
+		return nil
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).IP.To4()
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/firewall"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/metrics"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/obs"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/server"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/state"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/update"
+)
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, `Usage: %s [flags] [command]
+
+Commands:
+  status            print the running agent's /status JSON (or collect locally if it isn't running)
+  version           print the agent version
+  check-update      report whether a newer release is available
+  config validate   check the config files for typos and invalid values
+  firewall install  allow the agent's port and mDNS through Windows Firewall
+  firewall remove   delete the agent's firewall rules
+  obs hash-key      read a stream key from stdin and print its obs.stream.keySha256
+
+Flags:
+`, os.Args[0])
+	flag.PrintDefaults()
+}
+
+// runCommand runs --once or a subcommand. ran is false when neither was
+// given and the agent should start normally.
+func runCommand(opts options, cfg *config.Config) (code int, ran bool) {
+	if !opts.once && len(opts.args) == 0 {
+		return 0, false
+	}
+	attachConsole()
+
+	if opts.once {
+		return printOnce(cfg), true
+	}
+	switch {
+	case opts.args[0] == "status":
+		return cmdStatus(opts, cfg), true
+	case opts.args[0] == "version":
+		fmt.Println(version)
+		return 0, true
+	case opts.args[0] == "check-update":
+		return cmdCheckUpdate(cfg), true
+	case opts.args[0] == "config" && len(opts.args) > 1 && opts.args[1] == "validate":
+		return cmdValidateConfig(opts), true
+	case opts.args[0] == "firewall" && len(opts.args) > 1 && opts.args[1] == "install":
+		return cmdFirewall(firewall.Ensure(installPort(opts, cfg), true)), true
+	case opts.args[0] == "firewall" && len(opts.args) > 1 && opts.args[1] == "remove":
+		return cmdFirewall(firewall.Remove(true)), true
+	case opts.args[0] == "obs" && len(opts.args) > 1 && opts.args[1] == "hash-key":
+		return cmdHashStreamKey(), true
+	}
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", strings.Join(opts.args, " "))
+	usage()
+	return 2, true
+}
+
+// printOnce prints a single status snapshot and returns the exit code.
+// Rates need two samples, so it collects again after a second.
+func printOnce(cfg *config.Config) int {
+	collector := metrics.NewCollector(version, cfg)
+	time.Sleep(time.Second)
+	collector.Refresh()
+	return printJSON(collector.CurrentStatus())
+}
+
+// cmdStatus prints what the dashboard sees from the running agent, found by
+// probing its pinned port or the port it bound last, then the default range. If no agent answers, the status is
+// collected in this process instead.
+func cmdStatus(opts options, cfg *config.Config) int {
+	var ports []uint16
+	if p := pinnedPort(opts, cfg); p != 0 {
+		ports = append(ports, p)
+	} else {
+		last, ok := readLastPort()
+		if ok {
+			ports = append(ports, last)
+		}
+		for p := uint16(49990); p <= 50000; p++ {
+			if p != last {
+				ports = append(ports, p)
+			}
+		}
+	}
+
+	token := statusToken(cfg)
+	client := &http.Client{Timeout: 2 * time.Second}
+	for _, port := range ports {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("http://127.0.0.1:%d/status", port), nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			continue
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			continue
+		}
+		if resp.StatusCode != 200 {
+			// Possibly not ours, or a token it doesn't take; keep looking.
+			fmt.Fprintf(os.Stderr, "port %d answered %s\n", port, resp.Status)
+			continue
+		}
+		var probe struct {
+			HardwareUUID string `json:"hardwareUUID"`
+		}
+		if json.Unmarshal(body, &probe) != nil || probe.HardwareUUID == "" {
+			continue // something else on the port
+		}
+		fmt.Fprintf(os.Stderr, "agent on port %d\n", port)
+		var out bytes.Buffer
+		json.Indent(&out, body, "", "  ")
+		out.WriteByte('\n')
+		os.Stdout.Write(out.Bytes())
+		return 0
+	}
+
+	fmt.Fprintln(os.Stderr, "no running agent found; collecting locally")
+	return printOnce(cfg)
+}
+
+// statusToken returns a token that can read /status: the admin authToken,
+// else the first configured token with viewer scope or higher.
+func statusToken(cfg *config.Config) string {
+	if cfg.AuthToken != "" {
+		return cfg.AuthToken
+	}
+	for _, t := range cfg.Tokens {
+		switch strings.ToLower(t.Scope) {
+		case server.ScopeViewer, server.ScopeOperator, server.ScopeAdmin:
+			if t.Token != "" {
+				return t.Token
+			}
+		}
+	}
+	return ""
+}
+
+func cmdCheckUpdate(cfg *config.Config) int {
+	updater := update.NewUpdater(version)
+	updater.SetRollout(cfg.Updates, metrics.DefaultProviders(cfg).System.HardwareUUID())
+	latest, available, err := updater.Latest()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "update check failed: %v\n", err)
+		return 1
+	}
+	switch {
+	case latest == "":
+		fmt.Println("no releases found")
+	case available:
+		fmt.Printf("update available: v%s -> v%s\n", version, latest)
+	default:
+		fmt.Printf("up to date: v%s (latest release v%s for the %s ring)\n", version, latest, updater.Ring())
+	}
+	return 0
+}
+
+func cmdValidateConfig(opts options) int {
+	problems := config.Validate(config.PackPath(), config.FleetPath(), opts.configPath)
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+	if len(problems) > 0 {
+		return 1
+	}
+	fmt.Printf("%s: ok\n", opts.configPath)
+	return 0
+}
+
+// cmdHashStreamKey reads the stream key from stdin rather than the command
+// line, which shell history and process listings keep.
+func cmdHashStreamKey() int {
+	if term, err := os.Stdin.Stat(); err == nil && term.Mode()&os.ModeCharDevice != 0 {
+		fmt.Fprintln(os.Stderr, "paste the stream key, then press Enter:")
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		fmt.Fprintf(os.Stderr, "reading the key: %v\n", err)
+		return 1
+	}
+	if strings.TrimSpace(line) == "" {
+		fmt.Fprintln(os.Stderr, "empty stream key")
+		return 1
+	}
+	fmt.Println(obs.HashKey(line))
+	return 0
+}
+
+// readLastPort returns the port the agent bound last run.
+func readLastPort() (uint16, bool) {
+	var last uint16
+	ok := state.Open(statePath()).Get(lastPortKey, &last)
+	return last, ok
+}
+
+// installPort is the port firewall rules are created for before the agent
+// has run: pinned, else last bound, else the default.
+func installPort(opts options, cfg *config.Config) uint16 {
+	if p := pinnedPort(opts, cfg); p != 0 {
+		return p
+	}
+	if last, ok := readLastPort(); ok {
+		return last
+	}
+	return 49990
+}
+
+func cmdFirewall(err error) int {
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Println("ok")
+	return 0
+}
+
+func printJSON(v any) int {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// BackupPresets pull the show-control apps' own data.
+var BackupPresets = map[string]BackupItem{
+	"companion": {Name: "companion", URL: "http://127.0.0.1:8000/int/export/full"},
+	"nodered":   {Name: "node-red", URL: "http://127.0.0.1:1880/flows"},
+	"obs":       {Name: "obs-scenes", Path: obsScenesDir()},
+}
+
+// Resolved returns the item with its preset applied.
+func (b BackupItem) Resolved() BackupItem {
+	r := BackupPresets[b.Preset]
+	if b.Name != "" {
+		r.Name = b.Name
+	}
+	if b.URL != "" || b.Path != "" {
+		r.URL, r.Path = b.URL, b.Path
+	}
+	if b.Token != "" {
+		r.Token = b.Token
+	}
+	return r
+}
+
+// obsScenesDir is OBS's scene collection folder for the agent's user. The
+// service runs as SYSTEM, whose profile isn't the operator's; set path
+// there.
+func obsScenesDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "obs-studio", "basic", "scenes")
+}
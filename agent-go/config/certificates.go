@@ -0,0 +1,34 @@
+package config
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// CertificateAddress returns the host:port to dial for a certificate
+// check's URL, defaulting to port 443.
+func CertificateAddress(raw string) (string, error) {
+	if raw == "" {
+		return "", errors.New("no url")
+	}
+	host := raw
+	if strings.Contains(raw, "://") {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return "", err
+		}
+		if u.Scheme != "https" {
+			return "", errors.New("url must be https")
+		}
+		host = u.Host
+	}
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(host, "443")
+	}
+	if h, _, _ := net.SplitHostPort(host); h == "" {
+		return "", errors.New("no host")
+	}
+	return host, nil
+}
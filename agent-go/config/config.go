@@ -0,0 +1,670 @@
+// Package config loads the agent's optional JSON configuration: a fleet
+// document distributed by a dashboard, overridden by the local file. The
+// agent runs with no configuration; missing files yield defaults.
+package config
+
+// Config is the on-disk agent configuration. Every field has a usable zero value.
+type Config struct {
+	// AuthToken enables remote actions (e.g. pausing Windows Update).
+	// Requests must send "Authorization: Bearer <token>". Empty disables them.
+	// It is an admin token; Tokens adds tokens with narrower scopes.
+	AuthToken string     `json:"authToken"`
+	Tokens    []APIToken `json:"tokens"`
+	// RequireAuth also protects read endpoints (viewer scope) and POST
+	// /update (admin). Off by default so existing dashboards keep working.
+	RequireAuth bool          `json:"requireAuth"`
+	Pairing     PairingConfig `json:"pairing"`
+	Fleet       FleetConfig   `json:"fleet"`
+	// Port pins the listening port, so firewall rules and dashboard
+	// bookmarks never go stale. 0 reuses the port bound last time, falling
+	// back to the first free port from 49990.
+	Port int `json:"port"`
+
+	Site       SiteConfig       `json:"site"`
+	Network    NetworkConfig    `json:"network"`
+	Multicast  MulticastConfig  `json:"multicast"`
+	Power      PowerConfig      `json:"power"`
+	Defender   DefenderConfig   `json:"defender"`
+	Recording  RecordingConfig  `json:"recording"`
+	Checks     ChecksConfig     `json:"checks"`
+	PeerClock  PeerClockConfig  `json:"peerClock"`
+	CloneCheck CloneCheckConfig `json:"cloneCheck"`
+	KeepAwake  KeepAwakeConfig  `json:"keepAwake"`
+	Displays   DisplaysConfig   `json:"displays"`
+	Kiosk      KioskConfig      `json:"kiosk"`
+	Routines   []Routine        `json:"routines"`
+	// StartupApps must be running a few minutes after boot.
+	StartupApps []StartupApp `json:"startupApps"`
+	OBS         OBSConfig    `json:"obs"`
+	// ForegroundWindow controls reporting the focused application.
+	ForegroundWindow ForegroundWindowConfig `json:"foregroundWindow"`
+	// Instances are further machines this agent answers for, each with its
+	// own port and mDNS record.
+	Instances []Instance `json:"instances"`
+	// Containers controls the Docker/containerd container report.
+	Containers ContainersConfig `json:"containers"`
+	// Backup pulls application data (show-control configs, scene
+	// collections) daily.
+	Backup BackupConfig `json:"backup"`
+	// Snapshot tracks the machine's settings against a baseline.
+	Snapshot SnapshotConfig `json:"snapshot"`
+	// SharedFolders can be listed and downloaded from, read-only, with a
+	// token (GET /files).
+	SharedFolders []SharedFolder `json:"sharedFolders"`
+	// Bluetooth controls the paired Bluetooth device report.
+	Bluetooth BluetoothConfig `json:"bluetooth"`
+
+	Alerts         AlertsConfig         `json:"alerts"`
+	Report         ReportConfig         `json:"report"`
+	History        HistoryConfig        `json:"history"`
+	Updates        UpdatesConfig        `json:"updates"`
+	FlightRecorder FlightRecorderConfig `json:"flightRecorder"`
+	Events         EventsConfig         `json:"events"`
+	Export         ExportConfig         `json:"export"`
+	SNMP           SNMPConfig           `json:"snmp"`
+	PlanningCenter PlanningCenterConfig `json:"planningCenter"`
+	Watchdog       WatchdogConfig       `json:"watchdog"`
+	Firewall       FirewallConfig       `json:"firewall"`
+	Budget         BudgetConfig         `json:"budget"`
+
+	// Language selects the tray and notification language ("en", "es").
+	// Empty follows the Windows display language.
+	Language string `json:"language"`
+}
+
+// APIToken is a named bearer token limited to a scope: "viewer" (read-only
+// status), "operator" (service-time actions such as acknowledging alerts or
+// pausing updates), or "admin" (update, reboot, config). Each scope
+// includes the ones below it.
+type APIToken struct {
+	Name  string `json:"name"`
+	Token string `json:"token"`
+	Scope string `json:"scope"`
+}
+
+// PairingConfig controls dashboard pairing (POST /pair). Paired dashboards
+// get Scope, "operator" by default.
+type PairingConfig struct {
+	Disabled bool   `json:"disabled"`
+	Scope    string `json:"scope"`
+}
+
+// FleetConfig pulls shared configuration from a config server. Fleet
+// documents can also be pushed to POST /config. Only the local file can
+// set this section.
+type FleetConfig struct {
+	URL             string `json:"url"`
+	Token           string `json:"token"` // sent as a bearer token
+	IntervalMinutes int    `json:"intervalMinutes"`
+}
+
+// SiteConfig identifies the campus a machine belongs to, so dashboards
+// spanning sites can group machines and tell apart two machines with the
+// same hostname.
+type SiteConfig struct {
+	ID   string `json:"id"`   // short and stable, e.g. "north"
+	Name string `json:"name"` // display name, e.g. "North Campus"
+}
+
+// NetworkConfig describes the machine's network interfaces.
+type NetworkConfig struct {
+	// Roles tags interfaces with the fabric they belong to, keyed by
+	// interface name, e.g. {"Ethernet 2": "dante", "Ethernet": "control"}.
+	Roles map[string]string `json:"roles"`
+	// Static lists interfaces that must keep a static address, and
+	// StaticRoles the roles whose interfaces must, e.g. ["dante"]. One on
+	// DHCP or a self-assigned 169.254.x.x address raises an alert.
+	Static      []string `json:"static"`
+	StaticRoles []string `json:"staticRoles"`
+	// Subnets maps an interface name to the subnets (CIDR) its address
+	// must be in, and SubnetsByRole a role, e.g. {"dante": ["10.20.0.0/16"],
+	// "control": ["10.10.0.0/16"]}. An address outside them raises an
+	// alert naming the role whose subnet it is in, if any.
+	Subnets       map[string][]string `json:"subnets"`
+	SubnetsByRole map[string][]string `json:"subnetsByRole"`
+	// StartupWaitSeconds is how long the agent waits at start for a usable
+	// IPv4 address before advertising and checking for updates anyway.
+	// 0 means 120; negative doesn't wait.
+	StartupWaitSeconds int `json:"startupWaitSeconds"`
+}
+
+// MulticastConfig declares multicast groups that should be joined.
+type MulticastConfig struct {
+	// ExpectedGroups maps an interface name to the groups it must have
+	// joined, e.g. {"Ethernet 2": ["224.0.1.129"]} for Dante PTP.
+	ExpectedGroups map[string][]string `json:"expectedGroups"`
+	// ExpectedGroupsByRole applies to every interface with the given role
+	// (see NetworkConfig.Roles), e.g. {"dante": ["224.0.1.129"]}.
+	ExpectedGroupsByRole map[string][]string `json:"expectedGroupsByRole"`
+}
+
+// PowerConfig declares the power/sleep profile a machine should keep.
+type PowerConfig struct {
+	// Expected is compared against the live settings; nil disables the check.
+	Expected *PowerProfile `json:"expected"`
+	// Remediate re-applies the expected profile whenever drift is detected.
+	// Every change is logged. Requires the agent to run elevated.
+	Remediate bool `json:"remediate"`
+}
+
+// PowerProfile describes Windows power settings. Nil fields are not checked.
+// Timeouts are in minutes on AC power; 0 means "never".
+type PowerProfile struct {
+	Plan                  string `json:"plan"` // plan name or GUID, e.g. "High performance"
+	SleepTimeoutMinutes   *int   `json:"sleepTimeoutMinutes"`
+	DisplayTimeoutMinutes *int   `json:"displayTimeoutMinutes"`
+	USBSelectiveSuspend   *bool  `json:"usbSelectiveSuspend"`
+	FastStartup           *bool  `json:"fastStartup"`
+}
+
+// DefenderConfig lists folders that Windows Defender real-time scanning
+// should skip (recording drives, media libraries).
+type DefenderConfig struct {
+	Folders []string `json:"folders"`
+}
+
+// RecordingConfig lists recording/media folders whose size and growth
+// are reported.
+type RecordingConfig struct {
+	Folders []RecordingFolder `json:"folders"`
+	Verify  RecordingVerify   `json:"verify"`
+}
+
+// RecordingVerify checks the newest recording in Folders after each
+// service: at At (HH:MM local time on Days; empty is every day) and, with
+// AfterServices, once each Planning Center service has ended. When
+// ExpectedMinutes is 0 the service's own length is expected, if known.
+type RecordingVerify struct {
+	At               []string `json:"at"`
+	Days             []string `json:"days"`
+	AfterServices    bool     `json:"afterServices"`
+	ExpectedMinutes  int      `json:"expectedMinutes"`
+	ToleranceMinutes int      `json:"toleranceMinutes"` // default 10
+}
+
+// RecordingFolder is a watched folder. When RetentionDays is set, files
+// last modified more than that many days ago are deleted (and logged).
+type RecordingFolder struct {
+	Path          string `json:"path"`
+	RetentionDays int    `json:"retentionDays"` // 0 keeps everything
+}
+
+// ChecksConfig declares custom checks reported in customChecks.
+type ChecksConfig struct {
+	Files        []FileCheck        `json:"files"`
+	Listeners    []ListenerCheck    `json:"listeners"`
+	Certificates []CertificateCheck `json:"certificates"`
+	Licenses     []LicenseCheck     `json:"licenses"`
+}
+
+// LicenseCheck watches a license the show depends on. Set any of Expires
+// for a dated license or subscription (a ProPresenter seat), USBDevice for
+// a dongle that must be plugged in, and File for a license file that must
+// exist (the NDI|HX driver's).
+type LicenseCheck struct {
+	Name      string `json:"name"`
+	Expires   string `json:"expires"`   // YYYY-MM-DD, local time
+	WarnDays  int    `json:"warnDays"`  // warn when fewer days are left (default 30)
+	USBDevice string `json:"usbDevice"` // "vid:pid" in hex, e.g. "096e:0006"
+	File      string `json:"file"`
+}
+
+// CertificateCheck watches the TLS certificate of an HTTPS endpoint (the
+// streaming account page, an upload target, an internal tool) for expiry.
+type CertificateCheck struct {
+	Name string `json:"name"` // defaults to URL
+	// URL is "https://host[:port]/..." or "host:port".
+	URL string `json:"url"`
+	// WarnDays raises a warning when fewer days are left (default 21);
+	// under 7 it is critical.
+	WarnDays int `json:"warnDays"`
+}
+
+// ListenerCheck verifies that a local app still has its port open: an app
+// can keep running after its API or output has died. Preset names a known
+// app instead of a port (see ListenerPresets); fields set alongside it
+// override the preset's.
+type ListenerCheck struct {
+	Name     string `json:"name"` // defaults to the preset, else the port
+	Preset   string `json:"preset"`
+	Port     int    `json:"port"`
+	PortEnd  int    `json:"portEnd"`  // any port from Port to PortEnd will do
+	Protocol string `json:"protocol"` // "tcp" (default) or "udp"
+	// Process limits the check to while the process runs, e.g. "obs64"
+	// (".exe" optional), so a closed app isn't reported.
+	Process string `json:"process"`
+}
+
+// FileCheck verifies a critical show file or folder (ProPresenter library,
+// playlist, OBS scene collection). Path must exist; the other conditions
+// are only checked when set.
+type FileCheck struct {
+	Name   string `json:"name"` // defaults to Path
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"` // files only
+	// MaxAgeDays fails the check when the file (or, for a folder, the newest
+	// file inside it) hasn't been modified within that many days.
+	MaxAgeDays int `json:"maxAgeDays"`
+}
+
+// PeerClockConfig controls clock comparison with other agents. Agents
+// found over mDNS are compared automatically (only those in the same site,
+// when one is set); Peers adds agents mDNS can't reach.
+type PeerClockConfig struct {
+	Disabled bool     `json:"disabled"`
+	Peers    []string `json:"peers"` // host:port
+	// ToleranceMs is the largest skew against any peer before an alert is
+	// raised (default 50, a few frames at 60 fps).
+	ToleranceMs int `json:"toleranceMs"`
+}
+
+// CloneCheckConfig controls watching mDNS for another agent advertising
+// this machine's hardware UUID, as machines cloned from one image with
+// their SMBIOS data do.
+type CloneCheckConfig struct {
+	Disabled bool `json:"disabled"`
+}
+
+// KeepAwakeConfig blocks sleep while any of Processes is running, e.g.
+// ["obs64", "ProPresenter"] (".exe" optional). Empty disables it.
+type KeepAwakeConfig struct {
+	Processes     []string `json:"processes"`
+	KeepDisplayOn bool     `json:"keepDisplayOn"` // also stop the screen blanking
+}
+
+// DisplaysConfig sets the mode each output should keep. Outputs not listed
+// are compared with the first mode the agent saw (reset with POST
+// /displays/baseline).
+type DisplaysConfig struct {
+	Disabled bool `json:"disabled"`
+	// Expected is keyed by output name (\\.\DISPLAY2), the monitor's EDID
+	// name (DELL U2419H), or the driver's description of it (Generic PnP
+	// Monitor); in JSON, e.g. {"\\\\.\\DISPLAY2": {"width": 1920, "height": 1080}}.
+	Expected map[string]DisplayMode `json:"expected"`
+	// PowerSchedule turns outputs on and off over DDC/CI.
+	PowerSchedule []DisplayPowerSchedule `json:"powerSchedule"`
+}
+
+// DisplayPowerSchedule turns a monitor on at On and off at Off (HH:MM,
+// local time) on Days (weekday names; empty is every day), e.g. a lobby
+// screen from 07:30 to 22:00. Either time may be left empty.
+type DisplayPowerSchedule struct {
+	Output string   `json:"output"` // output name or monitor description
+	On     string   `json:"on"`
+	Off    string   `json:"off"`
+	Days   []string `json:"days"`
+}
+
+// KioskConfig watches the player on a signage PC: Process must be
+// running and, optionally, in the foreground on Display. Command relaunches
+// it, from POST /kiosk/relaunch or, with AutoRelaunch, after a minute of
+// failed checks.
+type KioskConfig struct {
+	Process string   `json:"process"` // e.g. "msedge"
+	Command string   `json:"command"` // e.g. the browser path; empty disables relaunching
+	Args    []string `json:"args"`    // e.g. ["--kiosk", "https://signage.example.org"]
+	// Display is the output the player's window must be on, e.g.
+	// \\.\DISPLAY2 (in JSON, "\\\\.\\DISPLAY2"). Empty isn't checked.
+	Display      string `json:"display"`
+	Foreground   bool   `json:"foreground"`
+	AutoRelaunch bool   `json:"autoRelaunch"`
+}
+
+// Routine is a scheduled power-up or power-down: Action "wake" wakes the
+// machine from sleep at At and starts Launch; "sleep", "hibernate", and
+// "shutdown" power it down. At is HH:MM local time on Days (weekday names;
+// empty is every day).
+type Routine struct {
+	Name   string          `json:"name"`
+	Action string          `json:"action"`
+	At     string          `json:"at"`
+	Days   []string        `json:"days"`
+	Launch []LaunchCommand `json:"launch"`
+}
+
+// LaunchCommand is an application a wake routine starts, e.g.
+// {"command": "C:\\Program Files\\ProPresenter\\ProPresenter.exe"}.
+type LaunchCommand struct {
+	Command string   `json:"command"`
+	Args    []string `json:"args"`
+}
+
+// StartupApp is an application that must be running WithinMinutes after
+// boot (default 5). Process is its process name, e.g. "ProPresenter".
+// With Launch, the agent starts Command when the app hasn't started by
+// then.
+type StartupApp struct {
+	Process       string   `json:"process"`
+	Command       string   `json:"command"`
+	Args          []string `json:"args"`
+	WithinMinutes int      `json:"withinMinutes"`
+	Launch        bool     `json:"launch"`
+}
+
+// OBSConfig checks OBS Studio on this machine through its WebSocket server
+// (OBS 28 and later: Tools > WebSocket Server Settings). Setting Profile,
+// SceneCollection, or Sources turns it on. Sources are input names that
+// must exist and not be in error: a missing media or image file, a media
+// source that failed, or an NDI source whose sender isn't on the network.
+// Stream checks the stream destination without the key ever leaving OBS.
+type OBSConfig struct {
+	Port            int             `json:"port"` // default 4455
+	Password        string          `json:"password"`
+	Profile         string          `json:"profile"`
+	SceneCollection string          `json:"sceneCollection"`
+	Sources         []string        `json:"sources"`
+	Stream          OBSStreamConfig `json:"stream"`
+}
+
+// OBSStreamConfig is the expected stream destination. KeySHA256 is the
+// hex SHA-256 of the stream key (dashboard-agent obs hash-key prints it),
+// so neither the config nor /status holds the key itself.
+type OBSStreamConfig struct {
+	Server    string `json:"server"`
+	KeySHA256 string `json:"keySha256"`
+}
+
+// Instance is a logical agent served by this process for another machine,
+// e.g. a VM on this host whose own agent is only reachable on an internal
+// switch. It gets its own fixed Port and mDNS record under Name; its
+// /status is the agent at StatusURL, fetched with Token when set. UUID
+// overrides the hardware UUID advertised before the first fetch succeeds.
+type Instance struct {
+	Name      string `json:"name"`
+	Port      int    `json:"port"`
+	UUID      string `json:"uuid"`
+	StatusURL string `json:"statusURL"`
+	Token     string `json:"token"`
+}
+
+// ContainersConfig controls the containers field of /status, listed with
+// the docker CLI (or nerdctl for plain containerd). Expected names
+// containers that must exist and be running, e.g. ["companion",
+// "node-red"].
+type ContainersConfig struct {
+	Disabled bool     `json:"disabled"`
+	Expected []string `json:"expected"`
+}
+
+// BluetoothConfig controls the bluetooth field of /status. Expected
+// names (or addresses of) devices that must stay connected, e.g.
+// ["Logitech R500"]; any device whose battery reports under
+// LowBatteryPercent (default 20) is flagged too.
+type BluetoothConfig struct {
+	Disabled          bool     `json:"disabled"`
+	Expected          []string `json:"expected"`
+	LowBatteryPercent int      `json:"lowBatteryPercent"`
+}
+
+// BackupConfig pulls application data daily at Time (local HH:MM, default
+// "03:00") into a zip in the agent's data directory, keeping the last Keep
+// (default 14). With Upload.URL set, each zip is also POSTed there, so a
+// dead machine's configs survive it.
+type BackupConfig struct {
+	Items  []BackupItem `json:"items"`
+	Time   string       `json:"time"`
+	Keep   int          `json:"keep"`
+	Upload BackupUpload `json:"upload"`
+}
+
+// BackupItem is one thing to back up: the response to a GET of URL (sent
+// with Token as a bearer token when set), or the file or folder at Path. A
+// Preset fills these in: "companion" (Companion's full config export),
+// "nodered" (Node-RED flows), or "obs" (OBS scene collections).
+type BackupItem struct {
+	Name   string `json:"name"`
+	Preset string `json:"preset"`
+	URL    string `json:"url"`
+	Token  string `json:"token"`
+	Path   string `json:"path"`
+}
+
+// BackupUpload is where each backup zip is POSTed, e.g. the dashboard
+// server.
+type BackupUpload struct {
+	URL   string `json:"url"`
+	Token string `json:"token"` // sent as a bearer token
+}
+
+// SnapshotConfig controls the settings snapshot: power plan, display
+// layout, addressing, default audio device, service startup types, and
+// production apps' firewall rules, compared with a baseline.
+// FirewallKeywords picks the firewall rules by name (default: common
+// production apps and the agent). Ignore lists setting key prefixes to
+// leave out, e.g. ["service."].
+type SnapshotConfig struct {
+	Disabled         bool     `json:"disabled"`
+	FirewallKeywords []string `json:"firewallKeywords"`
+	Ignore           []string `json:"ignore"`
+}
+
+// SharedFolder is a folder the dashboard may browse, e.g. {"name":
+// "recordings", "path": "D:\\Recordings"}. Name is its URL segment.
+type SharedFolder struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+// ForegroundWindowConfig controls the foregroundWindow field of /status.
+// HideTitle reports the process but not the window title, which can name
+// private documents.
+type ForegroundWindowConfig struct {
+	Disabled  bool `json:"disabled"`
+	HideTitle bool `json:"hideTitle"`
+}
+
+// DisplayMode is an expected display mode. Zero fields aren't checked.
+type DisplayMode struct {
+	Width        int `json:"width"`
+	Height       int `json:"height"`
+	RefreshHz    int `json:"refreshHz"`
+	ScalePercent int `json:"scalePercent"`
+}
+
+// AlertsConfig sets the alert routing policy. Zero values use defaults.
+type AlertsConfig struct {
+	// QuietHours holds back non-critical notifications overnight. Ignored
+	// in the hour before and during a service (see PlanningCenter).
+	QuietHours *QuietHours `json:"quietHours"`
+	// EscalateAfterMinutes promotes an unacknowledged warning to critical
+	// (default 30).
+	EscalateAfterMinutes int `json:"escalateAfterMinutes"`
+	// RepeatMinutes is how often an unacknowledged alert is re-sent (default 60).
+	RepeatMinutes int `json:"repeatMinutes"`
+	// DisableToasts turns off Windows toasts for local alerts (disk full,
+	// temperature), update notices, and dashboard disconnects.
+	DisableToasts bool `json:"disableToasts"`
+
+	Webhooks []WebhookConfig  `json:"webhooks"`
+	Email    *EmailConfig     `json:"email"`
+	SMS      *TwilioConfig    `json:"sms"`
+	Ntfy     []NtfyConfig     `json:"ntfy"`
+	Pushover []PushoverConfig `json:"pushover"`
+}
+
+// WebhookConfig posts alerts to a URL. Format is "slack", "discord",
+// "teams", or "generic" (the alert as JSON, the default). Template, if
+// set, is a Go text/template that renders the request body instead.
+type WebhookConfig struct {
+	URL         string `json:"url"`
+	Format      string `json:"format"`
+	Template    string `json:"template"`
+	MinSeverity string `json:"minSeverity"` // "warning", "critical"; empty sends all
+}
+
+// QuietHours is a local-time window, e.g. {"start": "22:00", "end": "07:00"}.
+type QuietHours struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// EmailConfig sends alerts over SMTP. Port defaults to 587 (STARTTLS);
+// 465 uses implicit TLS.
+type EmailConfig struct {
+	Host       string      `json:"host"`
+	Port       int         `json:"port"`
+	Username   string      `json:"username"`
+	Password   string      `json:"password"`
+	From       string      `json:"from"`
+	Recipients []Recipient `json:"recipients"`
+}
+
+// TwilioConfig sends alerts as SMS through Twilio.
+type TwilioConfig struct {
+	AccountSID string      `json:"accountSid"`
+	AuthToken  string      `json:"authToken"`
+	From       string      `json:"from"` // Twilio number, E.164
+	Recipients []Recipient `json:"recipients"`
+}
+
+// NtfyConfig publishes alerts to an ntfy topic. Server defaults to
+// https://ntfy.sh; Token is only needed for protected topics.
+type NtfyConfig struct {
+	Server      string `json:"server"`
+	Topic       string `json:"topic"`
+	Token       string `json:"token"`
+	MinSeverity string `json:"minSeverity"`
+}
+
+// PushoverConfig sends alerts to a Pushover user or group key.
+type PushoverConfig struct {
+	AppToken    string `json:"appToken"`
+	UserKey     string `json:"userKey"`
+	MinSeverity string `json:"minSeverity"`
+}
+
+// Recipient is an email address or phone number with its own severity
+// filter, so the on-call volunteer only gets texted for critical alerts.
+type Recipient struct {
+	Address     string `json:"address"`
+	MinSeverity string `json:"minSeverity"`
+}
+
+// ReportConfig schedules the weekly health report: uptime, alerts raised,
+// disk trends, and pending updates. It is mailed to Recipients through the
+// alerts.email SMTP settings and/or saved as HTML in Folder. With neither
+// set, the report is only available from GET /report.
+type ReportConfig struct {
+	Recipients []string `json:"recipients"`
+	Folder     string   `json:"folder"`
+	Day        string   `json:"day"`  // weekday, default "monday"
+	Time       string   `json:"time"` // local HH:MM, default "08:00"
+}
+
+// HistoryConfig sizes the metrics history behind GET /history/export and
+// the cooling trend. Zero values use defaults.
+type HistoryConfig struct {
+	IntervalSeconds int `json:"intervalSeconds"` // default 60; at least 5
+	RetentionDays   int `json:"retentionDays"`   // default 30
+	// MaxMB caps the history on disk; the oldest days go first. 0 is no
+	// cap beyond RetentionDays.
+	MaxMB int `json:"maxMB"`
+}
+
+// UpdatesConfig stages agent releases. With Ring or CanaryPercent set,
+// canary machines install a release as soon as it is published and the
+// rest wait until it is marked stable in its notes or has been out
+// StableAfterHours. Without either, every agent installs releases at once.
+// Pre-releases install only on the "beta" Channel.
+type UpdatesConfig struct {
+	Channel       string `json:"channel"`       // "stable" (default) or "beta"; the tray's choice overrides it
+	Ring          string `json:"ring"`          // "canary" or "stable"
+	CanaryPercent int    `json:"canaryPercent"` // of machines, by hardware UUID, when Ring is empty
+	// StableAfterHours lets stable machines take a release this long after
+	// it was published even if it isn't marked; 0 waits for the mark.
+	StableAfterHours int `json:"stableAfterHours"`
+	// ReportURL receives each update's outcome as a POSTed JSON object,
+	// with ReportToken as a bearer token when set.
+	ReportURL   string `json:"reportURL"`
+	ReportToken string `json:"reportToken"`
+}
+
+// FlightRecorderConfig sizes the per-second sample window saved with each
+// raised alert. Zero values use defaults.
+type FlightRecorderConfig struct {
+	Disabled      bool `json:"disabled"`
+	BeforeSeconds int  `json:"beforeSeconds"` // default 60
+	AfterSeconds  int  `json:"afterSeconds"`  // default 30
+}
+
+// BudgetConfig caps the agent's own footprint. Over budget, the agent
+// collects less often and then skips optional probes. Zero values use
+// defaults.
+type BudgetConfig struct {
+	Disabled   bool    `json:"disabled"`
+	CPUPercent float64 `json:"cpuPercent"` // of the whole machine; default 5
+	MemoryMB   int     `json:"memoryMB"`   // resident; default 250
+}
+
+// EventsConfig controls where lifecycle events, alerts, and remote actions
+// are recorded. The Windows Event Log is on by default.
+type EventsConfig struct {
+	DisableEventLog bool          `json:"disableEventLog"`
+	Syslog          *SyslogConfig `json:"syslog"`
+}
+
+// SyslogConfig forwards events to a remote syslog server in RFC 5424
+// format.
+type SyslogConfig struct {
+	Address  string `json:"address"`  // host:port
+	Protocol string `json:"protocol"` // "udp" (default) or "tcp"
+	Facility int    `json:"facility"` // default 16 (local0)
+}
+
+// ExportConfig pushes metric samples to a time-series database. Samples
+// are taken every IntervalSeconds (default 30) and sent in batches once a
+// minute; a batch that fails is retried with the next one.
+type ExportConfig struct {
+	IntervalSeconds int                     `json:"intervalSeconds"`
+	Influx          *InfluxConfig           `json:"influx"`
+	RemoteWrite     *PrometheusRemoteConfig `json:"prometheusRemoteWrite"`
+}
+
+// InfluxConfig writes InfluxDB line protocol. Set Org and Bucket for the
+// v2 API (Token as the API token), or Database for a 1.x server.
+type InfluxConfig struct {
+	URL      string `json:"url"` // e.g. "http://influx.local:8086"
+	Token    string `json:"token"`
+	Org      string `json:"org"`
+	Bucket   string `json:"bucket"`
+	Database string `json:"database"`
+}
+
+// PrometheusRemoteConfig sends Prometheus remote-write requests, e.g. to
+// Prometheus, Mimir, VictoriaMetrics, or TimescaleDB's Promscale.
+type PrometheusRemoteConfig struct {
+	URL         string `json:"url"`
+	Username    string `json:"username"`
+	Password    string `json:"password"`
+	BearerToken string `json:"bearerToken"`
+}
+
+// SNMPConfig enables the read-only SNMP v1/v2c agent. Setting Community
+// turns it on. EnterpriseOID roots the AVL-DASHBOARD-MIB objects; the
+// default is under Net-SNMP's arc for unregistered local MIBs.
+type SNMPConfig struct {
+	Community     string `json:"community"`
+	Port          int    `json:"port"` // UDP, default 161
+	EnterpriseOID string `json:"enterpriseOid"`
+}
+
+// PlanningCenterConfig enables reading upcoming service times from
+// Planning Center Services using a personal access token.
+type PlanningCenterConfig struct {
+	AppID  string `json:"appId"`
+	Secret string `json:"secret"`
+	// ServiceTypeIDs limits which service types count; empty means all.
+	ServiceTypeIDs []string `json:"serviceTypeIds"`
+}
+
+// FirewallConfig controls the Windows Firewall rules the agent keeps for
+// its port and mDNS. Disable it where firewall policy is centrally managed.
+type FirewallConfig struct {
+	Disabled bool `json:"disabled"`
+}
+
+// WatchdogConfig controls the Windows supervisor process. On Linux the
+// systemd unit's WatchdogSec plays this role.
+type WatchdogConfig struct {
+	Disabled bool `json:"disabled"`
+}
@@ -0,0 +1,190 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FleetDocument is configuration distributed to many agents by a dashboard
+// or config server. Config uses the same keys as the local file.
+type FleetDocument struct {
+	Version string          `json:"version"`
+	Config  json.RawMessage `json:"config"`
+}
+
+// localOnlyKeys can't be set by a fleet document: they decide where fleet
+// documents come from, who may change the agent, what it runs, and what
+// it serves, so a compromised or mistaken config server can't take over,
+// lock out, or read from a machine. A dot names a key inside an object;
+// "[]" applies the rest to every element of an array.
+var localOnlyKeys = []string{
+	"fleet", "authToken", "tokens", "requireAuth", "pairing.scope",
+	"kiosk.command", "kiosk.args",
+	"routines[].launch",
+	"startupApps[].command", "startupApps[].args",
+	"sharedFolders",
+}
+
+// FleetPath returns where the active fleet document is stored.
+func FleetPath() string {
+	return filepath.Join(DataDir(), "fleet.json")
+}
+
+// AssetsDir returns where the updater installs the asset pack shipped
+// with releases (see update.Updater).
+func AssetsDir() string {
+	return filepath.Join(DataDir(), "assets")
+}
+
+// PackPath returns the asset pack's config layer.
+func PackPath() string {
+	return filepath.Join(AssetsDir(), "config.json")
+}
+
+// LoadLayered loads the asset pack's config at packPath, the fleet
+// document at fleetPath over it, and the local file at localPath on top,
+// so a machine can override any fleet setting and the fleet any pack
+// default. Objects are merged key by key; arrays and scalars in a higher
+// layer replace the lower value. Missing files are not errors. A fleet
+// document that doesn't parse is logged and skipped, so a damaged
+// fleet.json can't undo the local settings, including the fleet URL that
+// would replace it. On any other parse error the defaults are returned
+// along with the error.
+func LoadLayered(packPath, fleetPath, localPath string) (*Config, error) {
+	merged := map[string]any{}
+	if data, err := os.ReadFile(packPath); err == nil {
+		pack, err := (&FleetDocument{Config: data}).values()
+		if err != nil {
+			return &Config{}, err
+		}
+		merged = pack
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return &Config{}, err
+	}
+	if fleet, err := fleetValues(fleetPath); err != nil {
+		log.Printf("Config: ignoring fleet document %s: %v", fleetPath, err)
+	} else if fleet != nil {
+		merged = mergeValues(merged, fleet)
+	}
+
+	data, err := os.ReadFile(localPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return &Config{}, err
+	}
+	if len(data) > 0 {
+		var local map[string]any
+		if err := json.Unmarshal(data, &local); err != nil {
+			return &Config{}, err
+		}
+		merged = mergeValues(merged, local)
+	}
+
+	raw, _ := json.Marshal(merged)
+	cfg := &Config{}
+	if err := json.Unmarshal(raw, cfg); err != nil {
+		return &Config{}, err
+	}
+	return cfg, nil
+}
+
+// fleetValues reads the fleet document's config layer, checking that it
+// decodes into a Config on its own. Returns nil, nil if there is none.
+func fleetValues(path string) (map[string]any, error) {
+	doc, err := ReadFleetDocument(path)
+	if err != nil || doc == nil {
+		return nil, err
+	}
+	values, err := doc.values()
+	if err != nil {
+		return nil, err
+	}
+	raw, _ := json.Marshal(values)
+	if err := json.Unmarshal(raw, &Config{}); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// ReadFleetDocument reads a stored fleet document. Returns nil, nil if
+// there is none.
+func ReadFleetDocument(path string) (*FleetDocument, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	doc := &FleetDocument{}
+	if err := json.Unmarshal(data, doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// Validate checks that the document has a version and decodes into a Config.
+func (d *FleetDocument) Validate() error {
+	if d.Version == "" {
+		return errors.New("fleet document has no version")
+	}
+	values, err := d.values()
+	if err != nil {
+		return err
+	}
+	raw, _ := json.Marshal(values)
+	return json.Unmarshal(raw, &Config{})
+}
+
+// values decodes the document's config with local-only keys removed.
+func (d *FleetDocument) values() (map[string]any, error) {
+	values := map[string]any{}
+	if len(d.Config) > 0 {
+		if err := json.Unmarshal(d.Config, &values); err != nil {
+			return nil, err
+		}
+	}
+	for _, key := range localOnlyKeys {
+		removeKey(values, key)
+	}
+	return values, nil
+}
+
+// removeKey deletes a localOnlyKeys path from values.
+func removeKey(values map[string]any, path string) {
+	key, rest, nested := strings.Cut(path, ".")
+	if !nested {
+		delete(values, key)
+		return
+	}
+	if name, ok := strings.CutSuffix(key, "[]"); ok {
+		list, _ := values[name].([]any)
+		for _, item := range list {
+			if obj, ok := item.(map[string]any); ok {
+				removeKey(obj, rest)
+			}
+		}
+		return
+	}
+	if obj, ok := values[key].(map[string]any); ok {
+		removeKey(obj, rest)
+	}
+}
+
+// mergeValues overlays override onto base, recursing into objects.
+func mergeValues(base, override map[string]any) map[string]any {
+	for k, v := range override {
+		if sub, ok := v.(map[string]any); ok {
+			if baseSub, ok := base[k].(map[string]any); ok {
+				base[k] = mergeValues(baseSub, sub)
+				continue
+			}
+		}
+		base[k] = v
+	}
+	return base
+}
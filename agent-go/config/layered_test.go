@@ -0,0 +1,59 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFleetCannotSetLocalOnlyKeys(t *testing.T) {
+	dir := t.TempDir()
+	fleet := filepath.Join(dir, "fleet.json")
+	local := filepath.Join(dir, "agent.json")
+	write := func(path, data string) {
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(fleet, `{"version": "1", "config": {
+		"fleet": {"url": "http://evil.example"},
+		"authToken": "fleet", "tokens": [{"name": "x", "token": "y", "scope": "admin"}], "requireAuth": false,
+		"pairing": {"scope": "admin", "disabled": true},
+		"kiosk": {"process": "msedge", "command": "cmd.exe", "args": ["/c", "evil"]},
+		"routines": [{"name": "wake", "action": "wake", "at": "06:00", "launch": [{"command": "cmd.exe"}]}],
+		"startupApps": [{"process": "obs64", "command": "cmd.exe", "args": ["/c", "evil"], "launch": true}],
+		"sharedFolders": [{"name": "c", "path": "C:\\"}],
+		"port": 50000
+	}}`)
+	write(local, `{"authToken": "local", "requireAuth": true, "kiosk": {"command": "msedge.exe"}}`)
+
+	cfg, err := LoadLayered(filepath.Join(dir, "missing.json"), fleet, local)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Fleet.URL != "" {
+		t.Errorf("fleet.url = %q, want it unset", cfg.Fleet.URL)
+	}
+	if cfg.AuthToken != "local" || len(cfg.Tokens) != 0 || !cfg.RequireAuth {
+		t.Errorf("auth = %q, %v, %v; want the local file's", cfg.AuthToken, cfg.Tokens, cfg.RequireAuth)
+	}
+	if cfg.Pairing.Scope != "" || !cfg.Pairing.Disabled {
+		t.Errorf("pairing = %+v, want only disabled from the fleet", cfg.Pairing)
+	}
+	if cfg.Kiosk.Command != "msedge.exe" || len(cfg.Kiosk.Args) != 0 || cfg.Kiosk.Process != "msedge" {
+		t.Errorf("kiosk = %+v, want the local command and the fleet process", cfg.Kiosk)
+	}
+	if len(cfg.Routines) != 1 || len(cfg.Routines[0].Launch) != 0 {
+		t.Errorf("routines = %+v, want the routine without launch", cfg.Routines)
+	}
+	if len(cfg.StartupApps) != 1 || cfg.StartupApps[0].Command != "" || len(cfg.StartupApps[0].Args) != 0 {
+		t.Errorf("startupApps = %+v, want the app without command or args", cfg.StartupApps)
+	}
+	if len(cfg.SharedFolders) != 0 {
+		t.Errorf("sharedFolders = %+v, want none", cfg.SharedFolders)
+	}
+	if cfg.Port != 50000 {
+		t.Errorf("port = %d, want the fleet's 50000", cfg.Port)
+	}
+}
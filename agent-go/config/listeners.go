@@ -0,0 +1,41 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ListenerPresets are the ports of common production apps.
+var ListenerPresets = map[string]ListenerCheck{
+	"obs":   {Name: "OBS WebSocket", Port: 4455, Protocol: "tcp", Process: "obs64"},
+	"ndi":   {Name: "NDI", Port: 5960, PortEnd: 5999, Protocol: "tcp"}, // one port per source
+	"vmix":  {Name: "vMix API", Port: 8088, Protocol: "tcp", Process: "vMix64"},
+	"dante": {Name: "Dante control", Port: 4440, Protocol: "udp"},
+}
+
+// Resolved returns the check with its preset applied and defaults filled in.
+func (l ListenerCheck) Resolved() ListenerCheck {
+	r := ListenerPresets[l.Preset]
+	if l.Name != "" {
+		r.Name = l.Name
+	}
+	if l.Port != 0 {
+		r.Port, r.PortEnd = l.Port, l.PortEnd
+	}
+	if l.Protocol != "" {
+		r.Protocol = l.Protocol
+	}
+	if l.Process != "" {
+		r.Process = l.Process
+	}
+	if r.Protocol == "" {
+		r.Protocol = "tcp"
+	}
+	if r.PortEnd < r.Port {
+		r.PortEnd = r.Port
+	}
+	if r.Name == "" {
+		r.Name = fmt.Sprintf("%s %d", strings.ToUpper(r.Protocol), r.Port)
+	}
+	return r
+}
@@ -0,0 +1,13 @@
+//go:build linux
+
+package config
+
+// DefaultPath returns the system-wide config location used by the systemd unit.
+func DefaultPath() string {
+	return "/etc/dashboard-agent/config.json"
+}
+
+// DataDir returns the directory for persisted agent state.
+func DataDir() string {
+	return "/var/lib/dashboard-agent"
+}
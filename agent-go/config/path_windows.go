@@ -0,0 +1,63 @@
+//go:build windows
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/sys/windows"
+)
+
+// DefaultPath returns %ProgramData%\AVL-Dashboard\agent.json.
+func DefaultPath() string {
+	return filepath.Join(machineDir(), "agent.json")
+}
+
+// DataDir returns where the agent keeps its persisted state. The service
+// (and an elevated agent) use %ProgramData%\AVL-Dashboard, which only
+// administrators and SYSTEM may write, as it holds what the service runs.
+// The tray runs as the signed-in user and keeps its own state in
+// %LOCALAPPDATA%\AVL-Dashboard.
+func DataDir() string {
+	return dataDir()
+}
+
+var dataDir = sync.OnceValue(func() string {
+	if windows.GetCurrentProcessToken().IsElevated() {
+		return machineDir()
+	}
+	base := os.Getenv("LOCALAPPDATA")
+	if base == "" {
+		return machineDir()
+	}
+	dir := filepath.Join(base, "AVL-Dashboard")
+	migrateUserState(dir)
+	return dir
+})
+
+// machineDir returns %ProgramData%\AVL-Dashboard.
+func machineDir() string {
+	base := os.Getenv("ProgramData")
+	if base == "" {
+		base = `C:\ProgramData`
+	}
+	return filepath.Join(base, "AVL-Dashboard")
+}
+
+// migrateUserState copies the state a tray agent kept in %ProgramData%
+// before it moved to dir, so the machine keeps its identity and counters.
+func migrateUserState(dir string) {
+	if _, err := os.Stat(dir); err == nil {
+		return
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return
+	}
+	for _, name := range []string{"hardware-id.json", "state.json"} {
+		if data, err := os.ReadFile(filepath.Join(machineDir(), name)); err == nil {
+			os.WriteFile(filepath.Join(dir, name), data, 0o644)
+		}
+	}
+}
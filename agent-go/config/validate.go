@@ -0,0 +1,411 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// maxFlightSeconds bounds each side of a flight recorder window.
+const maxFlightSeconds = 600
+
+var (
+	validScopes     = map[string]bool{"": true, "viewer": true, "operator": true, "admin": true}
+	validSeverities = map[string]bool{"": true, "info": true, "warning": true, "critical": true}
+	validFormats    = map[string]bool{"": true, "slack": true, "discord": true, "teams": true, "generic": true}
+	validActions    = map[string]bool{"wake": true, "sleep": true, "hibernate": true, "shutdown": true}
+	clockPattern    = regexp.MustCompile(`^([01]\d|2[0-3]):[0-5]\d$`)
+	oidPattern      = regexp.MustCompile(`^\.?\d+(\.\d+)+$`)
+	usbIDPattern    = regexp.MustCompile(`^[0-9A-Fa-f]{4}:[0-9A-Fa-f]{4}$`)
+	sha256Pattern   = regexp.MustCompile(`^[0-9A-Fa-f]{64}$`)
+)
+
+// Validate checks the local file, the stored fleet document, and the asset
+// pack's config more strictly than loading does: unknown keys (usually
+// typos) and out-of-range values are reported instead of silently ignored.
+// It returns every problem found.
+func Validate(packPath, fleetPath, localPath string) []error {
+	var problems []error
+
+	if data, err := os.ReadFile(packPath); err == nil {
+		problems = append(problems, strictDecode(packPath, data)...)
+	} else if !os.IsNotExist(err) {
+		problems = append(problems, err)
+	}
+
+	if doc, err := ReadFleetDocument(fleetPath); err != nil {
+		problems = append(problems, fmt.Errorf("%s: %w", fleetPath, err))
+	} else if doc != nil {
+		if err := doc.Validate(); err != nil {
+			problems = append(problems, fmt.Errorf("%s: %w", fleetPath, err))
+		} else if len(doc.Config) > 0 {
+			problems = append(problems, strictDecode(fleetPath, doc.Config)...)
+		}
+	}
+
+	if data, err := os.ReadFile(localPath); err == nil {
+		problems = append(problems, strictDecode(localPath, data)...)
+	} else if !os.IsNotExist(err) {
+		problems = append(problems, err)
+	}
+
+	cfg, err := LoadLayered(packPath, fleetPath, localPath)
+	if err != nil {
+		return problems
+	}
+	return append(problems, cfg.check()...)
+}
+
+// strictDecode reports keys that don't exist in Config.
+func strictDecode(path string, data []byte) []error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&Config{}); err != nil {
+		return []error{fmt.Errorf("%s: %w", path, err)}
+	}
+	return nil
+}
+
+// check validates enumerated values in the effective config.
+func (c *Config) check() []error {
+	var problems []error
+	bad := func(format string, args ...any) {
+		problems = append(problems, fmt.Errorf(format, args...))
+	}
+
+	if c.Port < 0 || c.Port > 65535 {
+		bad("port: %d is out of range", c.Port)
+	}
+	for _, t := range c.Tokens {
+		if t.Token == "" {
+			bad("tokens: %q has no token", t.Name)
+		}
+		if t.Scope == "" || !validScopes[strings.ToLower(t.Scope)] {
+			bad("tokens: %q has scope %q (want viewer, operator, or admin)", t.Name, t.Scope)
+		}
+	}
+	if !validScopes[strings.ToLower(c.Pairing.Scope)] {
+		bad("pairing.scope: %q (want viewer, operator, or admin)", c.Pairing.Scope)
+	}
+
+	if q := c.Alerts.QuietHours; q != nil && (!clockPattern.MatchString(q.Start) || !clockPattern.MatchString(q.End)) {
+		bad("alerts.quietHours: start and end must be HH:MM")
+	}
+	for _, w := range c.Alerts.Webhooks {
+		if w.URL == "" {
+			bad("alerts.webhooks: entry has no url")
+		}
+		if !validFormats[w.Format] {
+			bad("alerts.webhooks: format %q (want slack, discord, teams, or generic)", w.Format)
+		}
+		if !validSeverities[w.MinSeverity] {
+			bad("alerts.webhooks: minSeverity %q", w.MinSeverity)
+		}
+	}
+	for _, n := range c.Alerts.Ntfy {
+		if !validSeverities[n.MinSeverity] {
+			bad("alerts.ntfy: minSeverity %q", n.MinSeverity)
+		}
+	}
+	for _, p := range c.Alerts.Pushover {
+		if !validSeverities[p.MinSeverity] {
+			bad("alerts.pushover: minSeverity %q", p.MinSeverity)
+		}
+	}
+
+	if r := c.Report; r.Day != "" && !validWeekday(r.Day) {
+		bad("report.day: %q is not a weekday name", r.Day)
+	}
+	if t := c.Report.Time; t != "" && !clockPattern.MatchString(t) {
+		bad("report.time: %q must be HH:MM", t)
+	}
+	if len(c.Report.Recipients) > 0 && (c.Alerts.Email == nil || c.Alerts.Email.Host == "") {
+		bad("report.recipients: alerts.email must be configured to send the report")
+	}
+
+	if ch := c.Updates.Channel; ch != "" && ch != "stable" && ch != "beta" {
+		bad("updates.channel: %q must be stable or beta", ch)
+	}
+	if r := c.Updates.Ring; r != "" && r != "canary" && r != "stable" {
+		bad("updates.ring: %q must be canary or stable", r)
+	}
+	if p := c.Updates.CanaryPercent; p < 0 || p > 100 {
+		bad("updates.canaryPercent: %d is out of range (0-100)", p)
+	}
+	if c.Updates.StableAfterHours < 0 {
+		bad("updates.stableAfterHours: %d is negative", c.Updates.StableAfterHours)
+	}
+	if h := c.History; h.IntervalSeconds != 0 && (h.IntervalSeconds < 5 || h.IntervalSeconds > 3600) {
+		bad("history.intervalSeconds: %d is out of range (5-3600)", h.IntervalSeconds)
+	}
+	if c.History.RetentionDays < 0 {
+		bad("history.retentionDays: %d is negative", c.History.RetentionDays)
+	}
+	if c.History.MaxMB < 0 {
+		bad("history.maxMB: %d is negative", c.History.MaxMB)
+	}
+
+	if f := c.FlightRecorder; f.BeforeSeconds < 0 || f.BeforeSeconds > maxFlightSeconds {
+		bad("flightRecorder.beforeSeconds: %d is out of range (0-%d)", f.BeforeSeconds, maxFlightSeconds)
+	}
+	if f := c.FlightRecorder; f.AfterSeconds < 0 || f.AfterSeconds > maxFlightSeconds {
+		bad("flightRecorder.afterSeconds: %d is out of range (0-%d)", f.AfterSeconds, maxFlightSeconds)
+	}
+
+	if b := c.Budget; b.CPUPercent < 0 || b.CPUPercent > 100 {
+		bad("budget.cpuPercent: %g is out of range (0-100)", b.CPUPercent)
+	}
+	if c.Budget.MemoryMB < 0 {
+		bad("budget.memoryMB: %d is negative", c.Budget.MemoryMB)
+	}
+
+	for name, subnets := range c.Network.Subnets {
+		for _, s := range subnets {
+			if _, _, err := net.ParseCIDR(s); err != nil {
+				bad("network.subnets: %q has invalid subnet %q", name, s)
+			}
+		}
+	}
+	for role, subnets := range c.Network.SubnetsByRole {
+		for _, s := range subnets {
+			if _, _, err := net.ParseCIDR(s); err != nil {
+				bad("network.subnetsByRole: %q has invalid subnet %q", role, s)
+			}
+		}
+	}
+
+	for _, f := range c.Recording.Folders {
+		if f.Path == "" {
+			bad("recording.folders: entry has no path")
+		}
+	}
+	if v := c.Recording.Verify; len(v.At) > 0 || v.AfterServices {
+		if len(c.Recording.Folders) == 0 {
+			bad("recording.verify: needs recording.folders")
+		}
+		for _, t := range v.At {
+			if !clockPattern.MatchString(t) {
+				bad("recording.verify.at: %q must be HH:MM", t)
+			}
+		}
+		for _, d := range v.Days {
+			if !validWeekday(d) {
+				bad("recording.verify.days: %q is not a weekday name", d)
+			}
+		}
+		if v.ExpectedMinutes < 0 || v.ToleranceMinutes < 0 {
+			bad("recording.verify: minutes must not be negative")
+		}
+	}
+	for _, f := range c.Checks.Files {
+		if f.Path == "" {
+			bad("checks.files: %q has no path", f.Name)
+		}
+	}
+	for _, l := range c.Checks.Listeners {
+		if _, ok := ListenerPresets[l.Preset]; l.Preset != "" && !ok {
+			bad("checks.listeners: unknown preset %q (want obs, ndi, vmix, or dante)", l.Preset)
+			continue
+		}
+		r := l.Resolved()
+		if r.Port < 1 || r.PortEnd > 65535 {
+			bad("checks.listeners: %q has port %d-%d out of range", r.Name, r.Port, r.PortEnd)
+		}
+		if r.Protocol != "tcp" && r.Protocol != "udp" {
+			bad("checks.listeners: %q has protocol %q (want tcp or udp)", r.Name, r.Protocol)
+		}
+	}
+	for _, cert := range c.Checks.Certificates {
+		if _, err := CertificateAddress(cert.URL); err != nil {
+			bad("checks.certificates: %q: %v", cert.URL, err)
+		}
+		if cert.WarnDays < 0 {
+			bad("checks.certificates: %q has negative warnDays", cert.URL)
+		}
+	}
+	for _, l := range c.Checks.Licenses {
+		if l.Name == "" {
+			bad("checks.licenses: entry has no name")
+		}
+		if l.Expires == "" && l.USBDevice == "" && l.File == "" {
+			bad("checks.licenses: %q needs expires, usbDevice, or file", l.Name)
+		}
+		if _, err := time.Parse("2006-01-02", l.Expires); l.Expires != "" && err != nil {
+			bad("checks.licenses: %q expires %q is not YYYY-MM-DD", l.Name, l.Expires)
+		}
+		if l.USBDevice != "" && !usbIDPattern.MatchString(l.USBDevice) {
+			bad("checks.licenses: %q usbDevice %q is not vid:pid in hex", l.Name, l.USBDevice)
+		}
+		if l.WarnDays < 0 {
+			bad("checks.licenses: %q has negative warnDays", l.Name)
+		}
+	}
+	for _, p := range c.Displays.PowerSchedule {
+		if p.Output == "" {
+			bad("displays.powerSchedule: entry has no output")
+		}
+		if p.On == "" && p.Off == "" {
+			bad("displays.powerSchedule: %q needs on or off", p.Output)
+		}
+		for _, t := range []string{p.On, p.Off} {
+			if t != "" && !clockPattern.MatchString(t) {
+				bad("displays.powerSchedule: %q time %q must be HH:MM", p.Output, t)
+			}
+		}
+		for _, d := range p.Days {
+			if !validWeekday(d) {
+				bad("displays.powerSchedule: %q day %q is not a weekday name", p.Output, d)
+			}
+		}
+	}
+	for _, r := range c.Routines {
+		if !validActions[r.Action] {
+			bad("routines: %q has action %q (want wake, sleep, hibernate, or shutdown)", r.Name, r.Action)
+		}
+		if !clockPattern.MatchString(r.At) {
+			bad("routines: %q at %q must be HH:MM", r.Name, r.At)
+		}
+		for _, d := range r.Days {
+			if !validWeekday(d) {
+				bad("routines: %q day %q is not a weekday name", r.Name, d)
+			}
+		}
+		if len(r.Launch) > 0 && r.Action != "wake" {
+			bad("routines: %q launches apps but isn't a wake routine", r.Name)
+		}
+		for _, l := range r.Launch {
+			if l.Command == "" {
+				bad("routines: %q has a launch entry with no command", r.Name)
+			}
+		}
+	}
+	for _, a := range c.StartupApps {
+		if a.Process == "" {
+			bad("startupApps: entry has no process")
+		}
+		if a.WithinMinutes < 0 {
+			bad("startupApps: %q withinMinutes must not be negative", a.Process)
+		}
+		if a.Launch && a.Command == "" {
+			bad("startupApps: %q needs a command to launch", a.Process)
+		}
+	}
+	if c.OBS.Port < 0 || c.OBS.Port > 65535 {
+		bad("obs.port: %d is not a valid port", c.OBS.Port)
+	}
+	if h := c.OBS.Stream.KeySHA256; h != "" && !sha256Pattern.MatchString(h) {
+		bad("obs.stream.keySha256: not a hex SHA-256; use \"obs hash-key\" to make one")
+	}
+	backupNames := map[string]bool{}
+	for _, b := range c.Backup.Items {
+		if _, ok := BackupPresets[b.Preset]; b.Preset != "" && !ok {
+			bad("backup.items: unknown preset %q (want companion, nodered, or obs)", b.Preset)
+			continue
+		}
+		r := b.Resolved()
+		switch {
+		case r.Name == "":
+			bad("backup.items: entry has no name")
+		case backupNames[strings.ToLower(r.Name)]:
+			bad("backup.items: %q appears twice", r.Name)
+		case r.URL == "" && r.Path == "":
+			bad("backup.items: %q needs a url or path", r.Name)
+		case r.URL != "" && r.Path != "":
+			bad("backup.items: %q has both url and path", r.Name)
+		}
+		backupNames[strings.ToLower(r.Name)] = true
+	}
+	if t := c.Backup.Time; t != "" && !clockPattern.MatchString(t) {
+		bad("backup.time: %q must be HH:MM", t)
+	}
+	if c.Backup.Keep < 0 {
+		bad("backup.keep: must not be negative")
+	}
+	if b := c.Bluetooth.LowBatteryPercent; b < 0 || b > 100 {
+		bad("bluetooth.lowBatteryPercent: %d is not 0-100", b)
+	}
+	shared := map[string]bool{}
+	for _, f := range c.SharedFolders {
+		switch {
+		case f.Name == "" || strings.ContainsAny(f.Name, `/\`):
+			bad("sharedFolders: %q needs a name without slashes", f.Path)
+		case shared[f.Name]:
+			bad("sharedFolders: %q appears twice", f.Name)
+		case f.Path == "":
+			bad("sharedFolders: %q has no path", f.Name)
+		}
+		shared[f.Name] = true
+	}
+	names, ports := map[string]bool{}, map[int]bool{c.Port: c.Port != 0}
+	for _, in := range c.Instances {
+		switch {
+		case in.Name == "":
+			bad("instances: entry has no name")
+		case names[strings.ToLower(in.Name)]:
+			bad("instances: %q appears twice", in.Name)
+		}
+		names[strings.ToLower(in.Name)] = true
+		switch {
+		case in.Port < 1 || in.Port > 65535:
+			bad("instances: %q needs a port", in.Name)
+		case ports[in.Port]:
+			bad("instances: %q port %d is taken by the agent or another instance", in.Name, in.Port)
+		}
+		ports[in.Port] = true
+		if u, err := url.Parse(in.StatusURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			bad("instances: %q statusURL %q must be an http or https URL", in.Name, in.StatusURL)
+		}
+	}
+	if k := c.Kiosk; k.Process == "" && (k.Command != "" || k.Display != "" || k.Foreground || k.AutoRelaunch) {
+		bad("kiosk.process: required to watch the kiosk")
+	} else if k.AutoRelaunch && k.Command == "" {
+		bad("kiosk.command: required for autoRelaunch")
+	}
+	for _, peer := range c.PeerClock.Peers {
+		if _, _, err := net.SplitHostPort(peer); err != nil {
+			bad("peerClock.peers: %q is not host:port", peer)
+		}
+	}
+	if c.PeerClock.ToleranceMs < 0 {
+		bad("peerClock.toleranceMs: %d is negative", c.PeerClock.ToleranceMs)
+	}
+	if i := c.Export.Influx; i != nil {
+		if i.URL == "" {
+			bad("export.influx: url is required")
+		}
+		if i.Database == "" && (i.Org == "" || i.Bucket == "") {
+			bad("export.influx: set org and bucket (v2) or database (1.x)")
+		}
+	}
+	if r := c.Export.RemoteWrite; r != nil && r.URL == "" {
+		bad("export.prometheusRemoteWrite: url is required")
+	}
+	if s := c.SNMP; s.Community != "" {
+		if s.Port < 0 || s.Port > 65535 {
+			bad("snmp.port: %d is out of range", s.Port)
+		}
+		if s.EnterpriseOID != "" && !oidPattern.MatchString(s.EnterpriseOID) {
+			bad("snmp.enterpriseOid: %q is not a dotted OID", s.EnterpriseOID)
+		}
+	}
+	if s := c.Events.Syslog; s != nil && s.Protocol != "" && s.Protocol != "udp" && s.Protocol != "tcp" {
+		bad("events.syslog.protocol: %q (want udp or tcp)", s.Protocol)
+	}
+	return problems
+}
+
+// validWeekday reports whether s names a day of the week, in any case.
+func validWeekday(s string) bool {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(s, d.String()) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,6 @@
+//go:build linux
+
+package main
+
+// attachConsole is a no-op: the Linux agent is a console program.
+func attachConsole() {}
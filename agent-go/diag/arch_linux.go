@@ -0,0 +1,24 @@
+//go:build linux
+
+package diag
+
+import (
+	"runtime"
+
+	"golang.org/x/sys/unix"
+)
+
+// NativeArch returns the machine's architecture as a GOARCH name.
+func NativeArch() string {
+	var uts unix.Utsname
+	if err := unix.Uname(&uts); err != nil {
+		return runtime.GOARCH
+	}
+	switch unix.ByteSliceToString(uts.Machine[:]) {
+	case "aarch64", "arm64":
+		return "arm64"
+	case "x86_64":
+		return "amd64"
+	}
+	return runtime.GOARCH
+}
@@ -0,0 +1,36 @@
+//go:build windows
+
+package diag
+
+import (
+	"runtime"
+
+	"golang.org/x/sys/windows"
+)
+
+// Machine types from IsWow64Process2 (IMAGE_FILE_MACHINE_*).
+const (
+	machineI386  = 0x014c
+	machineAMD64 = 0x8664
+	machineARM64 = 0xaa64
+)
+
+// NativeArch returns the machine's architecture as a GOARCH name, which
+// differs from runtime.GOARCH when an amd64 agent runs under emulation on
+// Windows on ARM.
+func NativeArch() string {
+	var process, native uint16
+	// IsWow64Process2 needs Windows 10 1709, which predates ARM64 builds.
+	if err := windows.IsWow64Process2(windows.CurrentProcess(), &process, &native); err != nil {
+		return runtime.GOARCH
+	}
+	switch native {
+	case machineARM64:
+		return "arm64"
+	case machineAMD64:
+		return "amd64"
+	case machineI386:
+		return "386"
+	}
+	return runtime.GOARCH
+}
@@ -0,0 +1,174 @@
+// Package diag builds support bundles: a zip of recent logs, redacted
+// config, status snapshots, and environment info that a volunteer can
+// attach to an issue without being walked through collecting each piece.
+package diag
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Sources are the pieces of agent state included in a bundle. Each value is
+// written as JSON; nil values are skipped.
+type Sources struct {
+	Version string
+	Config  any // secrets are redacted
+	Files   map[string]any
+}
+
+// environment describes the machine and process.
+type environment struct {
+	AgentVersion string    `json:"agentVersion"`
+	Hostname     string    `json:"hostname"`
+	OS           string    `json:"os"`
+	Arch         string    `json:"arch"`
+	NativeArch   string    `json:"nativeArch"` // differs from arch under emulation
+	GoVersion    string    `json:"goVersion"`
+	Executable   string    `json:"executable"`
+	PID          int       `json:"pid"`
+	NumCPU       int       `json:"numCPU"`
+	Generated    time.Time `json:"generated"`
+}
+
+// Build returns a zip bundle of the given sources plus the recent log and
+// environment info.
+func Build(src Sources) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	hostname, _ := os.Hostname()
+	exe, _ := os.Executable()
+	env := environment{
+		AgentVersion: src.Version,
+		Hostname:     hostname,
+		OS:           runtime.GOOS,
+		Arch:         runtime.GOARCH,
+		NativeArch:   NativeArch(),
+		GoVersion:    runtime.Version(),
+		Executable:   exe,
+		PID:          os.Getpid(),
+		NumCPU:       runtime.NumCPU(),
+		Generated:    time.Now(),
+	}
+	if err := addJSON(zw, "environment.json", env); err != nil {
+		return nil, err
+	}
+	if err := addFile(zw, "agent.log", []byte(RecentLog())); err != nil {
+		return nil, err
+	}
+	if src.Config != nil {
+		redacted, err := Redact(src.Config)
+		if err != nil {
+			return nil, err
+		}
+		if err := addJSON(zw, "config.json", redacted); err != nil {
+			return nil, err
+		}
+	}
+	for name, v := range src.Files {
+		if v == nil {
+			continue
+		}
+		if err := addJSON(zw, name, v); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// secretWords mark config keys whose values are replaced in bundles.
+var secretWords = []string{"token", "secret", "password", "userkey"}
+
+// Redact returns v as generic JSON with secret values replaced.
+func Redact(v any) (any, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic any
+	if err := json.Unmarshal(raw, &generic); err != nil {
+		return nil, err
+	}
+	return redactValue(generic), nil
+}
+
+func redactValue(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, val := range t {
+			if s, ok := val.(string); ok && s != "" {
+				if isSecret(k) {
+					t[k] = "[redacted]"
+					continue
+				}
+				if strings.EqualFold(k, "url") {
+					t[k] = redactURL(s)
+					continue
+				}
+			}
+			t[k] = redactValue(val)
+		}
+	case []any:
+		for i := range t {
+			t[i] = redactValue(t[i])
+		}
+	}
+	return v
+}
+
+// redactURL keeps only the scheme and host: webhook paths and query
+// strings are credentials in their own right.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "[redacted]"
+	}
+	if u.Path == "" && u.RawQuery == "" && u.User == nil {
+		return raw
+	}
+	return u.Scheme + "://" + u.Host + "/[redacted]"
+}
+
+func isSecret(key string) bool {
+	key = strings.ToLower(key)
+	for _, w := range secretWords {
+		if strings.Contains(key, w) {
+			return true
+		}
+	}
+	return false
+}
+
+func addJSON(zw *zip.Writer, name string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return addFile(zw, name, data)
+}
+
+func addFile(zw *zip.Writer, name string, data []byte) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// FileName is a descriptive name for a bundle generated now.
+func FileName() string {
+	hostname, _ := os.Hostname()
+	return fmt.Sprintf("avl-agent-%s-%s.zip", hostname, time.Now().Format("20060102-150405"))
+}
@@ -0,0 +1,106 @@
+package diag
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Level filters what reaches stderr. Plain log.Printf lines are Info.
+// Everything, whatever its level, is kept in the ring for bundles.
+type Level int32
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = map[string]Level{
+	"debug": LevelDebug,
+	"info":  LevelInfo,
+	"warn":  LevelWarn,
+	"error": LevelError,
+}
+
+// ParseLevel parses "debug", "info", "warn", or "error".
+func ParseLevel(s string) (Level, error) {
+	if l, ok := levelNames[strings.ToLower(s)]; ok {
+		return l, nil
+	}
+	return LevelInfo, fmt.Errorf("unknown log level %q (want debug, info, warn, or error)", s)
+}
+
+var threshold atomic.Int32
+
+// SetLevel sets the lowest level written to stderr.
+func SetLevel(l Level) {
+	threshold.Store(int32(l))
+}
+
+// maxLogLines is how much recent log output is kept in memory.
+const maxLogLines = 2000
+
+// logRing keeps the most recent log lines. The Windows agent has no
+// console, so without this its log output is simply lost.
+var logRing = struct {
+	sync.Mutex
+	lines []string
+	next  int
+}{lines: make([]string, 0, maxLogLines)}
+
+func appendRing(line string) {
+	logRing.Lock()
+	defer logRing.Unlock()
+	if len(logRing.lines) < maxLogLines {
+		logRing.lines = append(logRing.lines, line)
+	} else {
+		logRing.lines[logRing.next] = line
+		logRing.next = (logRing.next + 1) % maxLogLines
+	}
+}
+
+// levelWriter sends lines to the ring, and to stderr at or above threshold.
+type levelWriter Level
+
+func (w levelWriter) Write(p []byte) (int, error) {
+	appendRing(string(p))
+	if int32(w) >= threshold.Load() {
+		return os.Stderr.Write(p)
+	}
+	return len(p), nil
+}
+
+var leveled = map[Level]*log.Logger{}
+
+func init() {
+	threshold.Store(int32(LevelInfo))
+	for _, l := range levelNames {
+		leveled[l] = log.New(levelWriter(l), "", log.LstdFlags)
+	}
+}
+
+// CaptureLog routes the standard logger through the ring and level filter.
+func CaptureLog() {
+	log.SetOutput(levelWriter(LevelInfo))
+}
+
+// Logf logs at the given level.
+func Logf(l Level, format string, args ...any) {
+	leveled[l].Output(2, fmt.Sprintf(format, args...))
+}
+
+// RecentLog returns the captured log lines, oldest first.
+func RecentLog() string {
+	logRing.Lock()
+	defer logRing.Unlock()
+	var b strings.Builder
+	for i := range logRing.lines {
+		b.WriteString(logRing.lines[(logRing.next+i)%len(logRing.lines)])
+	}
+	return b.String()
+}
@@ -0,0 +1,173 @@
+package display
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/events"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/schedule"
+)
+
+// VCP codes and values from the MCCS standard, which DDC/CI carries.
+const (
+	vcpInput = 0x60
+	vcpPower = 0xD6
+	// Off is DPM off (0x04) rather than power-button off (0x05): most
+	// monitors stop answering DDC after 0x05 and can't be turned back on.
+	powerOnValue  = 0x01
+	powerOffValue = 0x04
+)
+
+// Power states for Control.
+const (
+	PowerOn  = "on"
+	PowerOff = "off"
+)
+
+// inputSources are the VCP 0x60 values by input name.
+var inputSources = map[string]uint32{
+	"vga1": 0x01, "vga2": 0x02,
+	"dvi1": 0x03, "dvi2": 0x04,
+	"composite1": 0x05, "composite2": 0x06,
+	"svideo1": 0x07, "svideo2": 0x08,
+	"component1": 0x0C, "component2": 0x0D,
+	"dp1": 0x0F, "dp2": 0x10,
+	"hdmi1": 0x11, "hdmi2": 0x12,
+	"usbc1": 0x1B,
+}
+
+// ErrUnknownOutput is returned by Control for an output that isn't
+// connected.
+var ErrUnknownOutput = errors.New("no such output")
+
+// Control is a DDC/CI request for one output. Empty fields are left alone.
+type Control struct {
+	Output string `json:"output"` // output name or monitor description
+	Power  string `json:"power"`  // "on" or "off"
+	Input  string `json:"input"`  // e.g. "hdmi1", "dp1"
+}
+
+// Validate checks the request's values.
+func (c Control) Validate() error {
+	switch {
+	case c.Output == "":
+		return errors.New("output is required")
+	case c.Power == "" && c.Input == "":
+		return errors.New("set power or input")
+	case c.Power != "" && c.Power != PowerOn && c.Power != PowerOff:
+		return fmt.Errorf("power %q (want on or off)", c.Power)
+	}
+	if _, ok := inputSources[strings.ToLower(c.Input)]; c.Input != "" && !ok {
+		return fmt.Errorf("input %q (want one of %s)", c.Input, strings.Join(inputNames(), ", "))
+	}
+	return nil
+}
+
+// ddcState is what a monitor reports over DDC/CI. Empty fields didn't
+// answer.
+type ddcState struct {
+	power string
+	input string
+}
+
+// Control powers a monitor on or off or switches its input over DDC/CI,
+// then re-reads the outputs. Monitors without DDC/CI, or with it turned
+// off in their menus, return an error.
+func (m *Monitor) Control(c Control, by string) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	name, ok := m.outputName(c.Output)
+	if !ok {
+		return ErrUnknownOutput
+	}
+	var done []string
+	if c.Power != "" {
+		value := uint32(powerOffValue)
+		if c.Power == PowerOn {
+			value = powerOnValue
+		}
+		if err := setVCP(name, vcpPower, value); err != nil {
+			return fmt.Errorf("%s: power %s: %w", name, c.Power, err)
+		}
+		done = append(done, "power "+c.Power)
+	}
+	if c.Input != "" {
+		input := strings.ToLower(c.Input)
+		if err := setVCP(name, vcpInput, inputSources[input]); err != nil {
+			return fmt.Errorf("%s: input %s: %w", name, input, err)
+		}
+		done = append(done, "input "+input)
+	}
+
+	kind := events.RemoteAction
+	if by == "schedule" {
+		kind = events.Lifecycle
+	}
+	events.Record(kind, events.Info, "Display: %s %s by %s", name, strings.Join(done, ", "), by)
+	m.check()
+	return nil
+}
+
+// outputName resolves an output name or monitor description to a
+// connected output's name.
+func (m *Monitor) outputName(key string) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, o := range m.outputs {
+		if o.Width == 0 {
+			continue // configured but not connected
+		}
+		if strings.EqualFold(o.Name, key) || (o.Monitor != "" && o.Monitor == key) {
+			return o.Name, true
+		}
+	}
+	return "", false
+}
+
+// applySchedule sends the power changes scheduled after since and up to
+// now.
+func (m *Monitor) applySchedule(since, now time.Time) {
+	for _, p := range m.cfg.PowerSchedule {
+		for _, step := range []struct{ at, power string }{{p.On, PowerOn}, {p.Off, PowerOff}} {
+			if step.at == "" || !(schedule.Weekly{Clock: step.at, Days: p.Days}).Between(since, now) {
+				continue
+			}
+			if err := m.Control(Control{Output: p.Output, Power: step.power}, "schedule"); err != nil {
+				events.Record(events.Lifecycle, events.Warning, "Display: scheduled power %s for %s failed: %v", step.power, p.Output, err)
+			}
+		}
+	}
+}
+
+// powerName names a VCP 0xD6 value.
+func powerName(value uint32) string {
+	if value == powerOnValue {
+		return PowerOn
+	}
+	return PowerOff
+}
+
+// inputName names a VCP 0x60 value, or formats it in hex when it isn't a
+// standard input.
+func inputName(value uint32) string {
+	value &= 0xFF // some monitors set the high byte
+	for name, v := range inputSources {
+		if v == value {
+			return name
+		}
+	}
+	return fmt.Sprintf("0x%02x", value)
+}
+
+func inputNames() []string {
+	names := make([]string, 0, len(inputSources))
+	for name := range inputSources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
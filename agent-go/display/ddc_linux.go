@@ -0,0 +1,14 @@
+//go:build linux
+
+package display
+
+import "errors"
+
+// readDDC reports nothing on Linux; see readOutputs.
+func readDDC() map[string]ddcState {
+	return nil
+}
+
+func setVCP(output string, code byte, value uint32) error {
+	return errors.New("DDC/CI control is only available on Windows")
+}
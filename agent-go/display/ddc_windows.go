@@ -0,0 +1,125 @@
+//go:build windows
+
+package display
+
+import (
+	"fmt"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	dxva2                                       = windows.NewLazySystemDLL("dxva2.dll")
+	procGetNumberOfPhysicalMonitorsFromHMONITOR = dxva2.NewProc("GetNumberOfPhysicalMonitorsFromHMONITOR")
+	procGetPhysicalMonitorsFromHMONITOR         = dxva2.NewProc("GetPhysicalMonitorsFromHMONITOR")
+	procDestroyPhysicalMonitors                 = dxva2.NewProc("DestroyPhysicalMonitors")
+	procGetVCPFeatureAndVCPFeatureReply         = dxva2.NewProc("GetVCPFeatureAndVCPFeatureReply")
+	procSetVCPFeature                           = dxva2.NewProc("SetVCPFeature")
+)
+
+// physicalMonitor is PHYSICAL_MONITOR.
+type physicalMonitor struct {
+	handle      windows.Handle
+	description [128]uint16
+}
+
+// ddcMu serializes DDC/CI traffic; monitors handle one request at a time,
+// and the enumeration below shares its result variable.
+var ddcMu sync.Mutex
+
+// Windows never frees callbacks, so there is one, collecting into
+// enumMonitors.
+var (
+	enumMonitors  map[string]uintptr // output name to HMONITOR
+	enumMonitorFn = windows.NewCallback(func(monitor, hdc, rect, data uintptr) uintptr {
+		var info monitorInfoEx
+		info.cbSize = uint32(unsafe.Sizeof(info))
+		if r, _, _ := procGetMonitorInfo.Call(monitor, uintptr(unsafe.Pointer(&info))); r != 0 {
+			enumMonitors[windows.UTF16ToString(info.device[:])] = monitor
+		}
+		return 1
+	})
+)
+
+// monitorHandles maps output names to HMONITORs. Caller holds ddcMu.
+func monitorHandles() map[string]uintptr {
+	enumMonitors = map[string]uintptr{}
+	procEnumDisplayMonitors.Call(0, 0, enumMonitorFn, 0)
+	return enumMonitors
+}
+
+// withPhysical runs fn on each physical monitor behind an HMONITOR: more
+// than one when outputs are cloned. Caller holds ddcMu.
+func withPhysical(hmonitor uintptr, fn func(windows.Handle) error) error {
+	var n uint32
+	if r, _, err := procGetNumberOfPhysicalMonitorsFromHMONITOR.Call(hmonitor, uintptr(unsafe.Pointer(&n))); r == 0 {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("no physical monitor")
+	}
+	monitors := make([]physicalMonitor, n)
+	if r, _, err := procGetPhysicalMonitorsFromHMONITOR.Call(hmonitor, uintptr(n), uintptr(unsafe.Pointer(&monitors[0]))); r == 0 {
+		return err
+	}
+	defer procDestroyPhysicalMonitors.Call(uintptr(n), uintptr(unsafe.Pointer(&monitors[0])))
+
+	var first error
+	for _, m := range monitors {
+		if err := fn(m.handle); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// readDDC reads power and input from each monitor that answers DDC/CI.
+func readDDC() map[string]ddcState {
+	ddcMu.Lock()
+	defer ddcMu.Unlock()
+	states := map[string]ddcState{}
+	for name, hmonitor := range monitorHandles() {
+		withPhysical(hmonitor, func(h windows.Handle) error {
+			var state ddcState
+			if value, err := getVCP(h, vcpPower); err == nil {
+				state.power = powerName(value)
+			}
+			if value, err := getVCP(h, vcpInput); err == nil {
+				state.input = inputName(value)
+			}
+			if _, seen := states[name]; !seen {
+				states[name] = state // the first of cloned monitors
+			}
+			return nil
+		})
+	}
+	return states
+}
+
+func getVCP(h windows.Handle, code byte) (uint32, error) {
+	var kind, current, maximum uint32
+	if r, _, err := procGetVCPFeatureAndVCPFeatureReply.Call(uintptr(h), uintptr(code),
+		uintptr(unsafe.Pointer(&kind)), uintptr(unsafe.Pointer(&current)), uintptr(unsafe.Pointer(&maximum))); r == 0 {
+		return 0, err
+	}
+	return current, nil
+}
+
+// setVCP sets a VCP feature on every monitor behind output. The service
+// in session 0 sees no outputs.
+func setVCP(output string, code byte, value uint32) error {
+	ddcMu.Lock()
+	defer ddcMu.Unlock()
+	hmonitor, ok := monitorHandles()[output]
+	if !ok {
+		return ErrUnknownOutput
+	}
+	return withPhysical(hmonitor, func(h windows.Handle) error {
+		if r, _, err := procSetVCPFeature.Call(uintptr(h), uintptr(code), uintptr(value)); r == 0 {
+			return fmt.Errorf("monitor didn't accept the DDC/CI command (is DDC/CI enabled in its menu?): %w", err)
+		}
+		return nil
+	})
+}
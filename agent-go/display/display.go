@@ -0,0 +1,232 @@
+// Package display tracks each output's resolution, refresh rate, and
+// scaling, and reports drift from what is expected. Projectors that
+// power-cycle often renegotiate EDID to the wrong mode, and the first sign
+// is stretched 720p lyrics on the screen.
+package display
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/events"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/state"
+)
+
+const (
+	stateKey      = "displayBaseline"
+	checkInterval = 30 * time.Second
+)
+
+// Mode is an output's display mode. Zero fields are unknown (or, in an
+// expectation, not checked).
+type Mode struct {
+	Width        int `json:"width"`
+	Height       int `json:"height"`
+	RefreshHz    int `json:"refreshHz,omitempty"`
+	ScalePercent int `json:"scalePercent,omitempty"`
+}
+
+func (m Mode) String() string {
+	s := fmt.Sprintf("%dx%d", m.Width, m.Height)
+	if m.RefreshHz > 0 {
+		s += fmt.Sprintf("@%d", m.RefreshHz)
+	}
+	if m.ScalePercent > 0 {
+		s += fmt.Sprintf(" %d%%", m.ScalePercent)
+	}
+	return s
+}
+
+// Output is one connected display, as reported in /status.
+type Output struct {
+	Name    string `json:"name"`              // e.g. \\.\DISPLAY2
+	Monitor string `json:"monitor,omitempty"` // EDID name, else the driver's description
+	Primary bool   `json:"primary,omitempty"`
+	EDID    *EDID  `json:"edid,omitempty"`
+	// Power ("on" or "off") and Input (e.g. "hdmi1") are read over DDC/CI;
+	// empty when the monitor doesn't answer.
+	Power string `json:"power,omitempty"`
+	Input string `json:"input,omitempty"`
+	Mode
+	Expected *Mode    `json:"expected,omitempty"`
+	Learned  bool     `json:"learned,omitempty"` // Expected is a learned baseline, not from config
+	Drift    []string `json:"drift,omitempty"`
+
+	// description is the driver's description of the monitor, which
+	// displays.expected may still name it by: Monitor held it before the
+	// EDID name.
+	description string
+}
+
+// Monitor reads the outputs periodically and compares them to the
+// configured modes or, for outputs without one, the first mode seen.
+// Safe for concurrent use.
+type Monitor struct {
+	cfg   config.DisplaysConfig
+	store *state.Store
+
+	mu       sync.Mutex
+	baseline map[string]Mode
+	outputs  []Output
+}
+
+// New restores the learned baseline from the store. It returns nil when
+// display checks are disabled.
+func New(cfg config.DisplaysConfig, store *state.Store) *Monitor {
+	if cfg.Disabled {
+		return nil
+	}
+	m := &Monitor{cfg: cfg, store: store, baseline: map[string]Mode{}}
+	store.Get(stateKey, &m.baseline)
+	return m
+}
+
+// Run checks outputs every 30 seconds and applies the power schedule.
+// Blocks forever.
+func (m *Monitor) Run() {
+	last := time.Now()
+	for {
+		m.check()
+		now := time.Now()
+		m.applySchedule(last, now)
+		last = now
+		time.Sleep(checkInterval)
+	}
+}
+
+// Outputs returns the latest reading.
+func (m *Monitor) Outputs() []Output {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.outputs
+}
+
+// ResetBaseline forgets the learned modes; the next check learns the
+// current ones. Used after a deliberate change.
+func (m *Monitor) ResetBaseline(by string) {
+	m.mu.Lock()
+	m.baseline = map[string]Mode{}
+	m.save()
+	m.mu.Unlock()
+	events.Record(events.RemoteAction, events.Info, "Display: baseline reset by %s", by)
+	m.check()
+}
+
+func (m *Monitor) check() {
+	outputs, err := readOutputs()
+	if err != nil {
+		log.Printf("Display: %v", err)
+	}
+	sort.Slice(outputs, func(i, j int) bool { return outputs[i].Name < outputs[j].Name })
+	monitors := readDDC()
+	for i := range outputs {
+		state := monitors[outputs[i].Name]
+		outputs[i].Power, outputs[i].Input = state.power, state.input
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	previous := map[string]bool{}
+	for _, o := range m.outputs {
+		previous[o.Name] = len(o.Drift) > 0
+	}
+
+	learned := false
+	connected := map[string]bool{}
+	for i := range outputs {
+		o := &outputs[i]
+		connected[o.Name] = true
+		if want, ok := m.expected(o); ok {
+			o.Expected = &want
+		} else if base, ok := m.baseline[o.Name]; ok {
+			o.Expected, o.Learned = &base, true
+		} else {
+			m.baseline[o.Name] = o.Mode
+			learned = true
+			continue
+		}
+		o.Drift = drift(o.Mode, *o.Expected)
+		if len(o.Drift) > 0 && !previous[o.Name] {
+			events.Record(events.Lifecycle, events.Warning, "Display: %s changed to %s (expected %s)", o.Name, o.Mode, o.Expected)
+		}
+	}
+
+	// A configured output that isn't connected is drift too; a learned one
+	// isn't, since projectors are switched off between services.
+	for name, want := range m.cfg.Expected {
+		if !connected[name] && !m.matchesMonitor(name, outputs) {
+			want := modeOf(want)
+			outputs = append(outputs, Output{Name: name, Expected: &want, Drift: []string{"not connected"}})
+		}
+	}
+
+	m.outputs = outputs
+	if learned {
+		m.save()
+	}
+}
+
+// expected returns the configured mode for o, keyed by output name,
+// monitor name, or the driver's description of the monitor.
+func (m *Monitor) expected(o *Output) (Mode, bool) {
+	for _, key := range o.keys() {
+		if want, ok := m.cfg.Expected[key]; ok {
+			return modeOf(want), true
+		}
+	}
+	return Mode{}, false
+}
+
+func (m *Monitor) matchesMonitor(key string, outputs []Output) bool {
+	for _, o := range outputs {
+		if key != "" && (o.Monitor == key || o.description == key) {
+			return true
+		}
+	}
+	return false
+}
+
+// keys returns what displays.expected may name o by, most specific first.
+func (o *Output) keys() []string {
+	keys := []string{o.Name}
+	for _, key := range []string{o.Monitor, o.description} {
+		if key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// save persists the baseline. Caller holds m.mu.
+func (m *Monitor) save() {
+	if err := m.store.Set(stateKey, m.baseline); err != nil {
+		log.Printf("State: save display baseline failed: %v", err)
+	}
+}
+
+func modeOf(c config.DisplayMode) Mode {
+	return Mode{Width: c.Width, Height: c.Height, RefreshHz: c.RefreshHz, ScalePercent: c.ScalePercent}
+}
+
+// drift lists how got differs from want, skipping fields want leaves zero.
+func drift(got, want Mode) []string {
+	var out []string
+	if want.Width > 0 && (got.Width != want.Width || got.Height != want.Height) {
+		out = append(out, fmt.Sprintf("resolution %dx%d, expected %dx%d", got.Width, got.Height, want.Width, want.Height))
+	}
+	if want.RefreshHz > 0 && got.RefreshHz != want.RefreshHz {
+		out = append(out, fmt.Sprintf("refresh %d Hz, expected %d Hz", got.RefreshHz, want.RefreshHz))
+	}
+	if want.ScalePercent > 0 && got.ScalePercent != 0 && got.ScalePercent != want.ScalePercent {
+		out = append(out, fmt.Sprintf("scaling %d%%, expected %d%%", got.ScalePercent, want.ScalePercent))
+	}
+	return out
+}
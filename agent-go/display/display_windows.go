@@ -0,0 +1,189 @@
+//go:build windows
+
+package display
+
+import (
+	"runtime"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+var (
+	user32                           = windows.NewLazySystemDLL("user32.dll")
+	procEnumDisplayDevices           = user32.NewProc("EnumDisplayDevicesW")
+	procEnumDisplaySettings          = user32.NewProc("EnumDisplaySettingsW")
+	procEnumDisplayMonitors          = user32.NewProc("EnumDisplayMonitors")
+	procGetMonitorInfo               = user32.NewProc("GetMonitorInfoW")
+	procSetThreadDpiAwarenessContext = user32.NewProc("SetThreadDpiAwarenessContext")
+	procGetDpiForMonitor             = windows.NewLazySystemDLL("shcore.dll").NewProc("GetDpiForMonitor")
+)
+
+const (
+	displayDeviceAttachedToDesktop = 0x1
+	displayDevicePrimaryDevice     = 0x4
+	eddGetDeviceInterfaceName      = 0x1
+	enumCurrentSettings            = 0xFFFFFFFF
+	mdtEffectiveDPI                = 0
+	// dpiAwarenessPerMonitorV2 is DPI_AWARENESS_CONTEXT_PER_MONITOR_AWARE_V2.
+	dpiAwarenessPerMonitorV2 = ^uintptr(3) // (DPI_AWARENESS_CONTEXT)-4
+)
+
+// displayDevice is DISPLAY_DEVICEW.
+type displayDevice struct {
+	cb           uint32
+	deviceName   [32]uint16
+	deviceString [128]uint16
+	stateFlags   uint32
+	deviceID     [128]uint16
+	deviceKey    [128]uint16
+}
+
+// devMode is DEVMODEW with the display-device union members.
+type devMode struct {
+	deviceName         [32]uint16
+	specVersion        uint16
+	driverVersion      uint16
+	size               uint16
+	driverExtra        uint16
+	fields             uint32
+	positionX          int32
+	positionY          int32
+	displayOrientation uint32
+	displayFixedOutput uint32
+	color              int16
+	duplex             int16
+	yResolution        int16
+	ttOption           int16
+	collate            int16
+	formName           [32]uint16
+	logPixels          uint16
+	bitsPerPel         uint32
+	pelsWidth          uint32
+	pelsHeight         uint32
+	displayFlags       uint32
+	displayFrequency   uint32
+	icmMethod          uint32
+	icmIntent          uint32
+	mediaType          uint32
+	ditherType         uint32
+	reserved1          uint32
+	reserved2          uint32
+	panningWidth       uint32
+	panningHeight      uint32
+}
+
+// monitorInfoEx is MONITORINFOEXW.
+type monitorInfoEx struct {
+	cbSize    uint32
+	rcMonitor windows.Rect
+	rcWork    windows.Rect
+	flags     uint32
+	device    [32]uint16
+}
+
+// readOutputs lists the outputs attached to the desktop. The service runs
+// in session 0, which has no interactive desktop and sees none.
+func readOutputs() ([]Output, error) {
+	scales := readScales()
+
+	var outputs []Output
+	for i := uint32(0); ; i++ {
+		var adapter displayDevice
+		adapter.cb = uint32(unsafe.Sizeof(adapter))
+		if r, _, _ := procEnumDisplayDevices.Call(0, uintptr(i), uintptr(unsafe.Pointer(&adapter)), 0); r == 0 {
+			break
+		}
+		if adapter.stateFlags&displayDeviceAttachedToDesktop == 0 {
+			continue
+		}
+
+		var mode devMode
+		mode.size = uint16(unsafe.Sizeof(mode))
+		if r, _, _ := procEnumDisplaySettings.Call(uintptr(unsafe.Pointer(&adapter.deviceName[0])), enumCurrentSettings, uintptr(unsafe.Pointer(&mode))); r == 0 {
+			continue
+		}
+
+		name := windows.UTF16ToString(adapter.deviceName[:])
+		out := Output{
+			Name:    name,
+			Primary: adapter.stateFlags&displayDevicePrimaryDevice != 0,
+			Mode: Mode{
+				Width:        int(mode.pelsWidth),
+				Height:       int(mode.pelsHeight),
+				RefreshHz:    int(mode.displayFrequency),
+				ScalePercent: scales[name],
+			},
+		}
+		var monitor displayDevice
+		monitor.cb = uint32(unsafe.Sizeof(monitor))
+		if r, _, _ := procEnumDisplayDevices.Call(uintptr(unsafe.Pointer(&adapter.deviceName[0])), 0, uintptr(unsafe.Pointer(&monitor)), eddGetDeviceInterfaceName); r != 0 {
+			out.description = windows.UTF16ToString(monitor.deviceString[:])
+			out.Monitor = out.description
+			out.EDID = readEDID(windows.UTF16ToString(monitor.deviceID[:]))
+			if out.EDID != nil && out.EDID.Name != "" {
+				out.Monitor = out.EDID.Name
+			}
+		}
+		outputs = append(outputs, out)
+	}
+	return outputs, nil
+}
+
+// readEDID reads the EDID Windows cached for a monitor. The interface name
+// (\\?\DISPLAY#DEL4079#5&1a2b3c&0&UID4353#{guid}) names its device
+// instance under Enum\DISPLAY. HDCP status isn't available here: Windows
+// only reports it through OPM, which needs a signed certificate.
+func readEDID(interfaceName string) *EDID {
+	parts := strings.Split(interfaceName, "#")
+	if len(parts) < 3 {
+		return nil
+	}
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE,
+		`SYSTEM\CurrentControlSet\Enum\DISPLAY\`+parts[1]+`\`+parts[2]+`\Device Parameters`, registry.QUERY_VALUE)
+	if err != nil {
+		return nil
+	}
+	defer k.Close()
+	b, _, err := k.GetBinaryValue("EDID")
+	if err != nil {
+		return nil
+	}
+	return parseEDID(b)
+}
+
+// readScales returns the scaling percentage per output name. The effective
+// DPI is only reported to a per-monitor-aware thread, so the thread is
+// made aware for the duration of the call; the process (and the tray)
+// keep their own setting.
+func readScales() map[string]int {
+	scales := map[string]int{}
+	if procSetThreadDpiAwarenessContext.Find() != nil || procGetDpiForMonitor.Find() != nil {
+		return scales // before Windows 10 1607
+	}
+
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	previous, _, _ := procSetThreadDpiAwarenessContext.Call(dpiAwarenessPerMonitorV2)
+	if previous != 0 {
+		defer procSetThreadDpiAwarenessContext.Call(previous)
+	}
+
+	// The enumeration callback is shared with DDC/CI, as Windows never
+	// frees callbacks.
+	ddcMu.Lock()
+	monitors := make(map[string]uintptr)
+	for name, monitor := range monitorHandles() {
+		monitors[name] = monitor
+	}
+	ddcMu.Unlock()
+	for name, monitor := range monitors {
+		var dpiX, dpiY uint32
+		if r, _, _ := procGetDpiForMonitor.Call(monitor, mdtEffectiveDPI, uintptr(unsafe.Pointer(&dpiX)), uintptr(unsafe.Pointer(&dpiY))); r == 0 {
+			scales[name] = int(dpiX) * 100 / 96
+		}
+	}
+	return scales
+}
@@ -0,0 +1,69 @@
+package display
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strconv"
+	"strings"
+)
+
+// EDID is what the connected monitor reports about itself, so an output
+// can be matched to a physical device ("DISPLAY2 is the confidence
+// monitor, not the projector").
+type EDID struct {
+	Manufacturer string `json:"manufacturer"`   // PNP ID, e.g. DEL
+	ProductCode  uint16 `json:"productCode"`    // manufacturer's model number
+	Name         string `json:"name,omitempty"` // e.g. DELL U2419H
+	Serial       string `json:"serial,omitempty"`
+	Year         int    `json:"year,omitempty"` // of manufacture
+}
+
+var edidHeader = []byte{0x00, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x00}
+
+// parseEDID decodes the base EDID block. It returns nil if b isn't one.
+func parseEDID(b []byte) *EDID {
+	if len(b) < 128 || !bytes.Equal(b[:8], edidHeader) {
+		return nil
+	}
+	id := binary.BigEndian.Uint16(b[8:10])
+	e := &EDID{
+		Manufacturer: string([]byte{
+			byte(id>>10&0x1f) + 'A' - 1,
+			byte(id>>5&0x1f) + 'A' - 1,
+			byte(id&0x1f) + 'A' - 1,
+		}),
+		ProductCode: binary.LittleEndian.Uint16(b[10:12]),
+	}
+	if b[17] > 0 {
+		e.Year = 1990 + int(b[17])
+	}
+
+	// Four 18-byte descriptors; display descriptors start with 0000 00.
+	for off := 54; off+18 <= 126; off += 18 {
+		d := b[off : off+18]
+		if d[0] != 0 || d[1] != 0 || d[2] != 0 {
+			continue
+		}
+		text := descriptorText(d[5:])
+		switch d[3] {
+		case 0xfc:
+			e.Name = text
+		case 0xff:
+			e.Serial = text
+		}
+	}
+	// Monitors without a serial descriptor carry a numeric one.
+	if serial := binary.LittleEndian.Uint32(b[12:16]); e.Serial == "" && serial != 0 {
+		e.Serial = strconv.FormatUint(uint64(serial), 10)
+	}
+	return e
+}
+
+// descriptorText reads descriptor text, which ends at a line feed and is
+// padded with spaces.
+func descriptorText(b []byte) string {
+	if i := bytes.IndexByte(b, '\n'); i >= 0 {
+		b = b[:i]
+	}
+	return strings.TrimSpace(string(b))
+}
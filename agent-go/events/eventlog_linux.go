@@ -0,0 +1,9 @@
+//go:build linux
+
+package events
+
+// openPlatformSink returns nothing on Linux: the agent log already goes to
+// the systemd journal.
+func openPlatformSink() (sink, error) {
+	return nil, nil
+}
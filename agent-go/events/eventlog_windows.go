@@ -0,0 +1,40 @@
+//go:build windows
+
+package events
+
+import (
+	"log"
+	"strings"
+
+	"golang.org/x/sys/windows/svc/eventlog"
+)
+
+type eventLogSink struct {
+	log *eventlog.Log
+}
+
+// openPlatformSink registers the agent's Event Log source and opens it.
+// Registering needs admin the first time; without it events are still
+// written, Event Viewer just can't find the message format.
+func openPlatformSink() (sink, error) {
+	err := eventlog.InstallAsEventCreate(source, eventlog.Info|eventlog.Warning|eventlog.Error)
+	if err != nil && !strings.Contains(err.Error(), "registry key already exists") {
+		log.Printf("Events: cannot register event source: %v", err)
+	}
+	l, err := eventlog.Open(source)
+	if err != nil {
+		return nil, err
+	}
+	return &eventLogSink{log: l}, nil
+}
+
+func (s *eventLogSink) write(kind Kind, level Level, msg string) error {
+	switch level {
+	case Error:
+		return s.log.Error(uint32(kind), msg)
+	case Warning:
+		return s.log.Warning(uint32(kind), msg)
+	default:
+		return s.log.Info(uint32(kind), msg)
+	}
+}
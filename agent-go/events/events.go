@@ -0,0 +1,124 @@
+// Package events records agent lifecycle events, alerts, and remote
+// actions for IT: to the Windows Event Log under the agent's own source
+// and, optionally, to a remote syslog server. Every event is also written
+// to the agent log.
+package events
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/diag"
+)
+
+// Kind groups events; it doubles as the Windows event ID.
+type Kind uint32
+
+const (
+	Lifecycle    Kind = 1 // start, stop, update, watchdog restart
+	Alert        Kind = 2 // raised, escalated, resolved, acknowledged
+	RemoteAction Kind = 3 // actions requested over the API
+)
+
+// Level is an event's severity.
+type Level int
+
+const (
+	Info Level = iota
+	Warning
+	Error
+)
+
+// logLevel maps event levels to agent log levels.
+var logLevel = map[Level]diag.Level{
+	Info:    diag.LevelInfo,
+	Warning: diag.LevelWarn,
+	Error:   diag.LevelError,
+}
+
+// source is the Event Log source and syslog APP-NAME.
+const source = "AVL-Dashboard-Agent"
+
+// sink receives formatted events.
+type sink interface {
+	write(kind Kind, level Level, msg string) error
+}
+
+var sinks = struct {
+	sync.Mutex
+	list []sink
+}{}
+
+// Setup opens the configured outputs. Until it is called, events only go
+// to the agent log.
+func Setup(cfg config.EventsConfig) {
+	var list []sink
+	if !cfg.DisableEventLog {
+		if s, err := openPlatformSink(); err != nil {
+			log.Printf("Events: event log unavailable: %v", err)
+		} else if s != nil {
+			list = append(list, s)
+		}
+	}
+	if cfg.Syslog != nil && cfg.Syslog.Address != "" {
+		list = append(list, newSyslogSink(*cfg.Syslog))
+	}
+
+	sinks.Lock()
+	sinks.list = list
+	sinks.Unlock()
+}
+
+// Event is one recorded event, as kept for Recent.
+type Event struct {
+	Time    time.Time
+	Kind    Kind
+	Level   Level
+	Message string
+}
+
+// maxRecent is how many events Recent can return.
+const maxRecent = 500
+
+var recent = struct {
+	sync.Mutex
+	list []Event
+}{}
+
+// Record logs an event and forwards it to every output. Output failures
+// are logged, never returned: reporting must not break the caller.
+func Record(kind Kind, level Level, format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	diag.Logf(logLevel[level], "%s", msg)
+
+	recent.Lock()
+	if len(recent.list) == maxRecent {
+		recent.list = recent.list[1:]
+	}
+	recent.list = append(recent.list, Event{Time: time.Now(), Kind: kind, Level: level, Message: msg})
+	recent.Unlock()
+
+	sinks.Lock()
+	defer sinks.Unlock()
+	for _, s := range sinks.list {
+		if err := s.write(kind, level, msg); err != nil {
+			log.Printf("Events: %T write failed: %v", s, err)
+		}
+	}
+}
+
+// Recent returns the events recorded between from and to, oldest first.
+func Recent(from, to time.Time) []Event {
+	recent.Lock()
+	defer recent.Unlock()
+	var out []Event
+	for _, e := range recent.list {
+		if !e.Time.Before(from) && !e.Time.After(to) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
@@ -0,0 +1,114 @@
+package events
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/schedule"
+)
+
+// Syslog facility local0 unless configured otherwise.
+const defaultFacility = 16
+
+// syslogSeverity maps levels to RFC 5424 severities.
+var syslogSeverity = map[Level]int{Info: 6, Warning: 4, Error: 3}
+
+var msgIDs = map[Kind]string{Lifecycle: "lifecycle", Alert: "alert", RemoteAction: "action"}
+
+// The sink writes from its own goroutine, so Record never waits on a
+// dial or a slow collector. Messages queue while the collector is
+// unreachable, up to syslogQueue; later ones are dropped and counted.
+const (
+	syslogQueue    = 256
+	syslogRetryMin = time.Second
+	syslogRetryMax = 5 * time.Minute
+)
+
+// syslogSink sends RFC 5424 messages over UDP or TCP. The connection is
+// opened lazily and re-dialed after a failure, with backoff.
+type syslogSink struct {
+	cfg      config.SyslogConfig
+	hostname string
+	queue    chan string
+	dropped  atomic.Int64
+
+	// Used only by run.
+	conn  net.Conn
+	retry schedule.Backoff
+}
+
+func newSyslogSink(cfg config.SyslogConfig) *syslogSink {
+	if cfg.Protocol == "" {
+		cfg.Protocol = "udp"
+	}
+	if cfg.Facility == 0 {
+		cfg.Facility = defaultFacility
+	}
+	hostname, _ := os.Hostname()
+	s := &syslogSink{
+		cfg:      cfg,
+		hostname: hostname,
+		queue:    make(chan string, syslogQueue),
+		retry:    schedule.Backoff{Min: syslogRetryMin, Max: syslogRetryMax},
+	}
+	go s.run()
+	return s
+}
+
+// write formats the message and queues it for run.
+func (s *syslogSink) write(kind Kind, level Level, msg string) error {
+	pri := s.cfg.Facility*8 + syslogSeverity[level]
+	line := fmt.Sprintf("<%d>1 %s %s %s %d %s - %s",
+		pri, time.Now().Format(time.RFC3339Nano), s.hostname, source, os.Getpid(), msgIDs[kind], msg)
+	if s.cfg.Protocol == "tcp" {
+		// RFC 6587 octet counting framing
+		line = fmt.Sprintf("%d %s", len(line), line)
+	}
+	select {
+	case s.queue <- line:
+	default:
+		s.dropped.Add(1)
+	}
+	return nil
+}
+
+// run sends queued messages, retrying each until the collector takes it.
+func (s *syslogSink) run() {
+	for line := range s.queue {
+		for {
+			err := s.send(line)
+			if err == nil {
+				s.retry.Reset()
+				break
+			}
+			wait := s.retry.Fail()
+			log.Printf("Events: syslog to %s failed, retrying in %v: %v", s.cfg.Address, wait, err)
+			time.Sleep(wait)
+		}
+		if n := s.dropped.Swap(0); n > 0 {
+			log.Printf("Events: syslog queue was full, %d events dropped", n)
+		}
+	}
+}
+
+func (s *syslogSink) send(line string) error {
+	if s.conn == nil {
+		conn, err := net.DialTimeout(s.cfg.Protocol, s.cfg.Address, 5*time.Second)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+	s.conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	if _, err := s.conn.Write([]byte(line)); err != nil {
+		s.conn.Close()
+		s.conn = nil
+		return err
+	}
+	return nil
+}
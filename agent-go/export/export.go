@@ -0,0 +1,183 @@
+// Package export pushes metric samples to time-series databases (InfluxDB,
+// Prometheus remote-write) so AVL machines can sit next to other building
+// data. Samples are batched, and a batch that can't be delivered is kept
+// and retried with the next one.
+package export
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/history"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/metrics"
+)
+
+const (
+	defaultInterval = 30 * time.Second
+	flushInterval   = time.Minute
+	// maxBuffered caps points held for a sink that is down: about 12
+	// hours at the default interval for a machine with a few volumes.
+	maxBuffered = 10000
+)
+
+// Point is one measurement at one time.
+type Point struct {
+	Measurement string
+	Tags        map[string]string
+	Fields      map[string]float64
+	Time        time.Time
+}
+
+// errPermanent marks a rejected batch that retrying won't fix (bad
+// credentials, malformed data); it is dropped.
+var errPermanent = errors.New("rejected")
+
+// sink delivers a batch of points.
+type sink interface {
+	name() string
+	write(points []Point) error
+}
+
+// queue buffers points for one sink.
+type queue struct {
+	sink    sink
+	mu      sync.Mutex
+	pending []Point
+	failing bool
+	sending bool
+}
+
+// Exporter samples the collector and feeds every configured sink.
+type Exporter struct {
+	interval time.Duration
+	tags     map[string]string
+	queues   []*queue
+}
+
+// New creates an exporter for cfg. Tags (host, site) are added to every
+// point. It returns nil when no sink is configured.
+func New(cfg config.ExportConfig, tags map[string]string) *Exporter {
+	var sinks []sink
+	if cfg.Influx != nil && cfg.Influx.URL != "" {
+		sinks = append(sinks, newInflux(*cfg.Influx))
+	}
+	if cfg.RemoteWrite != nil && cfg.RemoteWrite.URL != "" {
+		sinks = append(sinks, newRemoteWrite(*cfg.RemoteWrite))
+	}
+	if len(sinks) == 0 {
+		return nil
+	}
+
+	e := &Exporter{interval: defaultInterval, tags: tags}
+	if cfg.IntervalSeconds > 0 {
+		e.interval = time.Duration(cfg.IntervalSeconds) * time.Second
+	}
+	for _, s := range sinks {
+		e.queues = append(e.queues, &queue{sink: s})
+	}
+	return e
+}
+
+// Run samples on the interval and flushes once a minute. Blocks forever.
+func (e *Exporter) Run(collector *metrics.Collector) {
+	sample := time.NewTicker(e.interval)
+	defer sample.Stop()
+	flush := time.NewTicker(flushInterval)
+	defer flush.Stop()
+
+	for {
+		select {
+		case now := <-sample.C:
+			points := e.points(collector.CurrentStatus(), now)
+			for _, q := range e.queues {
+				q.add(points)
+			}
+		case <-flush.C:
+			for _, q := range e.queues {
+				go q.flush()
+			}
+		}
+	}
+}
+
+// points converts a status snapshot into the headline metrics plus one
+// point per volume and GPU.
+func (e *Exporter) points(s metrics.MachineStatus, now time.Time) []Point {
+	points := []Point{{Measurement: "avl_agent", Tags: e.tags, Fields: history.Values(s), Time: now}}
+	for _, v := range s.Volumes {
+		points = append(points, Point{
+			Measurement: "avl_volume",
+			Tags:        e.with("mount", v.Mount),
+			Fields:      map[string]float64{"usedPercent": v.UsedPercent, "freeBytes": float64(v.FreeBytes)},
+			Time:        now,
+		})
+	}
+	for _, g := range s.GPUs {
+		points = append(points, Point{
+			Measurement: "avl_gpu",
+			Tags:        e.with("gpu", g.Name),
+			Fields:      map[string]float64{"usagePercent": g.UsagePercent, "temperatureCelsius": g.TemperatureCelsius},
+			Time:        now,
+		})
+	}
+	return points
+}
+
+// with returns the exporter's tags plus one more.
+func (e *Exporter) with(key, value string) map[string]string {
+	tags := make(map[string]string, len(e.tags)+1)
+	for k, v := range e.tags {
+		tags[k] = v
+	}
+	tags[key] = value
+	return tags
+}
+
+func (q *queue) add(points []Point) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pending = append(q.pending, points...)
+	if over := len(q.pending) - maxBuffered; over > 0 {
+		q.pending = q.pending[over:]
+	}
+}
+
+// flush sends everything pending. On a transient failure the batch stays
+// queued for the next flush; the first failure and the recovery are logged.
+func (q *queue) flush() {
+	q.mu.Lock()
+	if q.sending || len(q.pending) == 0 {
+		q.mu.Unlock()
+		return
+	}
+	batch := q.pending
+	q.pending, q.sending = nil, true
+	q.mu.Unlock()
+
+	err := q.sink.write(batch)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.sending = false
+	switch {
+	case err == nil:
+		if q.failing {
+			log.Printf("Export: %s recovered", q.sink.name())
+		}
+		q.failing = false
+	case errors.Is(err, errPermanent):
+		log.Printf("Export: %s dropped %d points: %v", q.sink.name(), len(batch), err)
+	default:
+		if !q.failing {
+			log.Printf("Export: %s failed, will retry: %v", q.sink.name(), err)
+		}
+		q.failing = true
+		q.pending = append(batch, q.pending...)
+		if over := len(q.pending) - maxBuffered; over > 0 {
+			q.pending = q.pending[over:]
+		}
+	}
+}
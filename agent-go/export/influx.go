@@ -0,0 +1,120 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+)
+
+// influx writes line protocol to the v2 API (/api/v2/write) or, when a
+// database is set, the 1.x API (/write).
+type influx struct {
+	cfg    config.InfluxConfig
+	client *http.Client
+}
+
+func newInflux(cfg config.InfluxConfig) *influx {
+	return &influx{cfg: cfg, client: &http.Client{Timeout: 15 * time.Second}}
+}
+
+func (i *influx) name() string { return "influx" }
+
+func (i *influx) write(points []Point) error {
+	q := url.Values{"precision": {"s"}}
+	endpoint := strings.TrimRight(i.cfg.URL, "/")
+	if i.cfg.Database != "" {
+		endpoint += "/write"
+		q.Set("db", i.cfg.Database)
+	} else {
+		endpoint += "/api/v2/write"
+		q.Set("org", i.cfg.Org)
+		q.Set("bucket", i.cfg.Bucket)
+	}
+
+	req, err := http.NewRequest("POST", endpoint+"?"+q.Encode(), bytes.NewReader(lineProtocol(points)))
+	if err != nil {
+		return fmt.Errorf("%w: %v", errPermanent, err)
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if i.cfg.Token != "" {
+		req.Header.Set("Authorization", "Token "+i.cfg.Token)
+	}
+	return send(i.client, req)
+}
+
+// lineProtocol encodes points one per line, tags sorted.
+func lineProtocol(points []Point) []byte {
+	var buf bytes.Buffer
+	for _, p := range points {
+		buf.WriteString(escape(p.Measurement, ", "))
+		for _, k := range sortedKeys(p.Tags) {
+			if p.Tags[k] == "" {
+				continue // empty tag values are invalid
+			}
+			// Backslashes are escaped too, or a mount such as C:\ would
+			// escape the following space.
+			fmt.Fprintf(&buf, ",%s=%s", escape(k, ",= \\"), escape(p.Tags[k], ",= \\"))
+		}
+		for n, k := range sortedKeys(p.Fields) {
+			sep := ","
+			if n == 0 {
+				sep = " "
+			}
+			fmt.Fprintf(&buf, "%s%s=%s", sep, escape(k, ",= "), strconv.FormatFloat(p.Fields[k], 'f', -1, 64))
+		}
+		fmt.Fprintf(&buf, " %d\n", p.Time.Unix())
+	}
+	return buf.Bytes()
+}
+
+// escape backslash-escapes the characters line protocol reserves in an
+// identifier.
+func escape(s, special string) string {
+	if !strings.ContainsAny(s, special) {
+		return s
+	}
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(special, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// send performs req. 4xx responses other than 429 are permanent; anything
+// else that isn't 2xx is retried.
+func send(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("%s returned %d: %s", req.URL.Host, resp.StatusCode, strings.TrimSpace(string(body)))
+	if resp.StatusCode/100 == 4 && resp.StatusCode != http.StatusTooManyRequests {
+		return fmt.Errorf("%w: %v", errPermanent, err)
+	}
+	return err
+}
@@ -0,0 +1,120 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+)
+
+// remoteWrite sends Prometheus remote-write 1.0 requests: a snappy-framed
+// protobuf WriteRequest. The message is small and fixed, so it is encoded
+// by hand rather than pulling in protobuf and snappy libraries.
+type remoteWrite struct {
+	cfg    config.PrometheusRemoteConfig
+	client *http.Client
+}
+
+func newRemoteWrite(cfg config.PrometheusRemoteConfig) *remoteWrite {
+	return &remoteWrite{cfg: cfg, client: &http.Client{Timeout: 15 * time.Second}}
+}
+
+func (r *remoteWrite) name() string { return "prometheus" }
+
+func (r *remoteWrite) write(points []Point) error {
+	req, err := http.NewRequest("POST", r.cfg.URL, bytes.NewReader(snappyBlock(writeRequest(points))))
+	if err != nil {
+		return fmt.Errorf("%w: %v", errPermanent, err)
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	switch {
+	case r.cfg.BearerToken != "":
+		req.Header.Set("Authorization", "Bearer "+r.cfg.BearerToken)
+	case r.cfg.Username != "":
+		req.SetBasicAuth(r.cfg.Username, r.cfg.Password)
+	}
+	return send(r.client, req)
+}
+
+// writeRequest encodes one time series per point field, e.g. the
+// avl_volume point's usedPercent becomes avl_volume_used_percent.
+//
+//	WriteRequest { repeated TimeSeries timeseries = 1; }
+//	TimeSeries   { repeated Label labels = 1; repeated Sample samples = 2; }
+//	Label        { string name = 1; string value = 2; }
+//	Sample       { double value = 1; int64 timestamp = 2; } // ms
+func writeRequest(points []Point) []byte {
+	var req []byte
+	for _, p := range points {
+		for _, field := range sortedKeys(p.Fields) {
+			labels := map[string]string{"__name__": p.Measurement + "_" + snakeCase(field)}
+			for k, v := range p.Tags {
+				if v != "" {
+					labels[k] = v
+				}
+			}
+
+			var series []byte
+			for _, k := range sortedKeys(labels) { // remote-write requires sorted labels
+				var label []byte
+				label = appendBytes(label, 1, []byte(k))
+				label = appendBytes(label, 2, []byte(labels[k]))
+				series = appendBytes(series, 1, label)
+			}
+			var sample []byte
+			sample = binary.AppendUvarint(sample, 1<<3|1) // fixed64
+			sample = binary.LittleEndian.AppendUint64(sample, math.Float64bits(p.Fields[field]))
+			sample = binary.AppendUvarint(sample, 2<<3|0) // varint
+			sample = binary.AppendUvarint(sample, uint64(p.Time.UnixMilli()))
+			series = appendBytes(series, 2, sample)
+
+			req = appendBytes(req, 1, series)
+		}
+	}
+	return req
+}
+
+// appendBytes appends a length-delimited protobuf field.
+func appendBytes(b []byte, field int, v []byte) []byte {
+	b = binary.AppendUvarint(b, uint64(field)<<3|2)
+	b = binary.AppendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+// snappyBlock wraps data in the snappy block format as literals only.
+// That is valid snappy that any decoder accepts; batches are small enough
+// that skipping compression doesn't matter.
+func snappyBlock(data []byte) []byte {
+	out := binary.AppendUvarint(nil, uint64(len(data)))
+	for len(data) > 0 {
+		n := min(len(data), 1<<16)
+		// Tag 61: literal whose length-1 follows in two bytes.
+		out = append(out, 61<<2, byte(n-1), byte((n-1)>>8))
+		out = append(out, data[:n]...)
+		data = data[n:]
+	}
+	return out
+}
+
+// snakeCase converts a camelCase field name to a Prometheus-style name.
+func snakeCase(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,132 @@
+// Package files serves read-only listings and downloads from configured
+// folders, so the dashboard can confirm last night's recording exists or
+// fetch a log without remote desktop. Only the configured folders are
+// reachable: paths are resolved, symlinks included, and anything that
+// lands outside its folder is refused.
+package files
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+)
+
+// maxEntries bounds a listing; the newest are kept.
+const maxEntries = 1000
+
+var (
+	// ErrNotFound is returned for an unknown folder or a missing path.
+	ErrNotFound = errors.New("not found")
+	// ErrOutside is returned for a path that resolves outside its folder.
+	ErrOutside = errors.New("path is outside the shared folder")
+)
+
+// Entry is one item in a listing.
+type Entry struct {
+	Name     string    `json:"name"`
+	Dir      bool      `json:"dir,omitempty"`
+	Bytes    int64     `json:"bytes"`
+	Modified time.Time `json:"modified"`
+}
+
+// Listing is a folder's contents, newest first.
+type Listing struct {
+	Folder    string  `json:"folder"`
+	Path      string  `json:"path"` // relative to the folder, "/"-separated
+	Entries   []Entry `json:"entries"`
+	Truncated bool    `json:"truncated,omitempty"`
+}
+
+// Browser resolves paths within the configured folders.
+type Browser struct {
+	folders map[string]string // name -> absolute path
+}
+
+// New creates a browser for the configured folders. It returns nil when
+// none are configured.
+func New(folders []config.SharedFolder) *Browser {
+	if len(folders) == 0 {
+		return nil
+	}
+	b := &Browser{folders: map[string]string{}}
+	for _, f := range folders {
+		if abs, err := filepath.Abs(f.Path); err == nil {
+			b.folders[f.Name] = abs
+		}
+	}
+	return b
+}
+
+// Folders returns the shared folders' names.
+func (b *Browser) Folders() []string {
+	var names []string
+	for name := range b.folders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Resolve returns the real path for rel within folder. It fails with
+// ErrOutside when rel, or a symlink along it, leads out of the folder.
+func (b *Browser) Resolve(folder, rel string) (string, error) {
+	root, ok := b.folders[folder]
+	if !ok {
+		return "", ErrNotFound
+	}
+	for _, part := range strings.Split(filepath.ToSlash(rel), "/") {
+		if part == ".." {
+			return "", ErrOutside
+		}
+	}
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", ErrNotFound
+	}
+	target, err := filepath.EvalSymlinks(filepath.Join(root, filepath.FromSlash(rel)))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", ErrNotFound
+	} else if err != nil {
+		return "", err
+	}
+	if target != realRoot && !strings.HasPrefix(target, realRoot+string(filepath.Separator)) {
+		return "", ErrOutside
+	}
+	return target, nil
+}
+
+// List returns the contents of the directory at rel within folder.
+func (b *Browser) List(folder, rel string) (Listing, error) {
+	listing := Listing{Folder: folder, Path: filepath.ToSlash(rel)}
+	dir, err := b.Resolve(folder, rel)
+	if err != nil {
+		return listing, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return listing, err
+	}
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		entry := Entry{Name: e.Name(), Dir: e.IsDir(), Modified: info.ModTime()}
+		if !e.IsDir() {
+			entry.Bytes = info.Size()
+		}
+		listing.Entries = append(listing.Entries, entry)
+	}
+	sort.Slice(listing.Entries, func(i, j int) bool {
+		return listing.Entries[i].Modified.After(listing.Entries[j].Modified)
+	})
+	if len(listing.Entries) > maxEntries {
+		listing.Entries, listing.Truncated = listing.Entries[:maxEntries], true
+	}
+	return listing, nil
+}
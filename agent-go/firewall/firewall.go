@@ -0,0 +1,18 @@
+// Package firewall keeps inbound firewall rules for the agent's port and
+// mDNS. Windows Firewall silently drops a new program's traffic (and
+// auto-creates Block rules for it), so a fresh install is unreachable
+// until the rules exist. Linux distributions differ too much (ufw,
+// firewalld, nftables) to manage, so there it is a no-op.
+package firewall
+
+import "errors"
+
+// Group names the rules the agent owns, so they can be found and removed.
+const Group = "AVL Dashboard Agent"
+
+// mdnsPort is the UDP port Bonjour discovery uses.
+const mdnsPort = 5353
+
+// ErrNotElevated is returned by Ensure when changing rules needs
+// administrator rights and prompting was not allowed.
+var ErrNotElevated = errors.New("changing firewall rules requires administrator rights")
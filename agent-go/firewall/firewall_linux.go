@@ -0,0 +1,18 @@
+//go:build linux
+
+package firewall
+
+// Check reports the rules as present: Linux firewalls are left to the admin.
+func Check(port uint16) (bool, error) {
+	return true, nil
+}
+
+// Ensure does nothing on Linux.
+func Ensure(port uint16, prompt bool) error {
+	return nil
+}
+
+// Remove does nothing on Linux.
+func Remove(prompt bool) error {
+	return nil
+}
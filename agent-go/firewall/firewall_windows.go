@@ -0,0 +1,85 @@
+//go:build windows
+
+package firewall
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/sys/windows"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/powershell"
+)
+
+// Check reports whether the agent's allow rules for port and mDNS exist
+// and no Block rule targets the executable. Reading rules doesn't need
+// elevation.
+func Check(port uint16) (bool, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return false, err
+	}
+	out, err := powershell.Run(preamble(exe) + fmt.Sprintf(`
+$blocks = Get-NetFirewallApplicationFilter -Program $exe -ErrorAction SilentlyContinue | Get-NetFirewallRule | Where-Object { $_.Action -eq 'Block' -and $_.Direction -eq 'Inbound' }
+$ports = @(Get-NetFirewallRule -Group $group -ErrorAction SilentlyContinue | Where-Object { $_.Enabled -eq 'True' } | Get-NetFirewallPortFilter | ForEach-Object { "$($_.Protocol)/$($_.LocalPort)" })
+if (-not $blocks -and $ports -contains 'TCP/%d' -and $ports -contains 'UDP/%d') { 'ok' } else { 'missing' }
+`, port, mdnsPort))
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(out) == "ok", nil
+}
+
+// Ensure replaces the agent's rules with allow rules for port and mDNS and
+// removes the Block rules Windows auto-creates for the executable. If the
+// agent isn't elevated and prompt is set, a UAC prompt asks for rights.
+func Ensure(port uint16, prompt bool) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	return runElevated(preamble(exe)+fmt.Sprintf(`
+Get-NetFirewallApplicationFilter -Program $exe -ErrorAction SilentlyContinue | Get-NetFirewallRule | Where-Object { $_.Action -eq 'Block' -and $_.Direction -eq 'Inbound' } | Remove-NetFirewallRule
+Get-NetFirewallRule -Group $group -ErrorAction SilentlyContinue | Remove-NetFirewallRule
+New-NetFirewallRule -DisplayName "$group (TCP %[1]d)" -Group $group -Direction Inbound -Protocol TCP -LocalPort %[1]d -Program $exe -Action Allow -Profile Any | Out-Null
+New-NetFirewallRule -DisplayName "$group (mDNS)" -Group $group -Direction Inbound -Protocol UDP -LocalPort %[2]d -Program $exe -Action Allow -Profile Any | Out-Null
+`, port, mdnsPort), prompt)
+}
+
+// Remove deletes the agent's rules, e.g. on uninstall.
+func Remove(prompt bool) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	return runElevated(preamble(exe)+`
+Get-NetFirewallRule -Group $group -ErrorAction SilentlyContinue | Remove-NetFirewallRule
+`, prompt)
+}
+
+func preamble(exe string) string {
+	return fmt.Sprintf("$ErrorActionPreference = 'Stop'\n$group = '%s'\n$exe = '%s'\n",
+		quote(Group), quote(exe))
+}
+
+// runElevated runs script directly when the agent is elevated (service
+// mode or "Run as administrator"), otherwise through a UAC prompt.
+func runElevated(script string, prompt bool) error {
+	if windows.GetCurrentProcessToken().IsElevated() {
+		_, err := powershell.Run(script)
+		return err
+	}
+	if !prompt {
+		return ErrNotElevated
+	}
+	_, err := powershell.Run(fmt.Sprintf(`$p = Start-Process powershell.exe -Verb RunAs -WindowStyle Hidden -Wait -PassThru -ArgumentList '-NoProfile','-NonInteractive','-EncodedCommand','%s'
+if ($p.ExitCode -ne 0) { throw "elevated script exited with $($p.ExitCode)" }
+`, powershell.Encode(script)))
+	return err
+}
+
+// quote escapes s for a single-quoted PowerShell string.
+func quote(s string) string {
+	return strings.ReplaceAll(s, "'", "''")
+}
@@ -0,0 +1,259 @@
+// Package fleet receives configuration documents from a dashboard or config
+// server, either pushed over the API or pulled on a schedule, and keeps a
+// trail of previous versions for rollback. The local config file is layered
+// on top (see config.LoadLayered), so per-machine overrides survive.
+package fleet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/events"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/schedule"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/state"
+)
+
+const (
+	historyKey      = "configHistory"
+	rolledBackKey   = "configRolledBack"
+	maxHistory      = 10
+	defaultInterval = 15 * time.Minute
+)
+
+// ErrUnknownVersion is returned by Rollback for a version not in the trail.
+var ErrUnknownVersion = errors.New("version not in config history")
+
+// Revision is one applied fleet document.
+type Revision struct {
+	Version   string               `json:"version"`
+	Source    string               `json:"source"` // who pushed it, the pull URL, or "rollback"
+	AppliedAt time.Time            `json:"appliedAt"`
+	Document  config.FleetDocument `json:"document"`
+}
+
+// Status describes the active fleet config. Documents are left out since
+// they may contain credentials.
+type Status struct {
+	Version         string     `json:"version,omitempty"`
+	Source          string     `json:"source,omitempty"`
+	AppliedAt       *time.Time `json:"appliedAt,omitempty"`
+	RestartRequired bool       `json:"restartRequired"`
+	// RolledBackFrom is the version a rollback replaced. Pulls ignore it
+	// until the config server publishes another.
+	RolledBackFrom string         `json:"rolledBackFrom,omitempty"`
+	History        []RevisionInfo `json:"history"`
+}
+
+// RevisionInfo is a Revision without its document.
+type RevisionInfo struct {
+	Version   string    `json:"version"`
+	Source    string    `json:"source"`
+	AppliedAt time.Time `json:"appliedAt"`
+}
+
+// Manager applies fleet documents. Safe for concurrent use.
+type Manager struct {
+	cfg   config.FleetConfig
+	path  string
+	store *state.Store
+
+	mu      sync.Mutex
+	history []Revision // oldest first; the last entry is active
+	// rolledBack is the version a rollback replaced, so the next pull
+	// doesn't put it straight back. Cleared once another is applied.
+	rolledBack string
+	restart    bool
+	onChange   func()
+}
+
+// New creates a manager storing the active document at path.
+func New(cfg config.FleetConfig, path string, store *state.Store) *Manager {
+	m := &Manager{cfg: cfg, path: path, store: store}
+	store.Get(historyKey, &m.history)
+	store.Get(rolledBackKey, &m.rolledBack)
+	return m
+}
+
+// OnChange registers a callback run after a new document is applied. The
+// running config is not reloaded in place; the callback decides how to
+// pick it up (typically by restarting).
+func (m *Manager) OnChange(fn func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onChange = fn
+}
+
+// Apply validates and activates doc. Applying the active version again is
+// a no-op.
+func (m *Manager) Apply(doc config.FleetDocument, source string) error {
+	if err := doc.Validate(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	if n := len(m.history); n > 0 && m.history[n-1].Version == doc.Version {
+		m.mu.Unlock()
+		return nil
+	}
+	if err := m.activate(Revision{Version: doc.Version, Source: source, AppliedAt: time.Now(), Document: doc}); err != nil {
+		m.mu.Unlock()
+		return err
+	}
+	if m.rolledBack != "" {
+		m.rolledBack = ""
+		m.store.Delete(rolledBackKey)
+	}
+	onChange := m.onChange
+	m.mu.Unlock()
+
+	events.Record(events.RemoteAction, events.Info, "Fleet config %s applied from %s", doc.Version, source)
+	if onChange != nil {
+		onChange()
+	}
+	return nil
+}
+
+// Rollback re-activates an earlier version from the trail. The version it
+// replaces is held back from pulls until the config server publishes a
+// different one.
+func (m *Manager) Rollback(version, source string) error {
+	m.mu.Lock()
+	var doc *config.FleetDocument
+	for i := range m.history {
+		if m.history[i].Version == version {
+			doc = &m.history[i].Document
+		}
+	}
+	if doc == nil {
+		m.mu.Unlock()
+		return ErrUnknownVersion
+	}
+	// A second rollback keeps holding the version the server serves;
+	// rolling back to that version lets pulls apply it again.
+	switch {
+	case version == m.rolledBack:
+		m.rolledBack = ""
+	case m.rolledBack == "":
+		m.rolledBack = m.history[len(m.history)-1].Version
+	}
+	err := m.activate(Revision{Version: version, Source: "rollback by " + source, AppliedAt: time.Now(), Document: *doc})
+	if err == nil {
+		err = m.store.Set(rolledBackKey, m.rolledBack)
+	}
+	onChange := m.onChange
+	m.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	events.Record(events.RemoteAction, events.Warning, "Fleet config rolled back to %s by %s", version, source)
+	if onChange != nil {
+		onChange()
+	}
+	return nil
+}
+
+// activate writes rev's document and appends it to the trail. Caller holds m.mu.
+func (m *Manager) activate(rev Revision) error {
+	raw, err := json.MarshalIndent(rev.Document, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(m.path), 0755); err != nil {
+		return err
+	}
+	tmp := m.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, m.path); err != nil {
+		return err
+	}
+
+	m.history = append(m.history, rev)
+	if len(m.history) > maxHistory {
+		m.history = m.history[len(m.history)-maxHistory:]
+	}
+	m.restart = true
+	return m.store.Set(historyKey, m.history)
+}
+
+// Status returns the active version and the trail, newest first.
+func (m *Manager) Status() Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := Status{RestartRequired: m.restart, RolledBackFrom: m.rolledBack, History: make([]RevisionInfo, 0, len(m.history))}
+	for i := len(m.history) - 1; i >= 0; i-- {
+		r := m.history[i]
+		s.History = append(s.History, RevisionInfo{Version: r.Version, Source: r.Source, AppliedAt: r.AppliedAt})
+	}
+	if n := len(m.history); n > 0 {
+		active := m.history[n-1]
+		s.Version, s.Source, s.AppliedAt = active.Version, active.Source, &active.AppliedAt
+	}
+	return s
+}
+
+// Run pulls the fleet document from the configured URL on a schedule.
+// Blocks; returns immediately when no URL is configured.
+func (m *Manager) Run() {
+	if m.cfg.URL == "" {
+		return
+	}
+	interval := defaultInterval
+	if m.cfg.IntervalMinutes > 0 {
+		interval = time.Duration(m.cfg.IntervalMinutes) * time.Minute
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	// Jittered so a site's agents don't all pull at once; backs off to an
+	// hour while the server is down.
+	schedule.Loop(context.Background(), schedule.Policy{
+		Interval:   interval,
+		Jitter:     0.1,
+		MaxBackoff: max(interval, time.Hour),
+	}, func() error {
+		err := m.pull(client)
+		if err != nil {
+			events.Record(events.Lifecycle, events.Warning, "Fleet config pull failed: %v", err)
+		}
+		return err
+	})
+}
+
+func (m *Manager) pull(client *http.Client) error {
+	req, err := http.NewRequest("GET", m.cfg.URL, nil)
+	if err != nil {
+		return err
+	}
+	if m.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+m.cfg.Token)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("config server returned %d", resp.StatusCode)
+	}
+
+	var doc config.FleetDocument
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	held := doc.Version == m.rolledBack
+	m.mu.Unlock()
+	if held {
+		return nil // rolled back from; wait for the next version
+	}
+	return m.Apply(doc, m.cfg.URL)
+}
@@ -7,6 +7,7 @@ require (
 	github.com/grandcat/zeroconf v1.0.0
 	github.com/shirou/gopsutil/v4 v4.25.1
 	github.com/yusufpapurcu/wmi v1.2.4
+	golang.org/x/sys v0.28.0
 )
 
 require (
@@ -21,5 +22,4 @@ require (
 	github.com/tklauser/numcpus v0.6.1 // indirect
 	golang.org/x/crypto v0.0.0-20191011191535-87dc89f01550 // indirect
 	golang.org/x/net v0.0.0-20200114155413-6afb5195e5aa // indirect
-	golang.org/x/sys v0.28.0 // indirect
 )
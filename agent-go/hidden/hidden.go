@@ -0,0 +1,5 @@
+// Package hidden runs console programs (powercfg, w32tm, PowerShell)
+// without a console window flashing up on the desktop, which the agent
+// running as a GUI program would otherwise get for each one. There is
+// nothing to hide elsewhere.
+package hidden
@@ -0,0 +1,177 @@
+// Package history keeps longer-term records of machine and agent behaviour.
+package history
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/host"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/state"
+)
+
+const (
+	availabilityKey   = "availability"
+	heartbeatInterval = time.Minute
+	retention         = 30 * 24 * time.Hour
+)
+
+// Session is one continuous run of the agent. End is the last heartbeat, so
+// a crash or power loss costs at most one heartbeat interval of accuracy.
+type Session struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+type availabilityRecord struct {
+	Sessions []Session   `json:"sessions"`
+	Boots    []time.Time `json:"boots"`
+}
+
+// Availability records agent sessions and machine boots in the state store
+// and computes uptime percentages over fixed windows.
+type Availability struct {
+	store *state.Store
+	mu    sync.Mutex
+	rec   availabilityRecord
+}
+
+// WindowStats summarizes availability over one window.
+type WindowStats struct {
+	Percent         float64 `json:"percent"`
+	DowntimeSeconds float64 `json:"downtimeSeconds"`
+	CoveredSeconds  float64 `json:"coveredSeconds"` // window length since tracking began
+	Boots           int     `json:"boots"`
+	AgentStarts     int     `json:"agentStarts"`
+}
+
+// AvailabilityReport is the body of GET /history/availability.
+type AvailabilityReport struct {
+	TrackingSince string                 `json:"trackingSince"`
+	Windows       map[string]WindowStats `json:"windows"`
+	Boots         []string               `json:"boots"`
+}
+
+// NewAvailability loads prior sessions and records the start of this one.
+func NewAvailability(store *state.Store) *Availability {
+	a := &Availability{store: store}
+	store.Get(availabilityKey, &a.rec)
+
+	now := time.Now()
+	a.rec.Sessions = append(a.rec.Sessions, Session{Start: now, End: now})
+	if boot, err := host.BootTime(); err == nil {
+		bootTime := time.Unix(int64(boot), 0)
+		if n := len(a.rec.Boots); n == 0 || !a.rec.Boots[n-1].Equal(bootTime) {
+			a.rec.Boots = append(a.rec.Boots, bootTime)
+		}
+	}
+	a.save()
+	return a
+}
+
+// Run extends the current session every heartbeat. Blocks forever.
+func (a *Availability) Run() {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		a.Heartbeat()
+	}
+}
+
+// Heartbeat marks the agent as alive now. Call on clean shutdown too.
+func (a *Availability) Heartbeat() {
+	a.mu.Lock()
+	a.rec.Sessions[len(a.rec.Sessions)-1].End = time.Now()
+	a.mu.Unlock()
+	a.save()
+}
+
+// save prunes records older than the retention window and persists them.
+func (a *Availability) save() {
+	a.mu.Lock()
+	cutoff := time.Now().Add(-retention)
+	for len(a.rec.Sessions) > 1 && a.rec.Sessions[0].End.Before(cutoff) {
+		a.rec.Sessions = a.rec.Sessions[1:]
+	}
+	for len(a.rec.Boots) > 0 && a.rec.Boots[0].Before(cutoff) {
+		a.rec.Boots = a.rec.Boots[1:]
+	}
+	rec := a.rec
+	a.mu.Unlock()
+
+	if err := a.store.Set(availabilityKey, rec); err != nil {
+		log.Printf("Availability: save failed: %v", err)
+	}
+}
+
+// Report computes availability for the last 24 hours, 7 days, and 30 days.
+func (a *Availability) Report() AvailabilityReport {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+	a.rec.Sessions[len(a.rec.Sessions)-1].End = now
+	first := a.rec.Sessions[0].Start
+
+	report := AvailabilityReport{
+		TrackingSince: first.Format(time.RFC3339),
+		Windows:       make(map[string]WindowStats),
+	}
+	for _, b := range a.rec.Boots {
+		report.Boots = append(report.Boots, b.Format(time.RFC3339))
+	}
+
+	windows := []struct {
+		name string
+		d    time.Duration
+	}{{"24h", 24 * time.Hour}, {"7d", 7 * 24 * time.Hour}, {"30d", 30 * 24 * time.Hour}}
+	for _, w := range windows {
+		report.Windows[w.name] = a.window(now.Add(-w.d), now, first)
+	}
+	return report
+}
+
+// window computes stats for [from, to], clipped to when tracking began so a
+// newly installed agent isn't reported as mostly down. Caller holds a.mu.
+func (a *Availability) window(from, to, trackingStart time.Time) WindowStats {
+	if from.Before(trackingStart) {
+		from = trackingStart
+	}
+	covered := to.Sub(from)
+	var up time.Duration
+	var starts int
+	for _, s := range a.rec.Sessions {
+		start, end := s.Start, s.End
+		if !start.Before(from) {
+			starts++
+		}
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		if end.After(start) {
+			up += end.Sub(start)
+		}
+	}
+	var boots int
+	for _, b := range a.rec.Boots {
+		if !b.Before(from) {
+			boots++
+		}
+	}
+
+	stats := WindowStats{
+		Percent:        100,
+		CoveredSeconds: covered.Seconds(),
+		Boots:          boots,
+		AgentStarts:    starts,
+	}
+	if covered > 0 {
+		stats.Percent = up.Seconds() / covered.Seconds() * 100
+		stats.DowntimeSeconds = (covered - up).Seconds()
+	}
+	return stats
+}
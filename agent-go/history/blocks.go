@@ -0,0 +1,198 @@
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"time"
+)
+
+// Day files hold blocks of samples, one per flush. A block stores each
+// field as a column of deltas between consecutive values, scaled to two
+// decimal places and written as varints; slowly moving metrics shrink to
+// a byte or two per sample before gzip sees them. On disk each block is a
+// 4-byte big-endian length followed by that many bytes of gzip.
+const (
+	blockVersion = 1
+	valueScale   = 100 // values are kept to two decimal places
+	// maxBlockSize bounds a frame length read back, so a corrupt length
+	// doesn't allocate gigabytes.
+	maxBlockSize = 16 << 20
+)
+
+var errCorrupt = errors.New("corrupt history block")
+
+// encodeBlock lays out samples, oldest first, as one compressed block.
+func encodeBlock(samples []Sample) ([]byte, error) {
+	names := map[string]bool{}
+	for _, s := range samples {
+		for name := range s.Values {
+			names[name] = true
+		}
+	}
+	fields := make([]string, 0, len(names))
+	for name := range names {
+		fields = append(fields, name)
+	}
+	sort.Strings(fields)
+
+	var b []byte
+	b = binary.AppendUvarint(b, blockVersion)
+	b = binary.AppendUvarint(b, uint64(len(samples)))
+	b = binary.AppendUvarint(b, uint64(len(fields)))
+	for _, f := range fields {
+		b = binary.AppendUvarint(b, uint64(len(f)))
+		b = append(b, f...)
+	}
+	var prevTime int64
+	for _, s := range samples {
+		t := s.Time.Unix()
+		b = binary.AppendVarint(b, t-prevTime)
+		prevTime = t
+	}
+	for _, f := range fields {
+		var prev int64
+		for _, s := range samples {
+			v := int64(math.Round(s.Values[f] * valueScale))
+			b = binary.AppendVarint(b, v-prev)
+			prev = v
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.Write(make([]byte, 4)) // length, filled in below
+	zw := gzip.NewWriter(&buf)
+	zw.Write(b)
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	frame := buf.Bytes()
+	binary.BigEndian.PutUint32(frame, uint32(len(frame)-4))
+	return frame, nil
+}
+
+// decodeBlock reverses encodeBlock for the bytes after the length.
+func decodeBlock(compressed []byte) ([]Sample, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, err
+	}
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, err
+	}
+	r := bytes.NewReader(raw)
+	uvarint := func() uint64 {
+		v, e := binary.ReadUvarint(r)
+		if e != nil {
+			err = errCorrupt
+		}
+		return v
+	}
+	varint := func() int64 {
+		v, e := binary.ReadVarint(r)
+		if e != nil {
+			err = errCorrupt
+		}
+		return v
+	}
+
+	if v := uvarint(); err == nil && v != blockVersion {
+		return nil, fmt.Errorf("history block version %d", v)
+	}
+	count, nfields := uvarint(), uvarint()
+	if err != nil || count > uint64(len(raw)) || nfields > uint64(len(raw)) {
+		return nil, errCorrupt
+	}
+	fields := make([]string, nfields)
+	for i := range fields {
+		n := uvarint()
+		if n > uint64(len(raw)) {
+			return nil, errCorrupt
+		}
+		name := make([]byte, n)
+		if _, e := io.ReadFull(r, name); e != nil {
+			return nil, errCorrupt
+		}
+		fields[i] = string(name)
+	}
+	samples := make([]Sample, count)
+	var t int64
+	for i := range samples {
+		t += varint()
+		samples[i] = Sample{Time: time.Unix(t, 0).UTC(), Values: make(map[string]float64, nfields)}
+	}
+	for _, f := range fields {
+		var v int64
+		for i := range samples {
+			v += varint()
+			samples[i].Values[f] = float64(v) / valueScale
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return samples, nil
+}
+
+// readBlocks returns the samples in a day file. It stops at a torn or
+// corrupt block and returns the samples before it, with the offset where
+// the good blocks end.
+func readBlocks(path string) ([]Sample, int64, error) {
+	var out []Sample
+	good, err := scanBlocks(path, func(samples []Sample) { out = append(out, samples...) })
+	return out, good, err
+}
+
+// scanBlocks calls fn with each block's samples in a day file, so a long
+// range can be summarized a block at a time. It stops like readBlocks.
+func scanBlocks(path string, fn func([]Sample)) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	var good int64
+	for {
+		var length [4]byte
+		if _, err := io.ReadFull(r, length[:]); err != nil {
+			return good, nil // end of file, or a torn length
+		}
+		n := binary.BigEndian.Uint32(length[:])
+		if n > maxBlockSize {
+			return good, nil
+		}
+		block := make([]byte, n)
+		if _, err := io.ReadFull(r, block); err != nil {
+			return good, nil
+		}
+		samples, err := decodeBlock(block)
+		if err != nil {
+			return good, nil
+		}
+		fn(samples)
+		good += 4 + int64(n)
+	}
+}
+
+// repairBlocks cuts a day file back to its last whole block, so blocks
+// appended after a power loss mid-write can be read.
+func repairBlocks(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	_, good, err := readBlocks(path)
+	if err != nil || good == info.Size() {
+		return err
+	}
+	return os.Truncate(path, good)
+}
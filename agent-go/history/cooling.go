@@ -0,0 +1,130 @@
+package history
+
+import (
+	"log"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/metrics"
+)
+
+// The cooling trend compares the last two days with a baseline from a week
+// to a month ago. Temperature depends on load, so samples are grouped by
+// CPU usage and only like is compared with like.
+const (
+	coolingInterval   = 6 * time.Hour
+	recentWindow      = 2 * 24 * time.Hour
+	baselineFrom      = 30 * 24 * time.Hour // ago
+	baselineTo        = 7 * 24 * time.Hour  // ago
+	minBandTime       = 2 * time.Hour       // of samples per load band and window
+	suspectDeviationC = 8.0                 // above seasonal room temperature swings
+)
+
+// loadBands are the CPU usage bands samples are grouped by.
+var loadBands = []float64{10, 25, 50, 75, 101}
+
+// WatchCooling computes the cooling trend from the recorded history every
+// six hours and reports it on collector; a suspect trend raises the
+// "cooling" alert. Blocks forever.
+func (r *Recorder) WatchCooling(collector *metrics.Collector) {
+	for {
+		trend, err := r.CoolingTrend(time.Now())
+		if err != nil {
+			log.Printf("History: cooling trend: %v", err)
+		}
+		collector.SetCooling(trend)
+		time.Sleep(coolingInterval)
+	}
+}
+
+// CoolingTrend compares recent CPU temperatures with the baseline at the
+// same load. It returns nil when there isn't enough history: a week's
+// baseline and two days of recent samples in at least one load band.
+func (r *Recorder) CoolingTrend(now time.Time) (*metrics.CoolingTrend, error) {
+	// A month of samples is summarized as it is read, into a histogram
+	// per load band, rather than loaded: at a 5-second interval it is
+	// half a million samples.
+	recentFrom, baselineEnd := now.Add(-recentWindow), now.Add(-baselineTo)
+	baseline := make([]tempHistogram, len(loadBands))
+	recent := make([]tempHistogram, len(loadBands))
+	err := r.scan(now.Add(-baselineFrom), now, func(s Sample) {
+		temp := s.Values["cpuTempCelsius"]
+		if temp <= 0 {
+			return // no sensor, or no reading
+		}
+		band := loadBand(s.Values["cpuUsagePercent"])
+		switch {
+		case !s.Time.Before(recentFrom):
+			recent[band].add(temp)
+		case s.Time.Before(baselineEnd):
+			baseline[band].add(temp)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Weight each band by its recent samples, so the bands the machine
+	// spends its time in count most.
+	var weight, baseSum, recentSum float64
+	minBandSamples := int(minBandTime / r.interval)
+	for band := range loadBands {
+		if baseline[band].n < minBandSamples || recent[band].n < minBandSamples {
+			continue
+		}
+		n := float64(recent[band].n)
+		weight += n
+		baseSum += baseline[band].median() * n
+		recentSum += recent[band].median() * n
+	}
+	if weight == 0 {
+		return nil, nil
+	}
+	trend := &metrics.CoolingTrend{
+		BaselineCelsius: baseSum / weight,
+		RecentCelsius:   recentSum / weight,
+		CheckedAt:       now,
+	}
+	trend.DeviationCelsius = trend.RecentCelsius - trend.BaselineCelsius
+	trend.Suspect = trend.DeviationCelsius >= suspectDeviationC
+	return trend, nil
+}
+
+func loadBand(usage float64) int {
+	for i, upper := range loadBands {
+		if usage < upper {
+			return i
+		}
+	}
+	return len(loadBands) - 1
+}
+
+// tempHistogram counts temperatures in histStep bins up to histMax, which
+// is all a median needs, in fixed memory.
+type tempHistogram struct {
+	bins [histMax / histStep]int
+	n    int
+}
+
+const (
+	histStep = 0.25 // °C
+	histMax  = 128  // °C; hotter readings count in the top bin
+)
+
+func (h *tempHistogram) add(temp float64) {
+	i := min(int(temp/histStep), len(h.bins)-1)
+	h.bins[i]++
+	h.n++
+}
+
+// median returns the middle bin's center.
+func (h *tempHistogram) median() float64 {
+	half := (h.n + 1) / 2
+	seen := 0
+	for i, count := range h.bins {
+		seen += count
+		if seen >= half {
+			return (float64(i) + 0.5) * histStep
+		}
+	}
+	return 0
+}
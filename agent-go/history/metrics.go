@@ -0,0 +1,313 @@
+package history
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/metrics"
+)
+
+const (
+	defaultInterval  = time.Minute
+	defaultRetention = 30 * 24 * time.Hour
+	// flushInterval is how long samples wait in memory to be written as
+	// one block; a power loss costs at most this much history, while a
+	// shutdown or relaunch flushes them.
+	flushInterval = 10 * time.Minute
+	dayFormat     = "2006-01-02"
+	// blockExt is the day file of encoded blocks (see blocks.go);
+	// legacyExt, the JSON lines days written before it, still read.
+	blockExt  = ".hist"
+	legacyExt = ".jsonl"
+)
+
+// Fields lists the exportable sample columns in their default order. Names
+// match the /status JSON keys.
+var Fields = []string{
+	"cpuUsagePercent",
+	"cpuTempCelsius",
+	"ramUsagePercent",
+	"networkBytesPerSec",
+	"diskBytesPerSec",
+	"uptimeSeconds",
+}
+
+// Sample is one interval's snapshot of the headline metrics.
+type Sample struct {
+	Time   time.Time          `json:"time"`
+	Values map[string]float64 `json:"values"`
+}
+
+// Recorder samples the collector every interval (a minute by default),
+// batches the samples in memory, and writes them ten minutes at a time as
+// compressed blocks to a file per UTC day. It keeps 30 days by default,
+// and with MaxMB set, deletes the oldest days to stay under it. Safe for
+// concurrent use.
+type Recorder struct {
+	dir       string
+	interval  time.Duration
+	retention time.Duration
+	maxBytes  int64
+
+	mu      sync.Mutex
+	pending []Sample // not yet written, all from one day
+}
+
+// NewRecorder stores samples under dir.
+func NewRecorder(dir string, cfg config.HistoryConfig) *Recorder {
+	r := &Recorder{
+		dir:       dir,
+		interval:  time.Duration(cfg.IntervalSeconds) * time.Second,
+		retention: time.Duration(cfg.RetentionDays) * 24 * time.Hour,
+		maxBytes:  int64(cfg.MaxMB) << 20,
+	}
+	if r.interval <= 0 {
+		r.interval = defaultInterval
+	}
+	if r.retention <= 0 {
+		r.retention = defaultRetention
+	}
+	return r
+}
+
+// Run samples the collector every interval. Blocks forever.
+func (r *Recorder) Run(collector *metrics.Collector) {
+	if err := os.MkdirAll(r.dir, 0755); err != nil {
+		log.Printf("History: %v", err)
+		return
+	}
+	now := time.Now()
+	if err := repairBlocks(r.dayFile(now, blockExt)); err != nil && !os.IsNotExist(err) {
+		log.Printf("History: %v", err)
+	}
+	r.prune(now)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		r.add(sampleOf(collector.CurrentStatus(), now))
+	}
+}
+
+// add queues a sample, first writing the queue when it is due or the
+// sample starts a new day.
+func (r *Recorder) add(s Sample) {
+	r.mu.Lock()
+	var batch []Sample
+	if len(r.pending) > 0 {
+		first := r.pending[0].Time
+		if s.Time.Sub(first) >= flushInterval || !sameDay(first, s.Time) {
+			batch, r.pending = r.pending, nil
+		}
+	}
+	r.pending = append(r.pending, s)
+	r.mu.Unlock()
+
+	if batch == nil {
+		return
+	}
+	if err := r.write(batch); err != nil {
+		log.Printf("History: %v", err)
+	}
+	if !sameDay(batch[0].Time, s.Time) {
+		r.prune(s.Time)
+	}
+}
+
+// Flush writes the queued samples now, for shutdown.
+func (r *Recorder) Flush() {
+	r.mu.Lock()
+	batch := r.pending
+	r.pending = nil
+	r.mu.Unlock()
+	if len(batch) == 0 {
+		return
+	}
+	if err := r.write(batch); err != nil {
+		log.Printf("History: %v", err)
+	}
+}
+
+func sampleOf(s metrics.MachineStatus, now time.Time) Sample {
+	return Sample{Time: now.UTC().Truncate(time.Second), Values: Values(s)}
+}
+
+// Values returns the headline metrics of a status snapshot, keyed by the
+// names in Fields.
+func Values(s metrics.MachineStatus) map[string]float64 {
+	return map[string]float64{
+		"cpuUsagePercent":    s.CPUUsagePercent,
+		"cpuTempCelsius":     s.CPUTempCelsius,
+		"ramUsagePercent":    s.RAMUsagePercent,
+		"networkBytesPerSec": s.NetworkBytesPS,
+		"diskBytesPerSec":    s.DiskBytesPS,
+		"uptimeSeconds":      s.UptimeSeconds,
+	}
+}
+
+// write appends a batch from one day to its day file in a single write.
+func (r *Recorder) write(batch []Sample) error {
+	block, err := encodeBlock(batch)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(r.dayFile(batch[0].Time, blockExt), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(block)
+	return err
+}
+
+func (r *Recorder) dayFile(t time.Time, ext string) string {
+	return filepath.Join(r.dir, "metrics-"+t.UTC().Format(dayFormat)+ext)
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.UTC().Format(dayFormat) == b.UTC().Format(dayFormat)
+}
+
+// prune deletes day files past the retention, then, over MaxMB, the
+// oldest days before today.
+func (r *Recorder) prune(now time.Time) {
+	cutoff := now.UTC().Add(-r.retention).Format(dayFormat)
+	today := now.UTC().Format(dayFormat)
+	files, _ := filepath.Glob(filepath.Join(r.dir, "metrics-*"))
+	sort.Sort(sort.Reverse(sort.StringSlice(files))) // newest first
+	var total int64
+	for _, f := range files {
+		day := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(f), "metrics-"), filepath.Ext(f))
+		info, err := os.Stat(f)
+		if err != nil {
+			continue
+		}
+		total += info.Size()
+		if day < cutoff || (r.maxBytes > 0 && total > r.maxBytes && day != today) {
+			os.Remove(f)
+		}
+	}
+}
+
+// Samples returns the samples in [from, to], oldest first.
+func (r *Recorder) Samples(from, to time.Time) ([]Sample, error) {
+	var out []Sample
+	err := r.scan(from, to, func(s Sample) { out = append(out, s) })
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Time.Before(out[j].Time) })
+	return out, nil
+}
+
+// scan calls fn with each sample in [from, to], in no particular order,
+// holding one block in memory at a time.
+func (r *Recorder) scan(from, to time.Time, fn func(Sample)) error {
+	keep := func(samples []Sample) {
+		for _, s := range samples {
+			if !s.Time.Before(from) && !s.Time.After(to) {
+				fn(s)
+			}
+		}
+	}
+	for day := from.UTC().Truncate(24 * time.Hour); !day.After(to); day = day.Add(24 * time.Hour) {
+		if _, err := scanBlocks(r.dayFile(day, blockExt), keep); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		samples, err := readLegacy(r.dayFile(day, legacyExt))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		keep(samples)
+	}
+	r.mu.Lock()
+	pending := slices.Clone(r.pending)
+	r.mu.Unlock()
+	keep(pending)
+	return nil
+}
+
+// readLegacy reads a JSON lines day file.
+func readLegacy(path string) ([]Sample, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var out []Sample
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var s Sample
+		if json.Unmarshal(scanner.Bytes(), &s) != nil {
+			continue // torn write from a power loss
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+// Export writes samples as "csv" (with a header row) or "jsonl" (one
+// object per line), with a time column followed by the given fields.
+func Export(w io.Writer, samples []Sample, fields []string, format string) error {
+	switch format {
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write(append([]string{"time"}, fields...))
+		for _, s := range samples {
+			row := []string{s.Time.Format(time.RFC3339)}
+			for _, f := range fields {
+				row = append(row, strconv.FormatFloat(s.Values[f], 'f', -1, 64))
+			}
+			cw.Write(row)
+		}
+		cw.Flush()
+		return cw.Error()
+	case "jsonl":
+		enc := json.NewEncoder(w)
+		for _, s := range samples {
+			obj := map[string]any{"time": s.Time.Format(time.RFC3339)}
+			for _, f := range fields {
+				obj[f] = s.Values[f]
+			}
+			if err := enc.Encode(obj); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown format %q (want csv or jsonl)", format)
+}
+
+// ParseFields splits a comma-separated field list. Empty selects every
+// field; unknown names are an error.
+func ParseFields(list string) ([]string, error) {
+	if list == "" {
+		return Fields, nil
+	}
+	known := make(map[string]bool, len(Fields))
+	for _, f := range Fields {
+		known[f] = true
+	}
+	var fields []string
+	for _, f := range strings.Split(list, ",") {
+		f = strings.TrimSpace(f)
+		if !known[f] {
+			return nil, fmt.Errorf("unknown field %q (want %s)", f, strings.Join(Fields, ", "))
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
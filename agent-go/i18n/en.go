@@ -0,0 +1,64 @@
+package i18n
+
+var english = map[string]string{
+	"app.title":                "AVL Dashboard Agent",
+	"menu.hostname.tip":        "Machine hostname",
+	"menu.starting":            "Starting...",
+	"menu.port":                "Port: %d",
+	"menu.port.tip":            "Listening port",
+	"menu.connected":           "Dashboard Connected",
+	"menu.disconnected":        "No Dashboard Connected",
+	"menu.network.waiting":     "Waiting for Network...",
+	"menu.connection.tip":      "Dashboard connection status",
+	"menu.version":             "Agent v%s",
+	"menu.version.tip":         "Agent version",
+	"menu.update":              "Check for Updates",
+	"menu.update.tip":          "Check GitHub for new releases",
+	"menu.notes":               "What's New in v%s",
+	"menu.notes.tip":           "Show the release notes of this version",
+	"menu.skip":                "Skip This Update",
+	"menu.skip.tip":            "Don't install this release; wait for the next one",
+	"menu.skip.version":        "Skip Update v%s",
+	"menu.beta":                "Receive beta updates",
+	"menu.beta.tip":            "Install pre-release agent versions on this machine",
+	"menu.alerts.none":         "No Alerts",
+	"menu.alerts.ack":          "Acknowledge %d Alert(s)",
+	"menu.alerts.tip":          "Acknowledge all alerts",
+	"menu.pair":                "Pair Dashboard...",
+	"menu.pair.code":           "Pairing Code: %s (%d min)",
+	"menu.pair.tip":            "Show a code to pair a dashboard with this agent",
+	"menu.qr":                  "Show QR Code",
+	"menu.qr.tip":              "Show this machine's address and a pairing code as a QR code",
+	"menu.maintenance":         "Maintenance Mode",
+	"menu.maintenance.until":   "In Maintenance Until %s",
+	"menu.maintenance.tip":     "Pause alerts while working on this machine",
+	"menu.maintenance.1h":      "For 1 Hour",
+	"menu.maintenance.4h":      "For 4 Hours",
+	"menu.maintenance.24h":     "For 24 Hours",
+	"menu.maintenance.for.tip": "Pause alerts for %d hours",
+	"menu.maintenance.end":     "End Maintenance",
+	"menu.maintenance.end.tip": "Resume alerts now",
+	"menu.diagnostics":         "Copy Diagnostics",
+	"menu.diagnostics.tip":     "Save a support bundle to the desktop",
+	"menu.quit":                "Quit",
+	"menu.quit.tip":            "Quit the agent",
+
+	"toast.updating":     "Updating from v%s to v%s. The agent will restart.",
+	"toast.updated":      "Updated to v%s.",
+	"toast.disconnected": "The dashboard stopped polling this machine.",
+	"toast.alert.title":  "%s on %s",
+
+	"qr.code":     "Pairing code %s",
+	"notes.title": "What's new in v%s",
+	"notes.none":  "This release has no notes.",
+	"notes.link":  "View on GitHub",
+
+	"intercom.title":           "Message from %s",
+	"intercom.title.dashboard": "Message from the dashboard",
+
+	"severity.info":     "Info",
+	"severity.warning":  "Warning",
+	"severity.critical": "Critical",
+
+	"format.time": "Mon 3:04 PM",
+}
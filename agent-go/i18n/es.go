@@ -0,0 +1,64 @@
+package i18n
+
+var spanish = map[string]string{
+	"app.title":                "Agente de AVL Dashboard",
+	"menu.hostname.tip":        "Nombre del equipo",
+	"menu.starting":            "Iniciando...",
+	"menu.port":                "Puerto: %d",
+	"menu.port.tip":            "Puerto de escucha",
+	"menu.connected":           "Dashboard conectado",
+	"menu.disconnected":        "Ningún dashboard conectado",
+	"menu.network.waiting":     "Esperando la red...",
+	"menu.connection.tip":      "Estado de la conexión con el dashboard",
+	"menu.version":             "Agente v%s",
+	"menu.version.tip":         "Versión del agente",
+	"menu.update":              "Buscar actualizaciones",
+	"menu.update.tip":          "Buscar nuevas versiones en GitHub",
+	"menu.notes":               "Novedades de v%s",
+	"menu.notes.tip":           "Mostrar las notas de esta versión",
+	"menu.skip":                "Omitir esta actualización",
+	"menu.skip.tip":            "No instalar esta versión; esperar la siguiente",
+	"menu.skip.version":        "Omitir actualización v%s",
+	"menu.beta":                "Recibir actualizaciones beta",
+	"menu.beta.tip":            "Instalar versiones preliminares del agente en este equipo",
+	"menu.alerts.none":         "Sin alertas",
+	"menu.alerts.ack":          "Confirmar %d alerta(s)",
+	"menu.alerts.tip":          "Confirmar todas las alertas",
+	"menu.pair":                "Vincular dashboard...",
+	"menu.pair.code":           "Código de vinculación: %s (%d min)",
+	"menu.pair.tip":            "Mostrar un código para vincular un dashboard con este agente",
+	"menu.qr":                  "Mostrar código QR",
+	"menu.qr.tip":              "Mostrar la dirección de este equipo y un código de vinculación como código QR",
+	"menu.maintenance":         "Modo de mantenimiento",
+	"menu.maintenance.until":   "En mantenimiento hasta %s",
+	"menu.maintenance.tip":     "Pausar las alertas mientras se trabaja en este equipo",
+	"menu.maintenance.1h":      "Durante 1 hora",
+	"menu.maintenance.4h":      "Durante 4 horas",
+	"menu.maintenance.24h":     "Durante 24 horas",
+	"menu.maintenance.for.tip": "Pausar las alertas durante %d horas",
+	"menu.maintenance.end":     "Terminar mantenimiento",
+	"menu.maintenance.end.tip": "Reanudar las alertas ahora",
+	"menu.diagnostics":         "Copiar diagnóstico",
+	"menu.diagnostics.tip":     "Guardar un paquete de soporte en el escritorio",
+	"menu.quit":                "Salir",
+	"menu.quit.tip":            "Cerrar el agente",
+
+	"toast.updating":     "Actualizando de v%s a v%s. El agente se reiniciará.",
+	"toast.updated":      "Actualizado a v%s.",
+	"toast.disconnected": "El dashboard dejó de consultar este equipo.",
+	"toast.alert.title":  "%s en %s",
+
+	"qr.code":     "Código de vinculación %s",
+	"notes.title": "Novedades de v%s",
+	"notes.none":  "Esta versión no tiene notas.",
+	"notes.link":  "Ver en GitHub",
+
+	"intercom.title":           "Mensaje de %s",
+	"intercom.title.dashboard": "Mensaje del dashboard",
+
+	"severity.info":     "Información",
+	"severity.warning":  "Advertencia",
+	"severity.critical": "Crítico",
+
+	"format.time": "02/01 15:04",
+}
@@ -0,0 +1,107 @@
+// Package i18n translates the strings shown to the person at the machine:
+// tray menu items and desktop notifications. Logs, events, and API
+// responses stay in English so they read the same across the fleet.
+package i18n
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+)
+
+// bundles maps a language to its messages. English is complete; other
+// bundles fall back to English for missing keys.
+var bundles = map[string]map[string]string{
+	"en": english,
+	"es": spanish,
+}
+
+var current atomic.Value // string
+
+// overrides holds messages from an asset pack, by language, ahead of the
+// built-in bundles. It can add a language.
+var overrides atomic.Value // map[string]map[string]string
+
+// LoadOverrides reads <lang>.json files from dir, each an object of keys
+// to messages, to take precedence over the built-in bundles. A missing
+// dir clears them. Call Setup afterwards to pick up a new language.
+func LoadOverrides(dir string) error {
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	loaded := map[string]map[string]string{}
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return err
+		}
+		var msgs map[string]string
+		if err := json.Unmarshal(data, &msgs); err != nil {
+			return fmt.Errorf("%s: %w", filepath.Base(f), err)
+		}
+		loaded[strings.ToLower(strings.TrimSuffix(filepath.Base(f), ".json"))] = msgs
+	}
+	overrides.Store(loaded)
+	return nil
+}
+
+// Setup selects the language: configured if set (e.g. "es"), otherwise
+// the user's system language, otherwise English.
+func Setup(configured string) string {
+	lang := match(configured)
+	if lang == "" {
+		for _, tag := range systemLanguages() {
+			if lang = match(tag); lang != "" {
+				break
+			}
+		}
+	}
+	if lang == "" {
+		lang = "en"
+	}
+	current.Store(lang)
+	return lang
+}
+
+// match returns the bundle for a tag such as "es-MX" or "es_ES.UTF-8".
+func match(tag string) string {
+	base, _, _ := strings.Cut(strings.ToLower(tag), "-")
+	base, _, _ = strings.Cut(base, "_")
+	if _, ok := bundles[base]; ok {
+		return base
+	}
+	if _, ok := loadedOverrides()[base]; ok {
+		return base
+	}
+	return ""
+}
+
+// T returns the message for key in the current language, formatted with
+// args when given.
+func T(key string, args ...any) string {
+	lang, _ := current.Load().(string)
+	extra := loadedOverrides()
+	msg, ok := extra[lang][key]
+	if !ok {
+		msg, ok = bundles[lang][key]
+	}
+	if !ok {
+		msg, ok = extra["en"][key]
+	}
+	if !ok {
+		msg, ok = english[key]
+	}
+	if !ok {
+		msg = key
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
+
+func loadedOverrides() map[string]map[string]string {
+	m, _ := overrides.Load().(map[string]map[string]string)
+	return m
+}
@@ -0,0 +1,17 @@
+//go:build linux
+
+package i18n
+
+import "os"
+
+// systemLanguages returns the locale from the usual environment variables,
+// in the order glibc consults them.
+func systemLanguages() []string {
+	var langs []string
+	for _, v := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if s := os.Getenv(v); s != "" {
+			langs = append(langs, s)
+		}
+	}
+	return langs
+}
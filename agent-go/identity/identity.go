@@ -0,0 +1,167 @@
+// Package identity notices when the machine's hostname or IPv4 addresses
+// change. A DHCP lease that moves a machine to a new address, or a rename,
+// otherwise looks on the dashboard like one machine vanishing and a
+// stranger appearing; the change list explains the move. The last known
+// values are kept in the state store, so changes across a reboot count.
+package identity
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/events"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/netready"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/state"
+)
+
+const (
+	checkInterval = 15 * time.Second
+	keepChanges   = 20 // most recent changes reported and stored
+	stateKey      = "identity"
+)
+
+// Change is one hostname or address change, reported in /status as
+// lastChanged.
+type Change struct {
+	Time time.Time `json:"time"`
+	Kind string    `json:"kind"` // "hostname" or "address"
+	// Interface is set for address changes.
+	Interface string `json:"interface,omitempty"`
+	// From and To are the old and new hostname, or the interface's
+	// comma-separated IPv4 addresses; empty when it had none.
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+func (c Change) String() string {
+	if c.Kind == "hostname" {
+		return fmt.Sprintf("hostname changed from %q to %q", c.From, c.To)
+	}
+	return fmt.Sprintf("%s address changed from %q to %q", c.Interface, c.From, c.To)
+}
+
+// known is what the monitor last saw, as stored.
+type known struct {
+	Hostname  string              `json:"hostname"`
+	Addresses map[string][]string `json:"addresses"`
+	Changes   []Change            `json:"changes,omitempty"`
+}
+
+// Monitor compares the hostname and addresses every 15 seconds. Safe for
+// concurrent use.
+type Monitor struct {
+	store *state.Store
+
+	mu       sync.Mutex
+	last     known
+	onChange func(hostname string)
+}
+
+// New creates a monitor starting from what store last recorded.
+func New(store *state.Store) *Monitor {
+	m := &Monitor{store: store}
+	store.Get(stateKey, &m.last)
+	return m
+}
+
+// OnChange registers fn to run after the hostname or an address changes,
+// with the current hostname.
+func (m *Monitor) OnChange(fn func(hostname string)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onChange = fn
+}
+
+// Changes returns the most recent changes, oldest first, or nil when
+// there are none or the monitor is nil.
+func (m *Monitor) Changes() []Change {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.last.Changes)
+}
+
+// Run checks for changes until ctx is cancelled. Start it once the network
+// is up, or the wait for DHCP is recorded as the addresses going away.
+func (m *Monitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+	for {
+		m.check()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *Monitor) check() {
+	hostname, _ := os.Hostname()
+	addresses := netready.Addresses()
+	for _, list := range addresses {
+		slices.Sort(list)
+	}
+	now := time.Now()
+
+	m.mu.Lock()
+	// First run on this machine: nothing to compare with yet.
+	first := m.last.Hostname == "" && m.last.Addresses == nil
+	var changes []Change
+	if !first {
+		if hostname != m.last.Hostname {
+			changes = append(changes, Change{Time: now, Kind: "hostname", From: m.last.Hostname, To: hostname})
+		}
+		changes = append(changes, addressChanges(m.last.Addresses, addresses, now)...)
+	}
+	if !first && len(changes) == 0 {
+		m.mu.Unlock()
+		return
+	}
+	m.last.Hostname, m.last.Addresses = hostname, addresses
+	m.last.Changes = append(m.last.Changes, changes...)
+	if extra := len(m.last.Changes) - keepChanges; extra > 0 {
+		m.last.Changes = slices.Clone(m.last.Changes[extra:])
+	}
+	saved := m.last
+	fn := m.onChange
+	m.mu.Unlock()
+
+	m.store.Set(stateKey, saved)
+	for _, c := range changes {
+		events.Record(events.Lifecycle, events.Info, "Identity: %s", c)
+	}
+	if len(changes) > 0 && fn != nil {
+		fn(hostname)
+	}
+}
+
+// addressChanges lists the interfaces whose addresses differ, by name.
+func addressChanges(before, after map[string][]string, now time.Time) []Change {
+	var names []string
+	for name := range before {
+		names = append(names, name)
+	}
+	for name := range after {
+		if _, ok := before[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	var changes []Change
+	for _, name := range names {
+		from, to := strings.Join(before[name], ", "), strings.Join(after[name], ", ")
+		if from != to {
+			changes = append(changes, Change{Time: now, Kind: "address", Interface: name, From: from, To: to})
+		}
+	}
+	return changes
+}
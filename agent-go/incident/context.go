@@ -0,0 +1,181 @@
+package incident
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/process"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/events"
+)
+
+// Context sources.
+const (
+	SourceAgent   = "agent"   // the agent's own events: alerts, remote actions
+	SourceSystem  = "system"  // Windows Event Log or journal errors
+	SourceProcess = "process" // long-running processes exiting and restarting
+	SourceNetwork = "network" // interface link changes
+)
+
+const (
+	maxContext      = 200 // entries per incident
+	maxSystemErrors = 50
+	// minProcessAge filters out short-lived helpers, which come and go all
+	// the time.
+	minProcessAge = time.Minute
+)
+
+// Entry is one item of correlated context in an incident's timeline.
+type Entry struct {
+	Time    time.Time `json:"time"`
+	Source  string    `json:"source"`
+	Message string    `json:"message"`
+}
+
+// watcher notices process and interface changes as they happen, since
+// neither leaves a trace to look up afterwards. Only the Run goroutine
+// calls poll; entries is guarded by the Recorder's mutex.
+type watcher struct {
+	links   map[string]bool      // interface name -> running
+	procs   map[int32]procInfo   // live processes
+	exited  map[string]time.Time // name -> when a long-running instance exited
+	entries []Entry
+}
+
+type procInfo struct {
+	name    string
+	started time.Time
+}
+
+func newWatcher() *watcher {
+	return &watcher{exited: map[string]time.Time{}}
+}
+
+// pollLinks records interfaces going up, going down, appearing, and
+// disappearing.
+func (w *watcher) pollLinks(now time.Time) []Entry {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+	links := make(map[string]bool, len(ifaces))
+	var out []Entry
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		running := iface.Flags&net.FlagRunning != 0
+		links[iface.Name] = running
+		was, known := w.links[iface.Name]
+		switch {
+		case w.links == nil:
+			// first poll: baseline only
+		case !known:
+			out = append(out, Entry{now, SourceNetwork, iface.Name + " appeared"})
+		case was && !running:
+			out = append(out, Entry{now, SourceNetwork, iface.Name + " link down"})
+		case !was && running:
+			out = append(out, Entry{now, SourceNetwork, iface.Name + " link up"})
+		}
+	}
+	for name := range w.links {
+		if _, ok := links[name]; !ok {
+			out = append(out, Entry{now, SourceNetwork, name + " disappeared"})
+		}
+	}
+	w.links = links
+	return out
+}
+
+// pollProcesses records long-running processes exiting, and the same
+// program starting again afterwards.
+func (w *watcher) pollProcesses(now time.Time) []Entry {
+	procs, err := process.Processes()
+	if err != nil {
+		return nil
+	}
+	live := make(map[int32]procInfo, len(procs))
+	var out []Entry
+	for _, p := range procs {
+		if info, ok := w.procs[p.Pid]; ok {
+			live[p.Pid] = info
+			continue
+		}
+		name, err := p.Name()
+		if err != nil {
+			continue
+		}
+		name = strings.TrimSuffix(name, ".exe")
+		info := procInfo{name: name, started: now}
+		if ms, err := p.CreateTime(); err == nil {
+			info.started = time.UnixMilli(ms)
+		}
+		live[p.Pid] = info
+		if exited, ok := w.exited[name]; ok {
+			out = append(out, Entry{now, SourceProcess, fmt.Sprintf("%s restarted (PID %d, %s after exiting)", name, p.Pid, now.Sub(exited).Round(time.Second))})
+			delete(w.exited, name)
+		}
+	}
+	for pid, info := range w.procs {
+		if _, ok := live[pid]; ok {
+			continue
+		}
+		if ran := now.Sub(info.started); ran >= minProcessAge {
+			out = append(out, Entry{now, SourceProcess, fmt.Sprintf("%s exited (PID %d, ran %s)", info.name, pid, ran.Round(time.Second))})
+			w.exited[info.name] = now
+		}
+	}
+	for name, t := range w.exited {
+		if now.Sub(t) > 2*maxSeconds*time.Second {
+			delete(w.exited, name)
+		}
+	}
+	w.procs = live
+	return out
+}
+
+// keep appends entries and drops those older than the longest window
+// that can still ask for them. Caller holds the Recorder's mutex.
+func (w *watcher) keep(entries []Entry, now time.Time) {
+	w.entries = append(w.entries, entries...)
+	cutoff := now.Add(-2 * maxSeconds * time.Second)
+	i := sort.Search(len(w.entries), func(i int) bool { return !w.entries[i].Time.Before(cutoff) })
+	w.entries = w.entries[i:]
+}
+
+// between returns the watched entries in [from, to]. Caller holds the
+// Recorder's mutex.
+func (w *watcher) between(from, to time.Time) []Entry {
+	var out []Entry
+	for _, e := range w.entries {
+		if !e.Time.Before(from) && !e.Time.After(to) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// agentEvents returns the agent's own events in [from, to].
+func agentEvents(from, to time.Time) []Entry {
+	var out []Entry
+	for _, e := range events.Recent(from, to) {
+		out = append(out, Entry{e.Time.UTC(), SourceAgent, e.Message})
+	}
+	return out
+}
+
+// timeline merges the sources by time and caps the result.
+func timeline(sources ...[]Entry) []Entry {
+	var out []Entry
+	for _, s := range sources {
+		out = append(out, s...)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Time.Before(out[j].Time) })
+	if len(out) > maxContext {
+		out = out[len(out)-maxContext:]
+	}
+	return out
+}
@@ -0,0 +1,297 @@
+// Package incident is the agent's flight recorder. It samples the headline
+// metrics every second into a short ring buffer, and when an alert is
+// raised it freezes the window around it (the seconds before, plus the
+// seconds after) as an incident served at /incidents. The 5-second status
+// averages smear out the spikes a post-mortem needs to see.
+//
+// Each incident also carries a timeline of what else happened in the
+// window: the agent's own events, system log errors, processes exiting
+// and restarting, and network links going down.
+package incident
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/alerts"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+)
+
+const (
+	sampleInterval = time.Second
+	processEvery   = 5 // samples between process polls
+	defaultBefore  = 60
+	defaultAfter   = 30
+	maxSeconds     = 600
+	maxIncidents   = 50
+	idFormat       = "20060102T150405Z"
+)
+
+// ErrNotFound is returned by Get for an unknown incident ID.
+var ErrNotFound = errors.New("no such incident")
+
+// Incident is one raised alert with the samples around it.
+type Incident struct {
+	ID       string    `json:"id"`
+	Key      string    `json:"key"`
+	Severity string    `json:"severity"`
+	Message  string    `json:"message"`
+	RaisedAt time.Time `json:"raisedAt"`
+	// Complete is false while the samples after the alert are still being
+	// recorded.
+	Complete bool     `json:"complete"`
+	Samples  []Sample `json:"samples,omitempty"`
+	// Context is filled in once the window closes.
+	Context []Entry `json:"context,omitempty"`
+
+	closing bool
+}
+
+// Recorder keeps the ring buffer and the captured incidents. Safe for
+// concurrent use.
+type Recorder struct {
+	dir           string
+	before, after time.Duration
+
+	mu      sync.Mutex
+	ring    []Sample
+	pending []*Incident
+	watch   *watcher
+}
+
+// New creates a recorder storing incidents under dir. It returns nil when
+// the flight recorder is disabled.
+func New(cfg config.FlightRecorderConfig, dir string) *Recorder {
+	if cfg.Disabled {
+		return nil
+	}
+	seconds := func(n, def int) time.Duration {
+		if n <= 0 {
+			n = def
+		}
+		return time.Duration(min(n, maxSeconds)) * time.Second
+	}
+	return &Recorder{
+		dir:    dir,
+		before: seconds(cfg.BeforeSeconds, defaultBefore),
+		after:  seconds(cfg.AfterSeconds, defaultAfter),
+		watch:  newWatcher(),
+	}
+}
+
+// Attach captures an incident each time engine raises an alert.
+func (r *Recorder) Attach(engine *alerts.Engine) {
+	engine.OnRaise(r.capture)
+}
+
+// Run samples every second. Blocks forever.
+func (r *Recorder) Run() {
+	if err := os.MkdirAll(r.dir, 0755); err != nil {
+		log.Printf("Incidents: %v", err)
+		return
+	}
+	s := newSampler()
+	ticker := time.NewTicker(sampleInterval)
+	defer ticker.Stop()
+	for n := 0; ; n++ {
+		now := (<-ticker.C).UTC()
+		changes := r.watch.pollLinks(now)
+		if n%processEvery == 0 {
+			changes = append(changes, r.watch.pollProcesses(now)...)
+		}
+		r.add(s.sample(now), changes)
+	}
+}
+
+// add appends a sample to the ring and to every incident still recording,
+// and closes those whose window has ended.
+func (r *Recorder) add(sample Sample, changes []Entry) {
+	r.mu.Lock()
+	r.watch.keep(changes, sample.Time)
+	r.ring = append(r.ring, sample)
+	if cutoff := sample.Time.Add(-r.before); r.ring[0].Time.Before(cutoff) {
+		i := sort.Search(len(r.ring), func(i int) bool { return !r.ring[i].Time.Before(cutoff) })
+		r.ring = append(r.ring[:0:0], r.ring[i:]...)
+	}
+
+	for _, inc := range r.pending {
+		if inc.closing {
+			continue
+		}
+		inc.Samples = append(inc.Samples, sample)
+		if sample.Time.Sub(inc.RaisedAt) >= r.after {
+			inc.closing = true
+			go r.close(inc)
+		}
+	}
+	r.mu.Unlock()
+}
+
+// close gathers the incident's context and saves it. Querying the system
+// log can take a while, so it runs apart from sampling; the incident stays
+// listed as incomplete until it is saved.
+func (r *Recorder) close(inc *Incident) {
+	from, to := inc.RaisedAt.Add(-r.before), inc.RaisedAt.Add(r.after)
+	system := systemErrors(from, to)
+
+	r.mu.Lock()
+	done := *inc
+	done.Samples = inc.Samples
+	done.Context = timeline(agentEvents(from, to), system, r.watch.between(from, to))
+	done.Complete = true
+	r.mu.Unlock()
+
+	if err := r.save(&done); err != nil {
+		log.Printf("Incidents: save %s failed: %v", done.ID, err)
+	}
+
+	r.mu.Lock()
+	for i, p := range r.pending {
+		if p == inc {
+			r.pending = append(r.pending[:i], r.pending[i+1:]...)
+			break
+		}
+	}
+	r.mu.Unlock()
+	r.prune()
+}
+
+// capture is the engine's OnRaise callback. It runs under the engine lock,
+// so it only copies the ring.
+func (r *Recorder) capture(a alerts.Alert) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, inc := range r.pending {
+		if inc.Key == a.Key && !inc.closing {
+			return // flapping: the open window already covers it
+		}
+	}
+	now := time.Now().UTC()
+	inc := &Incident{
+		ID:       r.newID(now),
+		Key:      a.Key,
+		Severity: a.BaseSeverity,
+		Message:  a.Message,
+		RaisedAt: now,
+		Samples:  append([]Sample(nil), r.ring...),
+	}
+	r.pending = append(r.pending, inc)
+}
+
+// newID names an incident by its time, with a suffix if several alerts
+// are raised in the same second. Caller holds r.mu.
+func (r *Recorder) newID(t time.Time) string {
+	base := t.Format(idFormat)
+	id := base
+	for n := 2; r.exists(id); n++ {
+		id = fmt.Sprintf("%s-%d", base, n)
+	}
+	return id
+}
+
+func (r *Recorder) exists(id string) bool {
+	for _, inc := range r.pending {
+		if inc.ID == id {
+			return true
+		}
+	}
+	_, err := os.Stat(r.path(id))
+	return err == nil
+}
+
+// List returns every incident without samples or context, newest first.
+func (r *Recorder) List() []Incident {
+	r.mu.Lock()
+	var out []Incident
+	open := map[string]bool{}
+	for _, inc := range r.pending {
+		summary := *inc
+		summary.Samples, summary.Context = nil, nil
+		out = append(out, summary)
+		open[inc.ID] = true
+	}
+	r.mu.Unlock()
+
+	files, _ := filepath.Glob(filepath.Join(r.dir, "*.json"))
+	for _, f := range files {
+		inc, err := load(f)
+		if err != nil || open[inc.ID] {
+			continue
+		}
+		inc.Samples, inc.Context = nil, nil
+		out = append(out, *inc)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].RaisedAt.After(out[j].RaisedAt) })
+	return out
+}
+
+// Get returns one incident with its samples.
+func (r *Recorder) Get(id string) (*Incident, error) {
+	r.mu.Lock()
+	for _, inc := range r.pending {
+		if inc.ID == id {
+			copied := *inc
+			copied.Samples = append([]Sample(nil), inc.Samples...)
+			r.mu.Unlock()
+			return &copied, nil
+		}
+	}
+	r.mu.Unlock()
+
+	if strings.ContainsAny(id, `/\.`) || id == "" {
+		return nil, ErrNotFound
+	}
+	inc, err := load(r.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return inc, err
+}
+
+func (r *Recorder) path(id string) string {
+	return filepath.Join(r.dir, id+".json")
+}
+
+func (r *Recorder) save(inc *Incident) error {
+	data, err := json.Marshal(inc)
+	if err != nil {
+		return err
+	}
+	tmp := r.path(inc.ID) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, r.path(inc.ID))
+}
+
+func load(path string) (*Incident, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var inc Incident
+	if err := json.Unmarshal(data, &inc); err != nil {
+		return nil, err
+	}
+	return &inc, nil
+}
+
+// prune keeps the newest incidents. IDs sort by time.
+func (r *Recorder) prune() {
+	files, _ := filepath.Glob(filepath.Join(r.dir, "*.json"))
+	if len(files) <= maxIncidents {
+		return
+	}
+	sort.Strings(files)
+	for _, f := range files[:len(files)-maxIncidents] {
+		os.Remove(f)
+	}
+}
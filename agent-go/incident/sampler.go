@@ -0,0 +1,54 @@
+package incident
+
+import (
+	"time"
+
+	"github.com/shirou/gopsutil/v4/cpu"
+	"github.com/shirou/gopsutil/v4/mem"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/metrics"
+)
+
+// Sample is one second of the headline metrics. Names match the /status
+// JSON keys.
+type Sample struct {
+	Time            time.Time `json:"time"`
+	CPUUsagePercent float64   `json:"cpuUsagePercent"`
+	RAMUsagePercent float64   `json:"ramUsagePercent"`
+	NetworkBytesPS  float64   `json:"networkBytesPerSec"`
+	DiskBytesPS     float64   `json:"diskBytesPerSec"`
+}
+
+// sampler reads the metrics that are cheap enough to take every second.
+// It keeps its own counters, so it doesn't disturb the collector's
+// 5-second averages (cpu.Percent(0) shares one baseline per process).
+type sampler struct {
+	net      *metrics.NetworkTracker
+	disk     *metrics.DiskTracker
+	prevBusy float64
+	prevAll  float64
+}
+
+func newSampler() *sampler {
+	s := &sampler{net: metrics.NewNetworkTracker(), disk: metrics.NewDiskTracker()}
+	s.sample(time.Now()) // baselines
+	return s
+}
+
+func (s *sampler) sample(now time.Time) Sample {
+	out := Sample{Time: now.UTC().Truncate(time.Millisecond), CPUUsagePercent: -1, RAMUsagePercent: -1}
+	if times, err := cpu.Times(false); err == nil && len(times) > 0 {
+		all := times[0].Total()
+		busy := all - times[0].Idle - times[0].Iowait
+		if d := all - s.prevAll; s.prevAll > 0 && d > 0 {
+			out.CPUUsagePercent = min(100, max(0, (busy-s.prevBusy)/d*100))
+		}
+		s.prevBusy, s.prevAll = busy, all
+	}
+	if v, err := mem.VirtualMemory(); err == nil {
+		out.RAMUsagePercent = v.UsedPercent
+	}
+	out.NetworkBytesPS = s.net.BytesPerSec()
+	out.DiskBytesPS, _ = s.disk.Sample()
+	return out
+}
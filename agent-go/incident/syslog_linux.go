@@ -0,0 +1,47 @@
+//go:build linux
+
+package incident
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+// systemErrors returns journal entries at priority err or worse in
+// [from, to].
+func systemErrors(from, to time.Time) []Entry {
+	out, err := exec.Command("journalctl", "--no-pager", "--output=json", "--priority=err",
+		"--lines="+strconv.Itoa(maxSystemErrors),
+		fmt.Sprintf("--since=@%d", from.Unix()), fmt.Sprintf("--until=@%d", to.Unix()+1)).Output()
+	if err != nil {
+		return nil
+	}
+	var entries []Entry
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var rec struct {
+			Realtime   string `json:"__REALTIME_TIMESTAMP"`
+			Identifier string `json:"SYSLOG_IDENTIFIER"`
+			Message    any    `json:"MESSAGE"` // a byte array when not UTF-8
+		}
+		if json.Unmarshal(scanner.Bytes(), &rec) != nil {
+			continue
+		}
+		msg, ok := rec.Message.(string)
+		if !ok {
+			continue
+		}
+		usec, _ := strconv.ParseInt(rec.Realtime, 10, 64)
+		if rec.Identifier != "" {
+			msg = rec.Identifier + ": " + msg
+		}
+		entries = append(entries, Entry{time.UnixMicro(usec).UTC(), SourceSystem, msg})
+	}
+	return entries
+}
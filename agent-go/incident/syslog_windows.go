@@ -0,0 +1,156 @@
+//go:build windows
+
+package incident
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	wevtapi                      = windows.NewLazySystemDLL("wevtapi.dll")
+	procEvtQuery                 = wevtapi.NewProc("EvtQuery")
+	procEvtNext                  = wevtapi.NewProc("EvtNext")
+	procEvtRender                = wevtapi.NewProc("EvtRender")
+	procEvtOpenPublisherMetadata = wevtapi.NewProc("EvtOpenPublisherMetadata")
+	procEvtFormatMessage         = wevtapi.NewProc("EvtFormatMessage")
+	procEvtClose                 = wevtapi.NewProc("EvtClose")
+)
+
+const (
+	evtQueryChannelPath      = 0x1
+	evtQueryReverseDirection = 0x200
+	evtRenderEventXML        = 1
+	evtFormatMessageEvent    = 1
+	evtBatch                 = 16
+)
+
+// systemErrors returns critical and error events from the System and
+// Application logs in [from, to], newest first per log.
+func systemErrors(from, to time.Time) []Entry {
+	query := fmt.Sprintf("*[System[(Level=1 or Level=2) and TimeCreated[@SystemTime>='%s' and @SystemTime<='%s']]]",
+		from.UTC().Format("2006-01-02T15:04:05.000Z"), to.UTC().Format("2006-01-02T15:04:05.000Z"))
+	var out []Entry
+	for _, channel := range []string{"System", "Application"} {
+		entries, err := queryChannel(channel, query, maxSystemErrors-len(out))
+		if err != nil {
+			continue
+		}
+		out = append(out, entries...)
+	}
+	return out
+}
+
+func queryChannel(channel, query string, limit int) ([]Entry, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+	if err := procEvtQuery.Find(); err != nil {
+		return nil, err
+	}
+	path, _ := windows.UTF16PtrFromString(channel)
+	q, _ := windows.UTF16PtrFromString(query)
+	results, _, err := procEvtQuery.Call(0, uintptr(unsafe.Pointer(path)), uintptr(unsafe.Pointer(q)), evtQueryChannelPath|evtQueryReverseDirection)
+	if results == 0 {
+		return nil, err
+	}
+	defer procEvtClose.Call(results)
+
+	var out []Entry
+	handles := make([]uintptr, evtBatch)
+	for len(out) < limit {
+		var returned uint32
+		r, _, _ := procEvtNext.Call(results, evtBatch, uintptr(unsafe.Pointer(&handles[0])), windows.INFINITE, 0, uintptr(unsafe.Pointer(&returned)))
+		if r == 0 {
+			break // ERROR_NO_MORE_ITEMS
+		}
+		for _, h := range handles[:returned] {
+			if len(out) < limit {
+				if e, ok := renderEvent(h); ok {
+					out = append(out, e)
+				}
+			}
+			procEvtClose.Call(h)
+		}
+	}
+	return out, nil
+}
+
+// eventXML is the part of an event's XML rendering that is used.
+type eventXML struct {
+	System struct {
+		Provider struct {
+			Name string `xml:"Name,attr"`
+		} `xml:"Provider"`
+		EventID     uint32 `xml:"EventID"`
+		TimeCreated struct {
+			SystemTime string `xml:"SystemTime,attr"`
+		} `xml:"TimeCreated"`
+	} `xml:"System"`
+}
+
+func renderEvent(h uintptr) (Entry, bool) {
+	buf, err := evtCall(func(size uint32, buf *uint16, used *uint32) (uintptr, error) {
+		var props uint32
+		r, _, err := procEvtRender.Call(0, h, evtRenderEventXML, uintptr(size*2), uintptr(unsafe.Pointer(buf)), uintptr(unsafe.Pointer(used)), uintptr(unsafe.Pointer(&props)))
+		*used /= 2 // EvtRender counts bytes
+		return r, err
+	})
+	if err != nil {
+		return Entry{}, false
+	}
+	var ev eventXML
+	if xml.Unmarshal([]byte(windows.UTF16ToString(buf)), &ev) != nil {
+		return Entry{}, false
+	}
+	t, _ := time.Parse(time.RFC3339Nano, ev.System.TimeCreated.SystemTime)
+	provider := ev.System.Provider.Name
+
+	msg := fmt.Sprintf("%s event %d", provider, ev.System.EventID)
+	if text := formatMessage(provider, h); text != "" {
+		msg = fmt.Sprintf("%s %d: %s", provider, ev.System.EventID, text)
+	}
+	return Entry{t.UTC(), SourceSystem, msg}, true
+}
+
+// formatMessage returns the event's localized message, or "" if the
+// publisher's resources can't be loaded.
+func formatMessage(provider string, h uintptr) string {
+	name, _ := windows.UTF16PtrFromString(provider)
+	meta, _, _ := procEvtOpenPublisherMetadata.Call(0, uintptr(unsafe.Pointer(name)), 0, 0, 0)
+	if meta == 0 {
+		return ""
+	}
+	defer procEvtClose.Call(meta)
+	buf, err := evtCall(func(size uint32, buf *uint16, used *uint32) (uintptr, error) {
+		r, _, err := procEvtFormatMessage.Call(meta, h, 0, 0, 0, evtFormatMessageEvent, uintptr(size), uintptr(unsafe.Pointer(buf)), uintptr(unsafe.Pointer(used)))
+		return r, err
+	})
+	if err != nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(windows.UTF16ToString(buf)), " ")
+}
+
+// evtCall calls a wevtapi function that fills a UTF-16 buffer, growing the
+// buffer once if it reports ERROR_INSUFFICIENT_BUFFER. call reports the
+// size used in UTF-16 units.
+func evtCall(call func(size uint32, buf *uint16, used *uint32) (uintptr, error)) ([]uint16, error) {
+	buf := make([]uint16, 1024)
+	var used uint32
+	r, err := call(uint32(len(buf)), &buf[0], &used)
+	if r == 0 && errors.Is(err, windows.ERROR_INSUFFICIENT_BUFFER) {
+		buf = make([]uint16, used+1)
+		r, err = call(uint32(len(buf)), &buf[0], &used)
+	}
+	if r == 0 {
+		return nil, err
+	}
+	return buf, nil
+}
@@ -0,0 +1,127 @@
+// Package instances lets one agent process answer for other machines: a
+// VM host reporting for show-critical guests whose own agents sit on an
+// internal switch the dashboard can't reach. Each instance has its own
+// port and mDNS record, so the dashboard lists it as a machine of its own.
+package instances
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+)
+
+const (
+	fetchTimeout = 3 * time.Second
+	// maxStatusSize bounds a proxied /status body.
+	maxStatusSize = 4 << 20
+)
+
+// Source produces an instance's /status body.
+type Source interface {
+	Status(ctx context.Context) ([]byte, error)
+}
+
+// Instance is one logical agent. Safe for concurrent use.
+type Instance struct {
+	Name   string
+	Port   uint16
+	source Source
+
+	mu   sync.Mutex
+	uuid string // advertised hardware UUID
+}
+
+// New creates the configured instances. hostUUID seeds the UUID of an
+// instance that has none configured and whose source hasn't answered.
+func New(cfgs []config.Instance, hostUUID string) []*Instance {
+	var list []*Instance
+	for _, c := range cfgs {
+		uuid := c.UUID
+		if uuid == "" {
+			uuid = derivedUUID(hostUUID, c.Name)
+		}
+		list = append(list, &Instance{
+			Name:   c.Name,
+			Port:   uint16(c.Port),
+			uuid:   uuid,
+			source: &proxy{url: c.StatusURL, token: c.Token, client: &http.Client{Timeout: fetchTimeout}},
+		})
+	}
+	return list
+}
+
+// Status returns the instance's /status body. The hardware UUID it
+// carries becomes the one advertised.
+func (i *Instance) Status(ctx context.Context) ([]byte, error) {
+	body, err := i.source.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var probe struct {
+		HardwareUUID string `json:"hardwareUUID"`
+	}
+	if json.Unmarshal(body, &probe) == nil && probe.HardwareUUID != "" {
+		i.mu.Lock()
+		i.uuid = probe.HardwareUUID
+		i.mu.Unlock()
+	}
+	return body, nil
+}
+
+// UUID returns the hardware UUID to advertise. Call Status first so a
+// reachable source's own UUID is used: a dashboard that already knows the
+// guest by it then matches the two.
+func (i *Instance) UUID() string {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.uuid
+}
+
+// proxy fetches another agent's /status.
+type proxy struct {
+	url, token string
+	client     *http.Client
+}
+
+func (p *proxy) Status(ctx context.Context) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", p.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if p.token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.token)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("%s answered %s", p.url, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxStatusSize))
+	if err != nil {
+		return nil, err
+	}
+	if !json.Valid(body) {
+		return nil, errors.New(p.url + " did not answer with JSON")
+	}
+	return body, nil
+}
+
+// derivedUUID is a stable name-based (version 5 layout) UUID for an
+// instance, so it keeps its identity across restarts.
+func derivedUUID(hostUUID, name string) string {
+	sum := sha1.Sum([]byte(hostUUID + "/" + name))
+	sum[6] = sum[6]&0x0f | 0x50
+	sum[8] = sum[8]&0x3f | 0x80
+	return fmt.Sprintf("%X-%X-%X-%X-%X", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
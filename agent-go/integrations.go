@@ -0,0 +1,19 @@
+//go:build !lite
+
+package main
+
+import (
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/export"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/snmp"
+)
+
+// startIntegrations starts the optional integrations that lite builds
+// leave out: metrics export and the SNMP agent.
+func (a *agent) startIntegrations(tags map[string]string) {
+	if exporter := export.New(a.cfg.Export, tags); exporter != nil {
+		go exporter.Run(a.collector)
+	}
+	if agent := snmp.New(a.cfg.SNMP, a.cfg.Site.Name, a.collector, a.alerts); agent != nil {
+		go agent.Run()
+	}
+}
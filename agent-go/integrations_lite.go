@@ -0,0 +1,16 @@
+//go:build lite
+
+package main
+
+import "log"
+
+// startIntegrations only warns in lite builds: metrics export and SNMP
+// aren't compiled in.
+func (a *agent) startIntegrations(map[string]string) {
+	if a.cfg.Export.Influx != nil || a.cfg.Export.RemoteWrite != nil {
+		log.Printf("Lite build: export is configured but not included; ignoring it")
+	}
+	if a.cfg.SNMP.Community != "" {
+		log.Printf("Lite build: snmp is configured but not included; ignoring it")
+	}
+}
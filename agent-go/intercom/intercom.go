@@ -0,0 +1,132 @@
+// Package intercom pops messages from the director onto a booth machine's
+// screen ("Switch to backup playlist", "Reboot after service") and tracks
+// whether the operator acknowledged them. Messages arrive over POST
+// /notify.
+package intercom
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/events"
+)
+
+const (
+	maxMessages = 20
+	// MaxText bounds a message; it has to fit in a message box.
+	MaxText = 500
+	// DefaultTimeout is how long a message stays up waiting for the
+	// operator to acknowledge it.
+	DefaultTimeout = time.Hour
+	// deliveryWait is how long Send waits for the message box to fail
+	// before reporting it shown.
+	deliveryWait = time.Second
+)
+
+// Message states.
+const (
+	StateShown        = "shown" // on screen, waiting for the operator
+	StateAcknowledged = "acknowledged"
+	StateExpired      = "expired" // taken down unacknowledged after the timeout
+	StateFailed       = "failed"  // couldn't be shown; see Error
+)
+
+// ErrNotFound is returned by Get for an unknown message ID.
+var ErrNotFound = errors.New("no such message")
+
+// Message is one message and its delivery state.
+type Message struct {
+	ID             string     `json:"id"`
+	Title          string     `json:"title"`
+	Text           string     `json:"text"`
+	From           string     `json:"from"` // the caller that sent it
+	SentAt         time.Time  `json:"sentAt"`
+	State          string     `json:"state"`
+	AcknowledgedAt *time.Time `json:"acknowledgedAt,omitempty"`
+	Error          string     `json:"error,omitempty"`
+}
+
+// Intercom shows messages and keeps the last 20. Safe for concurrent use.
+type Intercom struct {
+	mu       sync.Mutex
+	messages []*Message // oldest first
+}
+
+// New creates an intercom with no messages.
+func New() *Intercom {
+	return &Intercom{}
+}
+
+// Send puts a message on screen until the operator acknowledges it or
+// timeout passes. It returns once the message is shown or has failed; poll
+// Get for the acknowledgment.
+func (i *Intercom) Send(title, text, from string, timeout time.Duration) Message {
+	msg := &Message{ID: newID(), Title: title, Text: text, From: from, SentAt: time.Now(), State: StateShown}
+	i.mu.Lock()
+	i.messages = append(i.messages, msg)
+	if len(i.messages) > maxMessages {
+		i.messages = i.messages[len(i.messages)-maxMessages:]
+	}
+	i.mu.Unlock()
+	events.Record(events.RemoteAction, events.Info, "Intercom: message from %s: %q", from, text)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		acknowledged, err := show(title, text, timeout)
+		i.mu.Lock()
+		defer i.mu.Unlock()
+		switch {
+		case err != nil:
+			msg.State, msg.Error = StateFailed, err.Error()
+		case acknowledged:
+			now := time.Now()
+			msg.State, msg.AcknowledgedAt = StateAcknowledged, &now
+		default:
+			msg.State = StateExpired
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(deliveryWait):
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if msg.State == StateFailed {
+		events.Record(events.RemoteAction, events.Warning, "Intercom: message from %s not shown: %s", from, msg.Error)
+	}
+	return *msg
+}
+
+// List returns the recent messages, newest first.
+func (i *Intercom) List() []Message {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	list := make([]Message, 0, len(i.messages))
+	for j := len(i.messages) - 1; j >= 0; j-- {
+		list = append(list, *i.messages[j])
+	}
+	return list
+}
+
+// Get returns one recent message.
+func (i *Intercom) Get(id string) (Message, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for _, msg := range i.messages {
+		if msg.ID == id {
+			return *msg, nil
+		}
+	}
+	return Message{}, ErrNotFound
+}
+
+func newID() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
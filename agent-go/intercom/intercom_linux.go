@@ -0,0 +1,14 @@
+//go:build linux
+
+package intercom
+
+import (
+	"errors"
+	"time"
+)
+
+// show fails on Linux: the agent runs as a headless service with no screen
+// to show a message on.
+func show(title, text string, timeout time.Duration) (bool, error) {
+	return false, errors.New("no desktop to show messages on")
+}
@@ -0,0 +1,62 @@
+//go:build windows
+
+package intercom
+
+import (
+	"errors"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var procWTSSendMessage = windows.NewLazySystemDLL("wtsapi32.dll").NewProc("WTSSendMessageW")
+
+const (
+	mbOK            = 0x00000000
+	mbIconWarning   = 0x00000030
+	mbSystemModal   = 0x00001000
+	mbSetForeground = 0x00010000
+	mbTopmost       = 0x00040000
+	idOK            = 1
+	noSession       = 0xFFFFFFFF
+)
+
+// show puts up a message box with WTSSendMessage, which works from the
+// service in session 0 as well as the tray agent: the box appears on the
+// agent's own session, or the console's when that is session 0. It blocks
+// until the operator clicks OK or timeout passes.
+func show(title, text string, timeout time.Duration) (bool, error) {
+	var session uint32
+	windows.ProcessIdToSessionId(windows.GetCurrentProcessId(), &session)
+	if session == 0 {
+		session = windows.WTSGetActiveConsoleSessionId()
+	}
+	if session == noSession {
+		return false, errors.New("no one is signed in at the console")
+	}
+
+	t, err := windows.UTF16FromString(title)
+	if err != nil {
+		return false, err
+	}
+	m, err := windows.UTF16FromString(text)
+	if err != nil {
+		return false, err
+	}
+	var response uint32
+	r, _, err := procWTSSendMessage.Call(
+		0, // WTS_CURRENT_SERVER_HANDLE
+		uintptr(session),
+		uintptr(unsafe.Pointer(&t[0])), uintptr((len(t)-1)*2), // lengths in bytes, without the NUL
+		uintptr(unsafe.Pointer(&m[0])), uintptr((len(m)-1)*2),
+		mbOK|mbIconWarning|mbSystemModal|mbSetForeground|mbTopmost,
+		uintptr(timeout.Seconds()),
+		uintptr(unsafe.Pointer(&response)),
+		1, // wait for the response
+	)
+	if r == 0 {
+		return false, err
+	}
+	return response == idOK, nil
+}
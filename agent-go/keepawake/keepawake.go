@@ -0,0 +1,116 @@
+// Package keepawake stops the machine sleeping while designated software
+// (OBS, ProPresenter, a playback app) is running. A laptop that sleeps
+// mid-stream takes the stream down with it.
+package keepawake
+
+import (
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/process"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/events"
+)
+
+const checkInterval = 15 * time.Second
+
+// Status is reported in /status while keep-awake is configured.
+type Status struct {
+	Active    bool       `json:"active"`
+	Processes []string   `json:"processes,omitempty"` // watched processes now running
+	Since     *time.Time `json:"since,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// Monitor holds the sleep block while any watched process runs. Safe for
+// concurrent use.
+type Monitor struct {
+	cfg config.KeepAwakeConfig
+
+	mu     sync.Mutex
+	status Status
+}
+
+// New creates a monitor. It returns nil when no processes are configured.
+func New(cfg config.KeepAwakeConfig) *Monitor {
+	if len(cfg.Processes) == 0 {
+		return nil
+	}
+	return &Monitor{cfg: cfg}
+}
+
+// Status returns the current state, or nil when keep-awake isn't
+// configured.
+func (m *Monitor) Status() *Status {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	status := m.status
+	return &status
+}
+
+// Run checks for the watched processes every 15 seconds. Blocks forever.
+// The goroutine stays on one OS thread because Windows ties the sleep
+// block to the thread that requested it.
+func (m *Monitor) Run() {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	for {
+		m.check()
+		time.Sleep(checkInterval)
+	}
+}
+
+func (m *Monitor) check() {
+	running := runningOf(m.cfg.Processes)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.status.Processes = running
+	switch {
+	case len(running) > 0 && !m.status.Active:
+		if err := hold(m.cfg.KeepDisplayOn); err != nil {
+			m.status.Error = err.Error()
+			return
+		}
+		now := time.Now()
+		m.status.Active, m.status.Since, m.status.Error = true, &now, ""
+		events.Record(events.Lifecycle, events.Info, "Keep-awake: blocking sleep while %s runs", strings.Join(running, ", "))
+	case len(running) == 0 && m.status.Active:
+		release()
+		m.status.Active, m.status.Since = false, nil
+		events.Record(events.Lifecycle, events.Info, "Keep-awake: released")
+	}
+}
+
+// runningOf returns which of names have a running process, matched
+// case-insensitively with ".exe" optional.
+func runningOf(names []string) []string {
+	procs, err := process.Processes()
+	if err != nil {
+		return nil
+	}
+	live := map[string]bool{}
+	for _, p := range procs {
+		if name, err := p.Name(); err == nil {
+			live[normalize(name)] = true
+		}
+	}
+	var running []string
+	for _, want := range names {
+		if live[normalize(want)] {
+			running = append(running, want)
+		}
+	}
+	return running
+}
+
+func normalize(name string) string {
+	return strings.TrimSuffix(strings.ToLower(name), ".exe")
+}
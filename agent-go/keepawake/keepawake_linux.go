@@ -0,0 +1,31 @@
+//go:build linux
+
+package keepawake
+
+import "os/exec"
+
+// inhibitor is the running systemd-inhibit, which holds the block for as
+// long as it lives.
+var inhibitor *exec.Cmd
+
+func hold(display bool) error {
+	what := "sleep"
+	if display {
+		what = "sleep:idle"
+	}
+	cmd := exec.Command("systemd-inhibit", "--what="+what, "--who=AVL Dashboard Agent",
+		"--why=Watched software is running", "--mode=block", "sleep", "infinity")
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	inhibitor = cmd
+	return nil
+}
+
+func release() {
+	if inhibitor != nil {
+		inhibitor.Process.Kill()
+		inhibitor.Wait()
+		inhibitor = nil
+	}
+}
@@ -0,0 +1,34 @@
+//go:build windows
+
+package keepawake
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/windows"
+)
+
+const (
+	esSystemRequired  = 0x00000001
+	esDisplayRequired = 0x00000002
+	esContinuous      = 0x80000000
+)
+
+var procSetThreadExecutionState = windows.NewLazySystemDLL("kernel32.dll").NewProc("SetThreadExecutionState")
+
+// hold asks Windows not to sleep (and, with display, not to blank the
+// screen) until release is called from the same thread.
+func hold(display bool) error {
+	flags := uintptr(esContinuous | esSystemRequired)
+	if display {
+		flags |= esDisplayRequired
+	}
+	if r, _, err := procSetThreadExecutionState.Call(flags); r == 0 {
+		return fmt.Errorf("SetThreadExecutionState: %v", err)
+	}
+	return nil
+}
+
+func release() {
+	procSetThreadExecutionState.Call(esContinuous)
+}
@@ -0,0 +1,209 @@
+// Package kiosk watches the player on a lobby signage PC. The browser or
+// media player must be running, and optionally in the foreground on the
+// right output; an update prompt or a crash otherwise leaves the lobby
+// showing a desktop until someone walks past.
+package kiosk
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/process"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/events"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/launch"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/presence"
+)
+
+const (
+	checkInterval = 15 * time.Second
+	// relaunchAfter is how many failed checks in a row (a minute) trigger
+	// an automatic relaunch; relaunchBackoff spaces automatic relaunches
+	// so a player that can't start isn't killed and started forever.
+	relaunchAfter   = 4
+	relaunchBackoff = 5 * time.Minute
+	// exitTimeout bounds the wait for killed processes to go.
+	exitTimeout = 5 * time.Second
+)
+
+// Status is reported in /status while the kiosk is configured.
+type Status struct {
+	Process string `json:"process"`
+	Running bool   `json:"running"`
+	// Foreground and Display are where the player's window is. They are
+	// unset where the agent can't see the desktop: on Linux, in session 0,
+	// and while the workstation is locked.
+	Foreground   *bool      `json:"foreground,omitempty"`
+	Frontmost    string     `json:"frontmost,omitempty"` // the process in front instead
+	Display      string     `json:"display,omitempty"`   // e.g. \\.\DISPLAY2
+	Problems     []string   `json:"problems,omitempty"`
+	Relaunches   int        `json:"relaunches,omitempty"`
+	LastRelaunch *time.Time `json:"lastRelaunch,omitempty"`
+	Error        string     `json:"error,omitempty"` // the last relaunch failed
+}
+
+// placement is where the player's window is. Known is false when there is
+// no desktop to look at.
+type placement struct {
+	known      bool
+	foreground bool
+	frontmost  string // the foreground process when it isn't the player
+	display    string // empty when the player has no visible window
+}
+
+// Monitor checks the player every 15 seconds. Safe for concurrent use.
+type Monitor struct {
+	cfg config.KioskConfig
+
+	relaunching sync.Mutex // held for a whole relaunch
+
+	mu       sync.Mutex
+	status   Status
+	failures int // consecutive checks with problems
+}
+
+// New creates a monitor. It returns nil when no kiosk process is
+// configured.
+func New(cfg config.KioskConfig) *Monitor {
+	if cfg.Process == "" {
+		return nil
+	}
+	return &Monitor{cfg: cfg, status: Status{Process: cfg.Process}}
+}
+
+// Status returns the latest check, or nil when the kiosk isn't configured.
+func (m *Monitor) Status() *Status {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	status := m.status
+	return &status
+}
+
+// Run checks the player every 15 seconds. Blocks forever. An automatic
+// relaunch waits while an operator is at the machine, who may be setting
+// the player up.
+func (m *Monitor) Run() {
+	held := false
+	for {
+		switch {
+		case !m.check():
+			held = false
+		case presence.OperatorPresent():
+			if !held {
+				events.Record(events.Lifecycle, events.Info, "Kiosk: not relaunching %s while an operator is at the machine", m.cfg.Process)
+			}
+			held = true
+		default:
+			held = false
+			if err := m.relaunch("auto-relaunch"); err != nil {
+				events.Record(events.Lifecycle, events.Error, "Kiosk: relaunch failed: %v", err)
+			}
+			m.check()
+		}
+		time.Sleep(checkInterval)
+	}
+}
+
+// check updates the status and reports whether an automatic relaunch is
+// due.
+func (m *Monitor) check() bool {
+	pids := launch.PIDs(m.cfg.Process)
+	var where placement
+	if len(pids) > 0 {
+		where = locate(pids)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := &m.status
+	s.Running, s.Foreground, s.Frontmost, s.Display, s.Problems = len(pids) > 0, nil, "", "", nil
+	if where.known {
+		s.Foreground, s.Frontmost, s.Display = &where.foreground, where.frontmost, where.display
+	}
+	switch {
+	case !s.Running:
+		s.Problems = append(s.Problems, m.cfg.Process+" is not running")
+	case !where.known:
+	default:
+		switch {
+		case !m.cfg.Foreground || where.foreground:
+		case where.frontmost != "":
+			s.Problems = append(s.Problems, fmt.Sprintf("%s is not in the foreground (%s is)", m.cfg.Process, where.frontmost))
+		default:
+			s.Problems = append(s.Problems, m.cfg.Process+" is not in the foreground")
+		}
+		switch {
+		case m.cfg.Display == "":
+		case where.display == "":
+			s.Problems = append(s.Problems, m.cfg.Process+" has no visible window")
+		case !strings.EqualFold(where.display, m.cfg.Display):
+			s.Problems = append(s.Problems, fmt.Sprintf("%s is on %s, expected %s", m.cfg.Process, where.display, m.cfg.Display))
+		}
+	}
+
+	if len(s.Problems) == 0 {
+		m.failures = 0
+		return false
+	}
+	m.failures++
+	return m.cfg.AutoRelaunch && m.failures >= relaunchAfter &&
+		(s.LastRelaunch == nil || time.Since(*s.LastRelaunch) >= relaunchBackoff)
+}
+
+// Relaunch stops the player and starts the configured command, then
+// returns the new status.
+func (m *Monitor) Relaunch(by string) (*Status, error) {
+	if err := m.relaunch(by); err != nil {
+		return nil, err
+	}
+	m.check()
+	return m.Status(), nil
+}
+
+func (m *Monitor) relaunch(by string) error {
+	if m.cfg.Command == "" {
+		return errors.New("kiosk.command is not configured")
+	}
+	m.relaunching.Lock()
+	defer m.relaunching.Unlock()
+
+	pids := launch.PIDs(m.cfg.Process)
+	for pid := range pids {
+		if p, err := process.NewProcess(pid); err == nil {
+			p.Kill()
+		}
+	}
+	for deadline := time.Now().Add(exitTimeout); len(pids) > 0 && time.Now().Before(deadline); {
+		time.Sleep(250 * time.Millisecond)
+		pids = launch.PIDs(m.cfg.Process)
+	}
+
+	err := launch.Start(m.cfg.Command, m.cfg.Args)
+	now := time.Now()
+	m.mu.Lock()
+	m.status.Relaunches++
+	m.status.LastRelaunch = &now
+	m.status.Error = ""
+	if err != nil {
+		m.status.Error = err.Error()
+	}
+	m.failures = 0
+	m.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	kind := events.RemoteAction
+	if by == "auto-relaunch" {
+		kind = events.Lifecycle
+	}
+	events.Record(kind, events.Warning, "Kiosk: %s relaunched by %s", m.cfg.Process, by)
+	return nil
+}
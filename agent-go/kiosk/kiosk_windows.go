@@ -0,0 +1,100 @@
+//go:build windows
+
+package kiosk
+
+import (
+	"sync"
+	"unsafe"
+
+	"github.com/shirou/gopsutil/v4/process"
+	"golang.org/x/sys/windows"
+)
+
+var (
+	user32                = windows.NewLazySystemDLL("user32.dll")
+	procIsIconic          = user32.NewProc("IsIconic")
+	procMonitorFromWindow = user32.NewProc("MonitorFromWindow")
+	procGetMonitorInfo    = user32.NewProc("GetMonitorInfoW")
+)
+
+const monitorDefaultToNearest = 2
+
+// monitorInfoEx is MONITORINFOEXW.
+type monitorInfoEx struct {
+	cbSize    uint32
+	rcMonitor windows.Rect
+	rcWork    windows.Rect
+	flags     uint32
+	device    [32]uint16
+}
+
+// locate finds the player's window: the foreground window if it is the
+// player's, else its first visible, unminimized top-level window. The
+// service runs in session 0, which has no foreground window, and neither
+// does a locked workstation; both are reported as unknown.
+func locate(pids map[int32]bool) placement {
+	fg := windows.GetForegroundWindow()
+	if fg == 0 {
+		return placement{}
+	}
+	where := placement{known: true, foreground: pids[windowPID(fg)]}
+
+	window := fg
+	if !where.foreground {
+		if p, err := process.NewProcess(windowPID(fg)); err == nil {
+			where.frontmost, _ = p.Name()
+		}
+		window = playerWindow(pids)
+	}
+	if window != 0 {
+		where.display = monitorOf(window)
+	}
+	return where
+}
+
+// Windows never frees callbacks, so there is one, fed through enum*.
+var (
+	enumMu       sync.Mutex
+	enumPIDs     map[int32]bool
+	enumFound    windows.HWND
+	enumCallback = windows.NewCallback(func(hwnd windows.HWND, _ uintptr) uintptr {
+		if enumPIDs[windowPID(hwnd)] && windows.IsWindowVisible(hwnd) {
+			if iconic, _, _ := procIsIconic.Call(uintptr(hwnd)); iconic == 0 {
+				enumFound = hwnd
+				return 0 // stop
+			}
+		}
+		return 1
+	})
+)
+
+// playerWindow returns the first visible, unminimized top-level window
+// owned by one of pids, or 0.
+func playerWindow(pids map[int32]bool) windows.HWND {
+	enumMu.Lock()
+	defer enumMu.Unlock()
+	enumPIDs, enumFound = pids, 0
+	windows.EnumWindows(enumCallback, nil)
+	return enumFound
+}
+
+func windowPID(hwnd windows.HWND) int32 {
+	var pid uint32
+	windows.GetWindowThreadProcessId(hwnd, &pid)
+	return int32(pid)
+}
+
+// monitorOf returns the output name (\\.\DISPLAY2) of the monitor showing
+// most of the window.
+func monitorOf(hwnd windows.HWND) string {
+	monitor, _, _ := procMonitorFromWindow.Call(uintptr(hwnd), monitorDefaultToNearest)
+	if monitor == 0 {
+		return ""
+	}
+	var info monitorInfoEx
+	info.cbSize = uint32(unsafe.Sizeof(info))
+	if r, _, _ := procGetMonitorInfo.Call(monitor, uintptr(unsafe.Pointer(&info))); r == 0 {
+		return ""
+	}
+	return windows.UTF16ToString(info.device[:])
+}
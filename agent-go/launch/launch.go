@@ -0,0 +1,44 @@
+// Package launch starts applications for the agent (a signage player, a
+// wake routine's apps, startup apps) and finds them running.
+package launch
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// Start launches command detached, so it outlives the agent. On Windows an
+// app started by the service runs in session 0, where nobody sees it; the
+// tray agent starts it on the desktop.
+func Start(command string, args []string) error {
+	return start(command, args)
+}
+
+// PIDs returns the running processes named name, matched
+// case-insensitively with ".exe" optional. Browsers run many.
+func PIDs(name string) map[int32]bool {
+	procs, err := process.Processes()
+	if err != nil {
+		return nil
+	}
+	want := normalize(name)
+	pids := map[int32]bool{}
+	for _, p := range procs {
+		if n, err := p.Name(); err == nil && normalize(n) == want {
+			pids[p.Pid] = true
+		}
+	}
+	return pids
+}
+
+// Running reports whether a process with command's executable name runs.
+// command may be a name or a path.
+func Running(command string) bool {
+	return len(PIDs(filepath.Base(command))) > 0
+}
+
+func normalize(name string) string {
+	return strings.TrimSuffix(strings.ToLower(name), ".exe")
+}
@@ -0,0 +1,14 @@
+//go:build linux
+
+package launch
+
+import "os/exec"
+
+func start(command string, args []string) error {
+	cmd := exec.Command(command, args...)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
@@ -3,85 +3,176 @@
 package main
 
 import (
+	"bytes"
 	_ "embed"
-	"fmt"
 	"log"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"time"
 
 	"fyne.io/systray"
 
-	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/mdns"
-	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/metrics"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/alerts"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/diag"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/events"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/i18n"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/maintenance"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/pairing"
 	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/server"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/toast"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/trayicon"
 	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/update"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/watchdog"
 )
 
-// version is injected at build time via -ldflags "-X main.version=..."
-var version = "dev"
-
 //go:embed icon.ico
 var iconData []byte
 
 func main() {
-	systray.Run(onReady, onExit)
+	opts, cfg := setup()
+	if err := i18n.LoadOverrides(filepath.Join(config.AssetsDir(), "i18n")); err != nil {
+		log.Printf("Asset pack messages: %v", err)
+	}
+	i18n.Setup(cfg.Language)
+	if code, ran := runCommand(opts, cfg); ran {
+		os.Exit(code)
+	}
+	events.Setup(cfg.Events)
+
+	if watchdog.RunningAsService() {
+		runService(cfg, opts)
+		return
+	}
+
+	// Run as a headless supervisor unless this is the supervised child.
+	// The child owns the tray; the supervisor only restarts it.
+	if !cfg.Watchdog.Disabled && !watchdog.Supervised() {
+		os.Exit(watchdog.Supervise(os.Args[1:]))
+	}
+
+	if opts.headless {
+		a := newAgent(cfg, opts)
+		a.serve(nil)
+		a.runHeadless()
+		return
+	}
+	systray.Run(func() { onReady(cfg, opts) }, onExit)
+}
+
+// runService runs the agent headless under the service manager, which
+// restarts it on failure (see the installer's recovery settings).
+func runService(cfg *config.Config, opts options) {
+	var a *agent
+	err := watchdog.RunService(func() {
+		a = newAgent(cfg, opts)
+		a.serve(nil)
+		a.openPairing()
+	}, func() {
+		events.Record(events.Lifecycle, events.Info, "Service stopping")
+		a.shutdown()
+	})
+	if err != nil {
+		events.Record(events.Lifecycle, events.Error, "Service failed: %v", err)
+		os.Exit(1)
+	}
 }
 
-func onReady() {
+func onReady(cfg *config.Config, opts options) {
 	systray.SetIcon(iconData)
-	systray.SetTitle("AVL Dashboard Agent")
-	systray.SetTooltip("AVL Dashboard Agent")
+	systray.SetTitle(i18n.T("app.title"))
+	systray.SetTooltip(i18n.T("app.title"))
 
 	hostname, _ := os.Hostname()
 
-	mHostname := systray.AddMenuItem(hostname, "Machine hostname")
+	mHostname := systray.AddMenuItem(hostname, i18n.T("menu.hostname.tip"))
 	mHostname.Disable()
 
-	mPort := systray.AddMenuItem("Starting...", "Listening port")
+	mPort := systray.AddMenuItem(i18n.T("menu.starting"), i18n.T("menu.port.tip"))
 	mPort.Disable()
 
-	mConn := systray.AddMenuItem("No Dashboard Connected", "Dashboard connection status")
+	mConn := systray.AddMenuItem(i18n.T("menu.disconnected"), i18n.T("menu.connection.tip"))
 	mConn.Disable()
 
 	systray.AddSeparator()
 
-	mVersion := systray.AddMenuItem(fmt.Sprintf("Agent v%s", version), "Agent version")
+	mVersion := systray.AddMenuItem(i18n.T("menu.version", version), i18n.T("menu.version.tip"))
 	mVersion.Disable()
 
-	mUpdate := systray.AddMenuItem("Check for Updates", "Check GitHub for new releases")
+	mUpdate := systray.AddMenuItem(i18n.T("menu.update"), i18n.T("menu.update.tip"))
+	mNotes := systray.AddMenuItem(i18n.T("menu.notes", version), i18n.T("menu.notes.tip"))
+	mSkip := systray.AddMenuItem(i18n.T("menu.skip"), i18n.T("menu.skip.tip"))
+	mBeta := systray.AddMenuItemCheckbox(i18n.T("menu.beta"), i18n.T("menu.beta.tip"), false)
+	mAck := systray.AddMenuItem(i18n.T("menu.alerts.none"), i18n.T("menu.alerts.tip"))
+	mAck.Disable()
+	mPair := systray.AddMenuItem(i18n.T("menu.pair"), i18n.T("menu.pair.tip"))
+	mQR := systray.AddMenuItem(i18n.T("menu.qr"), i18n.T("menu.qr.tip"))
+	mMaint := systray.AddMenuItem(i18n.T("menu.maintenance"), i18n.T("menu.maintenance.tip"))
+	mMaint1h := mMaint.AddSubMenuItem(i18n.T("menu.maintenance.1h"), i18n.T("menu.maintenance.for.tip", 1))
+	mMaint4h := mMaint.AddSubMenuItem(i18n.T("menu.maintenance.4h"), i18n.T("menu.maintenance.for.tip", 4))
+	mMaint24h := mMaint.AddSubMenuItem(i18n.T("menu.maintenance.24h"), i18n.T("menu.maintenance.for.tip", 24))
+	mMaintEnd := mMaint.AddSubMenuItem(i18n.T("menu.maintenance.end"), i18n.T("menu.maintenance.end.tip"))
+	mDiag := systray.AddMenuItem(i18n.T("menu.diagnostics"), i18n.T("menu.diagnostics.tip"))
 
 	systray.AddSeparator()
-	mQuit := systray.AddMenuItem("Quit", "Quit the agent")
+	mQuit := systray.AddMenuItem(i18n.T("menu.quit"), i18n.T("menu.quit.tip"))
 
-	// Start subsystems
-	collector := metrics.NewCollector(version)
-	go collector.Start()
-
-	updater := update.NewUpdater(version)
+	a := newAgent(cfg, opts)
+	updateMaintenanceMenu(mMaint, mMaintEnd, a.maint.Current())
+	updateBetaMenu(mBeta, a.updater.Channel())
+	updateSkipMenu(mSkip, a.updater.Candidate())
+	if a.updater.InstalledNotes() == nil {
+		mNotes.Hide()
+	}
+	a.updater.OnInstalled(func(notes update.ReleaseNotes) {
+		mNotes.Show()
+		if !cfg.Alerts.DisableToasts {
+			toast.Show(i18n.T("app.title"), notesToast(i18n.T("toast.updated", notes.Version), notes))
+		}
+	})
 
-	srv := server.New(collector, updater)
-	go srv.ListenAndServe()
+	icons, err := trayicon.NewRenderer(iconData)
+	if err != nil {
+		log.Printf("Tray icon: %v", err)
+	}
 
-	// Wait for server to bind, then update menu and start mDNS
-	go func() {
-		port := srv.Port() // blocks until ready
-		mPort.SetTitle(fmt.Sprintf("Port: %d", port))
-		log.Printf("Server ready on port %d", port)
+	if !cfg.Alerts.DisableToasts {
+		a.updater.OnUpdate(func(from string, to update.ReleaseNotes) {
+			toast.Show(i18n.T("app.title"), notesToast(i18n.T("toast.updating", from, to.Version), to))
+		})
+	}
 
-		go mdns.Advertise(hostname, port)
-	}()
-	go updater.StartPeriodicChecks()
+	// Once the server is bound, show the port in the menu
+	a.serve(func(port uint16) {
+		mPort.SetTitle(i18n.T("menu.port", port))
+	})
 
 	// Track dashboard connection status in the menu
 	go func() {
 		ticker := time.NewTicker(5 * time.Second)
 		defer ticker.Stop()
+		wasConnected := false
 		for range ticker.C {
-			if srv.DashboardConnected() {
-				mConn.SetTitle("Dashboard Connected")
-			} else {
-				mConn.SetTitle("No Dashboard Connected")
+			connected := a.server.DashboardConnected()
+			switch {
+			case a.waitingForNetwork():
+				mConn.SetTitle(i18n.T("menu.network.waiting"))
+			case connected:
+				mConn.SetTitle(i18n.T("menu.connected"))
+			default:
+				mConn.SetTitle(i18n.T("menu.disconnected"))
 			}
+			if wasConnected && !connected && !cfg.Alerts.DisableToasts {
+				go toast.Show(i18n.T("app.title"), i18n.T("toast.disconnected"))
+			}
+			wasConnected = connected
+			updateAlertMenu(mAck, a.alerts.List())
+			updatePairMenu(mPair, a.pairer)
+			updateMaintenanceMenu(mMaint, mMaintEnd, a.maint.Current())
+			updateBetaMenu(mBeta, a.updater.Channel())
+			updateSkipMenu(mSkip, a.updater.Candidate())
+			updateIcon(icons, trayLevel(a.alerts.List(), a.maint.Current()))
 		}
 	}()
 
@@ -89,13 +180,178 @@ func onReady() {
 	for {
 		select {
 		case <-mUpdate.ClickedCh:
-			go updater.ForceCheck()
+			go a.updater.ForceCheck()
+		case <-mNotes.ClickedCh:
+			if notes := a.updater.InstalledNotes(); notes != nil {
+				go showReleaseNotes(*notes)
+			}
+		case <-mSkip.ClickedCh:
+			if v := a.updater.Candidate(); v != "" {
+				if err := a.updater.SkipVersion(v, "tray"); err != nil {
+					log.Printf("Skip update: %v", err)
+				}
+			}
+			updateSkipMenu(mSkip, a.updater.Candidate())
+		case <-mBeta.ClickedCh:
+			channel := update.ChannelBeta
+			if mBeta.Checked() {
+				channel = update.ChannelStable
+			}
+			if err := a.updater.SetChannel(channel, "tray"); err != nil {
+				log.Printf("Update channel: %v", err)
+			}
+			updateBetaMenu(mBeta, a.updater.Channel())
+		case <-mPair.ClickedCh:
+			if a.pairer != nil {
+				a.pairer.Open(5 * time.Minute)
+				updatePairMenu(mPair, a.pairer)
+			}
+		case <-mQR.ClickedCh:
+			go a.showQRCode()
+		case <-mAck.ClickedCh:
+			a.alerts.AckAll()
+			updateAlertMenu(mAck, a.alerts.List())
+		case <-mMaint1h.ClickedCh:
+			a.maint.Start(time.Hour, "", "tray")
+			updateMaintenanceMenu(mMaint, mMaintEnd, a.maint.Current())
+		case <-mMaint4h.ClickedCh:
+			a.maint.Start(4*time.Hour, "", "tray")
+			updateMaintenanceMenu(mMaint, mMaintEnd, a.maint.Current())
+		case <-mMaint24h.ClickedCh:
+			a.maint.Start(24*time.Hour, "", "tray")
+			updateMaintenanceMenu(mMaint, mMaintEnd, a.maint.Current())
+		case <-mMaintEnd.ClickedCh:
+			a.maint.End("tray")
+			updateMaintenanceMenu(mMaint, mMaintEnd, a.maint.Current())
+		case <-mDiag.ClickedCh:
+			go saveSupportBundle(a.server)
 		case <-mQuit.ClickedCh:
+			a.shutdown()
 			systray.Quit()
 		}
 	}
 }
 
+// saveSupportBundle writes a support bundle to the desktop and selects it
+// in Explorer so it can be dragged straight into an issue.
+func saveSupportBundle(srv *server.Server) {
+	data, err := srv.SupportBundle()
+	if err != nil {
+		log.Printf("Support bundle failed: %v", err)
+		return
+	}
+	dir := filepath.Join(os.Getenv("USERPROFILE"), "Desktop")
+	if _, err := os.Stat(dir); err != nil {
+		dir = config.DataDir()
+	}
+	path := filepath.Join(dir, diag.FileName())
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		log.Printf("Support bundle failed: %v", err)
+		return
+	}
+	log.Printf("Support bundle saved to %s", path)
+	exec.Command("explorer.exe", "/select,"+path).Start()
+}
+
+// updateAlertMenu shows the number of unacknowledged alerts on the
+// acknowledge item, disabling it when there is nothing to acknowledge.
+func updateAlertMenu(item *systray.MenuItem, list []alerts.Alert) {
+	pending := 0
+	for _, a := range list {
+		if !a.Acknowledged {
+			pending++
+		}
+	}
+	if pending == 0 {
+		item.SetTitle(i18n.T("menu.alerts.none"))
+		item.Disable()
+		return
+	}
+	item.SetTitle(i18n.T("menu.alerts.ack", pending))
+	item.Enable()
+}
+
+// trayLevel summarises alerts for the tray badge. Acknowledged alerts no
+// longer colour it: someone is already on them.
+func trayLevel(list []alerts.Alert, w *maintenance.Window) trayicon.Level {
+	if w != nil {
+		return trayicon.Maintenance
+	}
+	level := trayicon.Healthy
+	for _, a := range list {
+		if !a.Active || a.Acknowledged {
+			continue
+		}
+		switch a.Severity {
+		case alerts.SeverityCritical:
+			return trayicon.Critical
+		case alerts.SeverityWarning:
+			level = trayicon.Warning
+		}
+	}
+	return level
+}
+
+var shownIcon []byte
+
+// updateIcon swaps the tray icon when the level or taskbar theme changes.
+func updateIcon(icons *trayicon.Renderer, level trayicon.Level) {
+	if icons == nil {
+		return
+	}
+	icon := icons.Icon(level, trayicon.DarkTaskbar())
+	if bytes.Equal(icon, shownIcon) {
+		return
+	}
+	systray.SetIcon(icon)
+	shownIcon = icon
+}
+
+// updateMaintenanceMenu shows when an open maintenance window ends.
+func updateMaintenanceMenu(item, end *systray.MenuItem, w *maintenance.Window) {
+	if w == nil {
+		item.SetTitle(i18n.T("menu.maintenance"))
+		end.Disable()
+		return
+	}
+	item.SetTitle(i18n.T("menu.maintenance.until", w.Until.Format(i18n.T("format.time"))))
+	end.Enable()
+}
+
+// updateBetaMenu ticks "Receive beta updates" on the beta channel, which
+// may also be set remotely or in config.
+func updateBetaMenu(item *systray.MenuItem, channel string) {
+	if channel == update.ChannelBeta {
+		item.Check()
+	} else {
+		item.Uncheck()
+	}
+}
+
+// updateSkipMenu offers to skip the update the last check found; it is
+// hidden while there is none.
+func updateSkipMenu(item *systray.MenuItem, candidate string) {
+	if candidate == "" {
+		item.Hide()
+		return
+	}
+	item.SetTitle(i18n.T("menu.skip.version", candidate))
+	item.Show()
+}
+
+// updatePairMenu shows the pairing code while a window is open.
+func updatePairMenu(item *systray.MenuItem, pairer *pairing.Manager) {
+	if pairer == nil {
+		item.Hide()
+		return
+	}
+	if code, expires := pairer.Code(); code != "" {
+		item.SetTitle(i18n.T("menu.pair.code", code, int(time.Until(expires).Minutes())+1))
+		return
+	}
+	item.SetTitle(i18n.T("menu.pair"))
+}
+
 func onExit() {
-	log.Println("Agent shutting down")
+	events.Record(events.Lifecycle, events.Info, "Agent shutting down")
 }
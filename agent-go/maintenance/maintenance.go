@@ -0,0 +1,90 @@
+// Package maintenance tracks a time-boxed maintenance window. While a
+// window is open the agent keeps reporting metrics but marks itself as
+// under maintenance in /status and raises no alerts, so rebuilding a
+// machine doesn't page anyone.
+package maintenance
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/events"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/state"
+)
+
+const stateKey = "maintenance"
+
+// Window is an open maintenance window, as reported in /status.
+type Window struct {
+	Since  time.Time `json:"since"`
+	Until  time.Time `json:"until"`
+	Reason string    `json:"reason,omitempty"`
+	By     string    `json:"by,omitempty"`
+}
+
+// Mode holds the current window. It survives restarts, since rebuilding a
+// machine usually involves a few. Safe for concurrent use.
+type Mode struct {
+	store *state.Store
+
+	mu      sync.Mutex
+	current *Window
+}
+
+// New restores an open window from the store.
+func New(store *state.Store) *Mode {
+	m := &Mode{store: store}
+	var saved Window
+	if store.Get(stateKey, &saved) {
+		m.current = &saved
+	}
+	return m
+}
+
+// Start opens a window lasting d, replacing any open window.
+func (m *Mode) Start(d time.Duration, reason, by string) Window {
+	now := time.Now()
+	w := Window{Since: now, Until: now.Add(d), Reason: reason, By: by}
+
+	m.mu.Lock()
+	m.current = &w
+	m.mu.Unlock()
+
+	if err := m.store.Set(stateKey, w); err != nil {
+		log.Printf("State: save maintenance failed: %v", err)
+	}
+	events.Record(events.RemoteAction, events.Info, "Maintenance: started by %s until %s", by, w.Until.Format(time.RFC3339))
+	return w
+}
+
+// End closes the open window. Returns false if none was open.
+func (m *Mode) End(by string) bool {
+	m.mu.Lock()
+	open := m.current != nil
+	m.current = nil
+	m.mu.Unlock()
+	if !open {
+		return false
+	}
+	m.store.Delete(stateKey)
+	events.Record(events.RemoteAction, events.Info, "Maintenance: ended by %s", by)
+	return true
+}
+
+// Current returns the open window, or nil. An expired window is closed.
+func (m *Mode) Current() *Window {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.current == nil {
+		return nil
+	}
+	if time.Now().After(m.current.Until) {
+		m.current = nil
+		m.store.Delete(stateKey)
+		events.Record(events.Lifecycle, events.Info, "Maintenance: window expired")
+		return nil
+	}
+	w := *m.current
+	return &w
+}
@@ -1,7 +1,9 @@
 package mdns
 
 import (
+	"context"
 	"log"
+	"sync"
 
 	"github.com/grandcat/zeroconf"
 )
@@ -11,25 +13,121 @@ const (
 	serviceDomain = "local."
 )
 
+// Identity is published in TXT records so a dashboard can group and
+// de-duplicate machines before polling them. The instance name stays the
+// hostname, which existing dashboards display.
+type Identity struct {
+	HardwareUUID string
+	Version      string
+	SiteID       string
+	SiteName     string
+	Ring         string // update rollout ring, "canary" or "stable"
+	Channel      string // update channel, "stable" or "beta"
+	// Proxy marks a record advertised by another agent on the machine's
+	// behalf (an instance), which carries the machine's own UUID.
+	Proxy bool
+}
+
+// records returns the TXT records for id, omitting empty values.
+func (id Identity) records() []string {
+	var txt []string
+	proxy := ""
+	if id.Proxy {
+		proxy = "1"
+	}
+	for _, kv := range [][2]string{
+		{"uuid", id.HardwareUUID},
+		{"version", id.Version},
+		{"site", id.SiteID},
+		{"siteName", id.SiteName},
+		{"ring", id.Ring},
+		{"channel", id.Channel},
+		{"proxy", proxy},
+	} {
+		if kv[1] != "" {
+			txt = append(txt, kv[0]+"="+kv[1])
+		}
+	}
+	return txt
+}
+
+// Advertiser publishes the agent's mDNS record. Safe for concurrent use.
+type Advertiser struct {
+	ctx      context.Context
+	hostname string
+	port     uint16
+	id       Identity
+
+	mu     sync.Mutex
+	server *zeroconf.Server
+}
+
 // Advertise registers the agent as an mDNS service so the macOS dashboard
-// can discover it via NWBrowser. Blocks until the process exits.
-func Advertise(hostname string, port uint16) {
+// can discover it via NWBrowser. The responder runs in the background
+// until ctx is cancelled, when the record is withdrawn.
+func Advertise(ctx context.Context, hostname string, port uint16, id Identity) *Advertiser {
+	a := &Advertiser{ctx: ctx, hostname: hostname, port: port, id: id}
+	a.Announce()
+	context.AfterFunc(ctx, a.Withdraw)
+	return a
+}
+
+// Announce (re-)registers the record, picking up interfaces and addresses
+// that changed, e.g. after waking from sleep. It does nothing once the
+// context passed to Advertise is cancelled.
+func (a *Advertiser) Announce() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.ctx.Err() != nil {
+		return
+	}
+	if a.server != nil {
+		a.server.Shutdown()
+		a.server = nil
+	}
+
 	server, err := zeroconf.Register(
-		hostname,      // instance name (machine hostname)
+		a.hostname,    // instance name (machine hostname)
 		serviceType,   // "_computerdash._tcp"
 		serviceDomain, // "local."
-		int(port),
-		nil, // no TXT records (matches macOS agent)
+		int(a.port),
+		a.id.records(),
 		nil, // all network interfaces
 	)
 	if err != nil {
 		log.Printf("mDNS registration failed: %v", err)
 		return
 	}
-	defer server.Shutdown()
+	a.server = server
+	log.Printf("mDNS: advertising %s on port %d", serviceType, a.port)
+}
 
-	log.Printf("mDNS: advertising %s on port %d", serviceType, port)
+// Rename re-announces the record under hostname after the machine is
+// renamed. Announce withdraws the old name first, so browsers drop it.
+func (a *Advertiser) Rename(hostname string) {
+	a.mu.Lock()
+	a.hostname = hostname
+	a.mu.Unlock()
+	a.Announce()
+}
+
+// SetChannel re-announces the record with a new update channel.
+func (a *Advertiser) SetChannel(channel string) {
+	a.mu.Lock()
+	a.id.Channel = channel
+	a.mu.Unlock()
+	a.Announce()
+}
 
-	// Block forever; the mDNS responder runs in background goroutines.
-	select {}
+// Withdraw sends a goodbye (TTL 0) so browsers drop the record at once
+// rather than when it expires, then stops responding until Announce.
+func (a *Advertiser) Withdraw() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.server == nil {
+		return
+	}
+	a.server.Shutdown()
+	a.server = nil
+	log.Printf("mDNS: withdrawn")
 }
@@ -0,0 +1,79 @@
+package mdns
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grandcat/zeroconf"
+)
+
+// Peer is another agent found on the local network.
+type Peer struct {
+	Instance string // hostname
+	Address  string // host:port
+	Text     map[string]string
+}
+
+// Browse lists the agents that answer within timeout. Only peers with an
+// IPv4 address are returned; agents listen on all interfaces.
+func Browse(timeout time.Duration) ([]Peer, error) {
+	var peers []Peer
+	err := browse(serviceType, timeout, func(entry *zeroconf.ServiceEntry) {
+		if len(entry.AddrIPv4) == 0 {
+			return
+		}
+		peers = append(peers, Peer{
+			Instance: entry.Instance,
+			Address:  net.JoinHostPort(entry.AddrIPv4[0].String(), strconv.Itoa(entry.Port)),
+			Text:     parseText(entry.Text),
+		})
+	})
+	return peers, err
+}
+
+// Instances lists the instance names of another service, e.g. "_ndi._tcp"
+// for NDI senders, that answer within timeout.
+func Instances(service string, timeout time.Duration) ([]string, error) {
+	var names []string
+	err := browse(service, timeout, func(entry *zeroconf.ServiceEntry) {
+		// zeroconf leaves DNS escapes in: "STUDIO\ \(Camera\ 1\)".
+		names = append(names, strings.ReplaceAll(entry.Instance, `\`, ""))
+	})
+	return names, err
+}
+
+// browse calls found for each entry of service that answers within
+// timeout.
+func browse(service string, timeout time.Duration, found func(*zeroconf.ServiceEntry)) error {
+	resolver, err := zeroconf.NewResolver(nil)
+	if err != nil {
+		return fmt.Errorf("mDNS resolver: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	entries := make(chan *zeroconf.ServiceEntry)
+	if err := resolver.Browse(ctx, service, serviceDomain, entries); err != nil {
+		return fmt.Errorf("mDNS browse: %w", err)
+	}
+	for entry := range entries {
+		found(entry)
+	}
+	return nil
+}
+
+// parseText splits "key=value" TXT records.
+func parseText(records []string) map[string]string {
+	text := make(map[string]string, len(records))
+	for _, r := range records {
+		if k, v, ok := strings.Cut(r, "="); ok {
+			text[k] = v
+		}
+	}
+	return text
+}
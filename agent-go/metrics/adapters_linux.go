@@ -0,0 +1,73 @@
+//go:build linux
+
+package metrics
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ARPHRD values from /sys/class/net/<if>/type
+const (
+	arphrdEther    = 1
+	arphrdLoopback = 772
+	arphrdNone     = 65534 // WireGuard and other L3 tunnels
+)
+
+// readInterfaceKinds classifies interfaces from sysfs metadata, which is
+// independent of how the interface happens to be named.
+func readInterfaceKinds() map[string]string {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+	kinds := make(map[string]string)
+	for _, iface := range ifaces {
+		dir := filepath.Join("/sys/class/net", iface.Name)
+		arphrd := readSysInt(filepath.Join(dir, "type"))
+
+		switch {
+		case arphrd == arphrdLoopback:
+			continue
+		case sysExists(dir, "wireless"), sysExists(dir, "phy80211"):
+			kinds[iface.Name] = "Wi-Fi"
+		case strings.Contains(readSysString(filepath.Join(dir, "device", "uevent")), "DEVTYPE=wwan"):
+			kinds[iface.Name] = "Cellular"
+		case sysExists(dir, "tun_flags"), arphrd == arphrdNone:
+			kinds[iface.Name] = "VPN"
+		case sysExists(dir, "bridge"), sysExists(dir, "bonding"):
+			kinds[iface.Name] = "Bridge"
+		case !sysExists(dir, "device"):
+			kinds[iface.Name] = "Virtual" // veth, docker0, virbr, macvlan
+		case arphrd == arphrdEther:
+			kinds[iface.Name] = "Ethernet"
+		}
+	}
+	return kinds
+}
+
+func sysExists(dir, name string) bool {
+	_, err := os.Stat(filepath.Join(dir, name))
+	return err == nil
+}
+
+func readSysString(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func readSysInt(path string) int {
+	var v int
+	for _, c := range readSysString(path) {
+		if c < '0' || c > '9' {
+			return -1
+		}
+		v = v*10 + int(c-'0')
+	}
+	return v
+}
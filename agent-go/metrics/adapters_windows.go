@@ -0,0 +1,109 @@
+//go:build windows
+
+package metrics
+
+import (
+	"runtime"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// IP Helper interface types not named in x/sys/windows
+const (
+	ifTypePropVirtual = 53
+	ifTypeBridge      = 209
+	ifTypeWWANPP      = 243
+	ifTypeWWANPP2     = 244
+)
+
+// NDIS_PHYSICAL_MEDIUM values reported by MSFT_NetAdapter
+const (
+	ndisMediumWirelessLAN = 1
+	ndisMediumWirelessWAN = 8
+	ndisMediumNative80211 = 9
+)
+
+// msftNetAdapter maps the WMI class behind Get-NetAdapter.
+type msftNetAdapter struct {
+	Name               string
+	Virtual            bool
+	NdisPhysicalMedium uint32
+}
+
+// adapterAddresses returns the IP Helper adapter list. The returned pointers
+// reference the returned buffer, which must be kept alive while in use.
+func adapterAddresses(flags uint32) (*windows.IpAdapterAddresses, []byte) {
+	size := uint32(15 * 1024)
+	for i := 0; i < 3; i++ {
+		buf := make([]byte, size)
+		first := (*windows.IpAdapterAddresses)(unsafe.Pointer(&buf[0]))
+		err := windows.GetAdaptersAddresses(windows.AF_UNSPEC, flags, 0, first, &size)
+		if err == nil {
+			return first, buf
+		}
+		if err != windows.ERROR_BUFFER_OVERFLOW {
+			return nil, nil
+		}
+	}
+	return nil, nil
+}
+
+// readInterfaceKinds classifies adapters by their metadata rather than
+// their (renameable, localized) names. Keyed by friendly name, which is what
+// net.Interfaces reports on Windows.
+func readInterfaceKinds() map[string]string {
+	first, buf := adapterAddresses(0)
+	if first == nil {
+		return nil
+	}
+	defer runtime.KeepAlive(buf)
+
+	var netAdapters []msftNetAdapter
+	queryWMI("netAdapter", "SELECT Name, Virtual, NdisPhysicalMedium FROM MSFT_NetAdapter", &netAdapters, `root\StandardCimv2`)
+	byName := make(map[string]msftNetAdapter, len(netAdapters))
+	for _, a := range netAdapters {
+		byName[a.Name] = a
+	}
+
+	kinds := make(map[string]string)
+	for aa := first; aa != nil; aa = aa.Next {
+		name := windows.UTF16PtrToString(aa.FriendlyName)
+		desc := strings.ToLower(windows.UTF16PtrToString(aa.Description))
+		meta, hasMeta := byName[name]
+
+		switch {
+		case aa.IfType == windows.IF_TYPE_SOFTWARE_LOOPBACK:
+			continue
+		case aa.IfType == windows.IF_TYPE_IEEE80211,
+			meta.NdisPhysicalMedium == ndisMediumWirelessLAN,
+			meta.NdisPhysicalMedium == ndisMediumNative80211:
+			kinds[name] = "Wi-Fi"
+		case aa.IfType == ifTypeWWANPP, aa.IfType == ifTypeWWANPP2,
+			meta.NdisPhysicalMedium == ndisMediumWirelessWAN:
+			kinds[name] = "Cellular"
+		case aa.IfType == windows.IF_TYPE_TUNNEL, aa.IfType == windows.IF_TYPE_PPP,
+			aa.IfType == ifTypePropVirtual, isVPNDriver(desc):
+			kinds[name] = "VPN"
+		case aa.IfType == ifTypeBridge:
+			kinds[name] = "Bridge"
+		case hasMeta && meta.Virtual:
+			kinds[name] = "Virtual" // Hyper-V vEthernet, VMware/VirtualBox host adapters
+		default:
+			kinds[name] = "Ethernet"
+		}
+	}
+	return kinds
+}
+
+// isVPNDriver catches VPN clients that present as plain Ethernet (TAP).
+// Driver descriptions aren't user-renameable, unlike adapter names.
+func isVPNDriver(desc string) bool {
+	for _, d := range []string{"tap-windows", "wireguard", "wintun", "tailscale", "openvpn", "zerotier"} {
+		if strings.Contains(desc, d) {
+			return true
+		}
+	}
+	return false
+}
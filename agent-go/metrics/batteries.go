@@ -0,0 +1,30 @@
+package metrics
+
+// PeripheralBattery is a wireless peripheral's battery reported outside
+// Bluetooth: a keyboard or mouse on a USB receiver (Logitech Unifying and
+// Bolt through HID++), a game controller, or a pen tablet. Shared booth
+// keyboards die at the worst times.
+type PeripheralBattery struct {
+	ID       string `json:"id"` // kernel power supply, e.g. hidpp_battery_0
+	Name     string `json:"name"`
+	Percent  *int   `json:"percent,omitempty"`
+	Level    string `json:"level,omitempty"` // critical, low, normal, high, or full, for devices without a percentage
+	Charging bool   `json:"charging,omitempty"`
+	Low      bool   `json:"low,omitempty"`
+}
+
+// lowBatteries wraps read to flag batteries under threshold percent (0
+// for the default), or reporting a low or critical level.
+func lowBatteries(threshold int, read func() []PeripheralBattery) func() []PeripheralBattery {
+	if threshold == 0 {
+		threshold = defaultLowBattery
+	}
+	return func() []PeripheralBattery {
+		batteries := read()
+		for i := range batteries {
+			b := &batteries[i]
+			b.Low = b.Level == "low" || b.Level == "critical" || (b.Percent != nil && *b.Percent < threshold)
+		}
+		return batteries
+	}
+}
@@ -0,0 +1,68 @@
+//go:build linux
+
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const powerSupplyDir = "/sys/class/power_supply"
+
+// bluetoothHIDBattery matches the kernel's battery for a Bluetooth HID
+// device, named for its address; those are reported with the device in
+// BluetoothDevice instead.
+var bluetoothHIDBattery = regexp.MustCompile(`^hid-([0-9a-f]{2}:){5}[0-9a-f]{2}-battery$`)
+
+// readBatteries lists the kernel's device-scope power supplies: the
+// batteries of peripherals (HID++ receivers, HID devices, controllers),
+// as opposed to the machine's own battery or a UPS.
+func readBatteries() []PeripheralBattery {
+	entries, err := os.ReadDir(powerSupplyDir)
+	if err != nil {
+		return nil
+	}
+	var batteries []PeripheralBattery
+	for _, e := range entries {
+		id := e.Name()
+		read := func(attr string) string {
+			b, _ := os.ReadFile(filepath.Join(powerSupplyDir, id, attr))
+			return strings.TrimSpace(string(b))
+		}
+		if read("scope") != "Device" || bluetoothHIDBattery.MatchString(id) {
+			continue
+		}
+		b := PeripheralBattery{
+			ID:       id,
+			Name:     strings.TrimSpace(read("manufacturer") + " " + read("model_name")),
+			Charging: read("status") == "Charging",
+		}
+		if b.Name == "" {
+			b.Name = id
+		}
+		if n, err := strconv.Atoi(read("capacity")); err == nil {
+			b.Percent = &n
+		} else if level := read("capacity_level"); level != "" && level != "Unknown" {
+			b.Level = strings.ToLower(level)
+		}
+		batteries = append(batteries, b)
+	}
+	return batteries
+}
+
+// hidBatteryPercent reads the kernel's battery for the Bluetooth HID
+// device at address, for devices BlueZ reports no battery for.
+func hidBatteryPercent(address string) *int {
+	b, err := os.ReadFile(filepath.Join(powerSupplyDir, "hid-"+strings.ToLower(address)+"-battery", "capacity"))
+	if err != nil {
+		return nil
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(string(b)))
+	if err != nil {
+		return nil
+	}
+	return &n
+}
@@ -0,0 +1,10 @@
+//go:build windows
+
+package metrics
+
+// readBatteries returns nil: Windows has no common API for receiver-based
+// peripherals, whose charge only the vendor's own software reads.
+// Bluetooth devices report theirs in BluetoothDevice.
+func readBatteries() []PeripheralBattery {
+	return nil
+}
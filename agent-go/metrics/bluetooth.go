@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+)
+
+const defaultLowBattery = 20
+
+// BluetoothDevice is a paired Bluetooth device: a presenter's clicker, a
+// wireless keyboard, headphones. A clicker that dies mid-sermon is a small
+// thing that always gets noticed.
+type BluetoothDevice struct {
+	Name           string `json:"name"`
+	Address        string `json:"address,omitempty"` // 00:11:22:33:44:55
+	LowEnergy      bool   `json:"lowEnergy,omitempty"`
+	Connected      bool   `json:"connected"`
+	BatteryPercent *int   `json:"batteryPercent,omitempty"` // when the device reports it
+	// Expected is set for devices the config lists; Missing for one of
+	// them that isn't paired. LowBattery is set under the configured
+	// threshold.
+	Expected   bool `json:"expected,omitempty"`
+	Missing    bool `json:"missing,omitempty"`
+	LowBattery bool `json:"lowBattery,omitempty"`
+}
+
+// Problem describes what is wrong with the device, or "" when nothing is.
+// Only expected devices must be connected; any device can run low.
+func (d BluetoothDevice) Problem() string {
+	switch {
+	case d.Missing:
+		return "not paired"
+	case d.Expected && !d.Connected:
+		return "not connected"
+	case d.LowBattery:
+		return fmt.Sprintf("battery at %d%%", *d.BatteryPercent)
+	}
+	return ""
+}
+
+// BluetoothChecker lists the paired devices and applies the config.
+type BluetoothChecker struct {
+	cfg  config.BluetoothConfig
+	read func() []BluetoothDevice
+}
+
+// NewBluetoothChecker creates a checker for cfg reading devices with read.
+func NewBluetoothChecker(cfg config.BluetoothConfig, read func() []BluetoothDevice) *BluetoothChecker {
+	return &BluetoothChecker{cfg: cfg, read: read}
+}
+
+// Read returns every paired device, plus a Missing entry for each expected
+// one that isn't paired. Expected devices match by name or address.
+func (c *BluetoothChecker) Read() []BluetoothDevice {
+	if c.cfg.Disabled {
+		return nil
+	}
+	low := c.cfg.LowBatteryPercent
+	if low == 0 {
+		low = defaultLowBattery
+	}
+	devices := c.read()
+	for i := range devices {
+		d := &devices[i]
+		d.LowBattery = d.BatteryPercent != nil && *d.BatteryPercent < low
+	}
+	for _, want := range c.cfg.Expected {
+		found := false
+		for i := range devices {
+			if strings.EqualFold(devices[i].Name, want) || strings.EqualFold(devices[i].Address, want) {
+				devices[i].Expected, found = true, true
+			}
+		}
+		if !found {
+			devices = append(devices, BluetoothDevice{Name: want, Expected: true, Missing: true})
+		}
+	}
+	return devices
+}
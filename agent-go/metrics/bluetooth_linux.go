@@ -0,0 +1,80 @@
+//go:build linux
+
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// readBluetooth lists paired devices with bluetoothctl (BlueZ). Battery
+// levels come from BlueZ's battery plugin (on by default since 5.48), else
+// from the kernel's HID battery for the device.
+func readBluetooth() []BluetoothDevice {
+	path, err := exec.LookPath("bluetoothctl")
+	if err != nil {
+		return nil
+	}
+	out, err := bluetoothctl(path, "devices", "Paired")
+	if err != nil || !bytes.HasPrefix(bytes.TrimSpace(out), []byte("Device ")) {
+		// BlueZ before 5.65 has a separate command
+		if out, err = bluetoothctl(path, "paired-devices"); err != nil {
+			return nil
+		}
+	}
+	var devices []BluetoothDevice
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.SplitN(strings.TrimSpace(scanner.Text()), " ", 3)
+		if len(fields) < 2 || fields[0] != "Device" {
+			continue
+		}
+		d := BluetoothDevice{Address: fields[1]}
+		if len(fields) == 3 {
+			d.Name = fields[2]
+		}
+		if info, err := bluetoothctl(path, "info", d.Address); err == nil {
+			parseBluetoothInfo(info, &d)
+		}
+		if d.BatteryPercent == nil {
+			d.BatteryPercent = hidBatteryPercent(d.Address)
+		}
+		devices = append(devices, d)
+	}
+	return devices
+}
+
+// parseBluetoothInfo reads `bluetoothctl info` lines such as
+// "Connected: yes" and "Battery Percentage: 0x5a (90)".
+func parseBluetoothInfo(out []byte, d *BluetoothDevice) {
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ": ")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "Alias":
+			d.Name = value
+		case "Connected":
+			d.Connected = value == "yes"
+		case "Battery Percentage":
+			if _, pct, ok := strings.Cut(value, "("); ok {
+				if n, err := strconv.Atoi(strings.TrimSuffix(pct, ")")); err == nil {
+					d.BatteryPercent = &n
+				}
+			}
+		}
+	}
+}
+
+func bluetoothctl(path string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return exec.CommandContext(ctx, path, args...).Output()
+}
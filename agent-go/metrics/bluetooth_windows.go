@@ -0,0 +1,115 @@
+//go:build windows
+
+package metrics
+
+import (
+	"encoding/hex"
+	"regexp"
+	"strings"
+
+	"golang.org/x/sys/windows"
+)
+
+// bluetoothBatteryKey is the battery level Windows shows in Settings
+// ({104ea319-6ee2-4701-bd47-8ddbf425bbe5} 2, a BYTE percentage). It sits on
+// the device node or one of its service nodes, depending on the profile.
+var bluetoothBatteryKey = windows.DEVPROPKEY{
+	FmtID: windows.DEVPROPGUID{
+		Data1: 0x104ea319, Data2: 0x6ee2, Data3: 0x4701,
+		Data4: [8]byte{0xbd, 0x47, 0x8d, 0xdb, 0xf4, 0x25, 0xbb, 0xe5},
+	},
+	PID: 2,
+}
+
+// bluetoothDeviceID matches a paired device's node, e.g.
+// BTHENUM\DEV_001122334455\7&1A2B3C&0&BLUETOOTHDEVICE_001122334455 (classic)
+// or BTHLE\DEV_001122334455\... (Low Energy).
+var bluetoothDeviceID = regexp.MustCompile(`^(BTHENUM|BTHLE)\\DEV_([0-9A-F]{12})\\`)
+
+// bluetoothServiceEnumerators hold the device, profile, and GATT service
+// nodes of paired devices, which carry the battery level for some devices.
+var bluetoothServiceEnumerators = []string{"BTHENUM", "BTHLE", "BTHLEDEVICE", "BTHHFENUM"}
+
+// readBluetooth lists paired devices from the PnP tree. A device out of
+// range or switched off stays in the tree, flagged disconnected. Battery
+// levels come from the property store, else the GATT Battery Service.
+func readBluetooth() []BluetoothDevice {
+	var devices []BluetoothDevice
+	var macs []string
+	for _, enumerator := range []string{"BTHENUM", "BTHLE"} {
+		eachDevNode(enumerator, func(id string, devInst windows.DEVINST, name string) {
+			m := bluetoothDeviceID.FindStringSubmatch(strings.ToUpper(id))
+			if m == nil {
+				return
+			}
+			mac, _ := hex.DecodeString(m[2])
+			var status, problem uint32
+			connected := windows.CM_Get_DevNode_Status(&status, &problem, devInst, 0) == nil &&
+				status&windows.DN_DEVICE_DISCONNECTED == 0
+			devices = append(devices, BluetoothDevice{
+				Name:      name,
+				Address:   formatMAC(mac),
+				LowEnergy: m[1] == "BTHLE",
+				Connected: connected,
+			})
+			macs = append(macs, m[2])
+		})
+	}
+
+	for _, enumerator := range bluetoothServiceEnumerators {
+		eachDevNode(enumerator, func(id string, devInst windows.DEVINST, _ string) {
+			id = strings.ToUpper(id)
+			for i, mac := range macs {
+				if devices[i].BatteryPercent != nil || !strings.Contains(id, mac) {
+					continue
+				}
+				if level, ok := devNodeProperty(devInst, bluetoothBatteryKey); ok && level <= 100 {
+					pct := int(level)
+					devices[i].BatteryPercent = &pct
+				}
+			}
+		})
+	}
+
+	// Devices that don't publish a level in the property store, such as
+	// HID-over-GATT keyboards and mice, are read over GATT.
+	var unread []string
+	for i, d := range devices {
+		if d.BatteryPercent == nil && d.LowEnergy && d.Connected {
+			unread = append(unread, macs[i])
+		}
+	}
+	levels := gattBatteryLevels(unread)
+	for i, mac := range macs {
+		if level, ok := levels[mac]; ok {
+			devices[i].BatteryPercent = &level
+		}
+	}
+	return devices
+}
+
+// eachDevNode calls fn for every device node of an enumerator, present or
+// not, with its instance ID and display name.
+func eachDevNode(enumerator string, fn func(id string, devInst windows.DEVINST, name string)) {
+	devs, err := windows.SetupDiGetClassDevsEx(nil, enumerator, 0, windows.DIGCF_ALLCLASSES, 0, "")
+	if err != nil {
+		return
+	}
+	defer devs.Close()
+	for i := 0; ; i++ {
+		data, err := devs.EnumDeviceInfo(i)
+		if err != nil {
+			return
+		}
+		id, err := devs.DeviceInstanceID(data)
+		if err != nil {
+			continue
+		}
+		name, _ := devs.DeviceRegistryProperty(data, windows.SPDRP_FRIENDLYNAME)
+		if name == nil {
+			name, _ = devs.DeviceRegistryProperty(data, windows.SPDRP_DEVICEDESC)
+		}
+		deviceName, _ := name.(string)
+		fn(id, data.DevInst, deviceName)
+	}
+}
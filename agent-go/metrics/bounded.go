@@ -0,0 +1,101 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// readTimeout bounds how long a collection waits for its providers. It is
+// below collectInterval so a slow provider can't push the loop back.
+const readTimeout = 3 * time.Second
+
+// reads runs the collection's provider reads in parallel. Each read has a
+// slot keeping its last result. A read that overruns the timeout keeps
+// running; the snapshot reports the slot's previous value as stale, and the
+// slot isn't read again until the stuck call returns, so a hung WMI query
+// costs one goroutine rather than one per tick.
+type reads struct {
+	mu    sync.Mutex
+	slots map[string]*slot
+}
+
+type slot struct {
+	value any
+	busy  bool
+	done  chan struct{} // closed when the read in flight returns
+}
+
+func newReads() *reads {
+	return &reads{slots: make(map[string]*slot)}
+}
+
+// pending is a read started by launch.
+type pending[T any] struct {
+	r    *reads
+	name string
+	s    *slot
+}
+
+// launch starts read under name unless the previous one is still running.
+func launch[T any](r *reads, name string, read func() T) pending[T] {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.slots[name]
+	if s == nil {
+		s = &slot{}
+		r.slots[name] = s
+	}
+	if !s.busy {
+		s.busy = true
+		done := make(chan struct{})
+		s.done = done
+		go func() {
+			v := read()
+			r.mu.Lock()
+			s.value, s.busy = v, false
+			r.mu.Unlock()
+			close(done)
+		}()
+	}
+	return pending[T]{r, name, s}
+}
+
+// launchUnless is launch, except that when skip is set it starts nothing
+// and the pending result is the slot's last value.
+func launchUnless[T any](skip bool, r *reads, name string, read func() T) pending[T] {
+	if !skip {
+		return launch(r, name, read)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.slots[name]
+	if s == nil {
+		done := make(chan struct{})
+		close(done)
+		s = &slot{done: done}
+		r.slots[name] = s
+	}
+	return pending[T]{r, name, s}
+}
+
+// wait returns the read's result, or the slot's previous value (the zero
+// value if there is none) when ctx expires first, appending its name to
+// stale.
+func (p pending[T]) wait(ctx context.Context, stale *[]string) T {
+	p.r.mu.Lock()
+	done := p.s.done
+	p.r.mu.Unlock()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+
+	p.r.mu.Lock()
+	defer p.r.mu.Unlock()
+	if p.s.busy {
+		*stale = append(*stale, p.name)
+	}
+	v, _ := p.s.value.(T)
+	return v
+}
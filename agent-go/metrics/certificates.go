@@ -0,0 +1,143 @@
+package metrics
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+)
+
+const (
+	certCheckInterval   = 24 * time.Hour
+	certRetryInterval   = time.Hour // after a failed connection
+	certDialTimeout     = 10 * time.Second
+	defaultCertWarnDays = 21
+)
+
+// CertificateStatus is the last check of a configured endpoint's
+// certificate. Expires is the earliest expiry in the chain the server
+// presents, so an expiring intermediate counts too.
+type CertificateStatus struct {
+	Name      string     `json:"name"`
+	Address   string     `json:"address"` // host:port
+	Subject   string     `json:"subject,omitempty"`
+	Issuer    string     `json:"issuer,omitempty"`
+	Expires   *time.Time `json:"expires,omitempty"`
+	DaysLeft  int        `json:"daysLeft"`
+	WarnDays  int        `json:"warnDays"`
+	CheckedAt time.Time  `json:"checkedAt"`
+	// Untrusted says why the chain doesn't verify against the system
+	// roots, e.g. a self-signed internal tool. Expiry is still checked.
+	Untrusted string `json:"untrusted,omitempty"`
+	Error     string `json:"error,omitempty"` // the endpoint couldn't be checked
+}
+
+// CertificateChecker checks each configured endpoint once a day, or an
+// hour after a failed connection.
+type CertificateChecker struct {
+	checks []config.CertificateCheck
+	last   []*CertificateStatus
+	due    []time.Time
+}
+
+// NewCertificateChecker creates a checker for the given endpoints.
+func NewCertificateChecker(checks []config.CertificateCheck) *CertificateChecker {
+	return &CertificateChecker{
+		checks: checks,
+		last:   make([]*CertificateStatus, len(checks)),
+		due:    make([]time.Time, len(checks)),
+	}
+}
+
+// Read returns every endpoint's status, first checking those that are due
+// in parallel. Returns nil when none are configured.
+func (c *CertificateChecker) Read() []CertificateStatus {
+	if len(c.checks) == 0 {
+		return nil
+	}
+	now := time.Now()
+	var wg sync.WaitGroup
+	for i, check := range c.checks {
+		if now.Before(c.due[i]) {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status := checkCertificate(check)
+			c.last[i] = status
+			if status.Error != "" {
+				c.due[i] = now.Add(certRetryInterval)
+			} else {
+				c.due[i] = now.Add(certCheckInterval)
+			}
+		}()
+	}
+	wg.Wait()
+
+	results := make([]CertificateStatus, 0, len(c.last))
+	for _, status := range c.last {
+		s := *status
+		if s.Expires != nil {
+			s.DaysLeft = int(time.Until(*s.Expires).Hours() / 24)
+		}
+		results = append(results, s)
+	}
+	return results
+}
+
+func checkCertificate(check config.CertificateCheck) *CertificateStatus {
+	status := &CertificateStatus{
+		Name:      check.Name,
+		WarnDays:  check.WarnDays,
+		CheckedAt: time.Now(),
+	}
+	if status.Name == "" {
+		status.Name = check.URL
+	}
+	if status.WarnDays == 0 {
+		status.WarnDays = defaultCertWarnDays
+	}
+	addr, err := config.CertificateAddress(check.URL)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	status.Address = addr
+	host, _, _ := net.SplitHostPort(addr)
+
+	// Verification is done below rather than by the handshake, so an
+	// untrusted certificate still reports its expiry.
+	dialer := &net.Dialer{Timeout: certDialTimeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{ServerName: host, InsecureSkipVerify: true})
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	certs := conn.ConnectionState().PeerCertificates
+	conn.Close()
+	if len(certs) == 0 {
+		status.Error = "no certificate presented"
+		return status
+	}
+
+	leaf := certs[0]
+	status.Subject = leaf.Subject.CommonName
+	status.Issuer = leaf.Issuer.CommonName
+	expires := leaf.NotAfter
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+		if cert.NotAfter.Before(expires) {
+			expires = cert.NotAfter
+		}
+	}
+	status.Expires = &expires
+	if _, err := leaf.Verify(x509.VerifyOptions{DNSName: host, Intermediates: intermediates}); err != nil {
+		status.Untrusted = err.Error()
+	}
+	return status
+}
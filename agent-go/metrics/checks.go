@@ -0,0 +1,130 @@
+package metrics
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+)
+
+// CheckResult is the outcome of one configured custom check.
+type CheckResult struct {
+	Name   string `json:"name"`
+	Kind   string `json:"kind"` // "file" or "listener"
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"` // why the check failed
+}
+
+// FileChecker runs the configured file integrity checks. Hashes are cached
+// by size and modification time so large libraries are only re-read when
+// they change.
+type FileChecker struct {
+	checks []config.FileCheck
+	hashes map[string]fileHash
+}
+
+type fileHash struct {
+	size    int64
+	modTime time.Time
+	sum     string
+}
+
+// NewFileChecker creates a checker for the given file checks.
+func NewFileChecker(checks []config.FileCheck) *FileChecker {
+	return &FileChecker{checks: checks, hashes: make(map[string]fileHash)}
+}
+
+// Read runs every check, returning nil when none are configured.
+func (f *FileChecker) Read() []CheckResult {
+	if len(f.checks) == 0 {
+		return nil
+	}
+	results := make([]CheckResult, 0, len(f.checks))
+	for _, check := range f.checks {
+		name := check.Name
+		if name == "" {
+			name = check.Path
+		}
+		result := CheckResult{Name: name, Kind: "file", OK: true}
+		if detail := f.run(check); detail != "" {
+			result.OK, result.Detail = false, detail
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// run returns a failure description, or "" when the check passes.
+func (f *FileChecker) run(check config.FileCheck) string {
+	info, err := os.Stat(check.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "missing"
+		}
+		return err.Error()
+	}
+
+	if check.SHA256 != "" {
+		if info.IsDir() {
+			return "sha256 configured for a folder"
+		}
+		sum, err := f.hash(check.Path, info)
+		if err != nil {
+			return err.Error()
+		}
+		if !strings.EqualFold(sum, check.SHA256) {
+			return "hash mismatch"
+		}
+	}
+
+	if check.MaxAgeDays > 0 {
+		modified := info.ModTime()
+		if info.IsDir() {
+			modified = newestModTime(check.Path)
+		}
+		if age := time.Since(modified); age > time.Duration(check.MaxAgeDays)*24*time.Hour {
+			return fmt.Sprintf("not modified in %d days", int(age.Hours()/24))
+		}
+	}
+	return ""
+}
+
+func (f *FileChecker) hash(path string, info os.FileInfo) (string, error) {
+	if cached, ok := f.hashes[path]; ok && cached.size == info.Size() && cached.modTime.Equal(info.ModTime()) {
+		return cached.sum, nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+	f.hashes[path] = fileHash{size: info.Size(), modTime: info.ModTime(), sum: sum}
+	return sum, nil
+}
+
+// newestModTime returns the latest modification time of any file under root.
+func newestModTime(root string) time.Time {
+	var newest time.Time
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil && info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+		return nil
+	})
+	return newest
+}
@@ -1,37 +1,116 @@
 package metrics
 
 import (
+	"context"
+	"log"
+	"math"
 	"os"
+	"slices"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/backup"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/display"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/identity"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/keepawake"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/kiosk"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/maintenance"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/obs"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/peerclock"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/planningcenter"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/presence"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/recordcheck"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/routines"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/startup"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/update"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/winupdate"
 )
 
 // MachineStatus is the JSON payload returned by GET /status.
 // Field names and types must match the Swift MachineStatus struct exactly.
 type MachineStatus struct {
-	HardwareUUID     string        `json:"hardwareUUID"`
-	Hostname         string        `json:"hostname"`
-	CPUTempCelsius   float64       `json:"cpuTempCelsius"`
-	CPUUsagePercent  float64       `json:"cpuUsagePercent"`
-	NetworkBytesPS   float64       `json:"networkBytesPerSec"`
-	UptimeSeconds    float64       `json:"uptimeSeconds"`
-	OSVersion        string        `json:"osVersion"`
-	ChipType         string        `json:"chipType"`
-	Networks         []NetworkInfo `json:"networks"`
-	FileVaultEnabled bool          `json:"fileVaultEnabled"`
-	AgentVersion     string        `json:"agentVersion"`
-	RAMUsagePercent  float64       `json:"ramUsagePercent"`
-	RAMTotalGB       float64       `json:"ramTotalGB"`
-	DiskBytesPS      float64       `json:"diskBytesPerSec"`
-	GPUs             []GPUStatus   `json:"gpus,omitempty"`
+	HardwareUUID     string                  `json:"hardwareUUID"`
+	Hostname         string                  `json:"hostname"`
+	Site             *Site                   `json:"site,omitempty"`
+	CPUTempCelsius   float64                 `json:"cpuTempCelsius"`
+	CPUUsagePercent  float64                 `json:"cpuUsagePercent"`
+	NetworkBytesPS   float64                 `json:"networkBytesPerSec"`
+	UptimeSeconds    float64                 `json:"uptimeSeconds"`
+	OSVersion        string                  `json:"osVersion"`
+	ChipType         string                  `json:"chipType"`
+	Networks         []NetworkInfo           `json:"networks"`
+	FileVaultEnabled bool                    `json:"fileVaultEnabled"`
+	AgentVersion     string                  `json:"agentVersion"`
+	RAMUsagePercent  float64                 `json:"ramUsagePercent"`
+	RAMTotalGB       float64                 `json:"ramTotalGB"`
+	DiskBytesPS      float64                 `json:"diskBytesPerSec"`
+	Disks            []DiskIO                `json:"disks,omitempty"`
+	Volumes          []VolumeUsage           `json:"volumes,omitempty"`
+	GPUs             []GPUStatus             `json:"gpus,omitempty"`
+	TimeSync         *TimeSyncStatus         `json:"timeSync,omitempty"`
+	Multicast        *MulticastStatus        `json:"multicast,omitempty"`
+	Power            *PowerSettings          `json:"power,omitempty"`
+	Defender         *DefenderStatus         `json:"defender,omitempty"`
+	Recording        []RecordingFolderStatus `json:"recording,omitempty"`
+	WindowsUpdate    *winupdate.Status       `json:"windowsUpdate,omitempty"`
+	CPUFrequency     *CPUFrequency           `json:"cpuFrequency,omitempty"`
+	Hardware         *HardwareInventory      `json:"hardware,omitempty"`
+	PCIeLinks        []PCIeLink              `json:"pcieLinks,omitempty"`
+	Service          *planningcenter.Context `json:"service,omitempty"`
+	CustomChecks     []CheckResult           `json:"customChecks,omitempty"`
+	Certificates     []CertificateStatus     `json:"certificates,omitempty"`
+	Licenses         []LicenseStatus         `json:"licenses,omitempty"`
+	VMs              []VMGuest               `json:"vms,omitempty"`
+	Containers       []ContainerStatus       `json:"containers,omitempty"`
+	Bluetooth        []BluetoothDevice       `json:"bluetooth,omitempty"`
+	Batteries        []PeripheralBattery     `json:"batteries,omitempty"`
+	Cooling          *CoolingTrend           `json:"cooling,omitempty"`
+	ConfigDrift      *ConfigDrift            `json:"configDrift,omitempty"`
+	AgentHealth      *AgentHealth            `json:"agentHealth,omitempty"`
+	Maintenance      *maintenance.Window     `json:"maintenance,omitempty"`
+	PeerClock        *peerclock.Status       `json:"peerClock,omitempty"`
+	KeepAwake        *keepawake.Status       `json:"keepAwake,omitempty"`
+	Kiosk            *kiosk.Status           `json:"kiosk,omitempty"`
+	Routines         []routines.Status       `json:"routines,omitempty"`
+	StartupApps      []startup.Status        `json:"startupApps,omitempty"`
+	OBS              *obs.Status             `json:"obs,omitempty"`
+	Backup           *backup.Result          `json:"backup,omitempty"` // latest run
+	RecordingCheck   *recordcheck.Result     `json:"recordingCheck,omitempty"`
+	LastUpdate       *update.Result          `json:"lastUpdate,omitempty"`
+	UpdateChannel    string                  `json:"updateChannel,omitempty"` // "stable" or "beta"
+	ForegroundWindow *ForegroundWindow       `json:"foregroundWindow,omitempty"`
+	Displays         []display.Output        `json:"displays,omitempty"`
+	LastChanged      []identity.Change       `json:"lastChanged,omitempty"`
+	Simulated        string                  `json:"simulated,omitempty"` // --simulate scenario; metrics are fake
+	// InputIdleSeconds is the time since the last keyboard or mouse input;
+	// nil where input can't be seen. OperatorPresent is set while it is
+	// under five minutes; automatic fixes should wait for a person then.
+	InputIdleSeconds *float64 `json:"inputIdleSeconds,omitempty"`
+	OperatorPresent  bool     `json:"operatorPresent,omitempty"`
+	// Stale names the providers that didn't answer within the collection
+	// timeout; their fields hold the previous value.
+	Stale []string `json:"stale,omitempty"`
+}
+
+// Site is the campus from the config. Dashboards key machines by
+// hardwareUUID; Site is for grouping and display.
+type Site struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
 }
 
 // NetworkInfo describes a single network interface.
 type NetworkInfo struct {
-	InterfaceName string `json:"interfaceName"`
-	IPAddress     string `json:"ipAddress"`
-	MACAddress    string `json:"macAddress"`
-	InterfaceType string `json:"interfaceType"`
+	InterfaceName string    `json:"interfaceName"`
+	IPAddress     string    `json:"ipAddress"`
+	MACAddress    string    `json:"macAddress"`
+	InterfaceType string    `json:"interfaceType"`
+	Role          string    `json:"role,omitempty"` // from config, e.g. "dante"
+	IPConfig      *IPConfig `json:"ipConfig,omitempty"`
+	// Subnet is set when the config declares subnets for the interface.
+	Subnet *SubnetCheck `json:"subnet,omitempty"`
 }
 
 // Collector gathers system metrics periodically and exposes a thread-safe snapshot.
@@ -39,71 +118,523 @@ type Collector struct {
 	mu      sync.RWMutex
 	current MachineStatus
 	version string
+	cfg     *config.Config
+
+	lastCollected time.Time
+	lastDuration  time.Duration
+	driverList    []DriverInfo
+	lastStale     string // stale providers last logged
 
 	// Cached at init (don't change during runtime)
-	hardwareUUID    string
-	chipType        string
-	diskEncrypted   bool
+	hardwareUUID  string
+	chipType      string
+	diskEncrypted bool
+	hardware      *HardwareInventory
+
+	providers   Providers
+	reads       *reads
+	igmp        *IGMPMonitor
+	recording   *RecordingMonitor
+	services    *planningcenter.Schedule
+	maintenance *maintenance.Mode
+	peerClock   *peerclock.Monitor
+	keepAwake   *keepawake.Monitor
+	kiosk       *kiosk.Monitor
+	routines    *routines.Scheduler
+	startup     *startup.Checker
+	obs         *obs.Monitor
+	backup      *backup.Manager
+	updater     *update.Updater
+	recordCheck *recordcheck.Checker
+	displays    *display.Monitor
+	identity    *identity.Monitor
+	simulation  *Simulation
+	throttle    int           // see SetThrottle
+	cooling     *CoolingTrend // see SetCooling
+	configDrift *ConfigDrift  // see SetConfigDrift
+	foreground  foregroundTracker
+	// source replaces the machine readers; see NewCollectorFrom.
+	source func() MachineStatus
+
+	// Slow probes refreshed on their own schedule
+	osVersion *refresher[string]
+	timeSync  *refresher[*TimeSyncStatus]
+	ifKinds   *refresher[map[string]string]
+	ipConfigs *refresher[map[string]*IPConfig]
+	power     *refresher[*PowerSettings]
+	defender  *refresher[*DefenderStatus]
+	winUpdate *refresher[*winupdate.Status]
+	cpuFreq   *refresher[*CPUFrequency]
+	drivers   *refresher[[]DriverInfo]
+	links     *refresher[[]PCIeLink]
+	volumes   *refresher[[]VolumeUsage]
+	fileCheck *refresher[[]CheckResult]
+	listeners *refresher[[]CheckResult]
+	certs     *refresher[[]CertificateStatus]
+	licenses  *refresher[[]LicenseStatus]
+	vms       *refresher[[]VMGuest]
+	container *refresher[[]ContainerStatus]
+	bluetooth *refresher[[]BluetoothDevice]
+	batteries *refresher[[]PeripheralBattery]
+}
 
-	netTracker  *NetworkTracker
-	diskTracker *DiskTracker
-	cpuReader   *CPUReader
+// NewCollector creates a new metrics collector with the given agent version string
+// and configuration.
+func NewCollector(version string, cfg *config.Config) *Collector {
+	return NewCollectorWith(version, cfg, DefaultProviders(cfg))
 }
 
-// NewCollector creates a new metrics collector with the given agent version string.
-func NewCollector(version string) *Collector {
+// NewCollectorWith creates a collector reading from p instead of the
+// platform's providers.
+func NewCollectorWith(version string, cfg *config.Config, p Providers) *Collector {
 	c := &Collector{
-		version:      version,
-		hardwareUUID: readHardwareUUID(),
-		chipType:     readChipType(),
-		diskEncrypted: checkDiskEncryption(),
-		netTracker:    NewNetworkTracker(),
-		diskTracker:   NewDiskTracker(),
-		cpuReader:     NewCPUReader(),
+		version:       version,
+		cfg:           cfg,
+		providers:     p,
+		reads:         newReads(),
+		hardwareUUID:  p.System.HardwareUUID(),
+		chipType:      p.System.ChipType(),
+		diskEncrypted: p.System.DiskEncrypted(),
+		hardware:      p.System.Inventory(),
+		igmp:          NewIGMPMonitor(),
+		recording:     NewRecordingMonitor(cfg.Recording.Folders),
+		services:      planningcenter.New(cfg.PlanningCenter),
+		defender:      newRefresher(15*time.Second, p.OS.Defender),
+		winUpdate:     newRefresher(60*time.Second, p.OS.WindowsUpdate),
+		cpuFreq:       newRefresher(10*time.Second, p.CPU.ReadFrequency),
+		drivers:       newRefresher(time.Hour, p.OS.Drivers),
+		links:         newRefresher(60*time.Second, p.OS.PCIeLinks),
+		volumes:       newRefresher(60*time.Second, p.Storage.Volumes),
+		fileCheck:     newRefresher(60*time.Second, NewFileChecker(cfg.Checks.Files).Read),
+		listeners:     newRefresher(30*time.Second, NewListenerChecker(cfg.Checks.Listeners).Read),
+		certs:         newRefresher(time.Minute, NewCertificateChecker(cfg.Checks.Certificates).Read), // checks each endpoint daily
+		licenses:      newRefresher(60*time.Second, NewLicenseChecker(cfg.Checks.Licenses).Read),
+		vms:           newRefresher(30*time.Second, p.OS.VMs),
+		container:     newRefresher(30*time.Second, NewContainerChecker(cfg.Containers).Read),
+		bluetooth:     newRefresher(30*time.Second, NewBluetoothChecker(cfg.Bluetooth, p.OS.Bluetooth).Read),
+		batteries:     newRefresher(60*time.Second, lowBatteries(cfg.Bluetooth.LowBatteryPercent, p.OS.Batteries)),
+		timeSync:      newRefresher(30*time.Second, p.OS.TimeSync),
+		osVersion:     newRefresher(time.Hour, p.OS.OSVersion), // changes only with an update and reboot
+		ifKinds:       newRefresher(30*time.Second, p.Network.InterfaceKinds),
+		ipConfigs:     newRefresher(30*time.Second, p.Network.IPConfigs),
 	}
+	c.power = newRefresher(60*time.Second, c.readAndRemediatePower)
 	c.collect()
 	return c
 }
 
-// Start runs the collection loop every 5 seconds. Blocks forever.
-func (c *Collector) Start() {
-	ticker := time.NewTicker(5 * time.Second)
+// NewCollectorFrom creates a collector whose snapshots come from source
+// instead of the machine, for tests and tools that need an agent without
+// real hardware. Nothing is read from WMI or /proc; subsystems attached
+// with the Set methods work as usual.
+func NewCollectorFrom(version string, cfg *config.Config, source func() MachineStatus) *Collector {
+	c := &Collector{version: version, cfg: cfg, source: source}
+	c.collect()
+	return c
+}
+
+// SetMaintenance attaches the maintenance mode reported in /status.
+func (c *Collector) SetMaintenance(m *maintenance.Mode) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maintenance = m
+}
+
+// SetPeerClock attaches the peer clock comparison reported in /status.
+func (c *Collector) SetPeerClock(m *peerclock.Monitor) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.peerClock = m
+}
+
+// SetKeepAwake attaches the keep-awake state reported in /status.
+func (c *Collector) SetKeepAwake(m *keepawake.Monitor) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.keepAwake = m
+}
+
+// SetKiosk attaches the signage player watchdog reported in /status.
+func (c *Collector) SetKiosk(m *kiosk.Monitor) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.kiosk = m
+}
+
+// SetRoutines attaches the power routines reported in /status.
+func (c *Collector) SetRoutines(s *routines.Scheduler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.routines = s
+}
+
+// SetStartup attaches the startup app checks reported in /status.
+func (c *Collector) SetStartup(s *startup.Checker) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.startup = s
+}
+
+// SetOBS attaches the OBS checks reported in /status.
+func (c *Collector) SetOBS(m *obs.Monitor) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.obs = m
+}
+
+// SetBackup attaches the application data backups reported in /status.
+func (c *Collector) SetBackup(m *backup.Manager) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.backup = m
+}
+
+// SetUpdater attaches the updater whose latest outcome and channel are
+// reported in /status.
+func (c *Collector) SetUpdater(u *update.Updater) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.updater = u
+}
+
+// SetRecordCheck attaches the recording checks reported in /status.
+func (c *Collector) SetRecordCheck(r *recordcheck.Checker) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.recordCheck = r
+}
+
+// ServiceContext returns the Planning Center service phase at now, or nil
+// when the integration is not configured.
+func (c *Collector) ServiceContext(now time.Time) *planningcenter.Context {
+	return c.services.Context(now)
+}
+
+// Simulate replaces the headline metrics with sim's scripted values from
+// the next collection on.
+func (c *Collector) Simulate(sim *Simulation) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.simulation = sim
+}
+
+// SetDisplays attaches the display mode monitor reported in /status.
+func (c *Collector) SetDisplays(m *display.Monitor) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.displays = m
+}
+
+// SetIdentity attaches the hostname and address changes reported in
+// /status.
+func (c *Collector) SetIdentity(m *identity.Monitor) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.identity = m
+}
+
+// Throttle levels, set by the agent's resource budget guard.
+const (
+	ThrottleNone      = iota
+	ThrottleSlow      // collect every 10 seconds instead of 5
+	ThrottleEssential // also stop the optional reads in optionalReads
+)
+
+// optionalReads are skipped at ThrottleEssential; their last values are
+// reported until the level drops.
+var optionalReads = []string{"gpus", "multicast", "defender", "windowsUpdate", "cpuFrequency", "pcieLinks", "drivers", "containers", "bluetooth"}
+
+// SetThrottle sets how much collection work to shed.
+func (c *Collector) SetThrottle(level int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.throttle = level
+}
+
+// Shed lists what level gives up, for logs and /healthz.
+func Shed(level int) []string {
+	var shed []string
+	if level >= ThrottleSlow {
+		shed = append(shed, "collectInterval")
+	}
+	if level >= ThrottleEssential {
+		shed = append(shed, optionalReads...)
+	}
+	return shed
+}
+
+func (c *Collector) throttleLevel() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.throttle
+}
+
+// interval returns the time between collections at the current throttle.
+func (c *Collector) interval() time.Duration {
+	if c.throttleLevel() >= ThrottleSlow {
+		return 2 * collectInterval
+	}
+	return collectInterval
+}
+
+// Start runs the collection loop every 5 seconds (10 when throttled) until
+// ctx is cancelled.
+func (c *Collector) Start(ctx context.Context) {
+	if c.source == nil {
+		go c.igmp.Run()
+		go c.recording.Run()
+		go c.services.Run()
+	}
+
+	every := c.interval()
+	ticker := time.NewTicker(every)
 	defer ticker.Stop()
-	for range ticker.C {
-		c.collect()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.collect()
+		}
+		if next := c.interval(); next != every {
+			every = next
+			ticker.Reset(every)
+		}
 	}
 }
 
-// CurrentStatus returns the most recent metrics snapshot.
+// Refresh collects a snapshot now, outside the loop. Rates need two
+// samples, so a one-shot caller refreshes once after NewCollector.
+func (c *Collector) Refresh() {
+	c.collect()
+}
+
+// CurrentStatus returns the most recent metrics snapshot with the agent's
+// live health, maintenance window, peer clock comparison, keep-awake
+// state, kiosk player, power routines, startup apps, OBS checks, latest
+// backup, recording check, and update, display modes, hostname and
+// address changes, cooling trend, and settings drift attached.
 func (c *Collector) CurrentStatus() MachineStatus {
 	c.mu.RLock()
-	defer c.mu.RUnlock()
-	return c.current
+	status := c.current
+	mode := c.maintenance
+	peers := c.peerClock
+	awake := c.keepAwake
+	player := c.kiosk
+	scheduler := c.routines
+	apps := c.startup
+	studio := c.obs
+	backups := c.backup
+	checker := c.recordCheck
+	updater := c.updater
+	displays := c.displays
+	changes := c.identity
+	status.Cooling = c.cooling
+	status.ConfigDrift = c.configDrift
+	c.mu.RUnlock()
+
+	health := c.Health()
+	status.AgentHealth = &health
+	if mode != nil {
+		status.Maintenance = mode.Current()
+	}
+	status.PeerClock = peers.Status()
+	status.KeepAwake = awake.Status()
+	status.Kiosk = player.Status()
+	status.Routines = scheduler.Status()
+	status.StartupApps = apps.Status()
+	status.OBS = studio.Status()
+	status.Backup = backups.Last()
+	status.RecordingCheck = checker.Last()
+	status.LastUpdate = updater.LastUpdate()
+	status.UpdateChannel = updater.Channel()
+	status.Displays = displays.Outputs()
+	status.LastChanged = changes.Changes()
+	return status
 }
 
 func (c *Collector) collect() {
+	started := time.Now()
+	if c.source != nil {
+		status := c.source()
+		status.AgentVersion = c.version
+		c.mu.Lock()
+		c.current = status
+		c.lastCollected = time.Now()
+		c.lastDuration = c.lastCollected.Sub(started)
+		c.mu.Unlock()
+		return
+	}
+	// Providers run in parallel, each bounded by readTimeout; see reads.
+	ctx, cancel := context.WithTimeout(context.Background(), readTimeout)
+	defer cancel()
+	p, r := c.providers, c.reads
+	shed := c.throttleLevel() >= ThrottleEssential
+	temp := launch(r, "cpuTemperature", p.CPU.ReadTemperature)
+	usage := launch(r, "cpuUsage", p.CPU.ReadUsage)
+	netRate := launch(r, "networkRate", p.Network.BytesPerSec)
+	uptime := launch(r, "uptime", p.OS.Uptime)
+	osVersion := launch(r, "osVersion", c.osVersion.Get)
+	networks := launch(r, "networks", func() []NetworkInfo {
+		networks := p.Network.Interfaces(c.ifKinds.Get(), c.cfg.Network.Roles)
+		applyIPConfigs(networks, c.ipConfigs.Get(), c.cfg.Network)
+		checkSubnets(networks, c.cfg.Network)
+		return networks
+	})
+	memory := launch(r, "memory", func() [2]float64 {
+		percent, total := p.Memory.ReadMemory()
+		return [2]float64{percent, total}
+	})
+	disk := launch(r, "disks", func() diskSample {
+		rate, disks := p.Storage.Sample()
+		return diskSample{rate, disks}
+	})
+	volumes := launch(r, "volumes", c.volumes.Get)
+	gpus := launchUnless(shed, r, "gpus", p.GPU.GPUs)
+	timeSync := launch(r, "timeSync", c.timeSync.Get)
+	multicast := launchUnless(shed, r, "multicast", func() *MulticastStatus {
+		return readMulticast(c.igmp, c.cfg.Multicast, c.cfg.Network.Roles)
+	})
+	power := launch(r, "power", c.readPower)
+	defender := launchUnless(shed, r, "defender", c.defender.Get)
+	winUpdate := launchUnless(shed, r, "windowsUpdate", c.winUpdate.Get)
+	cpuFreq := launchUnless(shed, r, "cpuFrequency", c.cpuFreq.Get)
+	links := launchUnless(shed, r, "pcieLinks", c.links.Get)
+	fileChecks := launch(r, "fileChecks", c.fileCheck.Get)
+	listenerChecks := launch(r, "listenerChecks", c.listeners.Get)
+	certs := launch(r, "certificates", c.certs.Get)
+	licenses := launch(r, "licenses", c.licenses.Get)
+	vms := launch(r, "vms", c.vms.Get)
+	containers := launchUnless(shed, r, "containers", c.container.Get)
+	bluetooth := launchUnless(shed, r, "bluetooth", c.bluetooth.Get)
+	batteries := launch(r, "batteries", c.batteries.Get)
+	driverList := launchUnless(shed, r, "drivers", c.drivers.Get)
+	foreground := launch(r, "foregroundWindow", c.readForeground)
+	inputIdle := launch(r, "inputIdle", c.readInputIdle)
+
 	hostname, _ := os.Hostname()
-	ramPercent, ramTotal := readMemory()
+	var stale []string
+	mem := memory.wait(ctx, &stale)
+	diskRead := disk.wait(ctx, &stale)
 
 	status := MachineStatus{
 		HardwareUUID:     c.hardwareUUID,
 		Hostname:         hostname,
-		CPUTempCelsius:   c.cpuReader.ReadTemperature(),
-		CPUUsagePercent:  c.cpuReader.ReadUsage(),
-		NetworkBytesPS:   c.netTracker.BytesPerSec(),
-		UptimeSeconds:    readUptime(),
-		OSVersion:        readOSVersion(),
+		Site:             c.site(),
+		CPUTempCelsius:   temp.wait(ctx, &stale),
+		CPUUsagePercent:  usage.wait(ctx, &stale),
+		NetworkBytesPS:   netRate.wait(ctx, &stale),
+		UptimeSeconds:    uptime.wait(ctx, &stale),
+		OSVersion:        osVersion.wait(ctx, &stale),
 		ChipType:         c.chipType,
-		Networks:         readNetworkInterfaces(),
+		Networks:         networks.wait(ctx, &stale),
 		FileVaultEnabled: c.diskEncrypted,
 		AgentVersion:     c.version,
-		RAMUsagePercent:  ramPercent,
-		RAMTotalGB:       ramTotal,
-		DiskBytesPS:      c.diskTracker.BytesPerSec(),
-		GPUs:             readGPUs(),
+		RAMUsagePercent:  mem[0],
+		RAMTotalGB:       mem[1],
+		DiskBytesPS:      diskRead.rate,
+		Disks:            diskRead.disks,
+		Volumes:          volumes.wait(ctx, &stale),
+		GPUs:             gpus.wait(ctx, &stale),
+		TimeSync:         timeSync.wait(ctx, &stale),
+		Multicast:        multicast.wait(ctx, &stale),
+		Power:            power.wait(ctx, &stale),
+		Defender:         defender.wait(ctx, &stale),
+		Recording:        c.recording.Status(),
+		WindowsUpdate:    winUpdate.wait(ctx, &stale),
+		CPUFrequency:     cpuFreq.wait(ctx, &stale),
+		Hardware:         c.hardware,
+		PCIeLinks:        links.wait(ctx, &stale),
+		Service:          c.services.Context(started),
+		CustomChecks:     slices.Concat(fileChecks.wait(ctx, &stale), listenerChecks.wait(ctx, &stale)),
+		Certificates:     certs.wait(ctx, &stale),
+		Licenses:         licenses.wait(ctx, &stale),
+		VMs:              vms.wait(ctx, &stale),
+		Containers:       containers.wait(ctx, &stale),
+		Bluetooth:        bluetooth.wait(ctx, &stale),
+		Batteries:        batteries.wait(ctx, &stale),
+		ForegroundWindow: foreground.wait(ctx, &stale),
+	}
+	if idle := inputIdle.wait(ctx, &stale); idle != nil {
+		seconds := math.Round(idle.Seconds())
+		status.InputIdleSeconds = &seconds
+		status.OperatorPresent = presence.Present(*idle)
 	}
+	drivers := driverList.wait(ctx, &stale)
+	status.Stale = stale
 
 	c.mu.Lock()
+	if c.simulation != nil {
+		c.simulation.apply(&status, started)
+	}
 	c.current = status
+	c.driverList = drivers
+	if names := strings.Join(stale, ", "); names != c.lastStale {
+		if names != "" {
+			log.Printf("Metrics: no answer within %v from %s", readTimeout, names)
+		} else {
+			log.Printf("Metrics: all providers answering again")
+		}
+		c.lastStale = names
+	}
+	c.lastCollected = time.Now()
+	c.lastDuration = c.lastCollected.Sub(started)
 	c.mu.Unlock()
 }
+
+// diskSample is one DiskTracker.Sample result.
+type diskSample struct {
+	rate  float64
+	disks []DiskIO
+}
+
+// site returns the configured site, or nil if none is set.
+func (c *Collector) site() *Site {
+	if c.cfg.Site.ID == "" && c.cfg.Site.Name == "" {
+		return nil
+	}
+	return &Site{ID: c.cfg.Site.ID, Name: c.cfg.Site.Name}
+}
+
+// readAndRemediatePower reads power settings and, if remediation is enabled
+// and the machine has drifted, applies the expected profile and re-reads.
+func (c *Collector) readAndRemediatePower() *PowerSettings {
+	settings := c.providers.OS.PowerSettings()
+	want := c.cfg.Power.Expected
+	if !c.cfg.Power.Remediate || len(powerDrift(settings, want)) == 0 {
+		return settings
+	}
+	remediatePower(settings, want)
+	return c.providers.OS.PowerSettings()
+}
+
+// readForeground returns the foreground window unless reporting it is
+// disabled.
+func (c *Collector) readForeground() *ForegroundWindow {
+	if c.cfg.ForegroundWindow.Disabled {
+		return nil
+	}
+	return c.foreground.read(c.providers.OS, c.cfg.ForegroundWindow.HideTitle)
+}
+
+// readInputIdle returns the input idle time, or nil where it can't be
+// seen.
+func (c *Collector) readInputIdle() *time.Duration {
+	idle, ok := c.providers.OS.InputIdle()
+	if !ok {
+		return nil
+	}
+	return &idle
+}
+
+// readPower returns the cached power settings with drift against the
+// configured profile filled in.
+func (c *Collector) readPower() *PowerSettings {
+	cached := c.power.Get()
+	if cached == nil {
+		return nil
+	}
+	settings := *cached
+	settings.Drift = powerDrift(&settings, c.cfg.Power.Expected)
+	return &settings
+}
@@ -0,0 +1,146 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+)
+
+// containerTimeout bounds each CLI call; docker hangs rather than fails
+// while Docker Desktop is starting.
+const containerTimeout = 10 * time.Second
+
+// containerCLIs are tried in order: Docker (Desktop or Engine), then
+// nerdctl for plain containerd. Both print Docker's inspect format.
+var containerCLIs = []string{"docker", "nerdctl"}
+
+// ContainerStatus is a container on this machine. Companion, Node-RED, and
+// stream relays run in containers and fail on their own while the host
+// looks fine.
+type ContainerStatus struct {
+	Name         string     `json:"name"`
+	Image        string     `json:"image"`
+	State        string     `json:"state"`            // running, exited, restarting, paused, created, dead
+	Health       string     `json:"health,omitempty"` // healthy, unhealthy, starting; empty without a healthcheck
+	RestartCount int        `json:"restartCount"`
+	StartedAt    *time.Time `json:"startedAt,omitempty"`
+	// Expected is set for containers the config lists; Missing for one of
+	// them that doesn't exist.
+	Expected bool `json:"expected,omitempty"`
+	Missing  bool `json:"missing,omitempty"`
+}
+
+// Problem describes what is wrong with the container, or "" when nothing
+// is. Any container restarting or unhealthy is a problem; an expected one
+// must also be running.
+func (c ContainerStatus) Problem() string {
+	switch {
+	case c.Missing:
+		return "not found"
+	case c.State == "restarting":
+		return "restarting"
+	case c.Health == "unhealthy":
+		return "unhealthy"
+	case c.Expected && c.State != "running":
+		return c.State
+	}
+	return ""
+}
+
+// ContainerChecker lists the machine's containers.
+type ContainerChecker struct {
+	cfg config.ContainersConfig
+}
+
+// NewContainerChecker creates a checker for cfg.
+func NewContainerChecker(cfg config.ContainersConfig) *ContainerChecker {
+	return &ContainerChecker{cfg: cfg}
+}
+
+// Read returns every container, plus a Missing entry for each expected one
+// that doesn't exist. It returns nil when no container CLI is installed or
+// its daemon isn't answering, unless containers are expected.
+func (c *ContainerChecker) Read() []ContainerStatus {
+	if c.cfg.Disabled {
+		return nil
+	}
+	containers := listContainers()
+	for _, name := range c.cfg.Expected {
+		found := false
+		for i := range containers {
+			if strings.EqualFold(containers[i].Name, name) {
+				containers[i].Expected, found = true, true
+			}
+		}
+		if !found {
+			containers = append(containers, ContainerStatus{Name: name, Expected: true, Missing: true})
+		}
+	}
+	return containers
+}
+
+func listContainers() []ContainerStatus {
+	for _, cli := range containerCLIs {
+		path, err := exec.LookPath(cli)
+		if err != nil {
+			continue
+		}
+		ids, err := runContainerCLI(path, "ps", "--all", "--quiet")
+		if err != nil {
+			return nil
+		}
+		if len(strings.Fields(string(ids))) == 0 {
+			return nil
+		}
+		out, err := runContainerCLI(path, append([]string{"inspect"}, strings.Fields(string(ids))...)...)
+		if err != nil {
+			return nil
+		}
+		return parseContainers(out)
+	}
+	return nil
+}
+
+func runContainerCLI(path string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), containerTimeout)
+	defer cancel()
+	return exec.CommandContext(ctx, path, args...).Output()
+}
+
+// parseContainers reads `docker inspect` output.
+func parseContainers(out []byte) []ContainerStatus {
+	var inspected []struct {
+		Name         string
+		RestartCount int
+		Config       struct{ Image string }
+		State        struct {
+			Status    string
+			StartedAt time.Time
+			Health    *struct{ Status string }
+		}
+	}
+	if err := json.Unmarshal(out, &inspected); err != nil {
+		return nil
+	}
+	var containers []ContainerStatus
+	for _, in := range inspected {
+		ct := ContainerStatus{
+			Name:         strings.TrimPrefix(in.Name, "/"),
+			Image:        in.Config.Image,
+			State:        in.State.Status,
+			RestartCount: in.RestartCount,
+		}
+		if in.State.Health != nil {
+			ct.Health = in.State.Health.Status
+		}
+		if started := in.State.StartedAt; started.Year() > 1 {
+			ct.StartedAt = &started
+		}
+		containers = append(containers, ct)
+	}
+	return containers
+}
@@ -0,0 +1,23 @@
+package metrics
+
+import "time"
+
+// CoolingTrend compares recent CPU temperatures with the machine's own
+// baseline at similar load, computed from the metrics history. A failing
+// fan or a clogged filter shows up as a slow drift weeks before a thermal
+// shutdown.
+type CoolingTrend struct {
+	BaselineCelsius  float64   `json:"baselineCelsius"`
+	RecentCelsius    float64   `json:"recentCelsius"`
+	DeviationCelsius float64   `json:"deviationCelsius"`
+	Suspect          bool      `json:"suspect"` // possible cooling failure
+	CheckedAt        time.Time `json:"checkedAt"`
+}
+
+// SetCooling records the latest cooling trend, reported in /status. Nil
+// clears it, e.g. while there isn't enough history.
+func (c *Collector) SetCooling(trend *CoolingTrend) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cooling = trend
+}
@@ -2,32 +2,51 @@
 
 package metrics
 
-import (
-	"github.com/yusufpapurcu/wmi"
-)
-
 // thermalZone maps WMI MSAcpi_ThermalZoneTemperature fields.
 type thermalZone struct {
 	CurrentTemperature uint32
 }
 
-// ReadTemperature reads CPU temperature via WMI MSAcpi_ThermalZoneTemperature.
+// thermalZoneCounter maps the Thermal Zone Information performance
+// counters, which ARM64 devices (Snapdragon) publish when they have no
+// ACPI thermal zone in root\WMI.
+type thermalZoneCounter struct {
+	HighPrecisionTemperature uint32
+}
+
+// ReadTemperature reads CPU temperature via WMI MSAcpi_ThermalZoneTemperature,
+// falling back to the thermal zone performance counters.
 // Returns -1 if unavailable (common without admin privileges or on unsupported hardware).
-// WMI returns tenths of Kelvin; converted to Celsius: (val / 10) - 273.15
+// Both report tenths of Kelvin; converted to Celsius: (val / 10) - 273.15
 func (r *CPUReader) ReadTemperature() float64 {
+	var tenthsK []uint32
+
 	var zones []thermalZone
-	err := wmi.QueryNamespace(
+	err := queryWMI(
+		"temperature",
 		"SELECT CurrentTemperature FROM MSAcpi_ThermalZoneTemperature",
 		&zones,
 		`root\WMI`,
 	)
+	for _, z := range zones {
+		tenthsK = append(tenthsK, z.CurrentTemperature)
+	}
 	if err != nil || len(zones) == 0 {
-		return -1
+		var counters []thermalZoneCounter
+		queryWMI(
+			"temperatureCounters",
+			"SELECT HighPrecisionTemperature FROM Win32_PerfFormattedData_Counters_ThermalZoneInformation",
+			&counters,
+			"",
+		)
+		for _, c := range counters {
+			tenthsK = append(tenthsK, c.HighPrecisionTemperature)
+		}
 	}
 
 	var maxTemp float64 = -1
-	for _, z := range zones {
-		celsius := float64(z.CurrentTemperature)/10.0 - 273.15
+	for _, t := range tenthsK {
+		celsius := float64(t)/10.0 - 273.15
 		if celsius > 0 && celsius < 150 && celsius > maxTemp {
 			maxTemp = celsius
 		}
@@ -0,0 +1,17 @@
+package metrics
+
+// CPUFrequency reports clock behaviour that correlates with audio dropouts:
+// running below base clock, parked cores, and OS power throttling.
+type CPUFrequency struct {
+	CurrentMHz  float64 `json:"currentMHz"`
+	BaseMHz     float64 `json:"baseMHz"`
+	TurboActive bool    `json:"turboActive"`
+	LogicalCPUs int     `json:"logicalCpus"`
+	ParkedCores int     `json:"parkedCores"` // Windows core parking; offline CPUs on Linux
+	// MinUnparkedPercent is the power plan's "minimum cores unparked"
+	// setting (Windows only, -1 if unknown). 100 disables parking.
+	MinUnparkedPercent int `json:"minUnparkedPercent"`
+	// PowerThrottlingDisabled is true when Windows power throttling
+	// (EcoQoS for background processes) is turned off machine-wide.
+	PowerThrottlingDisabled bool `json:"powerThrottlingDisabled"`
+}
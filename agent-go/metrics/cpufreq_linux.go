@@ -0,0 +1,67 @@
+//go:build linux
+
+package metrics
+
+import (
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// readCPUFrequency averages cpufreq's current clock across CPUs and compares
+// it with the base (or, without intel_pstate, the maximum) frequency.
+// Returns nil if cpufreq isn't exposed (common in VMs).
+func readCPUFrequency() *CPUFrequency {
+	dirs, _ := filepath.Glob("/sys/devices/system/cpu/cpu[0-9]*/cpufreq")
+	if len(dirs) == 0 {
+		return nil
+	}
+
+	f := &CPUFrequency{MinUnparkedPercent: -1, LogicalCPUs: runtime.NumCPU()}
+	var sum float64
+	var n int
+	for _, dir := range dirs {
+		kHz := readSysInt(filepath.Join(dir, "scaling_cur_freq"))
+		if kHz <= 0 {
+			continue
+		}
+		sum += float64(kHz) / 1000
+		n++
+		if f.BaseMHz == 0 {
+			base := readSysInt(filepath.Join(dir, "base_frequency"))
+			if base <= 0 {
+				base = readSysInt(filepath.Join(dir, "cpuinfo_max_freq"))
+			}
+			f.BaseMHz = float64(base) / 1000
+		}
+	}
+	if n == 0 {
+		return nil
+	}
+	f.CurrentMHz = sum / float64(n)
+	f.TurboActive = f.BaseMHz > 0 && f.CurrentMHz > f.BaseMHz
+	f.ParkedCores = countCPUs(readSysString("/sys/devices/system/cpu/present")) -
+		countCPUs(readSysString("/sys/devices/system/cpu/online"))
+	return f
+}
+
+// countCPUs counts CPUs in a sysfs range list like "0-3,6,8-9".
+func countCPUs(list string) int {
+	var n int
+	for _, part := range strings.Split(list, ",") {
+		lo, hi, isRange := strings.Cut(part, "-")
+		a, err := strconv.Atoi(lo)
+		if err != nil {
+			continue
+		}
+		if !isRange {
+			n++
+			continue
+		}
+		if b, err := strconv.Atoi(hi); err == nil && b >= a {
+			n += b - a + 1
+		}
+	}
+	return n
+}
@@ -0,0 +1,58 @@
+//go:build windows
+
+package metrics
+
+import (
+	"strings"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+const powerThrottlingKey = `SYSTEM\CurrentControlSet\Control\Power\PowerThrottling`
+
+// processorInformation maps per-core and _Total rows of the Processor
+// Information performance counters.
+type processorInformation struct {
+	Name                        string
+	ProcessorFrequency          uint32 // base (nominal) MHz
+	PercentProcessorPerformance uint64 // current as % of base; >100 = turbo
+	ParkingStatus               uint32 // 1 = parked
+}
+
+// readCPUFrequency reads clock and parking state from performance counters
+// and power throttling policy from the registry. Returns nil if the
+// counters are unavailable.
+func readCPUFrequency() *CPUFrequency {
+	var rows []processorInformation
+	err := queryWMI("cpuFrequency",
+		"SELECT Name, ProcessorFrequency, PercentProcessorPerformance, ParkingStatus FROM Win32_PerfFormattedData_Counters_ProcessorInformation",
+		&rows, "")
+	if err != nil || len(rows) == 0 {
+		return nil
+	}
+
+	f := &CPUFrequency{MinUnparkedPercent: acSettingIndex("SUB_PROCESSOR", "CPMINCORES")}
+	for _, r := range rows {
+		if r.Name == "_Total" {
+			f.BaseMHz = float64(r.ProcessorFrequency)
+			f.CurrentMHz = f.BaseMHz * float64(r.PercentProcessorPerformance) / 100
+			continue
+		}
+		// Per-group totals look like "0,_Total"
+		if strings.HasSuffix(r.Name, "_Total") {
+			continue
+		}
+		f.LogicalCPUs++
+		if r.ParkingStatus == 1 {
+			f.ParkedCores++
+		}
+	}
+	f.TurboActive = f.CurrentMHz > f.BaseMHz
+
+	if k, err := registry.OpenKey(registry.LOCAL_MACHINE, powerThrottlingKey, registry.QUERY_VALUE); err == nil {
+		off, _, err := k.GetIntegerValue("PowerThrottlingOff")
+		k.Close()
+		f.PowerThrottlingDisabled = err == nil && off == 1
+	}
+	return f
+}
@@ -0,0 +1,24 @@
+package metrics
+
+// Defender scan activity above either threshold is reported as high impact.
+const (
+	defenderHighCPUPercent = 25
+	defenderHighIOBytesPS  = 20 * 1024 * 1024
+)
+
+// DefenderStatus reports Windows Defender settings and scan activity that
+// can cause dropped frames on capture machines.
+type DefenderStatus struct {
+	RealTimeProtection bool             `json:"realTimeProtection"`
+	Folders            []FolderExcluded `json:"folders,omitempty"`
+	ScanInProgress     bool             `json:"scanInProgress"`
+	EngineCPUPercent   float64          `json:"engineCpuPercent"`
+	EngineIOBytesPS    float64          `json:"engineIoBytesPerSec"`
+	HighImpact         bool             `json:"highImpact"`
+}
+
+// FolderExcluded reports whether a configured folder is excluded from scanning.
+type FolderExcluded struct {
+	Path     string `json:"path"`
+	Excluded bool   `json:"excluded"`
+}
@@ -0,0 +1,16 @@
+//go:build linux
+
+package metrics
+
+// DefenderMonitor is a no-op on Linux.
+type DefenderMonitor struct{}
+
+// NewDefenderMonitor returns a monitor that reports nothing.
+func NewDefenderMonitor(folders []string) *DefenderMonitor {
+	return &DefenderMonitor{}
+}
+
+// Read always returns nil; Windows Defender doesn't exist here.
+func (m *DefenderMonitor) Read() *DefenderStatus {
+	return nil
+}
@@ -1,53 +1,93 @@
 package metrics
 
 import (
+	"sort"
 	"time"
-
-	"github.com/shirou/gopsutil/v4/disk"
 )
 
-// DiskTracker tracks combined read+write bytes for delta-based throughput calculation.
+// DiskIO reports throughput and latency for one physical disk over the last
+// collection interval.
+type DiskIO struct {
+	Name            string  `json:"name"`
+	ReadBytesPS     float64 `json:"readBytesPerSec"`
+	WriteBytesPS    float64 `json:"writeBytesPerSec"`
+	ReadIOPS        float64 `json:"readIOPS"`
+	WriteIOPS       float64 `json:"writeIOPS"`
+	AvgReadLatency  float64 `json:"avgReadLatencyMs"`
+	AvgWriteLatency float64 `json:"avgWriteLatencyMs"`
+}
+
+// diskCounters are one physical disk's cumulative I/O counters. ReadTime
+// and WriteTime are in the platform's unit, timeUnitMs milliseconds each.
+type diskCounters struct {
+	ReadBytes, WriteBytes uint64
+	ReadCount, WriteCount uint64
+	ReadTime, WriteTime   uint64
+	timeUnitMs            float64
+}
+
+// DiskTracker tracks per-disk I/O counters for delta-based throughput,
+// IOPS, and latency calculation.
 type DiskTracker struct {
-	prevBytes uint64
-	prevTime  time.Time
+	prev     map[string]diskCounters
+	prevTime time.Time
 }
 
 // NewDiskTracker creates a new disk throughput tracker.
 func NewDiskTracker() *DiskTracker {
-	return &DiskTracker{}
+	return &DiskTracker{prev: make(map[string]diskCounters)}
 }
 
-// BytesPerSec returns combined read+write bytes/sec across all disk devices.
-func (t *DiskTracker) BytesPerSec() float64 {
-	counters, err := disk.IOCounters()
-	if err != nil || len(counters) == 0 {
-		return 0
-	}
-
-	var totalBytes uint64
-	for _, c := range counters {
-		totalBytes += c.ReadBytes + c.WriteBytes
+// Sample returns combined read+write bytes/sec across all physical disks
+// and the per-disk breakdown. The first sample only establishes a baseline.
+func (t *DiskTracker) Sample() (float64, []DiskIO) {
+	cur, err := readDiskCounters()
+	if err != nil || len(cur) == 0 {
+		return 0, nil
 	}
-
 	now := time.Now()
 
-	defer func() {
-		t.prevBytes = totalBytes
-		t.prevTime = now
-	}()
+	prev, prevTime := t.prev, t.prevTime
+	t.prev, t.prevTime = cur, now
 
-	if t.prevTime.IsZero() || t.prevBytes == 0 {
-		return 0
+	if prevTime.IsZero() {
+		return 0, nil
 	}
-
-	elapsed := now.Sub(t.prevTime).Seconds()
+	elapsed := now.Sub(prevTime).Seconds()
 	if elapsed <= 0 {
-		return 0
+		return 0, nil
 	}
 
-	delta := totalBytes - t.prevBytes
-	if totalBytes < t.prevBytes {
-		delta = 0 // counter wrapped
+	var total float64
+	var disks []DiskIO
+	for name, c := range cur {
+		p, ok := prev[name]
+		if !ok {
+			continue // new disk: this sample is its baseline
+		}
+		reads := counterDelta(p.ReadCount, c.ReadCount)
+		writes := counterDelta(p.WriteCount, c.WriteCount)
+		d := DiskIO{
+			Name:            name,
+			ReadBytesPS:     float64(counterDelta(p.ReadBytes, c.ReadBytes)) / elapsed,
+			WriteBytesPS:    float64(counterDelta(p.WriteBytes, c.WriteBytes)) / elapsed,
+			ReadIOPS:        float64(reads) / elapsed,
+			WriteIOPS:       float64(writes) / elapsed,
+			AvgReadLatency:  avgLatencyMs(counterDelta(p.ReadTime, c.ReadTime), reads, c.timeUnitMs),
+			AvgWriteLatency: avgLatencyMs(counterDelta(p.WriteTime, c.WriteTime), writes, c.timeUnitMs),
+		}
+		total += d.ReadBytesPS + d.WriteBytesPS
+		disks = append(disks, d)
+	}
+	sort.Slice(disks, func(i, j int) bool { return disks[i].Name < disks[j].Name })
+	return total, disks
+}
+
+// avgLatencyMs converts a busy-time delta (in unitMs units) into the
+// average time per operation.
+func avgLatencyMs(busy, ops uint64, unitMs float64) float64 {
+	if ops == 0 {
+		return 0
 	}
-	return float64(delta) / elapsed
+	return float64(busy) * unitMs / float64(ops)
 }
@@ -0,0 +1,46 @@
+//go:build linux
+
+package metrics
+
+import (
+	"strings"
+
+	"github.com/shirou/gopsutil/v4/disk"
+)
+
+// readDiskCounters reads /proc/diskstats through gopsutil, which reports
+// ReadTime/WriteTime in milliseconds.
+func readDiskCounters() (map[string]diskCounters, error) {
+	counters, err := disk.IOCounters()
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]diskCounters, len(counters))
+	for name, c := range counters {
+		if !isPhysicalDisk(name) {
+			continue
+		}
+		out[name] = diskCounters{
+			ReadBytes:  c.ReadBytes,
+			WriteBytes: c.WriteBytes,
+			ReadCount:  c.ReadCount,
+			WriteCount: c.WriteCount,
+			ReadTime:   c.ReadTime,
+			WriteTime:  c.WriteTime,
+			timeUnitMs: 1,
+		}
+	}
+	return out, nil
+}
+
+// isPhysicalDisk returns true for whole block devices. Partitions, loop and
+// RAM disks, and device-mapper/md volumes are skipped so their traffic isn't
+// counted on top of the underlying disk.
+func isPhysicalDisk(name string) bool {
+	for _, prefix := range []string{"loop", "ram", "zram", "dm-", "md", "sr"} {
+		if strings.HasPrefix(name, prefix) {
+			return false
+		}
+	}
+	return sysExists("/sys/block", name)
+}
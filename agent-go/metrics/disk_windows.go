@@ -0,0 +1,61 @@
+//go:build windows
+
+package metrics
+
+import (
+	"fmt"
+	"strings"
+)
+
+// physicalDiskCounters maps the raw PhysicalDisk performance counters.
+// gopsutil reads per-volume counters with whole-second times, so a disk's
+// latency read as 0 or 1000 ms; these are per disk, in PerfTime ticks.
+// AvgDisksecPerRead is the cumulative read time and its _Base the reads.
+type physicalDiskCounters struct {
+	Name                    string // "0 C: D:", or "_Total"
+	DiskReadBytesPersec     uint64
+	DiskWriteBytesPersec    uint64
+	AvgDisksecPerRead       uint32
+	AvgDisksecPerRead_Base  uint32
+	AvgDisksecPerWrite      uint32
+	AvgDisksecPerWrite_Base uint32
+	Frequency_PerfTime      uint64
+}
+
+// readDiskCounters reads the PhysicalDisk performance counters, one row per
+// disk, named like "Disk 0 (C: D:)".
+func readDiskCounters() (map[string]diskCounters, error) {
+	var rows []physicalDiskCounters
+	err := queryWMI("diskIO",
+		"SELECT Name, DiskReadBytesPersec, DiskWriteBytesPersec, AvgDisksecPerRead, AvgDisksecPerRead_Base, "+
+			"AvgDisksecPerWrite, AvgDisksecPerWrite_Base, Frequency_PerfTime FROM Win32_PerfRawData_PerfDisk_PhysicalDisk",
+		&rows, "")
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]diskCounters, len(rows))
+	for _, r := range rows {
+		if r.Name == "_Total" || r.Frequency_PerfTime == 0 {
+			continue
+		}
+		out[diskName(r.Name)] = diskCounters{
+			ReadBytes:  r.DiskReadBytesPersec,
+			WriteBytes: r.DiskWriteBytesPersec,
+			ReadCount:  uint64(r.AvgDisksecPerRead_Base),
+			WriteCount: uint64(r.AvgDisksecPerWrite_Base),
+			ReadTime:   uint64(r.AvgDisksecPerRead),
+			WriteTime:  uint64(r.AvgDisksecPerWrite),
+			timeUnitMs: 1000 / float64(r.Frequency_PerfTime),
+		}
+	}
+	return out, nil
+}
+
+// diskName turns a counter instance like "0 C: D:" into "Disk 0 (C: D:)".
+func diskName(instance string) string {
+	index, volumes, _ := strings.Cut(instance, " ")
+	if volumes == "" {
+		return "Disk " + index
+	}
+	return fmt.Sprintf("Disk %s (%s)", index, volumes)
+}
@@ -0,0 +1,19 @@
+package metrics
+
+// DriverInfo describes the driver bound to a device in one of the classes
+// that matter for AV work.
+type DriverInfo struct {
+	Category     string `json:"category"` // gpu, network, audio, capture
+	DeviceName   string `json:"deviceName"`
+	Driver       string `json:"driver"`
+	Version      string `json:"version"`
+	Date         string `json:"date,omitempty"` // YYYY-MM-DD
+	Manufacturer string `json:"manufacturer,omitempty"`
+}
+
+// Drivers returns the most recently collected driver inventory.
+func (c *Collector) Drivers() []DriverInfo {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.driverList
+}
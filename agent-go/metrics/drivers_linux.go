@@ -0,0 +1,56 @@
+//go:build linux
+
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// driverClasses maps sysfs device classes to inventory categories.
+var driverClasses = []struct {
+	glob     string
+	category string
+}{
+	{"/sys/class/drm/card[0-9]", "gpu"},
+	{"/sys/class/net/*", "network"},
+	{"/sys/class/sound/card[0-9]*", "audio"},
+	{"/sys/class/video4linux/video*", "capture"},
+}
+
+// readDrivers lists the kernel module bound to each device. Out-of-tree
+// modules (nvidia, decklink) publish a version; in-tree drivers carry the
+// kernel release.
+func readDrivers() []DriverInfo {
+	kernel := readSysString("/proc/sys/kernel/osrelease")
+
+	var drivers []DriverInfo
+	seen := make(map[string]bool)
+	for _, class := range driverClasses {
+		devices, _ := filepath.Glob(class.glob)
+		for _, dev := range devices {
+			target, err := os.Readlink(filepath.Join(dev, "device", "driver"))
+			if err != nil {
+				continue // virtual device, no driver
+			}
+			module := filepath.Base(target)
+			key := class.category + "/" + filepath.Base(dev)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			version := readSysString(filepath.Join("/sys/module", module, "version"))
+			if version == "" {
+				version = kernel
+			}
+			drivers = append(drivers, DriverInfo{
+				Category:   class.category,
+				DeviceName: filepath.Base(dev),
+				Driver:     module,
+				Version:    version,
+			})
+		}
+	}
+	return drivers
+}
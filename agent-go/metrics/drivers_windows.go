@@ -0,0 +1,54 @@
+//go:build windows
+
+package metrics
+
+import "time"
+
+type win32PnPSignedDriver struct {
+	DeviceName         string
+	DeviceClass        string
+	DriverName         string
+	DriverVersion      string
+	DriverDate         time.Time
+	DriverProviderName string
+}
+
+// driverCategories maps PnP device setup classes to inventory categories.
+var driverCategories = map[string]string{
+	"DISPLAY": "gpu",
+	"NET":     "network",
+	"MEDIA":   "audio", // sound cards and most USB/PCIe audio interfaces
+	"IMAGE":   "capture",
+	"CAMERA":  "capture",
+}
+
+// readDrivers queries signed PnP drivers. Win32_PnPSignedDriver is slow
+// (seconds), so callers cache the result.
+func readDrivers() []DriverInfo {
+	var rows []win32PnPSignedDriver
+	err := queryWMI("drivers",
+		"SELECT DeviceName, DeviceClass, DriverName, DriverVersion, DriverDate, DriverProviderName FROM Win32_PnPSignedDriver WHERE DeviceClass='DISPLAY' OR DeviceClass='NET' OR DeviceClass='MEDIA' OR DeviceClass='IMAGE' OR DeviceClass='CAMERA'",
+		&rows, "")
+	if err != nil {
+		return nil
+	}
+
+	var drivers []DriverInfo
+	for _, r := range rows {
+		if r.DeviceName == "" {
+			continue
+		}
+		d := DriverInfo{
+			Category:     driverCategories[r.DeviceClass],
+			DeviceName:   r.DeviceName,
+			Driver:       r.DriverName,
+			Version:      r.DriverVersion,
+			Manufacturer: r.DriverProviderName,
+		}
+		if !r.DriverDate.IsZero() {
+			d.Date = r.DriverDate.Format("2006-01-02")
+		}
+		drivers = append(drivers, d)
+	}
+	return drivers
+}
@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+)
+
+// ForegroundWindow is the focused application, so the dashboard can
+// confirm ProPresenter is frontmost on the lyrics machine rather than an
+// update prompt.
+type ForegroundWindow struct {
+	Process string `json:"process"`
+	PID     int32  `json:"pid"`
+	// Title is left out with foregroundWindow.hideTitle; document names
+	// and browser tabs can be private.
+	Title string    `json:"title,omitempty"`
+	Since time.Time `json:"since"` // when the process came to the front
+}
+
+// foregroundTracker stamps when the foreground process last changed.
+type foregroundTracker struct {
+	mu   sync.Mutex
+	last *ForegroundWindow
+}
+
+// read returns the foreground window from p, or nil when there is no
+// interactive desktop (Linux, the Windows service in session 0, a locked
+// workstation).
+func (t *foregroundTracker) read(p OSProvider, hideTitle bool) *ForegroundWindow {
+	w := p.ForegroundWindow()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if w == nil {
+		t.last = nil
+		return nil
+	}
+	w.Since = time.Now()
+	if t.last != nil && t.last.PID == w.PID {
+		w.Since = t.last.Since
+	}
+	t.last = w
+	window := *w
+	if hideTitle {
+		window.Title = ""
+	}
+	return &window
+}
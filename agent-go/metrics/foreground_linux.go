@@ -0,0 +1,9 @@
+//go:build linux
+
+package metrics
+
+// readForegroundWindow reports nothing on Linux, where the agent runs as a
+// system service without a desktop.
+func readForegroundWindow() *ForegroundWindow {
+	return nil
+}
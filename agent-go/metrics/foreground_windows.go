@@ -0,0 +1,34 @@
+//go:build windows
+
+package metrics
+
+import (
+	"unsafe"
+
+	"github.com/shirou/gopsutil/v4/process"
+	"golang.org/x/sys/windows"
+)
+
+var procGetWindowText = windows.NewLazySystemDLL("user32.dll").NewProc("GetWindowTextW")
+
+// readForegroundWindow returns the window with keyboard focus and its
+// process. Session 0, where the service runs, has none.
+func readForegroundWindow() *ForegroundWindow {
+	hwnd := windows.GetForegroundWindow()
+	if hwnd == 0 {
+		return nil
+	}
+	var pid uint32
+	if _, err := windows.GetWindowThreadProcessId(hwnd, &pid); err != nil || pid == 0 {
+		return nil
+	}
+	w := &ForegroundWindow{PID: int32(pid)}
+	if p, err := process.NewProcess(w.PID); err == nil {
+		w.Process, _ = p.Name()
+	}
+	title := make([]uint16, 512)
+	if n, _, _ := procGetWindowText.Call(uintptr(hwnd), uintptr(unsafe.Pointer(&title[0])), uintptr(len(title))); n > 0 {
+		w.Title = windows.UTF16ToString(title[:n])
+	}
+	return w
+}
@@ -0,0 +1,121 @@
+//go:build windows
+
+package metrics
+
+import (
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// HID-over-GATT keyboards, mice, and clickers report their charge through
+// the standard GATT Battery Service, which Windows exposes as a device
+// interface that BluetoothApis.dll can read without pairing anything anew.
+
+var (
+	bluetoothAPIs                  = windows.NewLazySystemDLL("BluetoothApis.dll")
+	procGATTGetCharacteristics     = bluetoothAPIs.NewProc("BluetoothGATTGetCharacteristics")
+	procGATTGetCharacteristicValue = bluetoothAPIs.NewProc("BluetoothGATTGetCharacteristicValue")
+)
+
+// batteryServiceGUID is the Battery Service (0x180F) as a device interface
+// class; batteryLevelUUID is its Battery Level characteristic.
+var batteryServiceGUID = windows.GUID{
+	Data1: 0x0000180f, Data2: 0x0000, Data3: 0x1000,
+	Data4: [8]byte{0x80, 0x00, 0x00, 0x80, 0x5f, 0x9b, 0x34, 0xfb},
+}
+
+const batteryLevelUUID = 0x2a19
+
+// bthLEUUID is BTH_LE_UUID; a short UUID is in the first two bytes of
+// Value.
+type bthLEUUID struct {
+	IsShortUUID uint8
+	_           [3]byte
+	Value       windows.GUID
+}
+
+// bthLEGattCharacteristic is BTH_LE_GATT_CHARACTERISTIC.
+type bthLEGattCharacteristic struct {
+	ServiceHandle             uint16
+	_                         [2]byte
+	CharacteristicUUID        bthLEUUID
+	AttributeHandle           uint16
+	CharacteristicValueHandle uint16
+	IsBroadcastable           uint8
+	IsReadable                uint8
+	IsWritable                uint8
+	IsWritableNoResponse      uint8
+	IsSignedWritable          uint8
+	IsNotifiable              uint8
+	IsIndicatable             uint8
+	HasExtendedProperties     uint8
+}
+
+// gattBatteryLevels reads the Battery Level of every present Battery
+// Service whose interface path names one of macs (12 upper-case hex
+// digits), keyed by that MAC.
+func gattBatteryLevels(macs []string) map[string]int {
+	if len(macs) == 0 || procGATTGetCharacteristicValue.Find() != nil {
+		return nil
+	}
+	paths, err := windows.CM_Get_Device_Interface_List("", &batteryServiceGUID, windows.CM_GET_DEVICE_INTERFACE_LIST_PRESENT)
+	if err != nil {
+		return nil
+	}
+	levels := map[string]int{}
+	for _, path := range paths {
+		upper := strings.ToUpper(path)
+		for _, mac := range macs {
+			if _, done := levels[mac]; done || !strings.Contains(upper, mac) {
+				continue
+			}
+			if level, ok := readGATTBattery(path); ok {
+				levels[mac] = level
+			}
+		}
+	}
+	return levels
+}
+
+// readGATTBattery reads the Battery Level characteristic of the service
+// at path, from the system's cache when the device was heard recently.
+func readGATTBattery(path string) (int, bool) {
+	name, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, false
+	}
+	h, err := windows.CreateFile(name, windows.GENERIC_READ,
+		windows.FILE_SHARE_READ|windows.FILE_SHARE_WRITE, nil, windows.OPEN_EXISTING, 0, 0)
+	if err != nil {
+		return 0, false
+	}
+	defer windows.CloseHandle(h)
+
+	var chars [8]bthLEGattCharacteristic
+	var count uint16
+	hr, _, _ := procGATTGetCharacteristics.Call(uintptr(h), 0, uintptr(len(chars)),
+		uintptr(unsafe.Pointer(&chars[0])), uintptr(unsafe.Pointer(&count)), 0)
+	if hr != 0 {
+		return 0, false
+	}
+	for i := 0; i < int(count) && i < len(chars); i++ {
+		c := &chars[i]
+		if c.CharacteristicUUID.IsShortUUID == 0 || uint16(c.CharacteristicUUID.Value.Data1) != batteryLevelUUID || c.IsReadable == 0 {
+			continue
+		}
+		// BTH_LE_GATT_CHARACTERISTIC_VALUE: a ULONG size, then the data
+		var value struct {
+			DataSize uint32
+			Data     [4]byte
+		}
+		var required uint16
+		hr, _, _ := procGATTGetCharacteristicValue.Call(uintptr(h), uintptr(unsafe.Pointer(c)),
+			unsafe.Sizeof(value), uintptr(unsafe.Pointer(&value)), uintptr(unsafe.Pointer(&required)), 0)
+		if hr == 0 && value.DataSize >= 1 && value.Data[0] <= 100 {
+			return int(value.Data[0]), true
+		}
+	}
+	return 0, false
+}
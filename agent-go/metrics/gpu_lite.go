@@ -0,0 +1,8 @@
+//go:build lite
+
+package metrics
+
+// readGPUs is left out of lite builds, which skip the GPU probe.
+func readGPUs() []GPUStatus {
+	return nil
+}
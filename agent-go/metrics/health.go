@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+// collectInterval is how often the collection loop runs. A snapshot older
+// than staleAfter means the loop is hung (usually on a stuck WMI query).
+const (
+	collectInterval = 5 * time.Second
+	staleAfter      = 3 * collectInterval
+)
+
+// AgentHealth describes the agent's own state so the dashboard can tell
+// live data from a snapshot that stopped updating hours ago.
+type AgentHealth struct {
+	Healthy              bool                      `json:"healthy"`
+	LastCollection       string                    `json:"lastCollection"` // RFC 3339
+	CollectionLagSeconds float64                   `json:"collectionLagSeconds"`
+	CollectionDurationMs float64                   `json:"collectionDurationMs"`
+	Goroutines           int                       `json:"goroutines"`
+	MemoryBytes          uint64                    `json:"memoryBytes"`
+	Providers            map[string]ProviderHealth `json:"providers,omitempty"`
+	// Throttle is the resource budget guard's level (see SetThrottle) and
+	// Shed what it gave up; both are empty while within budget.
+	Throttle int      `json:"throttle,omitempty"`
+	Shed     []string `json:"shed,omitempty"`
+}
+
+// ProviderHealth records the outcome of a data source's most recent queries.
+type ProviderHealth struct {
+	LastSuccess string `json:"lastSuccess,omitempty"` // RFC 3339
+	LastError   string `json:"lastError,omitempty"`
+}
+
+// providers tracks per-provider query outcomes. Package-level because the
+// platform readers are plain functions called from several places.
+var providers = struct {
+	sync.Mutex
+	m map[string]ProviderHealth
+}{m: make(map[string]ProviderHealth)}
+
+// recordProvider notes the result of a provider query.
+func recordProvider(name string, err error) {
+	providers.Lock()
+	defer providers.Unlock()
+	h := providers.m[name]
+	if err != nil {
+		h.LastError = err.Error()
+	} else {
+		h.LastSuccess = time.Now().Format(time.RFC3339)
+		h.LastError = ""
+	}
+	providers.m[name] = h
+}
+
+func providerSnapshot() map[string]ProviderHealth {
+	providers.Lock()
+	defer providers.Unlock()
+	if len(providers.m) == 0 {
+		return nil
+	}
+	out := make(map[string]ProviderHealth, len(providers.m))
+	for k, v := range providers.m {
+		out[k] = v
+	}
+	return out
+}
+
+// Health reports collection loop freshness and the agent's own footprint.
+func (c *Collector) Health() AgentHealth {
+	c.mu.RLock()
+	last, took, level := c.lastCollected, c.lastDuration, c.throttle
+	c.mu.RUnlock()
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	lag := time.Since(last)
+	return AgentHealth{
+		Healthy:              lag < staleAfter,
+		LastCollection:       last.Format(time.RFC3339),
+		CollectionLagSeconds: lag.Seconds(),
+		CollectionDurationMs: float64(took.Microseconds()) / 1000,
+		Goroutines:           runtime.NumGoroutine(),
+		MemoryBytes:          mem.Sys,
+		Providers:            providerSnapshot(),
+		Throttle:             level,
+		Shed:                 Shed(level),
+	}
+}
@@ -0,0 +1,247 @@
+package metrics
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+)
+
+// The dashboard keys machines by hardware UUID, so two machines with the
+// same UUID merge into one. Some OEM boards ship an all-F or placeholder
+// SMBIOS UUID; those fall back to the board serial, then (on Linux) to
+// /etc/machine-id, then to a physical MAC address. The chosen identity is kept in the data directory, where a
+// reinstall leaves it, and kept for as long as the hardware it came from is
+// still there. A clone that shares its SMBIOS UUID with another machine is
+// given a random identity instead (see RegenerateHardwareUUID).
+
+// hardwareIDFile is the persisted identity in config.DataDir().
+const hardwareIDFile = "hardware-id.json"
+
+// Identity sources, best first.
+const (
+	sourceSMBIOS    = "smbios"
+	sourceBoard     = "board"
+	sourceMachineID = "machineID" // Linux, where DMI needs root
+	sourceMAC       = "mac"
+	sourceHostname  = "hostname"
+	// sourceGenerated is a random UUID given after a collision; its
+	// evidence is the source and evidence it replaced, "smbios:<uuid>".
+	sourceGenerated = "generated"
+)
+
+// placeholderUUIDs are SMBIOS UUIDs that firmware vendors ship unchanged on
+// every board.
+var placeholderUUIDs = map[string]bool{
+	"03000200-0400-0500-0006-000700080009": true,
+	"00020003-0004-0005-0006-000700080009": true,
+}
+
+// placeholderSerials are board serial numbers left at the firmware default.
+var placeholderSerials = map[string]bool{
+	"none": true, "default string": true, "to be filled by o.e.m.": true,
+	"not applicable": true, "not specified": true, "n/a": true, "na": true,
+	"system serial number": true, "base board serial number": true,
+	"123456789": true, "0123456789": true, "invalid": true, "empty": true,
+}
+
+// virtualOUIs are MAC prefixes of hypervisor adapters, which a host can
+// share with its guests or hand out again.
+var virtualOUIs = []string{"00:15:5d", "00:50:56", "00:0c:29", "00:05:69", "08:00:27", "00:1c:42", "00:16:3e"}
+
+// hardwareIdentity is the persisted choice. Evidence is the SMBIOS UUID,
+// board serial, or MAC address the UUID came from.
+type hardwareIdentity struct {
+	UUID     string `json:"uuid"`
+	Source   string `json:"source"`
+	Evidence string `json:"evidence"`
+}
+
+// hardwareFacts are the identifiers read from this machine; empty when
+// unreadable or a placeholder.
+type hardwareFacts struct {
+	smbios    string
+	board     string
+	machineID string
+	macs      []string // sorted
+}
+
+// stableHardwareUUID returns the machine's persisted identity while its
+// evidence still matches, otherwise the best identity available now,
+// which it persists.
+func stableHardwareUUID() string {
+	facts := readHardwareFacts()
+	path := filepath.Join(config.DataDir(), hardwareIDFile)
+	var saved hardwareIdentity
+	if b, err := os.ReadFile(path); err == nil && json.Unmarshal(b, &saved) == nil && saved.UUID != "" {
+		if facts.supports(saved) {
+			return saved.UUID
+		}
+		log.Printf("Hardware UUID: %s %s is gone from this machine", saved.Source, saved.Evidence)
+	}
+
+	id := facts.choose()
+	log.Printf("Hardware UUID: %s (from %s)", id.UUID, id.Source)
+	if id.Source != sourceHostname {
+		if err := saveHardwareIdentity(id); err != nil {
+			log.Printf("Hardware UUID: saving: %v", err)
+		}
+	}
+	return id.UUID
+}
+
+// RegenerateHardwareUUID replaces the machine's identity with a random
+// UUID and persists it, for a machine found sharing its UUID with another.
+// The new identity is kept while the hardware the old one came from is
+// still there, so moving the disk to another machine still re-derives.
+// The running agent keeps reporting the old UUID until it restarts.
+func RegenerateHardwareUUID() (string, error) {
+	basis := readHardwareFacts().choose()
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	id := hardwareIdentity{
+		UUID:     fmt.Sprintf("%X-%X-%X-%X-%X", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]),
+		Source:   sourceGenerated,
+		Evidence: basis.Source + ":" + basis.Evidence,
+	}
+	if err := saveHardwareIdentity(id); err != nil {
+		return "", err
+	}
+	log.Printf("Hardware UUID: %s (generated, replacing %s)", id.UUID, basis.UUID)
+	return id.UUID, nil
+}
+
+func readHardwareFacts() hardwareFacts {
+	return hardwareFacts{
+		smbios:    validSMBIOSUUID(readSMBIOSUUID()),
+		board:     validBoardSerial(readBoardSerial()),
+		machineID: readMachineID(),
+		macs:      physicalMACs(),
+	}
+}
+
+func saveHardwareIdentity(id hardwareIdentity) error {
+	path := filepath.Join(config.DataDir(), hardwareIDFile)
+	b, _ := json.MarshalIndent(id, "", "  ")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o644)
+}
+
+// choose picks the best identity the facts allow.
+func (f hardwareFacts) choose() hardwareIdentity {
+	switch {
+	case f.smbios != "":
+		return hardwareIdentity{UUID: f.smbios, Source: sourceSMBIOS, Evidence: f.smbios}
+	case f.board != "":
+		return hardwareIdentity{UUID: nameUUID(sourceBoard, f.board), Source: sourceBoard, Evidence: f.board}
+	case f.machineID != "":
+		// Used as is, as agents did before these fallbacks, so the
+		// dashboard still knows the machine.
+		return hardwareIdentity{UUID: f.machineID, Source: sourceMachineID, Evidence: f.machineID}
+	case len(f.macs) > 0:
+		return hardwareIdentity{UUID: nameUUID(sourceMAC, f.macs[0]), Source: sourceMAC, Evidence: f.macs[0]}
+	}
+	hostname, _ := os.Hostname()
+	return hardwareIdentity{UUID: "unknown-" + hostname, Source: sourceHostname, Evidence: hostname}
+}
+
+// supports reports whether a saved identity still belongs to this
+// machine. An identifier that can't be read right now (WMI still
+// starting, DMI needing root) doesn't disprove it; a different one, as on
+// a disk cloned into another machine, does.
+func (f hardwareFacts) supports(id hardwareIdentity) bool {
+	switch id.Source {
+	case sourceSMBIOS:
+		return f.smbios == "" || strings.EqualFold(f.smbios, id.Evidence)
+	case sourceBoard:
+		return f.board == "" || f.board == id.Evidence
+	case sourceMachineID:
+		return f.machineID == "" || f.machineID == id.Evidence
+	case sourceMAC:
+		if len(f.macs) == 0 {
+			return true
+		}
+		for _, mac := range f.macs {
+			if mac == id.Evidence {
+				return true
+			}
+		}
+	case sourceGenerated:
+		source, evidence, _ := strings.Cut(id.Evidence, ":")
+		// A machine with nothing better than its hostname keeps what it
+		// was given.
+		return source == sourceHostname || f.supports(hardwareIdentity{Source: source, Evidence: evidence})
+	}
+	return false
+}
+
+// validSMBIOSUUID returns u trimmed, or "" for a placeholder: all one
+// digit (all-F and all-0 are common) or a known vendor default.
+func validSMBIOSUUID(u string) string {
+	u = strings.TrimSpace(u)
+	digits := strings.ToLower(strings.ReplaceAll(u, "-", ""))
+	if len(digits) != 32 || strings.Count(digits, digits[:1]) == len(digits) || placeholderUUIDs[strings.ToLower(u)] {
+		return ""
+	}
+	return u
+}
+
+// validBoardSerial returns s trimmed, or "" for a firmware default.
+func validBoardSerial(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) < 4 || placeholderSerials[strings.ToLower(s)] || strings.Count(s, s[:1]) == len(s) {
+		return ""
+	}
+	return s
+}
+
+// physicalMACs returns the burned-in addresses of the machine's network
+// adapters, up or down, sorted. Locally administered (randomized, bridge,
+// container) and hypervisor addresses are left out.
+func physicalMACs() []string {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+	var macs []string
+	for _, iface := range ifaces {
+		hw := iface.HardwareAddr
+		if len(hw) != 6 || iface.Flags&net.FlagLoopback != 0 || hw[0]&0x02 != 0 || bytes.Equal(hw, make([]byte, 6)) {
+			continue
+		}
+		mac := hw.String()
+		virtual := false
+		for _, oui := range virtualOUIs {
+			virtual = virtual || strings.HasPrefix(mac, oui)
+		}
+		if !virtual {
+			macs = append(macs, mac)
+		}
+	}
+	sort.Strings(macs)
+	return macs
+}
+
+// nameUUID derives a UUID from an identifier, laid out as a version 5
+// (SHA-1, name-based) UUID so it passes the dashboard's format checks.
+func nameUUID(kind, name string) string {
+	sum := sha1.Sum([]byte("avl-dashboard-hardware:" + kind + ":" + name))
+	sum[6] = sum[6]&0x0f | 0x50
+	sum[8] = sum[8]&0x3f | 0x80
+	return fmt.Sprintf("%X-%X-%X-%X-%X", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
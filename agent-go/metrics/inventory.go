@@ -0,0 +1,30 @@
+package metrics
+
+// HardwareInventory identifies the physical machine for asset management.
+// Collected once at startup; none of it changes without a reboot.
+type HardwareInventory struct {
+	Manufacturer string `json:"manufacturer"`
+	Model        string `json:"model"`
+	SerialNumber string `json:"serialNumber"`
+	Board        string `json:"board"`
+	BIOSVersion  string `json:"biosVersion"`
+	BIOSDate     string `json:"biosDate"`
+	TPMPresent   bool   `json:"tpmPresent"`
+	TPMVersion   string `json:"tpmVersion,omitempty"`
+	SecureBoot   bool   `json:"secureBoot"`
+	// Architecture is the machine's ("amd64", "arm64"). Emulated is set
+	// when the agent is an amd64 build running on ARM64 Windows.
+	Architecture  string         `json:"architecture,omitempty"`
+	Emulated      bool           `json:"emulated,omitempty"`
+	MemoryModules []MemoryModule `json:"memoryModules,omitempty"`
+}
+
+// MemoryModule describes one installed DIMM/SODIMM.
+type MemoryModule struct {
+	Slot         string  `json:"slot"`
+	CapacityGB   float64 `json:"capacityGB"`
+	SpeedMHz     int     `json:"speedMHz"`
+	Manufacturer string  `json:"manufacturer"`
+	PartNumber   string  `json:"partNumber"`
+	SerialNumber string  `json:"serialNumber"`
+}
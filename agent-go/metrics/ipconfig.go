@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"net"
+	"slices"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+)
+
+// Addressing methods reported in IPConfig.Method.
+const (
+	AddressDHCP       = "dhcp"
+	AddressStatic     = "static"
+	AddressAutoconfig = "autoconfig" // self-assigned 169.254.x.x: DHCP failed
+)
+
+// IPConfig is how an interface got its IPv4 address. A Dante NIC that
+// falls back from its static address to DHCP or a self-assigned one drops
+// off the audio network, so it is checked against the config.
+type IPConfig struct {
+	Method       string     `json:"method"`
+	DHCPServer   string     `json:"dhcpServer,omitempty"`
+	LeaseExpires *time.Time `json:"leaseExpires,omitempty"`
+	Gateways     []string   `json:"gateways,omitempty"`
+	DNSServers   []string   `json:"dnsServers,omitempty"`
+	// ExpectStatic is set when the config requires a static address (see
+	// NetworkConfig.Static).
+	ExpectStatic bool `json:"expectStatic,omitempty"`
+}
+
+// WrongMethod reports whether the interface is required to be static and
+// isn't.
+func (c *IPConfig) WrongMethod() bool {
+	return c != nil && c.ExpectStatic && c.Method != AddressStatic
+}
+
+// applyIPConfigs attaches each interface's addressing, and whether the
+// config requires it to be static.
+func applyIPConfigs(networks []NetworkInfo, configs map[string]*IPConfig, cfg config.NetworkConfig) {
+	for i := range networks {
+		n := &networks[i]
+		c, ok := configs[n.InterfaceName]
+		if !ok {
+			continue
+		}
+		ipc := *c
+		ipc.ExpectStatic = slices.Contains(cfg.Static, n.InterfaceName) ||
+			(n.Role != "" && slices.Contains(cfg.StaticRoles, n.Role))
+		// A self-assigned address means DHCP gave up, whatever the
+		// platform reports the method as.
+		if ip := net.ParseIP(n.IPAddress); ip != nil && ip.IsLinkLocalUnicast() {
+			ipc.Method = AddressAutoconfig
+		}
+		n.IPConfig = &ipc
+	}
+}
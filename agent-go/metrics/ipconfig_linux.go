@@ -0,0 +1,135 @@
+//go:build linux
+
+package metrics
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ipAddrJSON is the part of `ip -j addr` output used here. The kernel marks
+// addresses a DHCP client added with a finite lifetime as dynamic, whichever
+// client (NetworkManager, systemd-networkd, dhclient) added them.
+type ipAddrJSON struct {
+	Index    int    `json:"ifindex"`
+	Name     string `json:"ifname"`
+	AddrInfo []struct {
+		Family        string `json:"family"`
+		Local         string `json:"local"`
+		Dynamic       bool   `json:"dynamic"`
+		ValidLifeTime uint32 `json:"valid_life_time"`
+	} `json:"addr_info"`
+}
+
+// readIPConfigs reads addressing from iproute2 and /proc. The DHCP server
+// and per-link DNS servers come from systemd-networkd's lease files when it
+// manages the link; otherwise DNS servers are the system-wide ones.
+func readIPConfigs() map[string]*IPConfig {
+	out, err := exec.Command("ip", "-j", "-4", "addr", "show").Output()
+	if err != nil {
+		return nil
+	}
+	var links []ipAddrJSON
+	if err := json.Unmarshal(out, &links); err != nil {
+		return nil
+	}
+
+	now := time.Now()
+	gateways := readGateways()
+	systemDNS := readResolvConf()
+	configs := make(map[string]*IPConfig)
+	for _, link := range links {
+		if len(link.AddrInfo) == 0 || link.Name == "lo" {
+			continue
+		}
+		addr := link.AddrInfo[0]
+		c := &IPConfig{Method: AddressStatic, Gateways: gateways[link.Name], DNSServers: systemDNS}
+		if addr.Dynamic {
+			c.Method = AddressDHCP
+			expires := now.Add(time.Duration(addr.ValidLifeTime) * time.Second).Truncate(time.Second)
+			c.LeaseExpires = &expires
+		}
+		lease := readNetworkdLease(link.Index)
+		if c.Method == AddressDHCP && lease["SERVER_ADDRESS"] != "" {
+			c.DHCPServer = lease["SERVER_ADDRESS"]
+		}
+		if dns := lease["DNS"]; dns != "" {
+			c.DNSServers = strings.Fields(dns)
+		}
+		configs[link.Name] = c
+	}
+	return configs
+}
+
+// readGateways returns the IPv4 default gateways per interface from
+// /proc/net/route.
+func readGateways() map[string][]string {
+	f, err := os.Open("/proc/net/route")
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	gateways := make(map[string][]string)
+	scanner := bufio.NewScanner(f)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || fields[1] != "00000000" {
+			continue
+		}
+		raw, err := hex.DecodeString(fields[2])
+		if err != nil || len(raw) != 4 {
+			continue
+		}
+		// Host byte order, little-endian on every platform the agent ships for.
+		ip := make(net.IP, 4)
+		binary.BigEndian.PutUint32(ip, binary.LittleEndian.Uint32(raw))
+		gateways[fields[0]] = append(gateways[fields[0]], ip.String())
+	}
+	return gateways
+}
+
+// readResolvConf returns the system's DNS servers. systemd-resolved's own
+// file lists the upstream servers rather than its 127.0.0.53 stub.
+func readResolvConf() []string {
+	for _, path := range []string{"/run/systemd/resolve/resolv.conf", "/etc/resolv.conf"} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var servers []string
+		for _, line := range strings.Split(string(data), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) >= 2 && fields[0] == "nameserver" {
+				servers = append(servers, fields[1])
+			}
+		}
+		return servers
+	}
+	return nil
+}
+
+// readNetworkdLease returns systemd-networkd's DHCP lease for the link as
+// key/value pairs, or nil when networkd doesn't manage it.
+func readNetworkdLease(index int) map[string]string {
+	data, err := os.ReadFile("/run/systemd/netif/leases/" + strconv.Itoa(index))
+	if err != nil {
+		return nil
+	}
+	lease := make(map[string]string)
+	for _, line := range strings.Split(string(data), "\n") {
+		if key, value, ok := strings.Cut(line, "="); ok {
+			lease[key] = value
+		}
+	}
+	return lease
+}
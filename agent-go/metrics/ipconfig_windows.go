@@ -0,0 +1,66 @@
+//go:build windows
+
+package metrics
+
+import (
+	"runtime"
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+// ipAdapterDHCPEnabled is IP_ADAPTER_DHCP_ENABLED in IP_ADAPTER_ADDRESSES.Flags.
+const ipAdapterDHCPEnabled = 0x4
+
+// infiniteLease is the lease lifetime IP Helper reports for a static address.
+const infiniteLease = 0xffffffff
+
+// readIPConfigs reads addressing from IP Helper, keyed by friendly name like
+// net.Interfaces. Windows keeps DNS servers per adapter, so they are the
+// adapter's own.
+func readIPConfigs() map[string]*IPConfig {
+	first, buf := adapterAddresses(windows.GAA_FLAG_INCLUDE_GATEWAYS)
+	if first == nil {
+		return nil
+	}
+	defer runtime.KeepAlive(buf)
+
+	now := time.Now()
+	configs := make(map[string]*IPConfig)
+	for aa := first; aa != nil; aa = aa.Next {
+		if aa.IfType == windows.IF_TYPE_SOFTWARE_LOOPBACK {
+			continue
+		}
+		c := &IPConfig{Method: AddressStatic}
+		for ua := aa.FirstUnicastAddress; ua != nil; ua = ua.Next {
+			if ua.Address.IP().To4() == nil {
+				continue
+			}
+			if ua.PrefixOrigin == windows.IpPrefixOriginDhcp || ua.SuffixOrigin == windows.IpSuffixOriginDhcp {
+				c.Method = AddressDHCP
+				if ua.LeaseLifetime != infiniteLease {
+					expires := now.Add(time.Duration(ua.LeaseLifetime) * time.Second).Truncate(time.Second)
+					c.LeaseExpires = &expires
+				}
+			}
+			break
+		}
+		if c.Method == AddressDHCP || aa.Flags&ipAdapterDHCPEnabled != 0 {
+			if ip := aa.Dhcpv4Server.IP(); ip != nil && !ip.IsUnspecified() {
+				c.DHCPServer = ip.String()
+			}
+		}
+		for gw := aa.FirstGatewayAddress; gw != nil; gw = gw.Next {
+			if ip := gw.Address.IP().To4(); ip != nil {
+				c.Gateways = append(c.Gateways, ip.String())
+			}
+		}
+		for dns := aa.FirstDnsServerAddress; dns != nil; dns = dns.Next {
+			if ip := dns.Address.IP(); ip != nil {
+				c.DNSServers = append(c.DNSServers, ip.String())
+			}
+		}
+		configs[windows.UTF16PtrToString(aa.FriendlyName)] = c
+	}
+	return configs
+}
@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+)
+
+const defaultLicenseWarnDays = 30
+
+// LicenseStatus is a configured license's state. An expired license or a
+// missing dongle has blanked screens mid-service, so both alert ahead.
+type LicenseStatus struct {
+	Name     string     `json:"name"`
+	Expires  *time.Time `json:"expires,omitempty"`
+	DaysLeft *int       `json:"daysLeft,omitempty"`
+	WarnDays int        `json:"warnDays,omitempty"`
+	// Missing names what isn't there: "usbDevice" or "file".
+	Missing []string `json:"missing,omitempty"`
+}
+
+// LicenseChecker reads the configured licenses.
+type LicenseChecker struct {
+	checks []config.LicenseCheck
+
+	mu      sync.Mutex
+	lastUSB map[string]bool // the last USB devices read, for when a read fails
+}
+
+// NewLicenseChecker creates a checker for the given licenses.
+func NewLicenseChecker(checks []config.LicenseCheck) *LicenseChecker {
+	return &LicenseChecker{checks: checks}
+}
+
+// Read returns every license's status, or nil when none are configured.
+func (l *LicenseChecker) Read() []LicenseStatus {
+	if len(l.checks) == 0 {
+		return nil
+	}
+	var usb map[string]bool
+	for _, check := range l.checks {
+		if check.USBDevice != "" {
+			usb = l.usbDevices()
+			break
+		}
+	}
+
+	results := make([]LicenseStatus, 0, len(l.checks))
+	for _, check := range l.checks {
+		status := LicenseStatus{Name: check.Name}
+		if expires, err := time.ParseInLocation("2006-01-02", check.Expires, time.Local); err == nil {
+			days := int(time.Until(expires).Hours() / 24)
+			status.Expires, status.DaysLeft = &expires, &days
+			status.WarnDays = check.WarnDays
+			if status.WarnDays == 0 {
+				status.WarnDays = defaultLicenseWarnDays
+			}
+		}
+		if check.USBDevice != "" && usb != nil && !usb[strings.ToLower(check.USBDevice)] {
+			status.Missing = append(status.Missing, "usbDevice")
+		}
+		if check.File != "" {
+			if _, err := os.Stat(check.File); err != nil {
+				status.Missing = append(status.Missing, "file")
+			}
+		}
+		results = append(results, status)
+	}
+	return results
+}
+
+// usbDevices reads the connected USB devices. When the read fails it
+// returns the last good read, or nil before there is one, so dongles
+// aren't reported missing because WMI didn't answer.
+func (l *LicenseChecker) usbDevices() map[string]bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	devices, err := readUSBDevices()
+	if err != nil {
+		return l.lastUSB
+	}
+	l.lastUSB = devices
+	return devices
+}
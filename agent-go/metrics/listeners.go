@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"fmt"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+)
+
+// missesToFail is how many reads in a row must find a port closed before
+// its check fails, so an app still starting up isn't reported.
+const missesToFail = 2
+
+// ListenerChecker runs the configured listener checks: each passes when
+// something on the machine has one of its ports open.
+type ListenerChecker struct {
+	checks []config.ListenerCheck
+	misses map[string]int // consecutive reads with the port closed, by name
+}
+
+// NewListenerChecker creates a checker for the given listener checks.
+func NewListenerChecker(checks []config.ListenerCheck) *ListenerChecker {
+	resolved := make([]config.ListenerCheck, len(checks))
+	for i, check := range checks {
+		resolved[i] = check.Resolved()
+	}
+	return &ListenerChecker{checks: resolved, misses: make(map[string]int)}
+}
+
+// Read runs every check, returning nil when none are configured. Checks
+// whose process isn't running are left out.
+func (l *ListenerChecker) Read() []CheckResult {
+	if len(l.checks) == 0 {
+		return nil
+	}
+	open := map[string]map[int]bool{}
+	var results []CheckResult
+	for _, check := range l.checks {
+		if check.Process != "" && len(findProcesses(check.Process)) == 0 {
+			delete(l.misses, check.Name)
+			continue
+		}
+		ports, ok := open[check.Protocol]
+		if !ok {
+			ports = listeningPorts(check.Protocol)
+			open[check.Protocol] = ports
+		}
+		result := CheckResult{Name: check.Name, Kind: "listener", OK: true}
+		if anyOpen(ports, check) {
+			delete(l.misses, check.Name)
+		} else if l.misses[check.Name]++; l.misses[check.Name] >= missesToFail {
+			result.OK, result.Detail = false, "nothing listening on "+portRange(check)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func anyOpen(ports map[int]bool, check config.ListenerCheck) bool {
+	for port := check.Port; port <= check.PortEnd; port++ {
+		if ports[port] {
+			return true
+		}
+	}
+	return false
+}
+
+func portRange(check config.ListenerCheck) string {
+	if check.PortEnd > check.Port {
+		return fmt.Sprintf("%s %d-%d", check.Protocol, check.Port, check.PortEnd)
+	}
+	return fmt.Sprintf("%s %d", check.Protocol, check.Port)
+}
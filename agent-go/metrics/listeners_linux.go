@@ -0,0 +1,45 @@
+//go:build linux
+
+package metrics
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// tcpListen is the TCP_LISTEN state in /proc/net/tcp.
+const tcpListen = "0A"
+
+// listeningPorts returns the local ports open for protocol ("tcp" or
+// "udp"): listening TCP sockets, or bound UDP ones. Read from /proc/net
+// directly; gopsutil would also walk every process's file descriptors to
+// find owners, which isn't needed here.
+func listeningPorts(protocol string) map[int]bool {
+	ports := make(map[int]bool)
+	for _, path := range []string{"/proc/net/" + protocol, "/proc/net/" + protocol + "6"} {
+		f, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(f)
+		scanner.Scan() // header
+		for scanner.Scan() {
+			// sl local_address rem_address st ...
+			fields := strings.Fields(scanner.Text())
+			if len(fields) < 4 || (protocol == "tcp" && fields[3] != tcpListen) {
+				continue
+			}
+			_, hexPort, ok := strings.Cut(fields[1], ":")
+			if !ok {
+				continue
+			}
+			if port, err := strconv.ParseUint(hexPort, 16, 16); err == nil {
+				ports[int(port)] = true
+			}
+		}
+		f.Close()
+	}
+	return ports
+}
@@ -0,0 +1,25 @@
+//go:build windows
+
+package metrics
+
+import (
+	psnet "github.com/shirou/gopsutil/v4/net"
+)
+
+// listeningPorts returns the local ports open for protocol ("tcp" or
+// "udp"): listening TCP sockets, or bound UDP ones. Read from the IP
+// Helper connection tables.
+func listeningPorts(protocol string) map[int]bool {
+	conns, err := psnet.Connections(protocol)
+	if err != nil {
+		return nil
+	}
+	ports := make(map[int]bool)
+	for _, c := range conns {
+		if protocol == "tcp" && c.Status != "LISTEN" {
+			continue
+		}
+		ports[int(c.Laddr.Port)] = true
+	}
+	return ports
+}
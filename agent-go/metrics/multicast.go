@@ -0,0 +1,177 @@
+package metrics
+
+import (
+	"log"
+	"net"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+)
+
+// querierTimeout is twice the default IGMP query interval (125s). A querier
+// that hasn't been heard from in this long is considered absent.
+const querierTimeout = 250 * time.Second
+
+// MulticastStatus reports joined groups per interface and IGMP querier presence.
+type MulticastStatus struct {
+	// Monitoring is false when the agent can't open a raw IGMP socket
+	// (needs admin/root), in which case querier fields are meaningless.
+	Monitoring bool                 `json:"monitoring"`
+	Interfaces []MulticastInterface `json:"interfaces"`
+}
+
+// MulticastInterface describes one interface's multicast state.
+type MulticastInterface struct {
+	InterfaceName  string   `json:"interfaceName"`
+	Role           string   `json:"role,omitempty"`
+	Groups         []string `json:"groups"`
+	MissingGroups  []string `json:"missingGroups,omitempty"`
+	QuerierPresent bool     `json:"querierPresent"`
+	QuerierAddress string   `json:"querierAddress,omitempty"`
+}
+
+// IGMPMonitor listens for IGMP membership queries to detect whether a
+// querier is active on each attached segment. Without a querier, IGMP
+// snooping switches eventually stop forwarding Dante/NDI multicast.
+type IGMPMonitor struct {
+	mu         sync.Mutex
+	monitoring bool
+	lastQuery  map[string]time.Time // querier IP -> last query seen
+}
+
+// NewIGMPMonitor creates an idle monitor; call Run to start listening.
+func NewIGMPMonitor() *IGMPMonitor {
+	return &IGMPMonitor{lastQuery: make(map[string]time.Time)}
+}
+
+// Run listens for membership queries until the socket fails. Blocks.
+func (m *IGMPMonitor) Run() {
+	conn, err := net.ListenPacket("ip4:igmp", "0.0.0.0")
+	if err != nil {
+		log.Printf("IGMP monitor unavailable: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	m.mu.Lock()
+	m.monitoring = true
+	m.mu.Unlock()
+
+	buf := make([]byte, 1500)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			log.Printf("IGMP monitor stopped: %v", err)
+			m.mu.Lock()
+			m.monitoring = false
+			m.mu.Unlock()
+			return
+		}
+		// Type 0x11 is Membership Query (IGMPv1/v2/v3)
+		if n < 1 || buf[0] != 0x11 {
+			continue
+		}
+		m.mu.Lock()
+		m.lastQuery[addr.String()] = time.Now()
+		m.mu.Unlock()
+	}
+}
+
+// querierFor returns the most recent querier heard on one of the given subnets.
+func (m *IGMPMonitor) querierFor(subnets []*net.IPNet) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var best string
+	var bestTime time.Time
+	for ip, seen := range m.lastQuery {
+		if time.Since(seen) > querierTimeout {
+			continue
+		}
+		parsed := net.ParseIP(ip)
+		for _, subnet := range subnets {
+			if subnet.Contains(parsed) && seen.After(bestTime) {
+				best, bestTime = ip, seen
+			}
+		}
+	}
+	return best
+}
+
+// readMulticast enumerates joined IPv4 groups on each up, non-loopback
+// interface and compares them with the groups config expects for that
+// interface by name or by role.
+func readMulticast(monitor *IGMPMonitor, cfg config.MulticastConfig, roles map[string]string) *MulticastStatus {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+
+	monitor.mu.Lock()
+	status := &MulticastStatus{Monitoring: monitor.monitoring}
+	monitor.mu.Unlock()
+
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback != 0 || iface.Flags&net.FlagUp == 0 {
+			continue
+		}
+		if iface.Flags&net.FlagMulticast == 0 {
+			continue
+		}
+
+		addrs, err := iface.MulticastAddrs()
+		if err != nil {
+			continue
+		}
+		joined := make(map[string]bool)
+		var groups []string
+		for _, addr := range addrs {
+			if ipAddr, ok := addr.(*net.IPAddr); ok && ipAddr.IP.To4() != nil {
+				g := ipAddr.IP.String()
+				if !joined[g] {
+					joined[g] = true
+					groups = append(groups, g)
+				}
+			}
+		}
+		sort.Strings(groups)
+
+		role := roles[iface.Name]
+		expected := cfg.ExpectedGroups[iface.Name]
+		if role != "" {
+			expected = slices.Concat(expected, cfg.ExpectedGroupsByRole[role])
+		}
+		var missing []string
+		for _, g := range expected {
+			if !joined[g] && !slices.Contains(missing, g) {
+				missing = append(missing, g)
+			}
+		}
+
+		var subnets []*net.IPNet
+		if unicast, err := iface.Addrs(); err == nil {
+			for _, addr := range unicast {
+				if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.To4() != nil {
+					subnets = append(subnets, ipNet)
+				}
+			}
+		}
+		if len(subnets) == 0 {
+			continue
+		}
+		querier := monitor.querierFor(subnets)
+
+		status.Interfaces = append(status.Interfaces, MulticastInterface{
+			InterfaceName:  iface.Name,
+			Role:           role,
+			Groups:         groups,
+			MissingGroups:  missing,
+			QuerierPresent: querier != "",
+			QuerierAddress: querier,
+		})
+	}
+	return status
+}
@@ -2,6 +2,7 @@ package metrics
 
 import (
 	"fmt"
+	"math"
 	"net"
 	"os"
 	"sort"
@@ -11,15 +12,23 @@ import (
 	psnet "github.com/shirou/gopsutil/v4/net"
 )
 
-// NetworkTracker tracks combined in+out bytes for delta-based throughput calculation.
+// NetworkTracker tracks per-interface byte counters for delta-based throughput
+// calculation. Keeping a baseline per interface means an adapter appearing
+// (USB NIC hotplug, VPN connect) or disappearing only affects its own delta
+// instead of producing a bogus spike or zeroing the aggregate.
 type NetworkTracker struct {
-	prevBytes uint64
-	prevTime  time.Time
+	prev     map[string]ifCounters
+	prevTime time.Time
+}
+
+type ifCounters struct {
+	sent uint64
+	recv uint64
 }
 
 // NewNetworkTracker creates a new throughput tracker.
 func NewNetworkTracker() *NetworkTracker {
-	return &NetworkTracker{}
+	return &NetworkTracker{prev: make(map[string]ifCounters)}
 }
 
 // isBondOrBridgeSlave returns true if the named interface is enslaved to a
@@ -35,11 +44,11 @@ func isBondOrBridgeSlave(name string) bool {
 // excluding slaves of bonds/bridges to avoid double-counting.
 func (t *NetworkTracker) BytesPerSec() float64 {
 	counters, err := psnet.IOCounters(true)
-	if err != nil || len(counters) == 0 {
+	if err != nil {
 		return 0
 	}
 
-	var totalBytes uint64
+	cur := make(map[string]ifCounters, len(counters))
 	for _, c := range counters {
 		if c.Name == "lo" || strings.HasPrefix(c.Name, "lo:") {
 			continue
@@ -47,34 +56,56 @@ func (t *NetworkTracker) BytesPerSec() float64 {
 		if isBondOrBridgeSlave(c.Name) {
 			continue
 		}
-		totalBytes += c.BytesSent + c.BytesRecv
+		cur[c.Name] = ifCounters{sent: c.BytesSent, recv: c.BytesRecv}
 	}
 	now := time.Now()
 
-	defer func() {
-		t.prevBytes = totalBytes
-		t.prevTime = now
-	}()
+	prev, prevTime := t.prev, t.prevTime
+	t.prev, t.prevTime = cur, now
 
-	if t.prevTime.IsZero() || t.prevBytes == 0 {
+	if prevTime.IsZero() {
 		return 0
 	}
-
-	elapsed := now.Sub(t.prevTime).Seconds()
+	elapsed := now.Sub(prevTime).Seconds()
 	if elapsed <= 0 {
 		return 0
 	}
 
-	delta := totalBytes - t.prevBytes
-	if totalBytes < t.prevBytes {
-		delta = 0 // counter wrapped
+	var delta uint64
+	for name, c := range cur {
+		p, ok := prev[name]
+		if !ok {
+			continue // new interface: this sample is its baseline
+		}
+		delta += counterDelta(p.sent, c.sent) + counterDelta(p.recv, c.recv)
 	}
 	return float64(delta) / elapsed
 }
 
+// counterDelta returns cur-prev for a monotonically increasing counter.
+// When cur < prev, a 32-bit counter (still reported by some Windows
+// drivers) that wrapped is distinguished from a reset (adapter re-enabled,
+// driver reload): a wrap produces a plausible delta under half the 32-bit
+// range; anything else is treated as a reset and contributes nothing.
+func counterDelta(prev, cur uint64) uint64 {
+	if cur >= prev {
+		return cur - prev
+	}
+	if prev <= math.MaxUint32 {
+		wrapped := (math.MaxUint32 - prev) + cur + 1
+		if wrapped < math.MaxUint32/2 {
+			return wrapped
+		}
+	}
+	return 0
+}
+
 // readNetworkInterfaces enumerates active non-loopback interfaces with IPv4 addresses.
-// Sorted with Ethernet before Wi-Fi, matching the macOS agent behavior.
-func readNetworkInterfaces() []NetworkInfo {
+// kinds maps interface names to types derived from adapter metadata; names
+// missing from it (e.g. hotplugged since the last refresh) fall back to
+// name matching. roles attaches configured fabric roles. Sorted with
+// Ethernet before Wi-Fi, matching the macOS agent behavior.
+func readNetworkInterfaces(kinds, roles map[string]string) []NetworkInfo {
 	ifaces, err := net.Interfaces()
 	if err != nil {
 		return nil
@@ -109,13 +140,17 @@ func readNetworkInterfaces() []NetworkInfo {
 		}
 
 		mac := formatMAC(iface.HardwareAddr)
-		ifType := classifyInterface(iface.Name)
+		ifType, ok := kinds[iface.Name]
+		if !ok {
+			ifType = classifyInterface(iface.Name)
+		}
 
 		results = append(results, NetworkInfo{
 			InterfaceName: iface.Name,
 			IPAddress:     ipv4,
 			MACAddress:    mac,
 			InterfaceType: ifType,
+			Role:          roles[iface.Name],
 		})
 	}
 
@@ -144,6 +179,8 @@ func formatMAC(hw net.HardwareAddr) string {
 	return strings.Join(parts, ":")
 }
 
+// classifyInterface guesses the interface type from its name. Only used when
+// adapter metadata is unavailable.
 func classifyInterface(name string) string {
 	lower := strings.ToLower(name)
 	if strings.Contains(lower, "wi-fi") || strings.Contains(lower, "wifi") ||
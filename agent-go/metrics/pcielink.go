@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"log"
+	"sync"
+)
+
+// PCIeLink reports the negotiated link of a capture/audio device. Speeds are
+// in GT/s for PCIe and Gb/s for Thunderbolt.
+type PCIeLink struct {
+	Device       string  `json:"device"`
+	Category     string  `json:"category"` // audio, capture, thunderbolt
+	Location     string  `json:"location"` // PCI address or device instance ID
+	Thunderbolt  bool    `json:"thunderbolt"`
+	CurrentSpeed float64 `json:"currentSpeed"`
+	MaxSpeed     float64 `json:"maxSpeed"`
+	CurrentWidth int     `json:"currentWidth"`
+	MaxWidth     int     `json:"maxWidth"`
+	// Degraded is true when the link trained below its capability or below
+	// the best link seen for this device since the agent started.
+	Degraded bool `json:"degraded"`
+}
+
+// LinkMonitor remembers the best link seen per device so a device that
+// comes up at half width after a cold boot is flagged even if the driver
+// doesn't report a maximum.
+type LinkMonitor struct {
+	mu   sync.Mutex
+	best map[string]PCIeLink
+	bad  map[string]bool
+}
+
+// NewLinkMonitor creates an empty link monitor.
+func NewLinkMonitor() *LinkMonitor {
+	return &LinkMonitor{best: make(map[string]PCIeLink), bad: make(map[string]bool)}
+}
+
+// Read enumerates links and flags degraded ones, logging transitions.
+func (m *LinkMonitor) Read() []PCIeLink {
+	links := readPCIeLinks()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range links {
+		l := &links[i]
+		best, seen := m.best[l.Location]
+		if !seen || l.CurrentSpeed > best.CurrentSpeed || l.CurrentWidth > best.CurrentWidth {
+			m.best[l.Location] = *l
+			best = *l
+		}
+
+		l.Degraded = (l.MaxSpeed > 0 && l.CurrentSpeed < l.MaxSpeed) ||
+			(l.MaxWidth > 0 && l.CurrentWidth < l.MaxWidth) ||
+			l.CurrentSpeed < best.CurrentSpeed || l.CurrentWidth < best.CurrentWidth
+
+		if l.Degraded != m.bad[l.Location] {
+			if l.Degraded {
+				log.Printf("Link degraded: %s at %.1f x%d (capable of %.1f x%d)",
+					l.Device, l.CurrentSpeed, l.CurrentWidth, max(l.MaxSpeed, best.CurrentSpeed), max(l.MaxWidth, best.CurrentWidth))
+			} else {
+				log.Printf("Link restored: %s at %.1f x%d", l.Device, l.CurrentSpeed, l.CurrentWidth)
+			}
+			m.bad[l.Location] = l.Degraded
+		}
+	}
+	return links
+}
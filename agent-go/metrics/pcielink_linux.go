@@ -0,0 +1,84 @@
+//go:build linux
+
+package metrics
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// PCI class codes (upper 16 bits of the class file) for multimedia devices
+var pciCategories = map[string]string{
+	"0x0400": "capture", // video controller
+	"0x0401": "audio",
+	"0x0403": "audio",   // HD audio
+	"0x0480": "capture", // other multimedia (many capture cards)
+}
+
+// readPCIeLinks reads link state from PCI sysfs and the Thunderbolt bus.
+func readPCIeLinks() []PCIeLink {
+	var links []PCIeLink
+
+	devices, _ := filepath.Glob("/sys/bus/pci/devices/*")
+	for _, dev := range devices {
+		class := readSysString(filepath.Join(dev, "class"))
+		if len(class) < 6 {
+			continue
+		}
+		category, ok := pciCategories[class[:6]]
+		if !ok {
+			continue
+		}
+		cur := readSysString(filepath.Join(dev, "current_link_speed"))
+		if cur == "" {
+			continue // not PCIe (or integrated audio without a link)
+		}
+		links = append(links, PCIeLink{
+			Device:       pciDeviceName(dev),
+			Category:     category,
+			Location:     filepath.Base(dev),
+			CurrentSpeed: leadingFloat(cur),
+			MaxSpeed:     leadingFloat(readSysString(filepath.Join(dev, "max_link_speed"))),
+			CurrentWidth: readSysInt(filepath.Join(dev, "current_link_width")),
+			MaxWidth:     readSysInt(filepath.Join(dev, "max_link_width")),
+		})
+	}
+
+	tbDevices, _ := filepath.Glob("/sys/bus/thunderbolt/devices/*")
+	for _, dev := range tbDevices {
+		name := readSysString(filepath.Join(dev, "device_name"))
+		speed := readSysString(filepath.Join(dev, "rx_speed"))
+		if name == "" || speed == "" {
+			continue // domain/host router entries
+		}
+		links = append(links, PCIeLink{
+			Device:       strings.TrimSpace(readSysString(filepath.Join(dev, "vendor_name")) + " " + name),
+			Category:     "thunderbolt",
+			Location:     filepath.Base(dev),
+			Thunderbolt:  true,
+			CurrentSpeed: leadingFloat(speed),
+			CurrentWidth: readSysInt(filepath.Join(dev, "rx_lanes")),
+			MaxWidth:     2, // TB3/TB4 links bond two lanes
+		})
+	}
+	return links
+}
+
+// pciDeviceName returns vendor:device IDs; names need the pci.ids database,
+// which minimal installs don't ship.
+func pciDeviceName(dev string) string {
+	vendor := strings.TrimPrefix(readSysString(filepath.Join(dev, "vendor")), "0x")
+	device := strings.TrimPrefix(readSysString(filepath.Join(dev, "device")), "0x")
+	return vendor + ":" + device
+}
+
+// leadingFloat parses the number at the start of strings like "8.0 GT/s PCIe".
+func leadingFloat(s string) float64 {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return 0
+	}
+	v, _ := strconv.ParseFloat(fields[0], 64)
+	return v
+}
@@ -0,0 +1,108 @@
+//go:build windows
+
+package metrics
+
+import (
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var procCMGetDevNodeProperty = windows.NewLazySystemDLL("cfgmgr32.dll").NewProc("CM_Get_DevNode_PropertyW")
+
+// DEVPKEY_PciDevice_* property IDs ({3ab22e31-8264-4b4e-9af5-a8d2d8e33e62})
+const (
+	pciCurrentLinkSpeed = 9
+	pciCurrentLinkWidth = 10
+	pciMaxLinkSpeed     = 11
+	pciMaxLinkWidth     = 12
+)
+
+var pciDevicePropGUID = windows.DEVPROPGUID{
+	Data1: 0x3ab22e31, Data2: 0x8264, Data3: 0x4b4e,
+	Data4: [8]byte{0x9a, 0xf5, 0xa8, 0xd2, 0xd8, 0xe3, 0x3e, 0x62},
+}
+
+// pcieGTs maps DEVPKEY link speed codes to GT/s (PCIe gen 1-5).
+var pcieGTs = map[uint32]float64{1: 2.5, 2: 5, 3: 8, 4: 16, 5: 32}
+
+// setupClassCategories maps PnP setup classes to link categories.
+var setupClassCategories = map[string]string{
+	"MEDIA":  "audio",
+	"IMAGE":  "capture",
+	"CAMERA": "capture",
+}
+
+// readPCIeLinks enumerates present PCI devices in multimedia classes and
+// reads their negotiated link from the PnP property store. Thunderbolt-
+// attached devices appear here as ordinary PCI functions.
+func readPCIeLinks() []PCIeLink {
+	devs, err := windows.SetupDiGetClassDevsEx(nil, "PCI", 0, windows.DIGCF_PRESENT|windows.DIGCF_ALLCLASSES, 0, "")
+	if err != nil {
+		return nil
+	}
+	defer devs.Close()
+
+	var links []PCIeLink
+	for i := 0; ; i++ {
+		data, err := devs.EnumDeviceInfo(i)
+		if err != nil {
+			break
+		}
+		class, _ := devs.DeviceRegistryProperty(data, windows.SPDRP_CLASS)
+		className, _ := class.(string)
+		category, ok := setupClassCategories[strings.ToUpper(className)]
+		if !ok {
+			continue
+		}
+
+		width, ok := devNodeUint32(data.DevInst, pciCurrentLinkWidth)
+		if !ok {
+			continue // conventional PCI or integrated function
+		}
+		speed, _ := devNodeUint32(data.DevInst, pciCurrentLinkSpeed)
+		maxSpeed, _ := devNodeUint32(data.DevInst, pciMaxLinkSpeed)
+		maxWidth, _ := devNodeUint32(data.DevInst, pciMaxLinkWidth)
+
+		name, _ := devs.DeviceRegistryProperty(data, windows.SPDRP_FRIENDLYNAME)
+		if name == nil {
+			name, _ = devs.DeviceRegistryProperty(data, windows.SPDRP_DEVICEDESC)
+		}
+		deviceName, _ := name.(string)
+		instanceID, _ := devs.DeviceInstanceID(data)
+
+		links = append(links, PCIeLink{
+			Device:       deviceName,
+			Category:     category,
+			Location:     instanceID,
+			CurrentSpeed: pcieGTs[speed],
+			MaxSpeed:     pcieGTs[maxSpeed],
+			CurrentWidth: int(width),
+			MaxWidth:     int(maxWidth),
+		})
+	}
+	return links
+}
+
+// devNodeUint32 reads a DEVPKEY_PciDevice_* UINT32 property of a device node.
+func devNodeUint32(devInst windows.DEVINST, pid windows.DEVPROPID) (uint32, bool) {
+	return devNodeProperty(devInst, windows.DEVPROPKEY{FmtID: pciDevicePropGUID, PID: pid})
+}
+
+// devNodeProperty reads an integer property of up to four bytes (BYTE,
+// UINT16, UINT32) of a device node.
+func devNodeProperty(devInst windows.DEVINST, key windows.DEVPROPKEY) (uint32, bool) {
+	var propType windows.DEVPROPTYPE
+	var value uint32
+	size := uint32(unsafe.Sizeof(value))
+	ret, _, _ := procCMGetDevNodeProperty.Call(
+		uintptr(devInst),
+		uintptr(unsafe.Pointer(&key)),
+		uintptr(unsafe.Pointer(&propType)),
+		uintptr(unsafe.Pointer(&value)),
+		uintptr(unsafe.Pointer(&size)),
+		0,
+	)
+	return value, ret == 0 // CR_SUCCESS
+}
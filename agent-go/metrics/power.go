@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+)
+
+// PowerSettings reports the active power plan and the settings that make a
+// playback machine sleep mid-service. Timeouts are AC-power minutes,
+// 0 = never, -1 = unknown.
+type PowerSettings struct {
+	PlanName              string   `json:"planName"`
+	PlanGUID              string   `json:"planGuid"`
+	SleepTimeoutMinutes   int      `json:"sleepTimeoutMinutes"`
+	DisplayTimeoutMinutes int      `json:"displayTimeoutMinutes"`
+	USBSelectiveSuspend   bool     `json:"usbSelectiveSuspend"`
+	FastStartup           bool     `json:"fastStartup"`
+	Drift                 []string `json:"drift,omitempty"`
+}
+
+// powerDrift lists each setting that differs from the expected profile.
+func powerDrift(s *PowerSettings, want *config.PowerProfile) []string {
+	if s == nil || want == nil {
+		return nil
+	}
+	var drift []string
+	if want.Plan != "" &&
+		!strings.EqualFold(want.Plan, s.PlanName) &&
+		!strings.EqualFold(want.Plan, s.PlanGUID) {
+		drift = append(drift, fmt.Sprintf("plan is %q, expected %q", s.PlanName, want.Plan))
+	}
+	if want.SleepTimeoutMinutes != nil && *want.SleepTimeoutMinutes != s.SleepTimeoutMinutes {
+		drift = append(drift, fmt.Sprintf("sleep timeout is %d min, expected %d", s.SleepTimeoutMinutes, *want.SleepTimeoutMinutes))
+	}
+	if want.DisplayTimeoutMinutes != nil && *want.DisplayTimeoutMinutes != s.DisplayTimeoutMinutes {
+		drift = append(drift, fmt.Sprintf("display timeout is %d min, expected %d", s.DisplayTimeoutMinutes, *want.DisplayTimeoutMinutes))
+	}
+	if want.USBSelectiveSuspend != nil && *want.USBSelectiveSuspend != s.USBSelectiveSuspend {
+		drift = append(drift, fmt.Sprintf("USB selective suspend is %s", onOff(s.USBSelectiveSuspend)))
+	}
+	if want.FastStartup != nil && *want.FastStartup != s.FastStartup {
+		drift = append(drift, fmt.Sprintf("fast startup is %s", onOff(s.FastStartup)))
+	}
+	return drift
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
@@ -0,0 +1,12 @@
+//go:build linux
+
+package metrics
+
+import "github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+
+// readPowerSettings is Windows-only; Linux agents run headless under systemd.
+func readPowerSettings() *PowerSettings {
+	return nil
+}
+
+func remediatePower(current *PowerSettings, want *config.PowerProfile) {}
@@ -0,0 +1,165 @@
+//go:build windows
+
+package metrics
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/windows/registry"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/hidden"
+)
+
+// powercfg subgroup/setting aliases and GUIDs
+const (
+	usbSubgroup        = "2a737441-1930-4402-8d77-b2bebba308a3"
+	usbSelectiveSuspID = "48e6b7a6-50f5-4782-a5d4-53bb8f07e226"
+	sessionPowerKey    = `SYSTEM\CurrentControlSet\Control\Session Manager\Power`
+)
+
+var (
+	schemeRe = regexp.MustCompile(`([0-9a-fA-F-]{36})\s+\((.*)\)`)
+	hexRe    = regexp.MustCompile(`0x[0-9a-fA-F]+`)
+)
+
+// readPowerSettings reads the active scheme and key AC settings via powercfg.
+// Returns nil if powercfg is unavailable.
+func readPowerSettings() *PowerSettings {
+	out, err := hidden.Command("powercfg", "/getactivescheme").Output()
+	if err != nil {
+		return nil
+	}
+	s := &PowerSettings{}
+	if m := schemeRe.FindStringSubmatch(string(out)); m != nil {
+		s.PlanGUID = strings.ToLower(m[1])
+		s.PlanName = m[2]
+	}
+
+	s.SleepTimeoutMinutes = secondsToMinutes(acSettingIndex("SUB_SLEEP", "STANDBYIDLE"))
+	s.DisplayTimeoutMinutes = secondsToMinutes(acSettingIndex("SUB_VIDEO", "VIDEOIDLE"))
+	s.USBSelectiveSuspend = acSettingIndex(usbSubgroup, usbSelectiveSuspID) == 1
+
+	if k, err := registry.OpenKey(registry.LOCAL_MACHINE, sessionPowerKey, registry.QUERY_VALUE); err == nil {
+		enabled, _, err := k.GetIntegerValue("HiberbootEnabled")
+		k.Close()
+		s.FastStartup = err == nil && enabled == 1
+	}
+	return s
+}
+
+// acSettingIndex returns the current AC value of a power setting, or -1.
+// powercfg's labels are localized, but the AC and DC indexes are always the
+// last two hex values in the output, in that order.
+func acSettingIndex(subgroup, setting string) int {
+	out, err := hidden.Command("powercfg", "/query", "SCHEME_CURRENT", subgroup, setting).Output()
+	if err != nil {
+		return -1
+	}
+	values := hexRe.FindAllString(string(out), -1)
+	if len(values) < 2 {
+		return -1
+	}
+	v, err := strconv.ParseInt(strings.TrimPrefix(values[len(values)-2], "0x"), 16, 64)
+	if err != nil {
+		return -1
+	}
+	return int(v)
+}
+
+// secondsToMinutes converts a timeout index, preserving -1 for unknown.
+func secondsToMinutes(secs int) int {
+	if secs < 0 {
+		return -1
+	}
+	return secs / 60
+}
+
+// remediatePower applies each setting in want that differs from current,
+// logging every change. Failures are logged and the remaining settings are
+// still attempted; the caller re-reads to see what actually stuck.
+func remediatePower(current *PowerSettings, want *config.PowerProfile) {
+	if current == nil || want == nil {
+		return
+	}
+
+	if want.Plan != "" &&
+		!strings.EqualFold(want.Plan, current.PlanName) &&
+		!strings.EqualFold(want.Plan, current.PlanGUID) {
+		if guid := findSchemeGUID(want.Plan); guid != "" {
+			runPowerChange(fmt.Sprintf("plan %q -> %q", current.PlanName, want.Plan), "/setactive", guid)
+		} else {
+			log.Printf("Power remediation: no installed plan matches %q", want.Plan)
+		}
+	}
+	if want.SleepTimeoutMinutes != nil && *want.SleepTimeoutMinutes != current.SleepTimeoutMinutes {
+		runPowerChange(fmt.Sprintf("sleep timeout %d -> %d min", current.SleepTimeoutMinutes, *want.SleepTimeoutMinutes),
+			"/change", "standby-timeout-ac", strconv.Itoa(*want.SleepTimeoutMinutes))
+	}
+	if want.DisplayTimeoutMinutes != nil && *want.DisplayTimeoutMinutes != current.DisplayTimeoutMinutes {
+		runPowerChange(fmt.Sprintf("display timeout %d -> %d min", current.DisplayTimeoutMinutes, *want.DisplayTimeoutMinutes),
+			"/change", "monitor-timeout-ac", strconv.Itoa(*want.DisplayTimeoutMinutes))
+	}
+	if want.USBSelectiveSuspend != nil && *want.USBSelectiveSuspend != current.USBSelectiveSuspend {
+		value := "0"
+		if *want.USBSelectiveSuspend {
+			value = "1"
+		}
+		if runPowerChange(fmt.Sprintf("USB selective suspend -> %s", onOff(*want.USBSelectiveSuspend)),
+			"/setacvalueindex", "SCHEME_CURRENT", usbSubgroup, usbSelectiveSuspID, value) {
+			// Value index changes only take effect once the scheme is re-applied
+			hidden.Command("powercfg", "/setactive", "SCHEME_CURRENT").Run()
+		}
+	}
+	if want.FastStartup != nil && *want.FastStartup != current.FastStartup {
+		setFastStartup(*want.FastStartup)
+	}
+}
+
+// runPowerChange runs powercfg with args and logs the outcome.
+func runPowerChange(desc string, args ...string) bool {
+	out, err := hidden.Command("powercfg", args...).CombinedOutput()
+	if err != nil {
+		log.Printf("Power remediation failed: %s: %v %s", desc, err, strings.TrimSpace(string(out)))
+		return false
+	}
+	log.Printf("Power remediation: %s", desc)
+	return true
+}
+
+// findSchemeGUID resolves a plan name or GUID against the installed schemes.
+func findSchemeGUID(plan string) string {
+	out, err := hidden.Command("powercfg", "/list").Output()
+	if err != nil {
+		return ""
+	}
+	for _, m := range schemeRe.FindAllStringSubmatch(string(out), -1) {
+		if strings.EqualFold(m[1], plan) || strings.EqualFold(strings.TrimSpace(m[2]), plan) {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+func setFastStartup(enabled bool) {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, sessionPowerKey, registry.SET_VALUE)
+	if err != nil {
+		log.Printf("Power remediation failed: fast startup: %v", err)
+		return
+	}
+	defer k.Close()
+
+	var value uint32
+	if enabled {
+		value = 1
+	}
+	if err := k.SetDWordValue("HiberbootEnabled", value); err != nil {
+		log.Printf("Power remediation failed: fast startup: %v", err)
+		return
+	}
+	log.Printf("Power remediation: fast startup -> %s", onOff(enabled))
+}
@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// processSampler computes per-process CPU and I/O rates from deltas between
+// successive calls, keyed by PID so a restarted process starts fresh.
+type processSampler struct {
+	prev map[int32]procSample
+}
+
+type procSample struct {
+	cpuSeconds float64
+	ioBytes    uint64
+	at         time.Time
+}
+
+func newProcessSampler() *processSampler {
+	return &processSampler{prev: make(map[int32]procSample)}
+}
+
+// findProcesses returns running processes whose executable name matches
+// one of names (case-insensitive, ".exe" optional).
+func findProcesses(names ...string) []*process.Process {
+	procs, err := process.Processes()
+	if err != nil {
+		return nil
+	}
+	var matched []*process.Process
+	for _, p := range procs {
+		name, err := p.Name()
+		if err != nil {
+			continue
+		}
+		name = strings.TrimSuffix(strings.ToLower(name), ".exe")
+		for _, want := range names {
+			if name == strings.TrimSuffix(strings.ToLower(want), ".exe") {
+				matched = append(matched, p)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// sample returns CPU usage (0-100, normalized across all cores) and I/O
+// bytes/sec since the previous sample of the same PID. The first sample of a
+// PID returns zeros.
+func (s *processSampler) sample(p *process.Process) (cpuPercent, ioBytesPS float64) {
+	now := time.Now()
+	cur := procSample{at: now}
+	if times, err := p.Times(); err == nil {
+		cur.cpuSeconds = times.User + times.System
+	}
+	if io, err := p.IOCounters(); err == nil {
+		cur.ioBytes = io.ReadBytes + io.WriteBytes
+	}
+
+	prev, ok := s.prev[p.Pid]
+	s.prev[p.Pid] = cur
+	if !ok {
+		return 0, 0
+	}
+
+	elapsed := now.Sub(prev.at).Seconds()
+	if elapsed <= 0 {
+		return 0, 0
+	}
+	cpuPercent = (cur.cpuSeconds - prev.cpuSeconds) / elapsed / float64(runtime.NumCPU()) * 100
+	if cur.ioBytes >= prev.ioBytes {
+		ioBytesPS = float64(cur.ioBytes-prev.ioBytes) / elapsed
+	}
+	return cpuPercent, ioBytesPS
+}
+
+// prune forgets PIDs not in the live set so the map doesn't grow forever.
+func (s *processSampler) prune(live []*process.Process) {
+	keep := make(map[int32]bool, len(live))
+	for _, p := range live {
+		keep[p.Pid] = true
+	}
+	for pid := range s.prev {
+		if !keep[pid] {
+			delete(s.prev, pid)
+		}
+	}
+}
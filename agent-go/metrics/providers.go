@@ -0,0 +1,163 @@
+package metrics
+
+import (
+	"runtime"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/diag"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/presence"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/winupdate"
+)
+
+// SystemProvider reads what doesn't change while the agent runs. The
+// collector reads it once, at start.
+type SystemProvider interface {
+	HardwareUUID() string
+	ChipType() string
+	DiskEncrypted() bool
+	Inventory() *HardwareInventory
+}
+
+// CPUProvider reads processor load, temperature, and clock speed.
+type CPUProvider interface {
+	ReadUsage() float64       // 0-100, or -1
+	ReadTemperature() float64 // °C, or -1
+	ReadFrequency() *CPUFrequency
+}
+
+// MemoryProvider reads RAM usage.
+type MemoryProvider interface {
+	ReadMemory() (usedPercent, totalGB float64)
+}
+
+// NetworkProvider reads interfaces and throughput.
+type NetworkProvider interface {
+	BytesPerSec() float64
+	// Interfaces lists the up interfaces; kinds comes from InterfaceKinds
+	// and roles from the config.
+	Interfaces(kinds, roles map[string]string) []NetworkInfo
+	InterfaceKinds() map[string]string
+	// IPConfigs reports how each interface got its IPv4 address, keyed
+	// by interface name.
+	IPConfigs() map[string]*IPConfig
+}
+
+// StorageProvider reads disk throughput and volume usage.
+type StorageProvider interface {
+	Sample() (float64, []DiskIO)
+	Volumes() []VolumeUsage
+}
+
+// GPUProvider reads GPU load and temperature.
+type GPUProvider interface {
+	GPUs() []GPUStatus
+}
+
+// OSProvider reads operating system state: version, uptime, clock sync,
+// power plan, drivers, PCIe links, Defender, Windows Update, the
+// foreground window, input idle time, virtual machine guests, paired
+// Bluetooth devices, and other peripherals' batteries.
+type OSProvider interface {
+	OSVersion() string
+	Uptime() float64
+	TimeSync() *TimeSyncStatus
+	PowerSettings() *PowerSettings
+	Drivers() []DriverInfo
+	PCIeLinks() []PCIeLink
+	Defender() *DefenderStatus
+	WindowsUpdate() *winupdate.Status
+	ForegroundWindow() *ForegroundWindow // nil without a desktop
+	InputIdle() (idle time.Duration, ok bool)
+	VMs() []VMGuest // nil on machines that host none
+	Bluetooth() []BluetoothDevice
+	Batteries() []PeripheralBattery
+}
+
+// Providers are the data sources a Collector reads. DefaultProviders
+// returns this platform's; tests and ports can replace any of them.
+// NewCollectorFrom replaces the whole snapshot instead.
+type Providers struct {
+	System  SystemProvider
+	CPU     CPUProvider
+	Memory  MemoryProvider
+	Network NetworkProvider
+	Storage StorageProvider
+	GPU     GPUProvider
+	OS      OSProvider
+}
+
+// DefaultProviders returns the real providers for this platform. WMI
+// queries record their outcome in AgentHealth.Providers.
+func DefaultProviders(cfg *config.Config) Providers {
+	return Providers{
+		System:  systemProvider{},
+		CPU:     NewCPUReader(),
+		Memory:  memoryProvider{},
+		Network: networkProvider{NewNetworkTracker()},
+		Storage: storageProvider{NewDiskTracker()},
+		GPU:     gpuProvider{},
+		OS: osProvider{
+			defender: NewDefenderMonitor(cfg.Defender.Folders),
+			links:    NewLinkMonitor(),
+		},
+	}
+}
+
+type systemProvider struct{}
+
+func (systemProvider) HardwareUUID() string { return stableHardwareUUID() }
+func (systemProvider) ChipType() string     { return readChipType() }
+func (systemProvider) DiskEncrypted() bool  { return checkDiskEncryption() }
+func (systemProvider) Inventory() *HardwareInventory {
+	inv := readHardwareInventory()
+	if inv != nil {
+		inv.Architecture = diag.NativeArch()
+		inv.Emulated = inv.Architecture != runtime.GOARCH
+	}
+	return inv
+}
+
+// ReadFrequency returns the CPU clock speed, or nil if unknown.
+func (r *CPUReader) ReadFrequency() *CPUFrequency { return readCPUFrequency() }
+
+type memoryProvider struct{}
+
+func (memoryProvider) ReadMemory() (float64, float64) { return readMemory() }
+
+type networkProvider struct{ *NetworkTracker }
+
+func (networkProvider) Interfaces(kinds, roles map[string]string) []NetworkInfo {
+	return readNetworkInterfaces(kinds, roles)
+}
+func (networkProvider) InterfaceKinds() map[string]string { return readInterfaceKinds() }
+func (networkProvider) IPConfigs() map[string]*IPConfig   { return readIPConfigs() }
+
+type storageProvider struct{ *DiskTracker }
+
+func (storageProvider) Volumes() []VolumeUsage { return readVolumes() }
+
+type gpuProvider struct{}
+
+func (gpuProvider) GPUs() []GPUStatus { return readGPUs() }
+
+type osProvider struct {
+	defender *DefenderMonitor
+	links    *LinkMonitor
+}
+
+func (osProvider) OSVersion() string                { return readOSVersion() }
+func (osProvider) Uptime() float64                  { return readUptime() }
+func (osProvider) TimeSync() *TimeSyncStatus        { return readTimeSync() }
+func (osProvider) PowerSettings() *PowerSettings    { return readPowerSettings() }
+func (osProvider) Drivers() []DriverInfo            { return readDrivers() }
+func (p osProvider) PCIeLinks() []PCIeLink          { return p.links.Read() }
+func (p osProvider) Defender() *DefenderStatus      { return p.defender.Read() }
+func (osProvider) WindowsUpdate() *winupdate.Status { return winupdate.Read() }
+func (osProvider) ForegroundWindow() *ForegroundWindow {
+	return readForegroundWindow()
+}
+func (osProvider) InputIdle() (time.Duration, bool) { return presence.Idle() }
+func (osProvider) VMs() []VMGuest                   { return readVMs() }
+func (osProvider) Bluetooth() []BluetoothDevice     { return readBluetooth() }
+func (osProvider) Batteries() []PeripheralBattery   { return readBatteries() }
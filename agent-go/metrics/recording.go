@@ -0,0 +1,174 @@
+package metrics
+
+import (
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+	"github.com/shirou/gopsutil/v4/disk"
+)
+
+// Recording folders can hold tens of thousands of files, so they are walked
+// on their own schedule rather than in the collection loop.
+const recordingScanInterval = 5 * time.Minute
+
+// RecordingFolderStatus reports the size and growth of a watched folder.
+type RecordingFolderStatus struct {
+	Path            string   `json:"path"`
+	Exists          bool     `json:"exists"`
+	SizeBytes       uint64   `json:"sizeBytes"`
+	FileCount       int      `json:"fileCount"`
+	GrowthBytesPerH float64  `json:"growthBytesPerHour"`
+	VolumeFreeBytes uint64   `json:"volumeFreeBytes"`
+	HoursUntilFull  *float64 `json:"hoursUntilFull,omitempty"` // nil when not growing
+	RetentionDays   int      `json:"retentionDays,omitempty"`
+	DeletedFiles    int64    `json:"deletedFiles,omitempty"` // since agent start
+	DeletedBytes    uint64   `json:"deletedBytes,omitempty"`
+	Error           string   `json:"error,omitempty"`
+}
+
+// RecordingMonitor periodically sizes the configured folders and enforces
+// their retention policies.
+type RecordingMonitor struct {
+	folders []config.RecordingFolder
+
+	mu       sync.Mutex
+	status   []RecordingFolderStatus
+	lastScan time.Time
+}
+
+// NewRecordingMonitor creates a monitor for the given folders; call Run to
+// start scanning.
+func NewRecordingMonitor(folders []config.RecordingFolder) *RecordingMonitor {
+	return &RecordingMonitor{folders: folders}
+}
+
+// Run scans the folders every recordingScanInterval. Blocks; returns
+// immediately when no folders are configured.
+func (m *RecordingMonitor) Run() {
+	if len(m.folders) == 0 {
+		return
+	}
+	for {
+		m.scan()
+		time.Sleep(recordingScanInterval)
+	}
+}
+
+// Status returns the result of the most recent scan, or nil before the
+// first scan completes.
+func (m *RecordingMonitor) Status() []RecordingFolderStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.status
+}
+
+func (m *RecordingMonitor) scan() {
+	m.mu.Lock()
+	prev, prevScan := m.status, m.lastScan
+	m.mu.Unlock()
+
+	now := time.Now()
+	status := make([]RecordingFolderStatus, len(m.folders))
+	for i, folder := range m.folders {
+		s := RecordingFolderStatus{Path: folder.Path, RetentionDays: folder.RetentionDays}
+		var deletedFiles int64
+		var deletedBytes uint64
+		if i < len(prev) {
+			s.DeletedFiles, s.DeletedBytes = prev[i].DeletedFiles, prev[i].DeletedBytes
+		}
+		if folder.RetentionDays > 0 {
+			deletedFiles, deletedBytes = enforceRetention(folder.Path, now.AddDate(0, 0, -folder.RetentionDays))
+			s.DeletedFiles += deletedFiles
+			s.DeletedBytes += deletedBytes
+		}
+
+		size, count, err := folderSize(folder.Path)
+		switch {
+		case os.IsNotExist(err):
+		case err != nil:
+			s.Exists = true
+			s.Error = err.Error()
+		default:
+			s.Exists = true
+		}
+		s.SizeBytes, s.FileCount = size, count
+		if usage, err := disk.Usage(folder.Path); err == nil {
+			s.VolumeFreeBytes = usage.Free
+		}
+
+		// Growth counts what retention removed this pass, so a folder that
+		// is being recorded into and pruned still shows its write rate.
+		if i < len(prev) && prev[i].Exists && !prevScan.IsZero() {
+			hours := now.Sub(prevScan).Hours()
+			written := float64(size) + float64(deletedBytes) - float64(prev[i].SizeBytes)
+			s.GrowthBytesPerH = written / hours
+			if netGrowth := (float64(size) - float64(prev[i].SizeBytes)) / hours; netGrowth > 0 {
+				full := float64(s.VolumeFreeBytes) / netGrowth
+				s.HoursUntilFull = &full
+			}
+		}
+		status[i] = s
+	}
+
+	m.mu.Lock()
+	m.status, m.lastScan = status, now
+	m.mu.Unlock()
+}
+
+// folderSize returns the total size and count of regular files under root.
+// Unreadable subdirectories are skipped.
+func folderSize(root string) (uint64, int, error) {
+	if _, err := os.Stat(root); err != nil {
+		return 0, 0, err
+	}
+	var size uint64
+	var count int
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if d != nil && d.IsDir() && path != root {
+				return fs.SkipDir
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			size += uint64(info.Size())
+			count++
+		}
+		return nil
+	})
+	return size, count, err
+}
+
+// enforceRetention deletes regular files under root last modified before
+// cutoff, logging each one. Directories are left in place since recording
+// software often expects its folder layout to exist.
+func enforceRetention(root string, cutoff time.Time) (int64, uint64) {
+	var files int64
+	var bytes uint64
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || !info.ModTime().Before(cutoff) {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			log.Printf("Retention: failed to delete %s: %v", path, err)
+			return nil
+		}
+		log.Printf("Retention: deleted %s (%d bytes, modified %s)", path, info.Size(), info.ModTime().Format(time.DateOnly))
+		files++
+		bytes += uint64(info.Size())
+		return nil
+	})
+	return files, bytes
+}
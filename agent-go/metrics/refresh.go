@@ -0,0 +1,27 @@
+package metrics
+
+import "time"
+
+// refresher caches the result of a slow probe (shelling out, heavy WMI
+// classes) and re-runs it at most once per interval. Each is read from a
+// single slot of the collection's reads, which never runs twice at once,
+// so it needs no locking.
+type refresher[T any] struct {
+	interval time.Duration
+	read     func() T
+	last     time.Time
+	value    T
+}
+
+func newRefresher[T any](interval time.Duration, read func() T) *refresher[T] {
+	return &refresher[T]{interval: interval, read: read}
+}
+
+// Get returns the cached value, refreshing it first if the interval has elapsed.
+func (r *refresher[T]) Get() T {
+	if r.last.IsZero() || time.Since(r.last) >= r.interval {
+		r.value = r.read()
+		r.last = time.Now()
+	}
+	return r.value
+}
@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/audio"
+)
+
+// defaultFirewallKeywords pick the firewall rules worth snapshotting: the
+// production apps' and the agent's own.
+var defaultFirewallKeywords = []string{"obs", "ndi", "dante", "vmix", "propresenter", "companion", "node-red", "resolume", "AVL Dashboard"}
+
+// SettingDrift is a setting whose value differs from the baseline. An
+// empty value means the setting didn't exist.
+type SettingDrift struct {
+	Key      string `json:"key"`
+	Baseline string `json:"baseline"`
+	Current  string `json:"current"`
+}
+
+// ConfigDrift compares the machine's settings with a stored baseline.
+type ConfigDrift struct {
+	BaselineAt time.Time      `json:"baselineAt"`
+	CheckedAt  time.Time      `json:"checkedAt"`
+	Drift      []SettingDrift `json:"drift,omitempty"`
+}
+
+// SetConfigDrift records the latest settings comparison, reported in
+// /status.
+func (c *Collector) SetConfigDrift(drift *ConfigDrift) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.configDrift = drift
+}
+
+// Settings returns a flat snapshot of the machine's monitored settings,
+// keyed like "power.plan", "service.Spooler", or "display.\\.\DISPLAY2.mode":
+// the power plan, display layout, addressing, default audio output, service
+// startup types, and the firewall rules of production apps (rules whose
+// name contains one of firewallKeywords, or a default list when empty).
+func (c *Collector) Settings(firewallKeywords []string) map[string]string {
+	status := c.CurrentStatus()
+	settings := map[string]string{}
+	if p := status.Power; p != nil {
+		settings["power.plan"] = p.PlanName
+		settings["power.sleepTimeoutMinutes"] = strconv.Itoa(p.SleepTimeoutMinutes)
+		settings["power.displayTimeoutMinutes"] = strconv.Itoa(p.DisplayTimeoutMinutes)
+		settings["power.usbSelectiveSuspend"] = onOff(p.USBSelectiveSuspend)
+		settings["power.fastStartup"] = onOff(p.FastStartup)
+	}
+	for _, d := range status.Displays {
+		prefix := "display." + d.Name + "."
+		settings[prefix+"monitor"] = d.Monitor
+		settings[prefix+"mode"] = d.Mode.String()
+		settings[prefix+"primary"] = strconv.FormatBool(d.Primary)
+	}
+	for _, n := range status.Networks {
+		prefix := "network." + n.InterfaceName + "."
+		if n.IPConfig != nil {
+			settings[prefix+"method"] = n.IPConfig.Method
+			// A DHCP lease may renew to another address; only a static
+			// one is a setting.
+			if n.IPConfig.Method == AddressStatic {
+				settings[prefix+"address"] = n.IPAddress
+			}
+		}
+	}
+	if device, err := audio.DefaultDevice(); err == nil && device != "" {
+		settings["audio.defaultDevice"] = device
+	}
+	for name, mode := range readServiceStartModes() {
+		settings["service."+name] = mode
+	}
+	if len(firewallKeywords) == 0 {
+		firewallKeywords = defaultFirewallKeywords
+	}
+	for name, rule := range readFirewallRules() {
+		for _, k := range firewallKeywords {
+			if strings.Contains(strings.ToLower(name), strings.ToLower(k)) {
+				settings["firewall."+name] = rule
+				break
+			}
+		}
+	}
+	return settings
+}
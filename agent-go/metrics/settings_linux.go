@@ -0,0 +1,32 @@
+//go:build linux
+
+package metrics
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// readServiceStartModes returns each systemd service's enablement state
+// (enabled, disabled, masked, static, ...) by unit name.
+func readServiceStartModes() map[string]string {
+	out, err := exec.Command("systemctl", "list-unit-files", "--type=service", "--no-legend", "--no-pager").Output()
+	if err != nil {
+		return nil
+	}
+	modes := map[string]string{}
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		modes[strings.TrimSuffix(fields[0], ".service")] = fields[1]
+	}
+	return modes
+}
+
+// readFirewallRules returns nil: nftables and iptables rules have no names
+// to key a snapshot by.
+func readFirewallRules() map[string]string {
+	return nil
+}
@@ -0,0 +1,73 @@
+//go:build windows
+
+package metrics
+
+import (
+	"fmt"
+	"regexp"
+)
+
+const firewallNamespace = `root\StandardCimv2`
+
+// perUserService matches per-user service instances ("CDPUserSvc_3a4f1"),
+// whose suffix changes with every sign-in.
+var perUserService = regexp.MustCompile(`_[0-9a-f]{4,8}$`)
+
+type win32Service struct {
+	Name      string
+	StartMode string // Auto, Manual, Disabled, Boot, System
+}
+
+type msftNetFirewallRule struct {
+	DisplayName string
+	Enabled     uint16 // 1 true, 2 false
+	Action      uint16 // 2 allow, 3 allow bypass, 4 block
+	Direction   uint16 // 1 inbound, 2 outbound
+}
+
+// readServiceStartModes returns each service's startup type by name,
+// leaving out per-user instances.
+func readServiceStartModes() map[string]string {
+	var rows []win32Service
+	if err := queryWMI("services", "SELECT Name, StartMode FROM Win32_Service", &rows, ""); err != nil {
+		return nil
+	}
+	modes := make(map[string]string, len(rows))
+	for _, r := range rows {
+		if !perUserService.MatchString(r.Name) {
+			modes[r.Name] = r.StartMode
+		}
+	}
+	return modes
+}
+
+// readFirewallRules describes each firewall rule by display name, e.g.
+// "allow inbound, enabled". Rules sharing a name are told apart by a
+// number.
+func readFirewallRules() map[string]string {
+	var rows []msftNetFirewallRule
+	if err := queryWMI("firewallRules", "SELECT DisplayName, Enabled, Action, Direction FROM MSFT_NetFirewallRule", &rows, firewallNamespace); err != nil {
+		return nil
+	}
+	rules := make(map[string]string, len(rows))
+	for _, r := range rows {
+		action := "allow"
+		if r.Action == 4 {
+			action = "block"
+		}
+		direction := "inbound"
+		if r.Direction == 2 {
+			direction = "outbound"
+		}
+		enabled := "enabled"
+		if r.Enabled != 1 {
+			enabled = "disabled"
+		}
+		name := r.DisplayName
+		for i := 2; rules[name] != ""; i++ {
+			name = fmt.Sprintf("%s (%d)", r.DisplayName, i)
+		}
+		rules[name] = action + " " + direction + ", " + enabled
+	}
+	return rules
+}
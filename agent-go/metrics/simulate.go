@@ -0,0 +1,108 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// simulatedPeriod is one cycle of a scenario: normal for the first half,
+// the fault for the second.
+const simulatedPeriod = 2 * time.Minute
+
+// scenarios maps --simulate names to the fault they inject. "steady" never
+// faults; "all" rotates through the rest.
+var scenarios = map[string]func(*MachineStatus, float64){
+	"steady":        nil,
+	"temp-spike":    faultTempSpike,
+	"nic-loss":      faultNICLoss,
+	"process-death": faultProcessDeath,
+	"disk-fill":     faultDiskFill,
+}
+
+// rotation is the order "all" cycles through.
+var rotation = []string{"temp-spike", "nic-loss", "process-death", "disk-fill"}
+
+// Scenarios returns the names accepted by NewSimulation.
+func Scenarios() []string {
+	return append([]string{"steady", "all"}, rotation...)
+}
+
+// Simulation replaces the headline metrics with scripted values and
+// injects faults on a schedule, so the dashboard and alert rules can be
+// exercised without stressing real hardware. Everything not scripted
+// (hostname, UUID, inventory) is still read from the machine.
+type Simulation struct {
+	name    string
+	started time.Time
+}
+
+// NewSimulation returns the named scenario.
+func NewSimulation(name string) (*Simulation, error) {
+	if _, ok := scenarios[name]; !ok && name != "all" {
+		return nil, fmt.Errorf("unknown scenario %q (want %s)", name, strings.Join(Scenarios(), ", "))
+	}
+	return &Simulation{name: name, started: time.Now()}, nil
+}
+
+// apply overwrites status with the scenario's values at now.
+func (s *Simulation) apply(status *MachineStatus, now time.Time) {
+	elapsed := now.Sub(s.started)
+	t := elapsed.Seconds()
+	wave := math.Sin(t / 30 * math.Pi) // gentle 60-second swing
+
+	status.Simulated = s.name
+	status.CPUUsagePercent = 30 + 10*wave
+	status.CPUTempCelsius = 55 + 5*wave
+	status.RAMUsagePercent = 45 + 3*wave
+	status.NetworkBytesPS = 5e6 + 2e6*wave
+	status.DiskBytesPS = 2e6 + 1e6*wave
+
+	cycle := int(elapsed / simulatedPeriod)
+	into := elapsed % simulatedPeriod
+	if into < simulatedPeriod/2 {
+		return
+	}
+	name := s.name
+	if name == "all" {
+		name = rotation[cycle%len(rotation)]
+	}
+	if fault := scenarios[name]; fault != nil {
+		// progress runs 0 to 1 over the fault half of the cycle.
+		fault(status, float64(into-simulatedPeriod/2)/float64(simulatedPeriod/2))
+	}
+}
+
+func faultTempSpike(s *MachineStatus, progress float64) {
+	s.CPUUsagePercent = 100
+	s.CPUTempCelsius = 70 + 30*math.Min(1, progress*2) // critical from about 25 seconds in
+}
+
+func faultNICLoss(s *MachineStatus, _ float64) {
+	if len(s.Networks) > 0 {
+		s.Networks = s.Networks[1:]
+	}
+	s.NetworkBytesPS = 0
+}
+
+func faultProcessDeath(s *MachineStatus, _ float64) {
+	s.CustomChecks = append(s.CustomChecks, CheckResult{
+		Name:   "obs64 (simulated)",
+		Kind:   "process",
+		Detail: "process is not running",
+	})
+	s.CPUUsagePercent = 5
+}
+
+func faultDiskFill(s *MachineStatus, progress float64) {
+	used := 92 + 7*progress
+	if len(s.Volumes) == 0 {
+		s.Volumes = []VolumeUsage{{Mount: "simulated", TotalBytes: 500e9}}
+	}
+	vols := append([]VolumeUsage(nil), s.Volumes...)
+	vols[0].UsedPercent = used
+	vols[0].FreeBytes = uint64(float64(vols[0].TotalBytes) * (100 - used) / 100)
+	s.Volumes = vols
+	s.DiskBytesPS = 400e6
+}
@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"net"
+	"slices"
+	"sort"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+)
+
+// SubnetCheck compares an interface's address with the subnets the config
+// expects for it. After a stage change a control NIC patched into the
+// Dante switch gets an address on the wrong network and nothing else
+// looks wrong.
+type SubnetCheck struct {
+	Expected []string `json:"expected"`
+	OK       bool     `json:"ok"`
+	// FoundRole is the role whose subnet the address is in instead, e.g.
+	// "dante" for a control NIC on the audio network; empty if none.
+	FoundRole string `json:"foundRole,omitempty"`
+}
+
+// checkSubnets attaches a SubnetCheck to each interface the config
+// declares subnets for, by name or by role.
+func checkSubnets(networks []NetworkInfo, cfg config.NetworkConfig) {
+	if len(cfg.Subnets) == 0 && len(cfg.SubnetsByRole) == 0 {
+		return
+	}
+	for i := range networks {
+		n := &networks[i]
+		expected := cfg.Subnets[n.InterfaceName]
+		if n.Role != "" {
+			expected = slices.Concat(expected, cfg.SubnetsByRole[n.Role])
+		}
+		if len(expected) == 0 {
+			continue
+		}
+		ip := net.ParseIP(n.IPAddress)
+		check := &SubnetCheck{Expected: expected, OK: inAny(ip, expected)}
+		if !check.OK {
+			check.FoundRole = roleOf(ip, n.Role, cfg.SubnetsByRole)
+		}
+		n.Subnet = check
+	}
+}
+
+// roleOf returns the first role, other than own, with a subnet holding ip.
+func roleOf(ip net.IP, own string, byRole map[string][]string) string {
+	roles := make([]string, 0, len(byRole))
+	for role := range byRole {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	for _, role := range roles {
+		if role != own && inAny(ip, byRole[role]) {
+			return role
+		}
+	}
+	return ""
+}
+
+// inAny reports whether ip is in one of subnets. Invalid subnets are
+// skipped; config validate reports them.
+func inAny(ip net.IP, subnets []string) bool {
+	if ip == nil {
+		return false
+	}
+	for _, s := range subnets {
+		if _, subnet, err := net.ParseCIDR(s); err == nil && subnet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -4,29 +4,33 @@ package metrics
 
 import (
 	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/shirou/gopsutil/v4/host"
 )
 
-// readHardwareUUID reads the SMBIOS UUID from DMI data (requires root).
-// Falls back to /etc/machine-id if DMI is unavailable.
-func readHardwareUUID() string {
-	data, err := os.ReadFile("/sys/class/dmi/id/product_uuid")
-	if err == nil {
-		uuid := strings.TrimSpace(string(data))
-		if uuid != "" && uuid != "Not Settable" {
-			return uuid
-		}
-	}
+// readSMBIOSUUID reads the SMBIOS UUID from DMI data (requires root), or
+// "".
+func readSMBIOSUUID() string {
+	data, _ := os.ReadFile("/sys/class/dmi/id/product_uuid")
+	return strings.TrimSpace(string(data))
+}
 
-	data, err = os.ReadFile("/etc/machine-id")
-	if err == nil {
-		return strings.TrimSpace(string(data))
-	}
+// readBoardSerial reads the motherboard serial number from DMI data
+// (requires root), or "".
+func readBoardSerial() string {
+	data, _ := os.ReadFile("/sys/class/dmi/id/board_serial")
+	return strings.TrimSpace(string(data))
+}
 
-	hostname, _ := os.Hostname()
-	return "unknown-" + hostname
+// readMachineID reads /etc/machine-id, which needs no root, or "". Agents
+// before the SMBIOS fallbacks used it as the UUID when DMI was unreadable.
+func readMachineID() string {
+	data, _ := os.ReadFile("/etc/machine-id")
+	return strings.TrimSpace(string(data))
 }
 
 // readChipType reads the CPU model name from /proc/cpuinfo.
@@ -78,3 +82,73 @@ func checkDiskEncryption() bool {
 	}
 	return strings.Contains(string(data), "/dev/mapper/")
 }
+
+// secureBootVar is the EFI global variable holding the Secure Boot state.
+const secureBootVar = "/sys/firmware/efi/efivars/SecureBoot-8be4df61-93ca-11d2-aa0d-00e098032b8c"
+
+// readHardwareInventory reads SMBIOS data from /sys/class/dmi/id (serials
+// need root) and DIMM details from dmidecode when it is installed.
+func readHardwareInventory() *HardwareInventory {
+	dmi := func(name string) string {
+		return readSysString(filepath.Join("/sys/class/dmi/id", name))
+	}
+	inv := &HardwareInventory{
+		Manufacturer: dmi("sys_vendor"),
+		Model:        dmi("product_name"),
+		SerialNumber: dmi("product_serial"),
+		Board:        strings.TrimSpace(dmi("board_vendor") + " " + dmi("board_name")),
+		BIOSVersion:  dmi("bios_version"),
+		BIOSDate:     dmi("bios_date"),
+	}
+
+	if _, err := os.Stat("/sys/class/tpm/tpm0"); err == nil {
+		inv.TPMPresent = true
+		inv.TPMVersion = readSysString("/sys/class/tpm/tpm0/tpm_version_major")
+	}
+
+	// efivars data is a 4-byte attribute header followed by the value byte
+	if data, err := os.ReadFile(secureBootVar); err == nil && len(data) >= 5 {
+		inv.SecureBoot = data[4] == 1
+	}
+
+	inv.MemoryModules = readDIMMs()
+	return inv
+}
+
+// readDIMMs parses `dmidecode -t 17` (Memory Device) output. Empty slots
+// report "No Module Installed" and are skipped.
+func readDIMMs() []MemoryModule {
+	out, err := exec.Command("dmidecode", "-t", "17").Output()
+	if err != nil {
+		return nil
+	}
+
+	var modules []MemoryModule
+	for _, block := range strings.Split(string(out), "Memory Device")[1:] {
+		fields := parseKeyValueLines(block, ":")
+		size := strings.Fields(fields["Size"])
+		if len(size) != 2 {
+			continue
+		}
+		capacity, err := strconv.ParseFloat(size[0], 64)
+		if err != nil {
+			continue
+		}
+		if size[1] == "MB" {
+			capacity /= 1024
+		}
+		var speed int
+		if f := strings.Fields(fields["Speed"]); len(f) > 0 {
+			speed, _ = strconv.Atoi(f[0])
+		}
+		modules = append(modules, MemoryModule{
+			Slot:         fields["Locator"],
+			CapacityGB:   capacity,
+			SpeedMHz:     speed,
+			Manufacturer: fields["Manufacturer"],
+			PartNumber:   fields["Part Number"],
+			SerialNumber: fields["Serial Number"],
+		})
+	}
+	return modules
+}
@@ -3,10 +3,11 @@
 package metrics
 
 import (
-	"os"
+	"strings"
+	"time"
 
 	"github.com/shirou/gopsutil/v4/host"
-	"github.com/yusufpapurcu/wmi"
+	"golang.org/x/sys/windows/registry"
 )
 
 // WMI query result structs
@@ -23,25 +24,54 @@ type win32EncryptableVolume struct {
 	ProtectionStatus uint32
 }
 
-// readHardwareUUID gets the SMBIOS machine UUID via WMI.
-func readHardwareUUID() string {
+// readSMBIOSUUID gets the SMBIOS machine UUID via WMI, or "".
+func readSMBIOSUUID() string {
 	var products []win32ComputerSystemProduct
-	err := wmi.Query("SELECT UUID FROM Win32_ComputerSystemProduct", &products)
+	err := queryWMI("hardwareUUID", "SELECT UUID FROM Win32_ComputerSystemProduct", &products, "")
 	if err != nil || len(products) == 0 {
-		hostname, _ := os.Hostname()
-		return "unknown-" + hostname
+		return ""
 	}
 	return products[0].UUID
 }
 
-// readChipType gets the CPU name via WMI.
+// readBoardSerial gets the motherboard serial number via WMI, or "".
+func readBoardSerial() string {
+	var boards []struct{ SerialNumber string }
+	err := queryWMI("hardwareUUID", "SELECT SerialNumber FROM Win32_BaseBoard", &boards, "")
+	if err != nil || len(boards) == 0 {
+		return ""
+	}
+	return boards[0].SerialNumber
+}
+
+// readMachineID returns "": Windows agents never used a machine ID.
+func readMachineID() string {
+	return ""
+}
+
+// readChipType gets the CPU name via WMI, falling back to the registry.
+// Some ARM64 firmware leaves Win32_Processor.Name generic (e.g. "ARMv8
+// (64-bit) Family 8 Model D4B ...") while the registry has the marketing
+// name.
 func readChipType() string {
 	var processors []win32Processor
-	err := wmi.Query("SELECT Name FROM Win32_Processor", &processors)
-	if err != nil || len(processors) == 0 {
+	err := queryWMI("chipType", "SELECT Name FROM Win32_Processor", &processors, "")
+	name := ""
+	if err == nil && len(processors) > 0 {
+		name = strings.TrimSpace(processors[0].Name)
+	}
+	if name == "" || strings.HasPrefix(name, "ARMv8") {
+		if k, err := registry.OpenKey(registry.LOCAL_MACHINE, `HARDWARE\DESCRIPTION\System\CentralProcessor\0`, registry.QUERY_VALUE); err == nil {
+			if s, _, err := k.GetStringValue("ProcessorNameString"); err == nil && strings.TrimSpace(s) != "" {
+				name = strings.TrimSpace(s)
+			}
+			k.Close()
+		}
+	}
+	if name == "" {
 		return "Unknown"
 	}
-	return processors[0].Name
+	return name
 }
 
 // readUptime returns system uptime in seconds.
@@ -65,7 +95,8 @@ func readOSVersion() string {
 // checkDiskEncryption queries WMI for BitLocker protection on the C: drive.
 func checkDiskEncryption() bool {
 	var volumes []win32EncryptableVolume
-	err := wmi.QueryNamespace(
+	err := queryWMI(
+		"diskEncryption",
 		"SELECT ProtectionStatus FROM Win32_EncryptableVolume WHERE DriveLetter='C:'",
 		&volumes,
 		`root\CIMv2\Security\MicrosoftVolumeEncryption`,
@@ -75,3 +106,88 @@ func checkDiskEncryption() bool {
 	}
 	return volumes[0].ProtectionStatus == 1
 }
+
+type win32ComputerSystem struct {
+	Manufacturer string
+	Model        string
+}
+
+type win32BIOS struct {
+	SerialNumber      string
+	SMBIOSBIOSVersion string
+	ReleaseDate       time.Time
+}
+
+type win32BaseBoard struct {
+	Manufacturer string
+	Product      string
+}
+
+type win32PhysicalMemory struct {
+	DeviceLocator string
+	Capacity      uint64
+	Speed         uint32
+	Manufacturer  string
+	PartNumber    string
+	SerialNumber  string
+}
+
+type win32Tpm struct {
+	SpecVersion string
+}
+
+const secureBootKey = `SYSTEM\CurrentControlSet\Control\SecureBoot\State`
+
+// readHardwareInventory gathers SMBIOS, firmware, and DIMM details via WMI.
+// TPM details require admin; without it TPMPresent stays false.
+func readHardwareInventory() *HardwareInventory {
+	inv := &HardwareInventory{}
+
+	var systems []win32ComputerSystem
+	if queryWMI("inventory", "SELECT Manufacturer, Model FROM Win32_ComputerSystem", &systems, "") == nil && len(systems) > 0 {
+		inv.Manufacturer = strings.TrimSpace(systems[0].Manufacturer)
+		inv.Model = strings.TrimSpace(systems[0].Model)
+	}
+
+	var bios []win32BIOS
+	if queryWMI("inventory", "SELECT SerialNumber, SMBIOSBIOSVersion, ReleaseDate FROM Win32_BIOS", &bios, "") == nil && len(bios) > 0 {
+		inv.SerialNumber = strings.TrimSpace(bios[0].SerialNumber)
+		inv.BIOSVersion = strings.TrimSpace(bios[0].SMBIOSBIOSVersion)
+		if !bios[0].ReleaseDate.IsZero() {
+			inv.BIOSDate = bios[0].ReleaseDate.Format("2006-01-02")
+		}
+	}
+
+	var boards []win32BaseBoard
+	if queryWMI("inventory", "SELECT Manufacturer, Product FROM Win32_BaseBoard", &boards, "") == nil && len(boards) > 0 {
+		inv.Board = strings.TrimSpace(boards[0].Manufacturer + " " + boards[0].Product)
+	}
+
+	var dimms []win32PhysicalMemory
+	if queryWMI("inventory", "SELECT DeviceLocator, Capacity, Speed, Manufacturer, PartNumber, SerialNumber FROM Win32_PhysicalMemory", &dimms, "") == nil {
+		for _, d := range dimms {
+			inv.MemoryModules = append(inv.MemoryModules, MemoryModule{
+				Slot:         d.DeviceLocator,
+				CapacityGB:   float64(d.Capacity) / (1024 * 1024 * 1024),
+				SpeedMHz:     int(d.Speed),
+				Manufacturer: strings.TrimSpace(d.Manufacturer),
+				PartNumber:   strings.TrimSpace(d.PartNumber),
+				SerialNumber: strings.TrimSpace(d.SerialNumber),
+			})
+		}
+	}
+
+	var tpms []win32Tpm
+	if queryWMI("tpm", "SELECT SpecVersion FROM Win32_Tpm", &tpms, `root\CIMV2\Security\MicrosoftTpm`) == nil && len(tpms) > 0 {
+		inv.TPMPresent = true
+		// SpecVersion is "2.0, 0, 1.59"; the first field is the TPM version
+		inv.TPMVersion, _, _ = strings.Cut(tpms[0].SpecVersion, ",")
+	}
+
+	if k, err := registry.OpenKey(registry.LOCAL_MACHINE, secureBootKey, registry.QUERY_VALUE); err == nil {
+		enabled, _, err := k.GetIntegerValue("UEFISecureBootEnabled")
+		k.Close()
+		inv.SecureBoot = err == nil && enabled == 1
+	}
+	return inv
+}
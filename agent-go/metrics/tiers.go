@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Status tiers let a dashboard poll a small payload every two seconds and
+// fetch the rest less often. GET /status?tiers=core,... serves only the
+// named tiers; without the parameter it serves the whole MachineStatus, as
+// it always has.
+const (
+	// TierCore is what the poll needs: every field the Swift MachineStatus
+	// requires, so a core-only payload still decodes, plus the flags that
+	// change how the rest is read.
+	TierCore = "core"
+	// TierExtended is everything not listed in another tier, including
+	// fields added later.
+	TierExtended = "extended"
+	// TierInventory is what changes on the scale of days: hardware, slots,
+	// licenses, settings. Fetch it with If-None-Match.
+	TierInventory = "inventory"
+)
+
+// StatusTiers lists the tiers in the order they are documented.
+var StatusTiers = []string{TierCore, TierExtended, TierInventory}
+
+// ErrUnknownTier is returned by TierJSON for a tier not in StatusTiers.
+var ErrUnknownTier = errors.New("unknown tier")
+
+var coreFields = map[string]bool{
+	"hardwareUUID": true, "hostname": true, "site": true,
+	"cpuTempCelsius": true, "cpuUsagePercent": true, "networkBytesPerSec": true,
+	"uptimeSeconds": true, "osVersion": true, "chipType": true, "networks": true,
+	"fileVaultEnabled": true, "agentVersion": true, "ramUsagePercent": true,
+	"ramTotalGB": true, "diskBytesPerSec": true, "maintenance": true,
+	"inputIdleSeconds": true, "operatorPresent": true, "simulated": true, "stale": true,
+}
+
+var inventoryFields = map[string]bool{
+	"hardware": true, "pcieLinks": true, "licenses": true, "certificates": true,
+	"power": true, "lastChanged": true,
+}
+
+// fieldTier returns the tier of a MachineStatus JSON field.
+func fieldTier(field string) string {
+	switch {
+	case coreFields[field]:
+		return TierCore
+	case inventoryFields[field]:
+		return TierInventory
+	}
+	return TierExtended
+}
+
+// TierJSON returns the fields of s in the given tiers as a JSON object.
+// An unknown tier is an ErrUnknownTier.
+func (s MachineStatus) TierJSON(tiers ...string) ([]byte, error) {
+	want := map[string]bool{}
+	for _, t := range tiers {
+		if t != TierCore && t != TierExtended && t != TierInventory {
+			return nil, fmt.Errorf("%w %q (want %v)", ErrUnknownTier, t, StatusTiers)
+		}
+		want[t] = true
+	}
+	full, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(full, &fields); err != nil {
+		return nil, err
+	}
+	for field := range fields {
+		if !want[fieldTier(field)] {
+			delete(fields, field)
+		}
+	}
+	return json.Marshal(fields)
+}
@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"strconv"
+	"strings"
+)
+
+// TimeSyncStatus describes how the system clock is disciplined.
+// Offsets are pointers because negative values are meaningful; nil means unknown.
+type TimeSyncStatus struct {
+	Synchronized bool      `json:"synchronized"`
+	Source       string    `json:"source,omitempty"`
+	OffsetMs     *float64  `json:"offsetMs,omitempty"`
+	PTP          PTPStatus `json:"ptp"`
+}
+
+// PTPStatus reports IEEE 1588 state for machines on Dante/AES67/SMPTE 2110 networks.
+type PTPStatus struct {
+	Active      bool     `json:"active"`
+	Client      string   `json:"client,omitempty"`
+	Grandmaster string   `json:"grandmaster,omitempty"`
+	OffsetNs    *float64 `json:"offsetNs,omitempty"`
+}
+
+// parseKeyValueLines splits "Key: value" or "key   value" style tool output
+// into a map. sep selects the separator; an empty sep splits on whitespace.
+func parseKeyValueLines(out, sep string) map[string]string {
+	fields := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		var key, val string
+		if sep == "" {
+			parts := strings.Fields(line)
+			if len(parts) < 2 {
+				continue
+			}
+			key, val = parts[0], strings.Join(parts[1:], " ")
+		} else {
+			idx := strings.Index(line, sep)
+			if idx <= 0 {
+				continue
+			}
+			key, val = strings.TrimSpace(line[:idx]), strings.TrimSpace(line[idx+len(sep):])
+		}
+		if _, seen := fields[key]; !seen {
+			fields[key] = val
+		}
+	}
+	return fields
+}
+
+// parseFloatPtr parses s (ignoring a trailing unit suffix like "s") and
+// returns a pointer to the scaled value, or nil if s is not a number.
+func parseFloatPtr(s, suffix string, scale float64) *float64 {
+	s = strings.TrimSuffix(strings.TrimSpace(s), suffix)
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil
+	}
+	v *= scale
+	return &v
+}
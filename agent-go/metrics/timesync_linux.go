@@ -0,0 +1,58 @@
+//go:build linux
+
+package metrics
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// readTimeSync reports NTP state from timedatectl/chrony and PTP state from
+// linuxptp (ptp4l), queried over its management socket with pmc.
+func readTimeSync() *TimeSyncStatus {
+	status := &TimeSyncStatus{}
+
+	if out, err := exec.Command("timedatectl", "show", "--property=NTPSynchronized", "--value").Output(); err == nil {
+		status.Synchronized = strings.TrimSpace(string(out)) == "yes"
+	}
+
+	// chronyc -c tracking: refid,name,stratum,reftime,systemOffsetSeconds,...
+	if out, err := exec.Command("chronyc", "-c", "tracking").Output(); err == nil {
+		parts := strings.Split(strings.TrimSpace(string(out)), ",")
+		if len(parts) > 4 {
+			status.Source = parts[1]
+			status.OffsetMs = parseFloatPtr(parts[4], "", 1000)
+		}
+	}
+
+	if !processRunning("ptp4l") {
+		return status
+	}
+	status.PTP.Active = true
+	status.PTP.Client = "ptp4l"
+
+	out, err := exec.Command("pmc", "-u", "-b", "0", "GET TIME_STATUS_NP").Output()
+	if err != nil {
+		return status
+	}
+	fields := parseKeyValueLines(string(out), "")
+	status.PTP.OffsetNs = parseFloatPtr(fields["master_offset"], "", 1)
+	if fields["gmPresent"] == "true" {
+		status.PTP.Grandmaster = fields["gmIdentity"]
+	}
+	return status
+}
+
+// processRunning returns true if a process with the given comm name exists.
+func processRunning(name string) bool {
+	comms, _ := filepath.Glob("/proc/[0-9]*/comm")
+	for _, c := range comms {
+		data, err := os.ReadFile(c)
+		if err == nil && strings.TrimSpace(string(data)) == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+//go:build windows
+
+package metrics
+
+import (
+	"strings"
+
+	"golang.org/x/sys/windows/registry"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/hidden"
+)
+
+const ptpClientKey = `SYSTEM\CurrentControlSet\Services\W32Time\TimeProviders\PtpClient`
+
+// readTimeSync queries the Windows Time service. The built-in PTP client
+// (Windows 10 1809+) is a w32time provider, so PTP state comes from the same
+// place as NTP state. w32tm output is English-only; other locales report
+// only what the registry tells us.
+func readTimeSync() *TimeSyncStatus {
+	status := &TimeSyncStatus{}
+
+	if k, err := registry.OpenKey(registry.LOCAL_MACHINE, ptpClientKey, registry.QUERY_VALUE); err == nil {
+		enabled, _, err := k.GetIntegerValue("Enabled")
+		k.Close()
+		if err == nil && enabled == 1 {
+			status.PTP.Active = true
+			status.PTP.Client = "w32time PtpClient"
+		}
+	}
+
+	out, err := hidden.Command("w32tm", "/query", "/status", "/verbose").Output()
+	if err != nil {
+		return status
+	}
+	fields := parseKeyValueLines(string(out), ":")
+
+	source := fields["Source"]
+	status.Source = source
+	status.Synchronized = source != "" &&
+		!strings.EqualFold(source, "Local CMOS Clock") &&
+		!strings.EqualFold(source, "Free-running System Clock")
+	status.OffsetMs = parseFloatPtr(fields["Phase Offset"], "s", 1000)
+
+	// When w32time is disciplined by PTP, the reference is the grandmaster.
+	if status.PTP.Active && strings.Contains(strings.ToLower(source), "ptp") {
+		status.PTP.Grandmaster = referenceIP(fields["ReferenceId"])
+		status.PTP.OffsetNs = parseFloatPtr(fields["Phase Offset"], "s", 1e9)
+	}
+	return status
+}
+
+// referenceIP extracts the address from "0x0A0A0B01 (source IP:  10.10.11.1)".
+func referenceIP(refID string) string {
+	if idx := strings.Index(refID, "source IP:"); idx >= 0 {
+		return strings.TrimSpace(strings.TrimSuffix(refID[idx+len("source IP:"):], ")"))
+	}
+	return refID
+}
@@ -0,0 +1,25 @@
+//go:build linux
+
+package metrics
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// readUSBDevices returns the connected USB devices as lowercase "vid:pid".
+func readUSBDevices() (map[string]bool, error) {
+	devices := make(map[string]bool)
+	dirs, err := filepath.Glob("/sys/bus/usb/devices/*")
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range dirs {
+		vid := readSysString(filepath.Join(dir, "idVendor"))
+		pid := readSysString(filepath.Join(dir, "idProduct"))
+		if vid != "" && pid != "" {
+			devices[strings.ToLower(vid+":"+pid)] = true
+		}
+	}
+	return devices, nil
+}
@@ -0,0 +1,33 @@
+//go:build windows
+
+package metrics
+
+import (
+	"regexp"
+	"strings"
+)
+
+// usbIDPattern finds the vendor and product in a PnP device ID such as
+// USB\VID_096E&PID_0006\5&1A2B3C&0&2.
+var usbIDPattern = regexp.MustCompile(`(?i)VID_([0-9A-F]{4})&PID_([0-9A-F]{4})`)
+
+type win32PnPEntity struct {
+	DeviceID string
+}
+
+// readUSBDevices returns the connected USB devices as lowercase "vid:pid".
+// Win32_PnPEntity lists only devices that are present. An error means the
+// query didn't run (failed or backed off), not that nothing is connected.
+func readUSBDevices() (map[string]bool, error) {
+	var entities []win32PnPEntity
+	if err := queryWMI("usbDevices", "SELECT DeviceID FROM Win32_PnPEntity WHERE DeviceID LIKE 'USB%'", &entities, ""); err != nil {
+		return nil, err
+	}
+	devices := make(map[string]bool)
+	for _, e := range entities {
+		if m := usbIDPattern.FindStringSubmatch(e.DeviceID); m != nil {
+			devices[strings.ToLower(m[1]+":"+m[2])] = true
+		}
+	}
+	return devices, nil
+}
@@ -0,0 +1,23 @@
+package metrics
+
+// VMGuest is a virtual machine on this host. Hosts run playback and
+// encoder VMs that live or die with them, so the host reports its guests.
+type VMGuest struct {
+	Name          string  `json:"name"`
+	ID            string  `json:"id"`
+	State         string  `json:"state"` // running, off, saved, paused, starting, stopping, ...
+	Processors    int     `json:"processors,omitempty"`
+	MemoryMB      int     `json:"memoryMB,omitempty"` // startup memory
+	DynamicMemory bool    `json:"dynamicMemory,omitempty"`
+	UptimeSeconds float64 `json:"uptimeSeconds,omitempty"`
+	// Heartbeat is the guest integration heartbeat: ok, degraded, error,
+	// noContact, lostCommunication, paused, or disabled. Empty when the
+	// guest has no heartbeat service.
+	Heartbeat string `json:"heartbeat,omitempty"`
+}
+
+// HeartbeatLost reports whether a running guest has stopped answering its
+// heartbeat: the guest OS has hung or crashed while the VM still runs.
+func (v VMGuest) HeartbeatLost() bool {
+	return v.State == "running" && (v.Heartbeat == "noContact" || v.Heartbeat == "lostCommunication")
+}
@@ -0,0 +1,8 @@
+//go:build linux
+
+package metrics
+
+// readVMs returns nil: only Hyper-V guests are enumerated.
+func readVMs() []VMGuest {
+	return nil
+}
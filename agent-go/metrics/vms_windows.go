@@ -0,0 +1,117 @@
+//go:build windows
+
+package metrics
+
+import "strings"
+
+const hyperVNamespace = `root\virtualization\v2`
+
+type msvmComputerSystem struct {
+	Name                 string // VM GUID
+	ElementName          string // display name
+	EnabledState         uint16
+	OnTimeInMilliseconds uint64
+}
+
+type msvmSettingData struct {
+	InstanceID           string // "Microsoft:<VM GUID>\..." for the running config
+	VirtualQuantity      uint64
+	DynamicMemoryEnabled bool
+}
+
+type msvmProcessorSettingData struct {
+	InstanceID      string
+	VirtualQuantity uint64
+}
+
+type msvmHeartbeatComponent struct {
+	SystemName        string // VM GUID
+	EnabledState      uint16
+	OperationalStatus []uint16
+}
+
+// vmStates names Msvm_ComputerSystem.EnabledState values.
+var vmStates = map[uint16]string{
+	2:     "running",
+	3:     "off",
+	6:     "saved",
+	9:     "paused",
+	10:    "starting",
+	32768: "paused",
+	32769: "saved",
+	32770: "starting",
+	32771: "snapshotting",
+	32773: "saving",
+	32774: "stopping",
+	32776: "pausing",
+	32777: "resuming",
+}
+
+// heartbeatStates names the first Msvm_HeartbeatComponent.OperationalStatus.
+var heartbeatStates = map[uint16]string{
+	2:  "ok",
+	3:  "degraded",
+	7:  "error",
+	12: "noContact",
+	13: "lostCommunication",
+	15: "paused",
+}
+
+// readVMs enumerates Hyper-V guests. Without the Virtual Machine
+// Management service (vmms) there is no Hyper-V, and the namespace isn't
+// queried. Reading it needs admin or Hyper-V Administrators membership.
+func readVMs() []VMGuest {
+	if len(findProcesses("vmms")) == 0 {
+		return nil
+	}
+	var systems []msvmComputerSystem
+	err := queryWMI("vms",
+		"SELECT Name, ElementName, EnabledState, OnTimeInMilliseconds FROM Msvm_ComputerSystem WHERE Caption = 'Virtual Machine'",
+		&systems, hyperVNamespace)
+	if err != nil {
+		return nil
+	}
+	var memory []msvmSettingData
+	queryWMI("vmMemory", "SELECT InstanceID, VirtualQuantity, DynamicMemoryEnabled FROM Msvm_MemorySettingData", &memory, hyperVNamespace)
+	var processors []msvmProcessorSettingData
+	queryWMI("vmProcessors", "SELECT InstanceID, VirtualQuantity FROM Msvm_ProcessorSettingData", &processors, hyperVNamespace)
+	var heartbeats []msvmHeartbeatComponent
+	queryWMI("vmHeartbeats", "SELECT SystemName, EnabledState, OperationalStatus FROM Msvm_HeartbeatComponent", &heartbeats, hyperVNamespace)
+
+	var vms []VMGuest
+	for _, s := range systems {
+		vm := VMGuest{Name: s.ElementName, ID: s.Name, State: vmStates[s.EnabledState]}
+		if vm.State == "" {
+			vm.State = "unknown"
+		}
+		if vm.State == "running" {
+			vm.UptimeSeconds = float64(s.OnTimeInMilliseconds) / 1000
+		}
+		// Snapshots carry settings too, under their own IDs; the VM's
+		// current settings are under its own.
+		prefix := strings.ToUpper("Microsoft:" + s.Name + `\`)
+		for _, m := range memory {
+			if strings.HasPrefix(strings.ToUpper(m.InstanceID), prefix) {
+				vm.MemoryMB, vm.DynamicMemory = int(m.VirtualQuantity), m.DynamicMemoryEnabled
+			}
+		}
+		for _, p := range processors {
+			if strings.HasPrefix(strings.ToUpper(p.InstanceID), prefix) {
+				vm.Processors = int(p.VirtualQuantity)
+			}
+		}
+		for _, h := range heartbeats {
+			if !strings.EqualFold(h.SystemName, s.Name) {
+				continue
+			}
+			switch {
+			case h.EnabledState == 3:
+				vm.Heartbeat = "disabled"
+			case len(h.OperationalStatus) > 0:
+				vm.Heartbeat = heartbeatStates[h.OperationalStatus[0]]
+			}
+		}
+		vms = append(vms, vm)
+	}
+	return vms
+}
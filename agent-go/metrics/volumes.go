@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"strings"
+
+	"github.com/shirou/gopsutil/v4/disk"
+)
+
+// VolumeUsage reports capacity of a mounted volume.
+type VolumeUsage struct {
+	Mount       string  `json:"mount"`
+	TotalBytes  uint64  `json:"totalBytes"`
+	FreeBytes   uint64  `json:"freeBytes"`
+	UsedPercent float64 `json:"usedPercent"`
+}
+
+// pseudoFilesystems never fill up in a way an operator can act on.
+var pseudoFilesystems = map[string]bool{
+	"tmpfs": true, "devtmpfs": true, "squashfs": true, "overlay": true, "ramfs": true, "iso9660": true,
+}
+
+// readVolumes returns usage for physical volumes (fixed drives on Windows).
+func readVolumes() []VolumeUsage {
+	parts, err := disk.Partitions(false)
+	if err != nil {
+		return nil
+	}
+	var volumes []VolumeUsage
+	seen := make(map[string]bool)
+	for _, p := range parts {
+		if pseudoFilesystems[strings.ToLower(p.Fstype)] || seen[p.Mountpoint] {
+			continue
+		}
+		seen[p.Mountpoint] = true
+		usage, err := disk.Usage(p.Mountpoint)
+		if err != nil || usage.Total == 0 {
+			continue // empty card reader, unmounted media
+		}
+		volumes = append(volumes, VolumeUsage{
+			Mount:       p.Mountpoint,
+			TotalBytes:  usage.Total,
+			FreeBytes:   usage.Free,
+			UsedPercent: usage.UsedPercent,
+		})
+	}
+	return volumes
+}
@@ -0,0 +1,123 @@
+//go:build windows
+
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/yusufpapurcu/wmi"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/schedule"
+)
+
+// sessionIdle is how long a provider's WMI session may go unused before it
+// is closed. Providers read once at start don't hold a thread forever.
+const sessionIdle = 5 * time.Minute
+
+// A query that fails isn't run again until its backoff expires; many
+// machines have no ACPI thermal zone, and asking every five seconds only
+// costs CPU. Callers get the last error meanwhile.
+const (
+	minRetry = 10 * time.Second
+	maxRetry = 5 * time.Minute
+)
+
+// wmiSessions holds one WMI session per provider. A session keeps COM
+// initialized and the SWbemLocator open on its own OS thread, so a query
+// skips the per-call setup that dominated the agent's CPU on low-end
+// machines. Per provider rather than shared: the package-level wmi.Query
+// serializes every query behind one lock, so a hung thermal zone query
+// stalled Defender, drivers, and adapters with it.
+var wmiSessions = struct {
+	sync.Mutex
+	m       map[string]*wmiSession
+	janitor sync.Once
+}{m: make(map[string]*wmiSession)}
+
+type wmiSession struct {
+	services *wmi.SWbemServices
+	lastUsed time.Time
+	failing  map[string]*failedQuery // by query
+}
+
+type failedQuery struct {
+	retry     schedule.Backoff
+	notBefore time.Time
+	err       error
+}
+
+// queryWMI runs a WMI query in the given namespace (default root\CIMv2 when
+// empty) on provider's session and records the outcome under provider for
+// /healthz.
+func queryWMI(provider, query string, dst interface{}, namespace string) error {
+	s, err := sessionFor(provider)
+	if err != nil {
+		recordProvider(provider, err)
+		return err
+	}
+	wmiSessions.Lock()
+	failed := s.failing[query]
+	if failed != nil && time.Now().Before(failed.notBefore) {
+		err = failed.err
+		wmiSessions.Unlock()
+		return err
+	}
+	wmiSessions.Unlock()
+
+	if namespace == "" {
+		err = s.services.Query(query, dst)
+	} else {
+		err = s.services.Query(query, dst, nil, namespace)
+	}
+	recordProvider(provider, err)
+
+	wmiSessions.Lock()
+	defer wmiSessions.Unlock()
+	if err == nil {
+		delete(s.failing, query)
+		return nil
+	}
+	if failed == nil {
+		failed = &failedQuery{retry: schedule.Backoff{Min: minRetry, Max: maxRetry}}
+		s.failing[query] = failed
+	}
+	failed.notBefore = time.Now().Add(failed.retry.Fail())
+	failed.err = err
+	return err
+}
+
+// sessionFor returns provider's session, starting one if needed.
+func sessionFor(provider string) (*wmiSession, error) {
+	wmiSessions.janitor.Do(func() { go closeIdleSessions() })
+
+	wmiSessions.Lock()
+	defer wmiSessions.Unlock()
+	s := wmiSessions.m[provider]
+	if s == nil {
+		services, err := wmi.InitializeSWbemServices(wmi.DefaultClient)
+		if err != nil {
+			return nil, err
+		}
+		s = &wmiSession{services: services, failing: make(map[string]*failedQuery)}
+		wmiSessions.m[provider] = s
+	}
+	s.lastUsed = time.Now()
+	return s, nil
+}
+
+// closeIdleSessions closes sessions unused for sessionIdle. Blocks forever.
+func closeIdleSessions() {
+	for range time.Tick(time.Minute) {
+		wmiSessions.Lock()
+		for provider, s := range wmiSessions.m {
+			if time.Since(s.lastUsed) >= sessionIdle {
+				delete(wmiSessions.m, provider)
+				// Close waits for a query in flight; a hung one mustn't
+				// hold the lock.
+				go s.services.Close()
+			}
+		}
+		wmiSessions.Unlock()
+	}
+}
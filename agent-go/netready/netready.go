@@ -0,0 +1,77 @@
+// Package netready tells when the machine has a usable network. An agent
+// started from the Run key or as a service often comes up before DHCP has
+// finished, and an mDNS record announced then carries no address the
+// dashboard can reach.
+package netready
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// pollInterval is how often Wait re-checks the interfaces.
+const pollInterval = 2 * time.Second
+
+// Usable reports whether an up, non-loopback interface has a routable IPv4
+// address. Link-local (169.254.x.x) addresses don't count: Windows assigns
+// one while DHCP is still pending. IPv6 doesn't count either, since a SLAAC
+// address appears before DHCP finishes and dashboards connect over IPv4.
+func Usable() bool {
+	return len(Addresses()) > 0
+}
+
+// Addresses returns the routable IPv4 addresses of the up, non-loopback
+// interfaces, keyed by interface name. Interfaces without one are left out.
+func Addresses() map[string][]string {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+	addresses := make(map[string][]string)
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			ipnet, ok := addr.(*net.IPNet)
+			if ok && ipnet.IP.To4() != nil && ipnet.IP.IsGlobalUnicast() {
+				addresses[iface.Name] = append(addresses[iface.Name], ipnet.IP.String())
+			}
+		}
+	}
+	return addresses
+}
+
+// Wait blocks until the network is usable, timeout passes, or ctx is
+// cancelled, and reports whether the network is usable. A timeout of zero
+// or less waits until ctx is cancelled.
+func Wait(ctx context.Context, timeout time.Duration) bool {
+	if Usable() {
+		return true
+	}
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-expired:
+			return false
+		case <-ticker.C:
+			if Usable() {
+				return true
+			}
+		}
+	}
+}
@@ -0,0 +1,48 @@
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/i18n"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/update"
+)
+
+// showReleaseNotes shows a release's notes as a page in the browser. The
+// notes are markdown; they are shown as written rather than rendered.
+func showReleaseNotes(notes update.ReleaseNotes) {
+	title := i18n.T("notes.title", notes.Version)
+	body := notes.Notes
+	if body == "" {
+		body = i18n.T("notes.none")
+	}
+	link := ""
+	if notes.URL != "" {
+		link = fmt.Sprintf(`<p><a href="%s">%s</a></p>`, html.EscapeString(notes.URL), html.EscapeString(i18n.T("notes.link")))
+	}
+	page := fmt.Sprintf(`<!DOCTYPE html><html><head><meta charset="utf-8"><title>%s</title></head>`+
+		`<body style="font-family:sans-serif;max-width:48em;margin:5vh auto"><h2>%s</h2>`+
+		`<pre style="font-family:inherit;white-space:pre-wrap">%s</pre>%s</body></html>`,
+		html.EscapeString(title), html.EscapeString(title), html.EscapeString(body), link)
+
+	path := filepath.Join(os.TempDir(), "dashboard-agent-notes.html")
+	if err := os.WriteFile(path, []byte(page), 0o600); err != nil {
+		log.Printf("Release notes: %v", err)
+		return
+	}
+	exec.Command("explorer.exe", path).Start()
+}
+
+// notesToast adds the notes' first line to a notification message.
+func notesToast(message string, notes update.ReleaseNotes) string {
+	if summary := notes.Summary(); summary != "" {
+		return message + "\n" + summary
+	}
+	return message
+}
@@ -0,0 +1,156 @@
+//go:build !lite
+
+package obs
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// obs-websocket 5 opcodes.
+const (
+	opHello           = 0
+	opIdentify        = 1
+	opIdentified      = 2
+	opRequest         = 6
+	opRequestResponse = 7
+)
+
+// codeResourceNotFound is the request status for an unknown input.
+const codeResourceNotFound = 600
+
+// errNotFound is returned by request when OBS has no such resource.
+var errNotFound = errors.New("not found")
+
+type message struct {
+	Op   int             `json:"op"`
+	Data json.RawMessage `json:"d"`
+}
+
+// client is a session with obs-websocket 5 (OBS 28 and later).
+type client struct {
+	ws     *wsConn
+	nextID int
+}
+
+// connect opens a session, authenticating with password when OBS asks for
+// one. No events are subscribed to.
+func connect(address, password string, timeout time.Duration) (*client, error) {
+	ws, err := dialWebSocket(address, "obswebsocket.json", time.Now().Add(timeout))
+	if err != nil {
+		return nil, err
+	}
+	c := &client{ws: ws}
+	if err := c.identify(password); err != nil {
+		ws.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *client) Close() error {
+	return c.ws.Close()
+}
+
+func (c *client) identify(password string) error {
+	var hello struct {
+		RPCVersion     int `json:"rpcVersion"`
+		Authentication *struct {
+			Challenge string `json:"challenge"`
+			Salt      string `json:"salt"`
+		} `json:"authentication"`
+	}
+	if err := c.read(opHello, &hello); err != nil {
+		return err
+	}
+
+	identify := map[string]any{"rpcVersion": 1, "eventSubscriptions": 0}
+	if auth := hello.Authentication; auth != nil {
+		if password == "" {
+			return errors.New("OBS requires a WebSocket password (obs.password)")
+		}
+		secret := sha256.Sum256([]byte(password + auth.Salt))
+		response := sha256.Sum256([]byte(base64.StdEncoding.EncodeToString(secret[:]) + auth.Challenge))
+		identify["authentication"] = base64.StdEncoding.EncodeToString(response[:])
+	}
+	if err := c.write(opIdentify, identify); err != nil {
+		return err
+	}
+	return c.read(opIdentified, nil)
+}
+
+// request sends a request and decodes its response data into out, which
+// may be nil.
+func (c *client) request(requestType string, data, out any) error {
+	c.nextID++
+	id := strconv.Itoa(c.nextID)
+	req := map[string]any{"requestType": requestType, "requestId": id}
+	if data != nil {
+		req["requestData"] = data
+	}
+	if err := c.write(opRequest, req); err != nil {
+		return err
+	}
+	for {
+		var resp struct {
+			RequestID     string `json:"requestId"`
+			RequestStatus struct {
+				Result  bool   `json:"result"`
+				Code    int    `json:"code"`
+				Comment string `json:"comment"`
+			} `json:"requestStatus"`
+			ResponseData json.RawMessage `json:"responseData"`
+		}
+		if err := c.read(opRequestResponse, &resp); err != nil {
+			return err
+		}
+		if resp.RequestID != id {
+			continue
+		}
+		switch status := resp.RequestStatus; {
+		case status.Code == codeResourceNotFound:
+			return errNotFound
+		case !status.Result:
+			return fmt.Errorf("%s: %s (code %d)", requestType, status.Comment, status.Code)
+		}
+		if out == nil || len(resp.ResponseData) == 0 {
+			return nil
+		}
+		return json.Unmarshal(resp.ResponseData, out)
+	}
+}
+
+func (c *client) write(op int, data any) error {
+	body, err := json.Marshal(map[string]any{"op": op, "d": data})
+	if err != nil {
+		return err
+	}
+	return c.ws.writeText(body)
+}
+
+// read waits for a message with opcode op, skipping others, and decodes its
+// data into out, which may be nil.
+func (c *client) read(op int, out any) error {
+	for {
+		raw, err := c.ws.readMessage()
+		if err != nil {
+			return err
+		}
+		var msg message
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			return fmt.Errorf("OBS sent invalid JSON: %w", err)
+		}
+		if msg.Op != op {
+			continue
+		}
+		if out == nil {
+			return nil
+		}
+		return json.Unmarshal(msg.Data, out)
+	}
+}
@@ -0,0 +1,214 @@
+//go:build !lite
+
+package obs
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/launch"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/mdns"
+)
+
+const (
+	checkInterval = 30 * time.Second
+	timeout       = 5 * time.Second
+	defaultPort   = 4455
+	// ndiBrowseTimeout is how long NDI senders get to answer.
+	ndiBrowseTimeout = 2 * time.Second
+)
+
+// processes are OBS's process names on Windows and Linux.
+var processes = []string{"obs64", "obs"}
+
+// Monitor checks OBS every 30 seconds. Safe for concurrent use.
+type Monitor struct {
+	cfg     config.OBSConfig
+	address string
+
+	mu     sync.Mutex
+	status Status
+}
+
+// New creates a monitor. It returns nil when nothing is configured to be
+// checked.
+func New(cfg config.OBSConfig) *Monitor {
+	if cfg.Profile == "" && cfg.SceneCollection == "" && len(cfg.Sources) == 0 &&
+		cfg.Stream.Server == "" && cfg.Stream.KeySHA256 == "" {
+		return nil
+	}
+	port := cfg.Port
+	if port == 0 {
+		port = defaultPort
+	}
+	return &Monitor{cfg: cfg, address: net.JoinHostPort("127.0.0.1", strconv.Itoa(port))}
+}
+
+// Status returns the latest check, or nil when OBS isn't configured.
+func (m *Monitor) Status() *Status {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	status := m.status
+	return &status
+}
+
+// Run checks OBS every 30 seconds. Blocks forever.
+func (m *Monitor) Run() {
+	for {
+		status := m.check()
+		now := time.Now()
+		status.CheckedAt = &now
+		m.mu.Lock()
+		m.status = status
+		m.mu.Unlock()
+		time.Sleep(checkInterval)
+	}
+}
+
+// check connects for one check. OBS that isn't running isn't checked:
+// whether it should be is for startupApps or the kiosk watchdog.
+func (m *Monitor) check() Status {
+	var status Status
+	for _, name := range processes {
+		status.Running = status.Running || len(launch.PIDs(name)) > 0
+	}
+	if !status.Running {
+		return status
+	}
+
+	c, err := connect(m.address, m.cfg.Password, timeout)
+	if err != nil {
+		status.Error = fmt.Sprintf("connecting to the WebSocket server on %s: %v", m.address, err)
+		return status
+	}
+	defer c.Close()
+	if err := m.inspect(c, &status); err != nil {
+		status.Error = err.Error()
+	}
+	return status
+}
+
+func (m *Monitor) inspect(c *client, status *Status) error {
+	var profiles struct {
+		Current string `json:"currentProfileName"`
+	}
+	if err := c.request("GetProfileList", nil, &profiles); err != nil {
+		return err
+	}
+	var collections struct {
+		Current string `json:"currentSceneCollectionName"`
+	}
+	if err := c.request("GetSceneCollectionList", nil, &collections); err != nil {
+		return err
+	}
+	status.Profile, status.SceneCollection = profiles.Current, collections.Current
+	if m.cfg.Profile != "" && profiles.Current != m.cfg.Profile {
+		status.Problems = append(status.Problems, fmt.Sprintf("profile is %q, expected %q", profiles.Current, m.cfg.Profile))
+	}
+	if m.cfg.SceneCollection != "" && collections.Current != m.cfg.SceneCollection {
+		status.Problems = append(status.Problems, fmt.Sprintf("scene collection is %q, expected %q", collections.Current, m.cfg.SceneCollection))
+	}
+
+	if m.cfg.Stream.Server != "" || m.cfg.Stream.KeySHA256 != "" {
+		stream, err := inspectStream(c, m.cfg.Stream)
+		if err != nil {
+			return err
+		}
+		status.Stream = stream
+	}
+
+	var senders map[string]bool // NDI senders on the network, looked up once
+	for _, name := range m.cfg.Sources {
+		source, err := inspectSource(c, name, func() map[string]bool {
+			if senders == nil {
+				senders = ndiSenders()
+			}
+			return senders
+		})
+		if err != nil {
+			return err
+		}
+		status.Sources = append(status.Sources, source)
+	}
+	return nil
+}
+
+// inspectSource checks one input: that it exists, that its file does, that
+// a media source hasn't failed, and that an NDI source's sender is on the
+// network.
+func inspectSource(c *client, name string, senders func() map[string]bool) (Source, error) {
+	source := Source{Name: name}
+	var input struct {
+		Kind     string         `json:"inputKind"`
+		Settings map[string]any `json:"inputSettings"`
+	}
+	err := c.request("GetInputSettings", map[string]any{"inputName": name}, &input)
+	if errors.Is(err, errNotFound) {
+		source.Problem = "not in the scene collection"
+		return source, nil
+	} else if err != nil {
+		return source, err
+	}
+	source.Kind = input.Kind
+
+	if path := sourceFile(input.Kind, input.Settings); path != "" {
+		if _, err := os.Stat(path); err != nil {
+			source.Problem = "file missing: " + path
+			return source, nil
+		}
+	}
+	switch input.Kind {
+	case "ffmpeg_source", "vlc_source":
+		var media struct {
+			State string `json:"mediaState"`
+		}
+		if err := c.request("GetMediaInputStatus", map[string]any{"inputName": name}, &media); err != nil {
+			return source, err
+		}
+		if media.State == "OBS_MEDIA_STATE_ERROR" {
+			source.Problem = "media failed to play"
+		}
+	case "ndi_source":
+		if sender, _ := input.Settings["ndi_source_name"].(string); sender != "" && !senders()[sender] {
+			source.Problem = "NDI sender " + sender + " not found on the network"
+		}
+	}
+	return source, nil
+}
+
+// sourceFile returns the local file an input plays or shows, if any.
+func sourceFile(kind string, settings map[string]any) string {
+	switch kind {
+	case "ffmpeg_source":
+		if local, ok := settings["is_local_file"].(bool); ok && !local {
+			return ""
+		}
+		path, _ := settings["local_file"].(string)
+		return path
+	case "image_source":
+		path, _ := settings["file"].(string)
+		return path
+	}
+	return ""
+}
+
+// ndiSenders returns the NDI senders answering over mDNS, by the name NDI
+// sources store: "MACHINE (Source)". Senders registered only with an NDI
+// Discovery Server don't answer, so NDI sources can't be listed there.
+func ndiSenders() map[string]bool {
+	names, _ := mdns.Instances("_ndi._tcp", ndiBrowseTimeout)
+	senders := map[string]bool{}
+	for _, name := range names {
+		senders[name] = true
+	}
+	return senders
+}
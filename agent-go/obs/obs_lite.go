@@ -0,0 +1,25 @@
+//go:build lite
+
+package obs
+
+import (
+	"log"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+)
+
+// Monitor is a stub: lite builds leave out the obs-websocket client, and
+// New never returns one.
+type Monitor struct{}
+
+// New only warns in lite builds when OBS checks are configured.
+func New(cfg config.OBSConfig) *Monitor {
+	if cfg.Profile != "" || cfg.SceneCollection != "" || len(cfg.Sources) > 0 ||
+		cfg.Stream.Server != "" || cfg.Stream.KeySHA256 != "" {
+		log.Printf("Lite build: obs is configured but not included; ignoring it")
+	}
+	return nil
+}
+
+func (m *Monitor) Status() *Status { return nil }
+func (m *Monitor) Run()            {}
@@ -0,0 +1,49 @@
+// Package obs checks OBS Studio on this machine through obs-websocket: the
+// active profile and scene collection must be the expected ones, and the
+// listed sources must not be in error. The wrong profile streams to the
+// wrong stream key, and nothing in OBS looks wrong. The stream key itself
+// is checked against a hash, so it never leaves the machine.
+package obs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+// Status is reported in /status while OBS checks are configured.
+type Status struct {
+	Running         bool       `json:"running"`
+	Profile         string     `json:"profile,omitempty"`
+	SceneCollection string     `json:"sceneCollection,omitempty"`
+	Sources         []Source   `json:"sources,omitempty"`
+	Stream          *Stream    `json:"stream,omitempty"`
+	Problems        []string   `json:"problems,omitempty"` // profile and scene collection mismatches
+	Error           string     `json:"error,omitempty"`    // OBS runs but couldn't be checked
+	CheckedAt       *time.Time `json:"checkedAt,omitempty"`
+}
+
+// Source is one of the configured sources.
+type Source struct {
+	Name    string `json:"name"`
+	Kind    string `json:"kind,omitempty"` // OBS input kind, e.g. "ffmpeg_source"
+	Problem string `json:"problem,omitempty"`
+}
+
+// Stream is the stream destination check. The key is hashed inside the
+// agent and only whether it matches is reported.
+type Stream struct {
+	Service       string `json:"service,omitempty"` // e.g. "rtmp_custom"
+	ServerMatches *bool  `json:"serverMatches,omitempty"`
+	KeyMatches    *bool  `json:"keyMatches,omitempty"`
+	Problem       string `json:"problem,omitempty"`
+}
+
+// HashKey returns the hex SHA-256 of a stream key, the form
+// obs.stream.keySha256 takes. Surrounding whitespace is ignored, since
+// keys are pasted.
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(key)))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,55 @@
+//go:build !lite
+
+package obs
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+)
+
+// inspectStream compares OBS's stream destination with the expected one.
+// A key pasted from last year's event streams nowhere, or to last year's
+// event, and OBS shows nothing wrong until someone checks the platform.
+func inspectStream(c *client, want config.OBSStreamConfig) (*Stream, error) {
+	var service struct {
+		Type     string `json:"streamServiceType"`
+		Settings struct {
+			Server string `json:"server"`
+			Key    string `json:"key"`
+		} `json:"streamServiceSettings"`
+	}
+	if err := c.request("GetStreamServiceSettings", nil, &service); err != nil {
+		return nil, err
+	}
+	stream := &Stream{Service: service.Type}
+	var problems []string
+	if want.Server != "" {
+		ok := sameServer(service.Settings.Server, want.Server)
+		stream.ServerMatches = &ok
+		if !ok {
+			// The server isn't echoed: custom RTMP URLs often carry the key.
+			problems = append(problems, "stream server is not the expected one")
+		}
+	}
+	if want.KeySHA256 != "" {
+		got := HashKey(service.Settings.Key)
+		ok := subtle.ConstantTimeCompare([]byte(got), []byte(strings.ToLower(want.KeySHA256))) == 1
+		stream.KeyMatches = &ok
+		if !ok {
+			problems = append(problems, "stream key does not match the expected key")
+		}
+	}
+	if len(problems) > 0 {
+		stream.Problem = strings.Join(problems, "; ")
+	}
+	return stream, nil
+}
+
+// sameServer compares stream server URLs, ignoring case and a trailing
+// slash.
+func sameServer(got, want string) bool {
+	trim := func(s string) string { return strings.TrimRight(strings.TrimSpace(s), "/") }
+	return strings.EqualFold(trim(got), trim(want))
+}
@@ -0,0 +1,192 @@
+// Package pairing establishes trust between a dashboard and the agent
+// without typing shared secrets: the agent shows a short code, the
+// dashboard submits it to POST /pair, and the agent issues a long-lived
+// token for that dashboard. Only token hashes are stored.
+package pairing
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/events"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/state"
+)
+
+const (
+	stateKey = "pairings"
+	// maxAttempts wrong codes close the window, so a six-digit code can't
+	// be brute-forced.
+	maxAttempts = 5
+)
+
+// Pairing errors returned by Pair.
+var (
+	ErrNotOpen   = errors.New("pairing is not open on this agent")
+	ErrWrongCode = errors.New("wrong pairing code")
+)
+
+// Dashboard is a paired dashboard.
+type Dashboard struct {
+	Name      string    `json:"name"`
+	Scope     string    `json:"scope"`
+	Paired    time.Time `json:"paired"`
+	TokenHash string    `json:"tokenHash,omitempty"`
+}
+
+// Manager holds the current pairing window and the paired dashboards.
+// Safe for concurrent use.
+type Manager struct {
+	store *state.Store
+	scope string
+
+	mu         sync.Mutex
+	code       string
+	expires    time.Time
+	attempts   int
+	dashboards []Dashboard
+}
+
+// New loads paired dashboards from the store. New pairings get scope
+// ("operator" if empty).
+func New(store *state.Store, scope string) *Manager {
+	if scope == "" {
+		scope = "operator"
+	}
+	m := &Manager{store: store, scope: scope}
+	store.Get(stateKey, &m.dashboards)
+	return m
+}
+
+// Open starts a pairing window and returns its code, formatted "123-456".
+func (m *Manager) Open(window time.Duration) string {
+	n, _ := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	code := fmt.Sprintf("%06d", n.Int64())
+
+	m.mu.Lock()
+	m.code, m.expires, m.attempts = code, time.Now().Add(window), 0
+	m.mu.Unlock()
+
+	events.Record(events.RemoteAction, events.Info, "Pairing: window open for %s", window)
+	return code[:3] + "-" + code[3:]
+}
+
+// Close ends the pairing window.
+func (m *Manager) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.code = ""
+}
+
+// Code returns the open code and its expiry, or "" when pairing is closed.
+func (m *Manager) Code() (string, time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.code == "" || time.Now().After(m.expires) {
+		return "", time.Time{}
+	}
+	return m.code[:3] + "-" + m.code[3:], m.expires
+}
+
+// Pair checks code and, if it matches, issues a token for the named
+// dashboard. A dashboard that pairs again replaces its old token. The
+// window closes after a successful pairing.
+func (m *Manager) Pair(code, name string) (token, scope string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.code == "" || time.Now().After(m.expires) {
+		return "", "", ErrNotOpen
+	}
+	code = normalizeCode(code)
+	if subtle.ConstantTimeCompare([]byte(code), []byte(m.code)) != 1 {
+		m.attempts++
+		if m.attempts >= maxAttempts {
+			m.code = ""
+			events.Record(events.RemoteAction, events.Warning, "Pairing: too many wrong codes, window closed")
+		}
+		return "", "", ErrWrongCode
+	}
+	m.code = ""
+
+	raw := make([]byte, 32)
+	rand.Read(raw)
+	token = hex.EncodeToString(raw)
+
+	dashboards := make([]Dashboard, 0, len(m.dashboards)+1)
+	for _, d := range m.dashboards {
+		if d.Name != name {
+			dashboards = append(dashboards, d)
+		}
+	}
+	m.dashboards = append(dashboards, Dashboard{Name: name, Scope: m.scope, Paired: time.Now(), TokenHash: hashToken(token)})
+	if err := m.store.Set(stateKey, m.dashboards); err != nil {
+		return "", "", err
+	}
+	events.Record(events.RemoteAction, events.Info, "Pairing: paired dashboard %q with %s scope", name, m.scope)
+	return token, m.scope, nil
+}
+
+// Lookup returns the dashboard a token was issued to.
+func (m *Manager) Lookup(token string) (Dashboard, bool) {
+	hash := hashToken(token)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var found Dashboard
+	ok := false
+	for _, d := range m.dashboards {
+		if subtle.ConstantTimeCompare([]byte(hash), []byte(d.TokenHash)) == 1 {
+			found, ok = d, true
+		}
+	}
+	return found, ok
+}
+
+// List returns the paired dashboards without their token hashes.
+func (m *Manager) List() []Dashboard {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]Dashboard, len(m.dashboards))
+	for i, d := range m.dashboards {
+		d.TokenHash = ""
+		out[i] = d
+	}
+	return out
+}
+
+// Revoke removes the named dashboard's token. Returns false if it wasn't paired.
+func (m *Manager) Revoke(name string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, d := range m.dashboards {
+		if d.Name == name {
+			m.dashboards = append(m.dashboards[:i:i], m.dashboards[i+1:]...)
+			m.store.Set(stateKey, m.dashboards)
+			events.Record(events.RemoteAction, events.Info, "Pairing: revoked dashboard %q", name)
+			return true
+		}
+	}
+	return false
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeCode strips the separator and spaces users type.
+func normalizeCode(code string) string {
+	out := make([]byte, 0, len(code))
+	for i := 0; i < len(code); i++ {
+		if code[i] >= '0' && code[i] <= '9' {
+			out = append(out, code[i])
+		}
+	}
+	return string(out)
+}
@@ -0,0 +1,217 @@
+// Package peerclock compares this machine's clock with other agents in the
+// room. Playback spread across two machines needs their clocks within a
+// frame or two, and NTP reporting "synchronized" on each machine doesn't
+// prove they agree with each other.
+package peerclock
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/mdns"
+)
+
+const (
+	checkInterval      = 2 * time.Minute
+	browseTimeout      = 3 * time.Second
+	samplesPerPeer     = 3
+	defaultToleranceMs = 50
+)
+
+// Reading is an agent's clock, as served by GET /time.
+type Reading struct {
+	UnixNano int64 `json:"unixNano"`
+}
+
+// Now returns the reading for this machine.
+func Now() Reading {
+	return Reading{UnixNano: time.Now().UnixNano()}
+}
+
+// Status is the latest comparison, as reported in /status.
+type Status struct {
+	CheckedAt   time.Time `json:"checkedAt"`
+	Peers       []Peer    `json:"peers"`
+	MaxSkewMs   float64   `json:"maxSkewMs"` // largest |offset| among peers that answered
+	ToleranceMs int       `json:"toleranceMs"`
+}
+
+// Peer is one compared agent. OffsetMs is the peer's clock minus ours, so
+// a positive value means the peer is ahead.
+type Peer struct {
+	Name     string   `json:"name"`
+	Address  string   `json:"address"`
+	OffsetMs *float64 `json:"offsetMs,omitempty"`
+	RTTMs    float64  `json:"rttMs,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// Skewed reports whether any peer is further off than the tolerance.
+func (s *Status) Skewed() bool {
+	return s != nil && s.MaxSkewMs > float64(s.ToleranceMs)
+}
+
+// Monitor periodically measures skew against peers. Safe for concurrent use.
+type Monitor struct {
+	cfg      config.PeerClockConfig
+	site     string
+	selfUUID string
+	client   *http.Client
+
+	mu     sync.Mutex
+	status *Status
+}
+
+// New creates a monitor. Agents advertising a different site, or
+// selfUUID, are not compared.
+func New(cfg config.PeerClockConfig, site, selfUUID string) *Monitor {
+	if cfg.ToleranceMs <= 0 {
+		cfg.ToleranceMs = defaultToleranceMs
+	}
+	return &Monitor{
+		cfg:      cfg,
+		site:     site,
+		selfUUID: selfUUID,
+		client:   &http.Client{Timeout: 2 * time.Second},
+	}
+}
+
+// Run compares clocks every two minutes. Blocks; returns immediately when
+// disabled.
+func (m *Monitor) Run() {
+	if m.cfg.Disabled {
+		return
+	}
+	for {
+		m.check()
+		time.Sleep(checkInterval)
+	}
+}
+
+// Status returns the latest comparison, or nil before the first one or
+// when no peers were found.
+func (m *Monitor) Status() *Status {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.status
+}
+
+func (m *Monitor) check() {
+	targets := m.targets()
+	if len(targets) == 0 {
+		m.mu.Lock()
+		m.status = nil
+		m.mu.Unlock()
+		return
+	}
+
+	var wg sync.WaitGroup
+	for i := range targets {
+		wg.Add(1)
+		go func(p *Peer) {
+			defer wg.Done()
+			m.measure(p)
+		}(&targets[i])
+	}
+	wg.Wait()
+
+	status := &Status{CheckedAt: time.Now(), Peers: targets, ToleranceMs: m.cfg.ToleranceMs}
+	for _, p := range targets {
+		if p.OffsetMs != nil {
+			status.MaxSkewMs = math.Max(status.MaxSkewMs, math.Abs(*p.OffsetMs))
+		}
+	}
+
+	m.mu.Lock()
+	wasSkewed := m.status.Skewed()
+	m.status = status
+	m.mu.Unlock()
+
+	if status.Skewed() && !wasSkewed {
+		log.Printf("PeerClock: skew %.1f ms exceeds %d ms", status.MaxSkewMs, status.ToleranceMs)
+	}
+}
+
+// targets lists configured peers plus agents found over mDNS, sorted by name.
+func (m *Monitor) targets() []Peer {
+	seen := map[string]bool{}
+	var peers []Peer
+	for _, addr := range m.cfg.Peers {
+		if !seen[addr] {
+			seen[addr] = true
+			peers = append(peers, Peer{Name: addr, Address: addr})
+		}
+	}
+
+	found, err := mdns.Browse(browseTimeout)
+	if err != nil {
+		log.Printf("PeerClock: %v", err)
+	}
+	for _, f := range found {
+		// Agents without a uuid record predate GET /time.
+		uuid := f.Text["uuid"]
+		if uuid == "" || uuid == m.selfUUID || f.Text["site"] != m.site || seen[f.Address] {
+			continue
+		}
+		seen[f.Address] = true
+		peers = append(peers, Peer{Name: f.Instance, Address: f.Address})
+	}
+
+	sort.Slice(peers, func(i, j int) bool { return peers[i].Name < peers[j].Name })
+	return peers
+}
+
+// measure samples p a few times and keeps the sample with the shortest
+// round trip, which bounds the error best. The peer is assumed to read its
+// clock halfway through the round trip.
+func (m *Monitor) measure(p *Peer) {
+	best := time.Duration(math.MaxInt64)
+	var offset time.Duration
+	var lastErr error
+	for i := 0; i < samplesPerPeer; i++ {
+		sent := time.Now()
+		reading, err := m.fetch(p.Address)
+		rtt := time.Since(sent)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if rtt < best {
+			best = rtt
+			offset = time.Unix(0, reading.UnixNano).Sub(sent.Add(rtt / 2))
+		}
+	}
+	if best == time.Duration(math.MaxInt64) {
+		p.Error = lastErr.Error()
+		return
+	}
+	ms := float64(offset) / float64(time.Millisecond)
+	p.OffsetMs = &ms
+	p.RTTMs = float64(best) / float64(time.Millisecond)
+}
+
+func (m *Monitor) fetch(addr string) (Reading, error) {
+	var r Reading
+	resp, err := m.client.Get("http://" + addr + "/time")
+	if err != nil {
+		return r, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return r, fmt.Errorf("GET /time returned %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return r, err
+	}
+	return r, nil
+}
@@ -0,0 +1,32 @@
+// Package planningcenter reads upcoming service times from Planning Center
+// Services so alerting can tell a Sunday-morning failure from a Tuesday
+// 3 AM one.
+package planningcenter
+
+import "time"
+
+// PreServiceWindow is how long before a service starts that the schedule
+// reports the pre-service phase.
+const PreServiceWindow = time.Hour
+
+// Service phases reported by Schedule.Context.
+const (
+	PhaseIdle       = "idle"
+	PhasePreService = "pre-service"
+	PhaseInService  = "in-service"
+)
+
+// ServiceTime is one scheduled service.
+type ServiceTime struct {
+	Plan   string    `json:"plan"`
+	Starts time.Time `json:"startsAt"`
+	Ends   time.Time `json:"endsAt"`
+}
+
+// Context describes where the current moment falls relative to services.
+type Context struct {
+	Phase       string       `json:"phase"`
+	NextService *ServiceTime `json:"nextService,omitempty"`
+	LastSync    string       `json:"lastSync,omitempty"` // RFC 3339
+	Error       string       `json:"error,omitempty"`
+}
@@ -0,0 +1,208 @@
+//go:build !lite
+
+package planningcenter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/schedule"
+)
+
+const (
+	apiBase      = "https://api.planningcenteronline.com/services/v2"
+	syncInterval = 30 * time.Minute
+
+	// defaultServiceLength is used when a plan time has no end.
+	defaultServiceLength = 90 * time.Minute
+)
+
+// Schedule keeps a synced list of upcoming services.
+type Schedule struct {
+	cfg    config.PlanningCenterConfig
+	client *http.Client
+
+	mu       sync.Mutex
+	services []ServiceTime
+	lastSync time.Time
+	lastErr  error
+}
+
+// New creates a schedule for the given configuration. It is inert until
+// credentials are configured.
+func New(cfg config.PlanningCenterConfig) *Schedule {
+	return &Schedule{cfg: cfg, client: &http.Client{Timeout: 15 * time.Second}}
+}
+
+// Enabled reports whether Planning Center credentials are configured.
+func (s *Schedule) Enabled() bool {
+	return s != nil && s.cfg.AppID != "" && s.cfg.Secret != ""
+}
+
+// Run syncs the schedule about every 30 minutes, backing off while Planning
+// Center fails. Blocks; returns immediately when the integration is not
+// configured.
+func (s *Schedule) Run() {
+	if !s.Enabled() {
+		return
+	}
+	schedule.Loop(context.Background(), schedule.Policy{
+		Interval:   syncInterval,
+		Jitter:     0.1,
+		MaxBackoff: 4 * syncInterval,
+	}, func() error {
+		services, err := s.fetch()
+		if err != nil {
+			log.Printf("Planning Center: sync failed: %v", err)
+		}
+		s.mu.Lock()
+		if err == nil {
+			s.services = services
+			s.lastSync = time.Now()
+		}
+		s.lastErr = err
+		s.mu.Unlock()
+		return err
+	})
+}
+
+// Context returns the service phase at now. Returns nil when the
+// integration is not configured.
+func (s *Schedule) Context(now time.Time) *Context {
+	if !s.Enabled() {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ctx := &Context{Phase: PhaseIdle}
+	if !s.lastSync.IsZero() {
+		ctx.LastSync = s.lastSync.UTC().Format(time.RFC3339)
+	}
+	if s.lastErr != nil {
+		ctx.Error = s.lastErr.Error()
+	}
+	for _, svc := range s.services {
+		if now.After(svc.Ends) {
+			continue
+		}
+		next := svc
+		ctx.NextService = &next
+		switch {
+		case !now.Before(svc.Starts):
+			ctx.Phase = PhaseInService
+		case svc.Starts.Sub(now) <= PreServiceWindow:
+			ctx.Phase = PhasePreService
+		}
+		break
+	}
+	return ctx
+}
+
+// jsonAPIList is the subset of a JSON:API list response we read.
+type jsonAPIList struct {
+	Data []struct {
+		ID         string          `json:"id"`
+		Attributes json.RawMessage `json:"attributes"`
+	} `json:"data"`
+	Included []struct {
+		Type          string             `json:"type"`
+		Attributes    planTimeAttributes `json:"attributes"`
+		Relationships struct {
+			Plan struct {
+				Data struct {
+					ID string `json:"id"`
+				} `json:"data"`
+			} `json:"plan"`
+		} `json:"relationships"`
+	} `json:"included"`
+}
+
+type planTimeAttributes struct {
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+	TimeType string    `json:"time_type"` // "service", "rehearsal", "other"
+}
+
+type planAttributes struct {
+	Title string `json:"title"`
+	Dates string `json:"dates"`
+}
+
+// fetch returns upcoming service times across the configured service
+// types (all service types when none are configured), sorted by start.
+func (s *Schedule) fetch() ([]ServiceTime, error) {
+	typeIDs := s.cfg.ServiceTypeIDs
+	if len(typeIDs) == 0 {
+		var types jsonAPIList
+		if err := s.get(apiBase+"/service_types", &types); err != nil {
+			return nil, err
+		}
+		for _, t := range types.Data {
+			typeIDs = append(typeIDs, t.ID)
+		}
+	}
+
+	var services []ServiceTime
+	for _, typeID := range typeIDs {
+		q := url.Values{"filter": {"future"}, "order": {"sort_date"}, "per_page": {"5"}, "include": {"plan_times"}}
+		var plans jsonAPIList
+		if err := s.get(fmt.Sprintf("%s/service_types/%s/plans?%s", apiBase, url.PathEscape(typeID), q.Encode()), &plans); err != nil {
+			return nil, err
+		}
+
+		titles := make(map[string]string, len(plans.Data))
+		for _, p := range plans.Data {
+			var attrs planAttributes
+			json.Unmarshal(p.Attributes, &attrs)
+			title := attrs.Title
+			if title == "" {
+				title = attrs.Dates
+			}
+			titles[p.ID] = title
+		}
+		for _, inc := range plans.Included {
+			if inc.Type != "PlanTime" || inc.Attributes.TimeType != "service" {
+				continue
+			}
+			ends := inc.Attributes.EndsAt
+			if ends.IsZero() || !ends.After(inc.Attributes.StartsAt) {
+				ends = inc.Attributes.StartsAt.Add(defaultServiceLength)
+			}
+			services = append(services, ServiceTime{
+				Plan:   titles[inc.Relationships.Plan.Data.ID],
+				Starts: inc.Attributes.StartsAt,
+				Ends:   ends,
+			})
+		}
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].Starts.Before(services[j].Starts) })
+	return services, nil
+}
+
+func (s *Schedule) get(u string, v any) error {
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(s.cfg.AppID, s.cfg.Secret)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("Planning Center API returned %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
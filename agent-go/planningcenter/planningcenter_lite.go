@@ -0,0 +1,26 @@
+//go:build lite
+
+package planningcenter
+
+import (
+	"log"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+)
+
+// Schedule is a stub: lite builds leave out Planning Center, and New never
+// returns one.
+type Schedule struct{}
+
+// New only warns in lite builds when credentials are configured.
+func New(cfg config.PlanningCenterConfig) *Schedule {
+	if cfg.AppID != "" || cfg.Secret != "" {
+		log.Printf("Lite build: planningCenter is configured but not included; ignoring it")
+	}
+	return nil
+}
+
+func (s *Schedule) Enabled() bool                  { return false }
+func (s *Schedule) Run()                           {}
+func (s *Schedule) Context(now time.Time) *Context { return nil }
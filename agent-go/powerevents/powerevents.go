@@ -0,0 +1,4 @@
+// Package powerevents reports when the machine sleeps and wakes, so the
+// agent can withdraw its mDNS record before sleeping and refresh
+// everything on resume instead of serving minutes of stale data.
+package powerevents
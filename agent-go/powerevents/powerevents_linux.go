@@ -0,0 +1,29 @@
+//go:build linux
+
+package powerevents
+
+import "time"
+
+const (
+	pollInterval = 5 * time.Second
+	jumpAfter    = 30 * time.Second
+)
+
+// Watch calls onResume after the machine wakes. Linux has no cheap
+// pre-sleep hook without D-Bus, so onSuspend is never called; a resume is
+// detected by the wall clock jumping ahead while the monotonic clock (which
+// stops during suspend) doesn't. A large forward clock step looks the
+// same, and the extra refresh is harmless.
+func Watch(onSuspend, onResume func()) error {
+	go func() {
+		last := time.Now().Round(0)
+		for range time.Tick(pollInterval) {
+			now := time.Now().Round(0)
+			if now.Sub(last) > jumpAfter {
+				onResume()
+			}
+			last = now
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,56 @@
+//go:build windows
+
+package powerevents
+
+import (
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+const (
+	deviceNotifyCallback  = 2
+	pbtAPMSuspend         = 0x4
+	pbtAPMResumeAutomatic = 0x12
+)
+
+var procPowerRegisterSuspendResumeNotification = windows.NewLazySystemDLL("powrprof.dll").NewProc("PowerRegisterSuspendResumeNotification")
+
+// deviceNotifySubscribeParameters is DEVICE_NOTIFY_SUBSCRIBE_PARAMETERS.
+type deviceNotifySubscribeParameters struct {
+	callback uintptr
+	context  uintptr
+}
+
+// params and handle must outlive the registration, which lasts for the
+// life of the process.
+var (
+	params deviceNotifySubscribeParameters
+	handle uintptr
+)
+
+// Watch calls onSuspend before the machine sleeps and onResume after it
+// wakes. It works without a window, so it also works in service mode.
+// onSuspend runs synchronously: Windows waits (briefly) for it, which is
+// what lets an mDNS goodbye go out before the network drops.
+func Watch(onSuspend, onResume func()) error {
+	params.callback = windows.NewCallback(func(context, typ, setting uintptr) uintptr {
+		switch typ {
+		case pbtAPMSuspend:
+			onSuspend()
+		case pbtAPMResumeAutomatic:
+			go onResume()
+		}
+		return 0
+	})
+	r, _, _ := procPowerRegisterSuspendResumeNotification.Call(
+		deviceNotifyCallback,
+		uintptr(unsafe.Pointer(&params)),
+		uintptr(unsafe.Pointer(&handle)),
+	)
+	if r != 0 {
+		return fmt.Errorf("PowerRegisterSuspendResumeNotification: %w", windows.Errno(r))
+	}
+	return nil
+}
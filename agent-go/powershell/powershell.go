@@ -0,0 +1,4 @@
+// Package powershell runs PowerShell scripts without a console window
+// flashing up, for the Windows features that have no simpler API
+// (toasts, firewall rules). There is nothing to run elsewhere.
+package powershell
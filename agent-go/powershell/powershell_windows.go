@@ -0,0 +1,34 @@
+//go:build windows
+
+package powershell
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/hidden"
+)
+
+// Run runs script in a hidden PowerShell and returns its output. On
+// failure the error carries the output, which holds PowerShell's message.
+func Run(script string) (string, error) {
+	out, err := hidden.Command("powershell.exe", "-NoProfile", "-NonInteractive", "-EncodedCommand", Encode(script)).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return string(out), nil
+}
+
+// Encode encodes a script for -EncodedCommand (base64 UTF-16LE), which
+// sidesteps command-line quoting entirely.
+func Encode(script string) string {
+	units := utf16.Encode([]rune(script))
+	buf := make([]byte, len(units)*2)
+	for i, u := range units {
+		buf[i*2] = byte(u)
+		buf[i*2+1] = byte(u >> 8)
+	}
+	return base64.StdEncoding.EncodeToString(buf)
+}
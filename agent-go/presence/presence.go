@@ -0,0 +1,31 @@
+// Package presence tells whether someone is at the machine, from the time
+// since the last keyboard or mouse input. Automatic fixes hold off while an
+// operator is present: restarting OBS mid-configuration is worse than the
+// fault it fixes.
+package presence
+
+import "time"
+
+// presentWithin is how recent input must be for an operator to count as
+// present.
+const presentWithin = 5 * time.Minute
+
+// Idle returns the time since the last keyboard or mouse input. ok is false
+// when the agent can't see input: on Linux and in session 0, where the
+// Windows service runs.
+func Idle() (idle time.Duration, ok bool) {
+	return readIdle()
+}
+
+// Present reports whether input idle for idle means an operator is at the
+// machine: there was input in the last five minutes.
+func Present(idle time.Duration) bool {
+	return idle < presentWithin
+}
+
+// OperatorPresent reports whether an operator is at the machine now. It is
+// false when input can't be seen.
+func OperatorPresent() bool {
+	idle, ok := Idle()
+	return ok && Present(idle)
+}
@@ -0,0 +1,11 @@
+//go:build linux
+
+package presence
+
+import "time"
+
+// readIdle reports nothing on Linux, where the agent runs as a system
+// service without a desktop.
+func readIdle() (time.Duration, bool) {
+	return 0, false
+}
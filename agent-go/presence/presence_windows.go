@@ -0,0 +1,39 @@
+//go:build windows
+
+package presence
+
+import (
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	procGetLastInputInfo = windows.NewLazySystemDLL("user32.dll").NewProc("GetLastInputInfo")
+	procGetTickCount     = windows.NewLazySystemDLL("kernel32.dll").NewProc("GetTickCount")
+)
+
+// lastInputInfo is LASTINPUTINFO.
+type lastInputInfo struct {
+	cbSize uint32
+	dwTime uint32 // tick count of the last input
+}
+
+// readIdle reads the input idle time of the agent's session, console or
+// Remote Desktop. Session 0 never has input, so its idle time means
+// nothing.
+func readIdle() (time.Duration, bool) {
+	var session uint32
+	if err := windows.ProcessIdToSessionId(windows.GetCurrentProcessId(), &session); err != nil || session == 0 {
+		return 0, false
+	}
+	info := lastInputInfo{cbSize: uint32(unsafe.Sizeof(lastInputInfo{}))}
+	if r, _, _ := procGetLastInputInfo.Call(uintptr(unsafe.Pointer(&info))); r == 0 {
+		return 0, false
+	}
+	// Both tick counts wrap after 49.7 days; the uint32 difference doesn't.
+	now, _, _ := procGetTickCount.Call()
+	ms := uint32(now) - info.dwTime
+	return time.Duration(ms) * time.Millisecond, true
+}
@@ -0,0 +1,71 @@
+//go:build windows
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/i18n"
+)
+
+// onboarding is what the tray's QR code encodes: enough for a dashboard or
+// phone to add and pair the machine without anyone reading out addresses.
+type onboarding struct {
+	Name        string `json:"name"`
+	URL         string `json:"url"`
+	UUID        string `json:"uuid,omitempty"`
+	PairingCode string `json:"pairingCode,omitempty"`
+}
+
+// showQRCode opens a pairing window (or reuses the open one) and shows a
+// page with the machine's address and code as a QR code in the browser.
+func (a *agent) showQRCode() {
+	status := a.collector.CurrentStatus()
+	address := a.hostname
+	for _, n := range status.Networks {
+		if n.IPAddress != "" {
+			address = n.IPAddress
+			break
+		}
+	}
+	info := onboarding{
+		Name: displayName(a.hostname, a.cfg.Site),
+		URL:  fmt.Sprintf("http://%s:%d", address, a.server.Port()),
+		UUID: status.HardwareUUID,
+	}
+	if a.pairer != nil {
+		info.PairingCode, _ = a.pairer.Code()
+		if info.PairingCode == "" {
+			info.PairingCode = a.pairer.Open(5 * time.Minute)
+		}
+	}
+	payload, _ := json.Marshal(info)
+	svg, err := qrSVG(string(payload))
+	if err != nil {
+		log.Printf("QR code: %v", err)
+		return
+	}
+
+	lines := []string{info.Name, info.URL}
+	if info.PairingCode != "" {
+		lines = append(lines, i18n.T("qr.code", info.PairingCode))
+	}
+	page := fmt.Sprintf(`<!DOCTYPE html><html><head><meta charset="utf-8"><title>%s</title></head>`+
+		`<body style="font-family:sans-serif;text-align:center;margin-top:5vh">%s<p style="font-size:24px">%s</p></body></html>`,
+		html.EscapeString(i18n.T("app.title")), svg, html.EscapeString(strings.Join(lines, " · ")))
+
+	path := filepath.Join(os.TempDir(), "dashboard-agent-qr.html")
+	if err := os.WriteFile(path, []byte(page), 0o600); err != nil {
+		log.Printf("QR code: %v", err)
+		return
+	}
+	exec.Command("explorer.exe", path).Start()
+}
@@ -0,0 +1,9 @@
+//go:build windows && lite
+
+package main
+
+// qrSVG is left out of lite builds, whose QR page shows the address and
+// pairing code as text only.
+func qrSVG(payload string) (string, error) {
+	return "", nil
+}
@@ -0,0 +1,14 @@
+//go:build windows && !lite
+
+package main
+
+import "github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/qrcode"
+
+// qrSVG renders payload as an SVG QR code.
+func qrSVG(payload string) (string, error) {
+	code, err := qrcode.Encode(payload)
+	if err != nil {
+		return "", err
+	}
+	return code.SVG(10), nil
+}
@@ -0,0 +1,287 @@
+// Package recordcheck inspects the newest recording after each service:
+// that a new file exists, that it isn't empty, and, when ffprobe is
+// installed, that it opens, runs about as long as the service, and has
+// audio. "Did the recording actually work" gets answered Sunday noon
+// instead of Monday morning.
+package recordcheck
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/events"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/planningcenter"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/schedule"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/state"
+)
+
+const (
+	stateKey         = "recordingCheck"
+	tickInterval     = time.Minute
+	probeTimeout     = 30 * time.Second
+	defaultTolerance = 10 * time.Minute
+	// settleDelay gives the recorder time to stop and finalize the file
+	// after a Planning Center service ends.
+	settleDelay = 10 * time.Minute
+	// firstWindow is how far back the first check ever looks.
+	firstWindow = 24 * time.Hour
+)
+
+// mediaExts are the file types considered recordings.
+var mediaExts = map[string]bool{
+	".mkv": true, ".mp4": true, ".mov": true, ".flv": true, ".ts": true,
+	".m4v": true, ".avi": true, ".mxf": true,
+	".wav": true, ".mp3": true, ".m4a": true, ".aac": true, ".flac": true,
+}
+
+// Result is one check of the newest recording.
+type Result struct {
+	Time            time.Time `json:"time"`
+	Trigger         string    `json:"trigger"` // "10:45" or the service plan
+	File            string    `json:"file,omitempty"`
+	Bytes           int64     `json:"bytes,omitempty"`
+	Probed          bool      `json:"probed"` // ffprobe was available
+	DurationSeconds float64   `json:"durationSeconds,omitempty"`
+	ExpectedSeconds float64   `json:"expectedSeconds,omitempty"`
+	AudioStreams    int       `json:"audioStreams,omitempty"`
+	Problems        []string  `json:"problems,omitempty"`
+}
+
+// OK reports whether the recording passed every check.
+func (r Result) OK() bool {
+	return len(r.Problems) == 0
+}
+
+// saved is what persists across restarts.
+type saved struct {
+	Last        *Result   `json:"last"`
+	LastService time.Time `json:"lastService"` // start of the last service checked
+}
+
+// Checker runs the checks. Safe for concurrent use.
+type Checker struct {
+	cfg      config.RecordingVerify
+	folders  []string
+	services func(time.Time) *planningcenter.Context
+	store    *state.Store
+
+	mu      sync.Mutex
+	rec     saved
+	pending *planningcenter.ServiceTime // current or next service, or an ended one not yet checked
+}
+
+// New restores the last check. services reports the Planning Center
+// schedule. It returns nil when no check is scheduled.
+func New(cfg config.RecordingConfig, services func(time.Time) *planningcenter.Context, store *state.Store) *Checker {
+	v := cfg.Verify
+	if len(cfg.Folders) == 0 || (len(v.At) == 0 && !v.AfterServices) {
+		return nil
+	}
+	c := &Checker{cfg: v, services: services, store: store}
+	for _, f := range cfg.Folders {
+		c.folders = append(c.folders, f.Path)
+	}
+	store.Get(stateKey, &c.rec)
+	return c
+}
+
+// Last returns the latest check, or nil before the first.
+func (c *Checker) Last() *Result {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.rec.Last == nil {
+		return nil
+	}
+	last := *c.rec.Last
+	return &last
+}
+
+// Run checks at each configured time and after each service. Blocks
+// forever.
+func (c *Checker) Run() {
+	since := time.Now()
+	for {
+		time.Sleep(tickInterval)
+		now := time.Now()
+		for _, at := range c.cfg.At {
+			if (schedule.Weekly{Clock: at, Days: c.cfg.Days}).Between(since, now) {
+				c.Check(at, time.Duration(c.cfg.ExpectedMinutes)*time.Minute)
+			}
+		}
+		if c.cfg.AfterServices {
+			c.checkServices(now)
+		}
+		since = now
+	}
+}
+
+// checkServices checks once the service last seen on the schedule has
+// ended and the recorder has had time to finish the file.
+func (c *Checker) checkServices(now time.Time) {
+	// Once a service ends, Planning Center moves on to the next one; keep
+	// the ended service until it has been checked.
+	if ctx := c.services(now); ctx != nil && ctx.NextService != nil && (c.pending == nil || now.Before(c.pending.Ends)) {
+		c.pending = ctx.NextService
+	}
+	svc := c.pending
+	if svc == nil || now.Before(svc.Ends.Add(settleDelay)) {
+		return
+	}
+	c.pending = nil
+	c.mu.Lock()
+	done := !svc.Starts.After(c.rec.LastService)
+	c.mu.Unlock()
+	if done {
+		return
+	}
+	expected := time.Duration(c.cfg.ExpectedMinutes) * time.Minute
+	if expected == 0 {
+		expected = svc.Ends.Sub(svc.Starts)
+	}
+	c.Check(svc.Plan, expected)
+	c.mu.Lock()
+	c.rec.LastService = svc.Starts
+	c.save()
+	c.mu.Unlock()
+}
+
+// Check inspects the newest recording written since the previous check.
+// expected is the duration it should have, or 0 to accept any.
+func (c *Checker) Check(trigger string, expected time.Duration) Result {
+	now := time.Now()
+	since := now.Add(-firstWindow)
+	if last := c.Last(); last != nil {
+		since = last.Time
+	}
+	result := Result{Time: now, Trigger: trigger, ExpectedSeconds: expected.Seconds()}
+	c.inspect(&result, since, expected)
+
+	if result.OK() {
+		events.Record(events.Lifecycle, events.Info, "Recording check (%s): %s OK", trigger, filepath.Base(result.File))
+	} else {
+		events.Record(events.Lifecycle, events.Warning, "Recording check (%s): %s", trigger, strings.Join(result.Problems, "; "))
+	}
+	c.mu.Lock()
+	c.rec.Last = &result
+	c.save()
+	c.mu.Unlock()
+	return result
+}
+
+func (c *Checker) inspect(r *Result, since time.Time, expected time.Duration) {
+	file, info := newest(c.folders)
+	if info == nil || !info.ModTime().After(since) {
+		r.Problems = append(r.Problems, "no recording written since "+since.Format("Mon 15:04"))
+		return
+	}
+	r.File, r.Bytes = file, info.Size()
+	if r.Bytes == 0 {
+		r.Problems = append(r.Problems, "file is empty")
+		return
+	}
+
+	ffprobe, err := exec.LookPath("ffprobe")
+	if err != nil {
+		return
+	}
+	r.Probed = true
+	duration, audio, err := probe(ffprobe, file)
+	if err != nil {
+		r.Problems = append(r.Problems, "unreadable: "+err.Error())
+		return
+	}
+	r.DurationSeconds, r.AudioStreams = duration.Seconds(), audio
+	tolerance := time.Duration(c.cfg.ToleranceMinutes) * time.Minute
+	if tolerance == 0 {
+		tolerance = defaultTolerance
+	}
+	if expected > 0 && (duration < expected-tolerance || duration > expected+tolerance) {
+		r.Problems = append(r.Problems, fmt.Sprintf("runs %.0f min, expected %.0f ± %.0f min",
+			duration.Minutes(), expected.Minutes(), tolerance.Minutes()))
+	}
+	if audio == 0 {
+		r.Problems = append(r.Problems, "no audio stream")
+	}
+}
+
+// newest returns the most recently modified recording under the folders.
+// Unreadable subdirectories are skipped.
+func newest(folders []string) (string, fs.FileInfo) {
+	var file string
+	var latest fs.FileInfo
+	for _, root := range folders {
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if d != nil && d.IsDir() && path != root {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			if !d.Type().IsRegular() || !mediaExts[strings.ToLower(filepath.Ext(path))] {
+				return nil
+			}
+			if info, err := d.Info(); err == nil && (latest == nil || info.ModTime().After(latest.ModTime())) {
+				file, latest = path, info
+			}
+			return nil
+		})
+	}
+	return file, latest
+}
+
+// probe reads a file's duration and audio stream count with ffprobe. A
+// recording cut off mid-write (an MP4 without its index) fails here.
+func probe(ffprobe, file string) (time.Duration, int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, ffprobe, "-v", "error",
+		"-show_entries", "format=duration:stream=codec_type", "-of", "json", file).Output()
+	if err != nil {
+		if ee, ok := err.(*exec.ExitError); ok && len(ee.Stderr) > 0 {
+			return 0, 0, errors.New(strings.TrimSpace(string(ee.Stderr)))
+		}
+		return 0, 0, err
+	}
+	var info struct {
+		Format struct {
+			Duration string `json:"duration"`
+		} `json:"format"`
+		Streams []struct {
+			CodecType string `json:"codec_type"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(out, &info); err != nil {
+		return 0, 0, err
+	}
+	seconds, err := strconv.ParseFloat(info.Format.Duration, 64)
+	if err != nil {
+		return 0, 0, errors.New("no duration")
+	}
+	audio := 0
+	for _, s := range info.Streams {
+		if s.CodecType == "audio" {
+			audio++
+		}
+	}
+	return time.Duration(seconds * float64(time.Second)), audio, nil
+}
+
+func (c *Checker) save() {
+	if err := c.store.Set(stateKey, c.rec); err != nil {
+		log.Printf("State: save recording check failed: %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+//go:build !lite
+
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"time"
+)
+
+// HTML renders rep as a self-contained page. Styles are inline so the
+// report survives email clients and prints cleanly.
+func HTML(rep Report) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := page.Execute(&buf, rep); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+var page = template.Must(template.New("report").Funcs(template.FuncMap{
+	"date":     func(t time.Time) string { return t.Local().Format("Mon Jan 2") },
+	"datetime": func(t time.Time) string { return t.Local().Format("Mon Jan 2 15:04") },
+	"pct":      func(f float64) string { return fmt.Sprintf("%.1f%%", f) },
+	"hours":    func(s float64) string { return fmt.Sprintf("%.1f h", s/3600) },
+	"change": func(f *float64) string {
+		if f == nil {
+			return "–"
+		}
+		return fmt.Sprintf("%+.1f pts", *f)
+	},
+}).Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Weekly report: {{.Machine}}</title></head>
+<body style="font-family: -apple-system, 'Segoe UI', sans-serif; color: #222; max-width: 720px;">
+<h2 style="margin-bottom: 0;">{{.Machine}}</h2>
+<p style="color: #666; margin-top: 4px;">{{date .From}} – {{date .To}} · agent v{{.AgentVersion}}</p>
+
+<h3>Uptime</h3>
+<p>{{pct .Uptime.Percent}} available ({{hours .Uptime.DowntimeSeconds}} down), {{.Uptime.Boots}} boots, {{.Uptime.AgentStarts}} agent starts.</p>
+
+<h3>Alerts</h3>
+{{if .Alerts}}<table style="border-collapse: collapse; width: 100%;">
+<tr style="text-align: left; border-bottom: 1px solid #ccc;"><th>Alert</th><th>Severity</th><th>Times</th><th>Last</th></tr>
+{{range .Alerts}}<tr style="border-bottom: 1px solid #eee;"><td>{{.Message}}</td><td>{{.Severity}}</td><td>{{.Count}}</td><td>{{datetime .Last}}</td></tr>
+{{end}}</table>{{else}}<p>No alerts this week.</p>{{end}}
+{{if .ActiveAlerts}}<p><b>{{.ActiveAlerts}} still active.</b></p>{{end}}
+
+<h3>Disks</h3>
+{{if .Disks}}<table style="border-collapse: collapse; width: 100%;">
+<tr style="text-align: left; border-bottom: 1px solid #ccc;"><th>Volume</th><th>Used</th><th>Free</th><th>This week</th></tr>
+{{range .Disks}}<tr style="border-bottom: 1px solid #eee;"><td>{{.Mount}}</td><td>{{pct .UsedPercent}}</td><td>{{printf "%.0f GB" .FreeGB}}</td><td>{{change .Change}}</td></tr>
+{{end}}</table>{{else}}<p>No volumes reported.</p>{{end}}
+
+<h3>Updates</h3>
+<ul>
+{{with .WindowsUpdate}}{{if .RebootRequired}}<li>Windows Update is waiting to restart.</li>{{end}}{{if .PausedUntil}}<li>Windows Update is paused until {{.PausedUntil}}.</li>{{end}}{{end}}
+{{if .AgentUpdate}}<li>Agent v{{.AgentUpdate}} is available.</li>{{end}}
+{{if not (or .AgentUpdate (and .WindowsUpdate (or .WindowsUpdate.RebootRequired .WindowsUpdate.PausedUntil)))}}<li>Nothing pending.</li>{{end}}
+</ul>
+</body></html>
+`))
@@ -0,0 +1,22 @@
+//go:build lite
+
+package report
+
+import (
+	"encoding/json"
+	"html"
+)
+
+// HTML renders rep as its JSON inside a plain page. Lite builds leave out
+// html/template and the styled report.
+func HTML(rep Report) ([]byte, error) {
+	data, err := json.MarshalIndent(rep, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	page := `<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Weekly report: ` + html.EscapeString(rep.Machine) + `</title></head>
+<body><pre>` + html.EscapeString(string(data)) + `</pre></body></html>
+`
+	return []byte(page), nil
+}
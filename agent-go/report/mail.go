@@ -0,0 +1,26 @@
+//go:build !lite
+
+package report
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/alerts"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/events"
+)
+
+// mail sends the report through alerts.email.
+func (r *Reporter) mail(rep Report, body []byte) {
+	if r.deps.Email == nil || r.deps.Email.Host == "" {
+		log.Printf("Report: recipients set but alerts.email is not configured")
+		return
+	}
+	subject := fmt.Sprintf("Weekly report: %s", rep.Machine)
+	if err := alerts.NewEmail(*r.deps.Email, rep.Machine).SendHTML(r.deps.Config.Recipients, subject, string(body)); err != nil {
+		events.Record(events.Lifecycle, events.Warning, "Report: email failed: %v", err)
+		return
+	}
+	log.Printf("Report: mailed to %s", strings.Join(r.deps.Config.Recipients, ", "))
+}
@@ -0,0 +1,10 @@
+//go:build lite
+
+package report
+
+import "log"
+
+// mail is left out of lite builds, which have no email transport.
+func (r *Reporter) mail(Report, []byte) {
+	log.Printf("Lite build: report email is not included; ignoring report.recipients")
+}
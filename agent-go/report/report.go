@@ -0,0 +1,346 @@
+// Package report compiles the weekly health report for this machine:
+// uptime, alerts raised, disk trends, and pending updates. It is mailed
+// and/or saved on a schedule and served by GET /report.
+package report
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/alerts"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/history"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/metrics"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/state"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/update"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/winupdate"
+)
+
+const (
+	stateKey      = "report"
+	period        = 7 * 24 * time.Hour
+	retention     = 2 * period
+	checkInterval = time.Minute
+)
+
+// Deps are the subsystems a report draws from.
+type Deps struct {
+	Config       config.ReportConfig
+	Email        *config.EmailConfig
+	Name         string // machine name as shown in notifications
+	Store        *state.Store
+	Collector    *metrics.Collector
+	Availability *history.Availability
+	Alerts       *alerts.Engine
+	Updater      *update.Updater
+}
+
+// Report is one week's summary. Fields are also served as JSON.
+type Report struct {
+	Machine       string              `json:"machine"`
+	From          time.Time           `json:"from"`
+	To            time.Time           `json:"to"`
+	AgentVersion  string              `json:"agentVersion"`
+	Uptime        history.WindowStats `json:"uptime"`
+	Alerts        []AlertSummary      `json:"alerts"`
+	ActiveAlerts  int                 `json:"activeAlerts"`
+	Disks         []DiskTrend         `json:"disks"`
+	WindowsUpdate *winupdate.Status   `json:"windowsUpdate,omitempty"`
+	// AgentUpdate is a newer agent release, checked only when the report
+	// is delivered.
+	AgentUpdate string `json:"agentUpdate,omitempty"`
+}
+
+// AlertSummary groups the times one alert was raised during the week.
+type AlertSummary struct {
+	Key      string    `json:"key"`
+	Severity string    `json:"severity"` // highest seen
+	Message  string    `json:"message"`  // latest
+	Count    int       `json:"count"`
+	Last     time.Time `json:"last"`
+}
+
+// DiskTrend is a volume's usage now and its change over the week.
+type DiskTrend struct {
+	Mount       string   `json:"mount"`
+	UsedPercent float64  `json:"usedPercent"`
+	FreeGB      float64  `json:"freeGB"`
+	Change      *float64 `json:"changePoints,omitempty"` // percentage points since the oldest sample this week
+}
+
+type raisedAlert struct {
+	Key      string    `json:"key"`
+	Severity string    `json:"severity"`
+	Message  string    `json:"message"`
+	At       time.Time `json:"at"`
+}
+
+type diskSample struct {
+	At          time.Time `json:"at"`
+	Mount       string    `json:"mount"`
+	UsedPercent float64   `json:"usedPercent"`
+}
+
+// journal is what the report needs that nothing else keeps: alerts
+// already acknowledged and gone from the engine, and daily disk usage.
+type journal struct {
+	Alerts        []raisedAlert `json:"alerts"`
+	Disks         []diskSample  `json:"disks"`
+	LastDelivered time.Time     `json:"lastDelivered"`
+}
+
+// Reporter records the week's history and delivers the report. Safe for
+// concurrent use.
+type Reporter struct {
+	deps Deps
+
+	mu  sync.Mutex
+	rec journal
+}
+
+// New restores the journal and starts recording raised alerts.
+func New(deps Deps) *Reporter {
+	r := &Reporter{deps: deps}
+	deps.Store.Get(stateKey, &r.rec)
+	deps.Alerts.OnRaise(r.recordAlert)
+	return r
+}
+
+// Run samples disk usage daily and delivers the report at the configured
+// time each week. A schedule missed while the machine was off is caught up
+// at the next start. Blocks forever.
+func (r *Reporter) Run() {
+	r.mu.Lock()
+	if r.rec.LastDelivered.IsZero() {
+		// Don't send a near-empty report the moment the agent is installed.
+		r.rec.LastDelivered = time.Now()
+		r.save()
+	}
+	r.mu.Unlock()
+
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+	for {
+		now := time.Now()
+		r.sampleDisks(now)
+		r.mu.Lock()
+		due := r.rec.LastDelivered.Before(r.lastScheduled(now))
+		r.mu.Unlock()
+		if due {
+			r.deliver(now)
+		}
+		<-ticker.C
+	}
+}
+
+// Build compiles the report for the week ending now.
+func (r *Reporter) Build() Report {
+	now := time.Now()
+	from := now.Add(-period)
+	status := r.deps.Collector.CurrentStatus()
+
+	rep := Report{
+		Machine:       r.deps.Name,
+		From:          from,
+		To:            now,
+		AgentVersion:  status.AgentVersion,
+		Uptime:        r.deps.Availability.Report().Windows["7d"],
+		WindowsUpdate: status.WindowsUpdate,
+	}
+	for _, a := range r.deps.Alerts.List() {
+		if a.Active {
+			rep.ActiveAlerts++
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	byKey := map[string]*AlertSummary{}
+	for _, a := range r.rec.Alerts {
+		if a.At.Before(from) {
+			continue
+		}
+		s, ok := byKey[a.Key]
+		if !ok {
+			s = &AlertSummary{Key: a.Key}
+			byKey[a.Key] = s
+		}
+		s.Count++
+		if severityRank(a.Severity) > severityRank(s.Severity) {
+			s.Severity = a.Severity
+		}
+		if !a.At.Before(s.Last) {
+			s.Message, s.Last = a.Message, a.At
+		}
+	}
+	for _, s := range byKey {
+		rep.Alerts = append(rep.Alerts, *s)
+	}
+	sort.Slice(rep.Alerts, func(i, j int) bool {
+		if rep.Alerts[i].Count != rep.Alerts[j].Count {
+			return rep.Alerts[i].Count > rep.Alerts[j].Count
+		}
+		return rep.Alerts[i].Key < rep.Alerts[j].Key
+	})
+
+	for _, v := range status.Volumes {
+		trend := DiskTrend{
+			Mount:       v.Mount,
+			UsedPercent: v.UsedPercent,
+			FreeGB:      float64(v.FreeBytes) / 1e9,
+		}
+		for _, s := range r.rec.Disks {
+			if s.Mount == v.Mount && !s.At.Before(from) {
+				change := v.UsedPercent - s.UsedPercent
+				trend.Change = &change
+				break
+			}
+		}
+		rep.Disks = append(rep.Disks, trend)
+	}
+	return rep
+}
+
+// deliver mails and/or saves the report, then records it as delivered
+// even if a transport failed, so a broken SMTP server doesn't resend
+// every minute.
+func (r *Reporter) deliver(now time.Time) {
+	cfg := r.deps.Config
+	if len(cfg.Recipients) > 0 || cfg.Folder != "" {
+		rep := r.Build()
+		if latest, available, err := r.deps.Updater.Latest(); err == nil && available {
+			rep.AgentUpdate = latest
+		}
+		body, err := HTML(rep)
+		if err != nil {
+			log.Printf("Report: render failed: %v", err)
+			return
+		}
+		if cfg.Folder != "" {
+			r.saveCopy(rep, body)
+		}
+		if len(cfg.Recipients) > 0 {
+			r.mail(rep, body)
+		}
+	}
+
+	r.mu.Lock()
+	r.rec.LastDelivered = now
+	r.save()
+	r.mu.Unlock()
+}
+
+func (r *Reporter) saveCopy(rep Report, body []byte) {
+	name := fmt.Sprintf("report-%s-%s.html", safeName(rep.Machine), rep.To.Format("2006-01-02"))
+	path := filepath.Join(r.deps.Config.Folder, name)
+	if err := os.MkdirAll(r.deps.Config.Folder, 0755); err != nil {
+		log.Printf("Report: %v", err)
+		return
+	}
+	if err := os.WriteFile(path, body, 0644); err != nil {
+		log.Printf("Report: %v", err)
+		return
+	}
+	log.Printf("Report: saved %s", path)
+}
+
+// recordAlert is the engine's OnRaise callback.
+func (r *Reporter) recordAlert(a alerts.Alert) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rec.Alerts = append(r.rec.Alerts, raisedAlert{Key: a.Key, Severity: a.BaseSeverity, Message: a.Message, At: a.LastSeen})
+	r.prune(a.LastSeen)
+	r.save()
+}
+
+// sampleDisks records volume usage once a day.
+func (r *Reporter) sampleDisks(now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if n := len(r.rec.Disks); n > 0 && sameDay(r.rec.Disks[n-1].At, now) {
+		return
+	}
+	for _, v := range r.deps.Collector.CurrentStatus().Volumes {
+		r.rec.Disks = append(r.rec.Disks, diskSample{At: now, Mount: v.Mount, UsedPercent: v.UsedPercent})
+	}
+	r.prune(now)
+	r.save()
+}
+
+// prune drops records older than the retention. Caller holds r.mu.
+func (r *Reporter) prune(now time.Time) {
+	cutoff := now.Add(-retention)
+	i := 0
+	for i < len(r.rec.Alerts) && r.rec.Alerts[i].At.Before(cutoff) {
+		i++
+	}
+	r.rec.Alerts = r.rec.Alerts[i:]
+	i = 0
+	for i < len(r.rec.Disks) && r.rec.Disks[i].At.Before(cutoff) {
+		i++
+	}
+	r.rec.Disks = r.rec.Disks[i:]
+}
+
+// save persists the journal. Caller holds r.mu.
+func (r *Reporter) save() {
+	if err := r.deps.Store.Set(stateKey, r.rec); err != nil {
+		log.Printf("State: save report failed: %v", err)
+	}
+}
+
+// lastScheduled returns the most recent scheduled delivery at or before now.
+func (r *Reporter) lastScheduled(now time.Time) time.Time {
+	day := time.Monday
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(r.deps.Config.Day, d.String()) {
+			day = d
+		}
+	}
+	hour, minute := 8, 0
+	if t, err := time.Parse("15:04", r.deps.Config.Time); err == nil {
+		hour, minute = t.Hour(), t.Minute()
+	}
+
+	t := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+	t = t.AddDate(0, 0, -((int(now.Weekday()) - int(day) + 7) % 7))
+	if t.After(now) {
+		t = t.AddDate(0, 0, -7)
+	}
+	return t
+}
+
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+func severityRank(severity string) int {
+	switch severity {
+	case alerts.SeverityCritical:
+		return 3
+	case alerts.SeverityWarning:
+		return 2
+	case alerts.SeverityInfo:
+		return 1
+	}
+	return 0
+}
+
+// safeName makes a machine name usable in a file name.
+func safeName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`<>:"/\|?* `, r) {
+			return '-'
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,238 @@
+// Package routines runs the configured power routines: waking the machine
+// before a service and starting its apps, and sleeping or shutting it down
+// after hours. Without them, Sunday's 6 AM power-up is a person with a
+// checklist walking the building.
+package routines
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/events"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/launch"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/presence"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/schedule"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/state"
+)
+
+const (
+	stateKey      = "routines"
+	checkInterval = 15 * time.Second
+	// wakeLead wakes the machine ahead of a wake routine, so it has resumed
+	// and joined the network by the time the apps start.
+	wakeLead = 2 * time.Minute
+	// shutdownDelay warns whoever is at the machine before it powers off.
+	shutdownDelay = time.Minute
+)
+
+// Results other than errors.
+const (
+	resultOK      = "ok"
+	resultSkipped = "skipped: an operator is at the machine"
+)
+
+// Status is one routine as reported in /status.
+type Status struct {
+	Name       string     `json:"name"`
+	Action     string     `json:"action"`
+	At         string     `json:"at"`
+	Days       []string   `json:"days,omitempty"`
+	Next       *time.Time `json:"next,omitempty"`
+	LastRun    *time.Time `json:"lastRun,omitempty"`
+	LastResult string     `json:"lastResult,omitempty"` // "ok", "skipped: ...", or the error
+	// WakeArmed is set on the wake routine the wake timer is set for;
+	// WakeError says why the timer couldn't be set for it.
+	WakeArmed bool   `json:"wakeArmed,omitempty"`
+	WakeError string `json:"wakeError,omitempty"`
+}
+
+// Failed reports whether the last run failed. A skipped run didn't.
+func (s Status) Failed() bool {
+	return s.LastResult != "" && s.LastResult != resultOK && s.LastResult != resultSkipped
+}
+
+// Label names the routine: its name, else its action and time.
+func (s Status) Label() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	return s.Action + " at " + s.At
+}
+
+// run is a routine's last execution. It is stored, since shutdown
+// routines end the agent too.
+type run struct {
+	At     time.Time `json:"at"`
+	Result string    `json:"result"`
+}
+
+// Scheduler runs the routines. Safe for concurrent use.
+type Scheduler struct {
+	routines []config.Routine
+	store    *state.Store
+
+	mu      sync.Mutex
+	last    map[string]run // by routine key
+	armed   string         // key of the wake routine the timer is set for
+	armedAt time.Time
+	wakeErr error // setting the timer for armed failed
+}
+
+// New restores the last runs from the store. It returns nil when no
+// routines are configured.
+func New(routines []config.Routine, store *state.Store) *Scheduler {
+	if len(routines) == 0 {
+		return nil
+	}
+	s := &Scheduler{routines: routines, store: store, last: map[string]run{}}
+	store.Get(stateKey, &s.last)
+	return s
+}
+
+// Status returns every routine with its next and last run, or nil when
+// none are configured.
+func (s *Scheduler) Status() []Status {
+	if s == nil {
+		return nil
+	}
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]Status, 0, len(s.routines))
+	for _, r := range s.routines {
+		status := Status{Name: r.Name, Action: r.Action, At: r.At, Days: r.Days}
+		if s.armed == key(r) {
+			status.WakeArmed = s.wakeErr == nil
+			if s.wakeErr != nil {
+				status.WakeError = s.wakeErr.Error()
+			}
+		}
+		if next := weekly(r).Next(now); !next.IsZero() {
+			status.Next = &next
+		}
+		if last, ok := s.last[key(r)]; ok {
+			status.LastRun, status.LastResult = &last.At, last.Result
+		}
+		list = append(list, status)
+	}
+	return list
+}
+
+// Run executes routines as they come due and keeps the wake timer set for
+// the next wake routine. Blocks forever. It compares wall-clock times
+// rather than sleeping until the next routine, since timers stop while the
+// machine sleeps.
+func (s *Scheduler) Run() {
+	since := time.Now()
+	for {
+		s.arm(since)
+		time.Sleep(checkInterval)
+		now := time.Now()
+		for _, r := range s.routines {
+			if weekly(r).Between(since, now) {
+				s.execute(r)
+			}
+		}
+		since = now
+	}
+}
+
+// arm sets the wake timer for the next wake routine.
+func (s *Scheduler) arm(now time.Time) {
+	var next config.Routine
+	var at time.Time
+	for _, r := range s.routines {
+		if r.Action != "wake" {
+			continue
+		}
+		if t := weekly(r).Next(now.Add(wakeLead)); !t.IsZero() && (at.IsZero() || t.Before(at)) {
+			next, at = r, t
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if at.Equal(s.armedAt) {
+		return
+	}
+	s.armed, s.armedAt, s.wakeErr = key(next), at, nil
+	if at.IsZero() {
+		s.armed = ""
+		return
+	}
+	if s.wakeErr = setWakeTimer(at.Add(-wakeLead)); s.wakeErr != nil {
+		log.Printf("Routines: setting wake timer for %s: %v", name(next), s.wakeErr)
+		return
+	}
+	log.Printf("Routines: wake timer set for %s (%s)", at.Add(-wakeLead).Format(time.RFC3339), name(next))
+}
+
+func (s *Scheduler) execute(r config.Routine) {
+	var err error
+	result := resultOK
+	switch {
+	case r.Action == "wake":
+		err = launchAll(r.Launch)
+	case presence.OperatorPresent():
+		result = resultSkipped
+	default:
+		// Saved first: a shutdown doesn't come back to save it.
+		s.save(r, run{At: time.Now(), Result: resultOK})
+		events.Record(events.Lifecycle, events.Warning, "Routine %s: %s", name(r), r.Action)
+		err = powerDown(r.Action, shutdownDelay)
+	}
+	if err != nil {
+		result = err.Error()
+		events.Record(events.Lifecycle, events.Error, "Routine %s failed: %v", name(r), err)
+	} else if r.Action == "wake" || result == resultSkipped {
+		events.Record(events.Lifecycle, events.Info, "Routine %s: %s", name(r), result)
+	}
+	s.save(r, run{At: time.Now(), Result: result})
+}
+
+func (s *Scheduler) save(r config.Routine, last run) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.last[key(r)] = last
+	if err := s.store.Set(stateKey, s.last); err != nil {
+		log.Printf("State: save routines failed: %v", err)
+	}
+}
+
+// launchAll starts each command that isn't already running.
+func launchAll(commands []config.LaunchCommand) error {
+	var errs []error
+	for _, c := range commands {
+		if launch.Running(c.Command) {
+			continue
+		}
+		if err := launch.Start(c.Command, c.Args); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", c.Command, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func weekly(r config.Routine) schedule.Weekly {
+	return schedule.Weekly{Clock: r.At, Days: r.Days}
+}
+
+// key identifies a routine in the store; names are optional.
+func key(r config.Routine) string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return r.Action + " " + r.At + " " + strings.Join(r.Days, ",")
+}
+
+func name(r config.Routine) string {
+	if r.Name != "" {
+		return fmt.Sprintf("%q", r.Name)
+	}
+	return r.Action + " at " + r.At
+}
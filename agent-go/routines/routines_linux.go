@@ -0,0 +1,35 @@
+//go:build linux
+
+package routines
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// setWakeTimer sets the RTC alarm, which also wakes most boards from
+// power-off.
+func setWakeTimer(at time.Time) error {
+	return runCommand("rtcwake", "-m", "no", "-t", strconv.FormatInt(at.Unix(), 10))
+}
+
+func powerDown(action string, delay time.Duration) error {
+	switch action {
+	case "sleep":
+		return runCommand("systemctl", "suspend")
+	case "hibernate":
+		return runCommand("systemctl", "hibernate")
+	}
+	minutes := max(1, int(delay.Minutes()))
+	return runCommand("shutdown", "-h", "+"+strconv.Itoa(minutes), "Scheduled shutdown by the AVL Dashboard Agent")
+}
+
+func runCommand(name string, args ...string) error {
+	if out, err := exec.Command(name, args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %v: %s", name, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
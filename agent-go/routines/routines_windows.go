@@ -0,0 +1,79 @@
+//go:build windows
+
+package routines
+
+import (
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	kernel32                = windows.NewLazySystemDLL("kernel32.dll")
+	procCreateWaitableTimer = kernel32.NewProc("CreateWaitableTimerW")
+	procSetWaitableTimer    = kernel32.NewProc("SetWaitableTimer")
+	procSetSuspendState     = windows.NewLazySystemDLL("powrprof.dll").NewProc("SetSuspendState")
+)
+
+// wakeTimer stays open for the agent's life: closing it would cancel the
+// wake.
+var wakeTimer windows.Handle
+
+// setWakeTimer sets a waitable timer that resumes the machine from sleep or
+// hibernation. It can't start a machine that is shut down, and Windows
+// ignores it unless "Allow wake timers" is enabled in the power plan.
+func setWakeTimer(at time.Time) error {
+	if wakeTimer == 0 {
+		h, _, err := procCreateWaitableTimer.Call(0, 0, 0)
+		if h == 0 {
+			return err
+		}
+		wakeTimer = windows.Handle(h)
+	}
+	ft := windows.NsecToFiletime(at.UnixNano())
+	due := int64(ft.HighDateTime)<<32 | int64(ft.LowDateTime) // positive: absolute UTC
+	if r, _, err := procSetWaitableTimer.Call(uintptr(wakeTimer), uintptr(unsafe.Pointer(&due)), 0, 0, 0, 1); r == 0 {
+		return err
+	}
+	return nil
+}
+
+func powerDown(action string, delay time.Duration) error {
+	if err := enableShutdownPrivilege(); err != nil {
+		return err
+	}
+	switch action {
+	case "sleep", "hibernate":
+		var hibernate uintptr
+		if action == "hibernate" {
+			hibernate = 1
+		}
+		if r, _, err := procSetSuspendState.Call(hibernate, 0, 0); r == 0 {
+			return err
+		}
+		return nil
+	}
+	message, _ := windows.UTF16PtrFromString("Scheduled shutdown by the AVL Dashboard Agent")
+	// Planned, other (SHTDN_REASON_FLAG_PLANNED).
+	return windows.InitiateSystemShutdownEx(nil, message, uint32(delay.Seconds()), false, false, 0x80000000)
+}
+
+// enableShutdownPrivilege enables SeShutdownPrivilege, which both the
+// service account and a signed-in user hold but have disabled.
+func enableShutdownPrivilege() error {
+	var token windows.Token
+	if err := windows.OpenProcessToken(windows.CurrentProcess(), windows.TOKEN_ADJUST_PRIVILEGES|windows.TOKEN_QUERY, &token); err != nil {
+		return err
+	}
+	defer token.Close()
+	var luid windows.LUID
+	if err := windows.LookupPrivilegeValue(nil, windows.StringToUTF16Ptr("SeShutdownPrivilege"), &luid); err != nil {
+		return err
+	}
+	privileges := windows.Tokenprivileges{
+		PrivilegeCount: 1,
+		Privileges:     [1]windows.LUIDAndAttributes{{Luid: luid, Attributes: windows.SE_PRIVILEGE_ENABLED}},
+	}
+	return windows.AdjustTokenPrivileges(token, false, &privileges, 0, nil, nil)
+}
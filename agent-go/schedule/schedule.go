@@ -0,0 +1,130 @@
+// Package schedule spaces out the agent's periodic work. Jitter keeps a
+// room of agents that started together (after a power cut or a fleet-wide
+// update) from hitting GitHub or a config server in lockstep, and Backoff
+// keeps a failing call from being retried at full rate. Weekly is the
+// configured times of day that display and power schedules run at.
+package schedule
+
+import (
+	"context"
+	"math/rand/v2"
+	"strings"
+	"time"
+)
+
+// Jitter returns d moved randomly by up to fraction of itself either way,
+// e.g. 30 minutes ±10% for Jitter(30*time.Minute, 0.1).
+func Jitter(d time.Duration, fraction float64) time.Duration {
+	if d <= 0 || fraction <= 0 {
+		return d
+	}
+	return d + time.Duration((rand.Float64()*2-1)*fraction*float64(d))
+}
+
+// Backoff doubles the wait after each consecutive failure, from Min up to
+// Max. The zero value never waits. Not safe for concurrent use.
+type Backoff struct {
+	Min, Max time.Duration
+	failures int
+}
+
+// Fail records a failure and returns how long to wait before retrying.
+func (b *Backoff) Fail() time.Duration {
+	wait := b.Min
+	for i := 0; i < b.failures && wait < b.Max; i++ {
+		wait *= 2
+	}
+	b.failures++
+	return min(wait, b.Max)
+}
+
+// Reset records a success, so the next failure waits Min again.
+func (b *Backoff) Reset() {
+	b.failures = 0
+}
+
+// Policy is when Loop runs its function.
+type Policy struct {
+	// First is the delay before the first run, spread by Jitter too.
+	First time.Duration
+	// Interval is the time between runs that succeed.
+	Interval time.Duration
+	// Jitter is the fraction of each wait to spread it by, e.g. 0.1.
+	Jitter float64
+	// MaxBackoff is the longest wait after repeated failures. Failures
+	// wait Interval, doubling each time; zero keeps Interval.
+	MaxBackoff time.Duration
+}
+
+// Loop runs fn on policy until ctx is cancelled.
+func Loop(ctx context.Context, policy Policy, fn func() error) {
+	backoff := Backoff{Min: policy.Interval, Max: max(policy.MaxBackoff, policy.Interval)}
+	wait := policy.First
+	for {
+		timer := time.NewTimer(Jitter(wait, policy.Jitter))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		if err := fn(); err != nil {
+			wait = backoff.Fail()
+		} else {
+			backoff.Reset()
+			wait = policy.Interval
+		}
+	}
+}
+
+// Weekly is a time of day on some weekdays, as configs write it: Clock is
+// "HH:MM" local time and Days are weekday names, none meaning every day.
+type Weekly struct {
+	Clock string
+	Days  []string
+}
+
+// Between reports whether an occurrence fell after since and up to now.
+func (w Weekly) Between(since, now time.Time) bool {
+	at, ok := w.onDay(now)
+	if ok && at.After(now) {
+		at, ok = w.onDay(now.AddDate(0, 0, -1))
+	}
+	return ok && at.After(since) && w.onWeekday(at.Weekday())
+}
+
+// Next returns the first occurrence after now, or the zero time if Clock
+// doesn't parse.
+func (w Weekly) Next(now time.Time) time.Time {
+	for i := 0; i <= 7; i++ {
+		at, ok := w.onDay(now.AddDate(0, 0, i))
+		if !ok {
+			return time.Time{}
+		}
+		if at.After(now) && w.onWeekday(at.Weekday()) {
+			return at
+		}
+	}
+	return time.Time{}
+}
+
+// onDay returns the occurrence on day's date, whatever the weekday.
+func (w Weekly) onDay(day time.Time) (time.Time, bool) {
+	t, err := time.Parse("15:04", w.Clock)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, day.Location()), true
+}
+
+func (w Weekly) onWeekday(d time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, day := range w.Days {
+		if strings.EqualFold(day, d.String()) {
+			return true
+		}
+	}
+	return false
+}
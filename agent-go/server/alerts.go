@@ -0,0 +1,33 @@
+package server
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/events"
+)
+
+// ackRequest is the body of POST /alerts/ack. An empty key acknowledges
+// every alert.
+type ackRequest struct {
+	Key string `json:"key"`
+}
+
+func (s *Server) handleAckAlerts(conn net.Conn, req *http.Request) {
+	var body ackRequest
+	if err := decodeBody(req, &body); err != nil {
+		writeResponse(conn, 400, "text/plain", []byte(err.Error()))
+		return
+	}
+
+	if body.Key == "" {
+		n := s.alerts.AckAll()
+		events.Record(events.RemoteAction, events.Info, "Alerts: %d acknowledged by %s", n, caller(conn, req))
+	} else if s.alerts.Ack(body.Key) {
+		events.Record(events.RemoteAction, events.Info, "Alerts: %s acknowledged by %s", body.Key, caller(conn, req))
+	} else {
+		writeResponse(conn, 404, "text/plain", []byte("No such alert"))
+		return
+	}
+	writeJSON(conn, 200, s.alerts.List())
+}
@@ -0,0 +1,37 @@
+package server
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/audio"
+)
+
+func (s *Server) handleGetAudio(conn net.Conn, _ *http.Request) {
+	state, err := audio.Get()
+	if err != nil {
+		writeResponse(conn, 500, "text/plain", []byte(err.Error()))
+		return
+	}
+	writeJSON(conn, 200, state)
+}
+
+// handleSetAudio sets the output volume and/or mute; body
+// {"volumePercent": 60, "muted": false}, either field optional.
+func (s *Server) handleSetAudio(conn net.Conn, req *http.Request) {
+	var body audio.Change
+	if err := decodeBody(req, &body); err != nil {
+		writeResponse(conn, 400, "text/plain", []byte(err.Error()))
+		return
+	}
+	if v := body.VolumePercent; v != nil && (*v < 0 || *v > 100) {
+		writeResponse(conn, 400, "text/plain", []byte("volumePercent must be between 0 and 100"))
+		return
+	}
+	state, err := audio.Set(body, caller(conn, req))
+	if err != nil {
+		writeResponse(conn, 500, "text/plain", []byte(err.Error()))
+		return
+	}
+	writeJSON(conn, 200, state)
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Scopes, lowest to highest. A token satisfies its own scope and every
+// scope below it.
+const (
+	ScopeViewer   = "viewer"
+	ScopeOperator = "operator"
+	ScopeAdmin    = "admin"
+)
+
+var scopeRank = map[string]int{ScopeViewer: 1, ScopeOperator: 2, ScopeAdmin: 3}
+
+type callerKey struct{}
+
+// requireScope runs handler only if the request carries a bearer token
+// with at least the given scope. Remote actions are disabled entirely when
+// no token is configured or paired, so a fresh install never exposes them
+// to the whole network.
+func (s *Server) requireScope(conn net.Conn, req *http.Request, scope string, handler func(net.Conn, *http.Request)) {
+	if s.cfg.AuthToken == "" && len(s.cfg.Tokens) == 0 && (s.pairing == nil || len(s.pairing.List()) == 0) {
+		writeResponse(conn, 403, "text/plain", []byte("Remote actions are disabled (no authToken configured)"))
+		return
+	}
+
+	name, granted, ok := s.authenticate(req)
+	if !ok {
+		writeResponse(conn, 401, "text/plain", []byte("Unauthorized"))
+		return
+	}
+	if scopeRank[granted] < scopeRank[scope] {
+		writeResponse(conn, 403, "text/plain", []byte(fmt.Sprintf("Token %q lacks %s scope", name, scope)))
+		return
+	}
+	handler(conn, req.WithContext(context.WithValue(req.Context(), callerKey{}, name)))
+}
+
+// requireScopeIf applies requireScope only when RequireAuth is set,
+// leaving the endpoint open otherwise.
+func (s *Server) requireScopeIf(conn net.Conn, req *http.Request, scope string, handler func(net.Conn, *http.Request)) {
+	if !s.cfg.RequireAuth {
+		handler(conn, req)
+		return
+	}
+	s.requireScope(conn, req, scope, handler)
+}
+
+// authenticate returns the name and scope of the token the request carries.
+// Every configured token is compared so timing doesn't reveal which matched.
+func (s *Server) authenticate(req *http.Request) (name, scope string, ok bool) {
+	token, found := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if !found || token == "" {
+		return "", "", false
+	}
+	if s.cfg.AuthToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.cfg.AuthToken)) == 1 {
+		name, scope, ok = "authToken", ScopeAdmin, true
+	}
+	for _, t := range s.cfg.Tokens {
+		if t.Token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(t.Token)) == 1 && !ok {
+			name, scope, ok = t.Name, strings.ToLower(t.Scope), true
+		}
+	}
+	if s.pairing != nil && !ok {
+		if d, found := s.pairing.Lookup(token); found {
+			name, scope, ok = "dashboard:"+d.Name, strings.ToLower(d.Scope), true
+		}
+	}
+	return name, scope, ok
+}
+
+// caller describes who made an authenticated request, for audit logs.
+func caller(conn net.Conn, req *http.Request) string {
+	if name, ok := req.Context().Value(callerKey{}).(string); ok && name != "" {
+		return fmt.Sprintf("%s (%s)", name, conn.RemoteAddr())
+	}
+	return conn.RemoteAddr().String()
+}
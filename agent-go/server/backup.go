@@ -0,0 +1,42 @@
+package server
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/backup"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/events"
+)
+
+// backupList is the body of GET /backups.
+type backupList struct {
+	Last  *backup.Result `json:"last"`
+	Files []string       `json:"files"` // newest first
+}
+
+// handleRunBackup serves POST /backups: start a backup and answer 202.
+// Fetching the items and uploading outlast the connection's deadline, so
+// the result is read from GET /backups or /status once it finishes.
+func (s *Server) handleRunBackup(conn net.Conn, req *http.Request) {
+	events.Record(events.RemoteAction, events.Info, "Backup requested by %s", caller(conn, req))
+	writeResponse(conn, 202, "text/plain", []byte("Backup started"))
+	go s.backup.Backup()
+}
+
+// handleGetBackup serves GET /backups/{file}. Backups hold application
+// configs with their passwords, so this needs admin scope.
+func (s *Server) handleGetBackup(conn net.Conn, req *http.Request) {
+	name := strings.TrimPrefix(req.URL.Path, "/backups/")
+	body, err := s.backup.Open(name)
+	switch {
+	case errors.Is(err, backup.ErrNotFound):
+		writeResponse(conn, 404, "text/plain", []byte(err.Error()))
+	case err != nil:
+		writeResponse(conn, 500, "text/plain", []byte(err.Error()))
+	default:
+		events.Record(events.RemoteAction, events.Info, "Backup %s downloaded by %s", name, caller(conn, req))
+		writeAttachment(conn, "application/zip", name, body)
+	}
+}
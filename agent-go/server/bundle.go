@@ -0,0 +1,76 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/diag"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/events"
+)
+
+// recentStatusCount is how many served /status payloads a support bundle
+// includes.
+const recentStatusCount = 5
+
+// servedStatus is a /status payload as the dashboard received it.
+type servedStatus struct {
+	Served time.Time `json:"served"`
+	Status any       `json:"status"`
+}
+
+// rememberStatus keeps the last few /status payloads for support bundles.
+func (s *Server) rememberStatus(status any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.recentStatus = append(s.recentStatus, servedStatus{Served: time.Now(), Status: status})
+	if len(s.recentStatus) > recentStatusCount {
+		s.recentStatus = s.recentStatus[len(s.recentStatus)-recentStatusCount:]
+	}
+}
+
+// SupportBundle returns a zip of recent logs, redacted config, status, and
+// subsystem state for attaching to an issue.
+func (s *Server) SupportBundle() ([]byte, error) {
+	status := s.collector.CurrentStatus()
+
+	s.mu.RLock()
+	recent := append([]servedStatus(nil), s.recentStatus...)
+	s.mu.RUnlock()
+
+	files := map[string]any{
+		"status.json":        status,
+		"status-served.json": recent,
+		"health.json":        s.collector.Health(),
+		"drivers.json":       s.collector.Drivers(),
+	}
+	if s.availability != nil {
+		files["availability.json"] = s.availability.Report()
+	}
+	if s.alerts != nil {
+		files["alerts.json"] = s.alerts.List()
+	}
+	if s.fleet != nil {
+		files["fleet.json"] = s.fleet.Status()
+	}
+	if s.pairing != nil {
+		files["pairings.json"] = s.pairing.List()
+	}
+
+	return diag.Build(diag.Sources{
+		Version: status.AgentVersion,
+		Config:  s.cfg,
+		Files:   files,
+	})
+}
+
+func (s *Server) handleSupportBundle(conn net.Conn, req *http.Request) {
+	data, err := s.SupportBundle()
+	if err != nil {
+		writeResponse(conn, 500, "text/plain", []byte(err.Error()))
+		return
+	}
+	events.Record(events.RemoteAction, events.Info, "Support bundle downloaded by %s", caller(conn, req))
+
+	writeAttachment(conn, "application/zip", diag.FileName(), data)
+}
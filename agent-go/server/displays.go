@@ -0,0 +1,39 @@
+package server
+
+import (
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/display"
+)
+
+// handleResetDisplays re-learns the display baseline from the current
+// modes and returns them.
+func (s *Server) handleResetDisplays(conn net.Conn, req *http.Request) {
+	s.displays.ResetBaseline(caller(conn, req))
+	writeJSON(conn, 200, s.displays.Outputs())
+}
+
+// handleControlDisplay powers a monitor on or off or switches its input
+// over DDC/CI and returns the outputs.
+func (s *Server) handleControlDisplay(conn net.Conn, req *http.Request) {
+	var body display.Control
+	if err := decodeBody(req, &body); err != nil {
+		writeResponse(conn, 400, "text/plain", []byte(err.Error()))
+		return
+	}
+	if err := body.Validate(); err != nil {
+		writeResponse(conn, 400, "text/plain", []byte(err.Error()))
+		return
+	}
+	err := s.displays.Control(body, caller(conn, req))
+	switch {
+	case errors.Is(err, display.ErrUnknownOutput):
+		writeResponse(conn, 404, "text/plain", []byte(err.Error()))
+	case err != nil:
+		writeResponse(conn, 500, "text/plain", []byte(err.Error()))
+	default:
+		writeJSON(conn, 200, s.displays.Outputs())
+	}
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/events"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/files"
+)
+
+// downloadChunk is how much of a file is sent per write deadline, so a
+// multi-gigabyte recording can stream over a slow link.
+const downloadChunk = 1 << 20
+
+// handleFiles serves GET /files/{folder}/{path}: a directory listing as
+// JSON, or the file itself. Listings need viewer scope and downloads
+// operator scope; both are logged as remote actions.
+func (s *Server) handleFiles(conn net.Conn, req *http.Request) {
+	folder, rel, _ := strings.Cut(strings.TrimPrefix(req.URL.Path, "/files/"), "/")
+	target, err := s.files.Resolve(folder, rel)
+	var info os.FileInfo
+	if err == nil {
+		info, err = os.Stat(target)
+	}
+	if err != nil {
+		writeFilesError(conn, err)
+		return
+	}
+
+	if info.IsDir() {
+		listing, err := s.files.List(folder, rel)
+		if err != nil {
+			writeFilesError(conn, err)
+			return
+		}
+		events.Record(events.RemoteAction, events.Info, "Files: %s/%s listed by %s", folder, rel, caller(conn, req))
+		writeJSON(conn, 200, listing)
+		return
+	}
+
+	s.requireScope(conn, req, ScopeOperator, func(conn net.Conn, req *http.Request) {
+		f, err := os.Open(target)
+		if err != nil {
+			writeFilesError(conn, err)
+			return
+		}
+		defer f.Close()
+		events.Record(events.RemoteAction, events.Info, "Files: %s/%s (%d bytes) downloaded by %s", folder, rel, info.Size(), caller(conn, req))
+		streamFile(conn, filepath.Base(target), f, info.Size())
+	})
+}
+
+func writeFilesError(conn net.Conn, err error) {
+	switch {
+	case errors.Is(err, files.ErrNotFound), errors.Is(err, os.ErrNotExist):
+		writeResponse(conn, 404, "text/plain", []byte("Not Found"))
+	case errors.Is(err, files.ErrOutside):
+		writeResponse(conn, 403, "text/plain", []byte(err.Error()))
+	default:
+		writeResponse(conn, 500, "text/plain", []byte(err.Error()))
+	}
+}
+
+// streamFile sends a file as a download, extending the write deadline for
+// each chunk.
+func streamFile(conn net.Conn, filename string, r io.Reader, size int64) {
+	header := fmt.Sprintf(
+		"HTTP/1.1 200 OK\r\nContent-Type: application/octet-stream\r\nContent-Disposition: attachment; filename=%q\r\nContent-Length: %d\r\nConnection: close\r\n\r\n",
+		filename, size,
+	)
+	conn.SetDeadline(time.Now().Add(30 * time.Second))
+	if _, err := conn.Write([]byte(header)); err != nil {
+		return
+	}
+	for {
+		conn.SetDeadline(time.Now().Add(30 * time.Second))
+		if _, err := io.CopyN(conn, r, downloadChunk); err != nil {
+			return
+		}
+	}
+}
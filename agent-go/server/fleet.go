@@ -0,0 +1,45 @@
+package server
+
+import (
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/fleet"
+)
+
+func (s *Server) handlePushConfig(conn net.Conn, req *http.Request) {
+	var doc config.FleetDocument
+	if err := decodeBody(req, &doc); err != nil {
+		writeResponse(conn, 400, "text/plain", []byte(err.Error()))
+		return
+	}
+	if err := s.fleet.Apply(doc, caller(conn, req)); err != nil {
+		writeResponse(conn, 400, "text/plain", []byte(err.Error()))
+		return
+	}
+	writeJSON(conn, 200, s.fleet.Status())
+}
+
+// rollbackRequest is the body of POST /config/rollback.
+type rollbackRequest struct {
+	Version string `json:"version"`
+}
+
+func (s *Server) handleRollbackConfig(conn net.Conn, req *http.Request) {
+	var body rollbackRequest
+	if err := decodeBody(req, &body); err != nil {
+		writeResponse(conn, 400, "text/plain", []byte(err.Error()))
+		return
+	}
+	err := s.fleet.Rollback(body.Version, caller(conn, req))
+	switch {
+	case errors.Is(err, fleet.ErrUnknownVersion):
+		writeResponse(conn, 404, "text/plain", []byte(err.Error()))
+	case err != nil:
+		writeResponse(conn, 500, "text/plain", []byte(err.Error()))
+	default:
+		writeJSON(conn, 200, s.fleet.Status())
+	}
+}
@@ -1,84 +1,373 @@
 package server
 
 import (
+	"bufio"
+	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net"
+	"net/http"
 	"strings"
 	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/diag"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/events"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/metrics"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/peerclock"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/report"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/winupdate"
 )
 
+// maxBodySize caps request bodies; every endpoint takes small JSON documents.
+const maxBodySize = 1 << 20
+
 func (s *Server) handleConnection(conn net.Conn) {
 	defer conn.Close()
 	conn.SetDeadline(time.Now().Add(10 * time.Second))
 
-	buf := make([]byte, 65536)
-	n, err := conn.Read(buf)
+	req, err := http.ReadRequest(bufio.NewReader(conn))
 	if err != nil {
+		writeResponse(conn, 400, "text/plain", []byte("Bad Request"))
 		return
 	}
-	request := string(buf[:n])
+	defer req.Body.Close()
 
-	// Parse first line: "GET /status HTTP/1.1"
-	firstLine := request
-	if idx := strings.Index(request, "\r\n"); idx >= 0 {
-		firstLine = request[:idx]
-	}
-	parts := strings.Fields(firstLine)
-	if len(parts) < 2 {
-		writeResponse(conn, 400, "text/plain", []byte("Bad Request"))
+	method := req.Method
+	path := req.URL.Path
+	diag.Logf(diag.LevelDebug, "HTTP: %s %s from %s", method, path, conn.RemoteAddr())
+	if s.instance != nil {
+		s.handleInstance(conn, req)
 		return
 	}
 
-	method := parts[0]
-	path := parts[1]
-
+	// /healthz and /time stay open: the watchdog and peer agents call them
+	// without a token.
 	switch {
 	case method == "GET" && path == "/status":
-		s.handleStatus(conn)
+		s.requireScopeIf(conn, req, ScopeViewer, s.handleStatus)
+	case method == "GET" && path == "/healthz":
+		s.handleHealthz(conn)
+	case method == "GET" && path == "/time":
+		writeJSON(conn, 200, peerclock.Now())
+	case method == "GET" && path == "/inventory/drivers":
+		s.requireScopeIf(conn, req, ScopeViewer, serveJSON(func() any { return s.collector.Drivers() }))
+	case method == "GET" && path == "/history/availability" && s.availability != nil:
+		s.requireScopeIf(conn, req, ScopeViewer, serveJSON(func() any { return s.availability.Report() }))
+	case method == "GET" && path == "/history/export" && s.history != nil:
+		s.requireScopeIf(conn, req, ScopeViewer, s.handleHistoryExport)
+	case method == "GET" && path == "/alerts" && s.alerts != nil:
+		s.requireScopeIf(conn, req, ScopeViewer, serveJSON(func() any { return s.alerts.List() }))
+	case method == "POST" && path == "/alerts/ack" && s.alerts != nil:
+		s.requireScope(conn, req, ScopeOperator, s.handleAckAlerts)
+	case method == "GET" && path == "/incidents" && s.incidents != nil:
+		s.requireScopeIf(conn, req, ScopeViewer, serveJSON(func() any { return s.incidents.List() }))
+	case method == "GET" && strings.HasPrefix(path, "/incidents/") && s.incidents != nil:
+		s.requireScopeIf(conn, req, ScopeViewer, s.handleIncident)
+	case method == "GET" && path == "/maintenance" && s.maintenance != nil:
+		s.requireScopeIf(conn, req, ScopeViewer, serveJSON(func() any { return s.maintenance.Current() }))
+	case method == "POST" && path == "/maintenance" && s.maintenance != nil:
+		s.requireScope(conn, req, ScopeOperator, s.handleStartMaintenance)
+	case method == "POST" && path == "/maintenance/end" && s.maintenance != nil:
+		s.requireScope(conn, req, ScopeOperator, s.handleEndMaintenance)
+	case method == "POST" && path == "/displays/baseline" && s.displays != nil:
+		s.requireScope(conn, req, ScopeOperator, s.handleResetDisplays)
+	case method == "POST" && path == "/displays/control" && s.displays != nil:
+		s.requireScope(conn, req, ScopeOperator, s.handleControlDisplay)
+	case method == "POST" && path == "/notify" && s.intercom != nil:
+		s.requireScope(conn, req, ScopeOperator, s.handleNotify)
+	case method == "GET" && path == "/notify" && s.intercom != nil:
+		s.requireScopeIf(conn, req, ScopeViewer, serveJSON(func() any { return s.intercom.List() }))
+	case method == "GET" && strings.HasPrefix(path, "/notify/") && s.intercom != nil:
+		s.requireScopeIf(conn, req, ScopeViewer, s.handleMessage)
+	case method == "GET" && path == "/audio":
+		s.requireScopeIf(conn, req, ScopeViewer, s.handleGetAudio)
+	case method == "POST" && path == "/audio":
+		s.requireScope(conn, req, ScopeOperator, s.handleSetAudio)
+	case method == "POST" && path == "/kiosk/relaunch" && s.kiosk != nil:
+		s.requireScope(conn, req, ScopeOperator, s.handleRelaunchKiosk)
+	case method == "GET" && path == "/backups" && s.backup != nil:
+		s.requireScopeIf(conn, req, ScopeViewer, serveJSON(func() any { return backupList{s.backup.Last(), s.backup.List()} }))
+	case method == "POST" && path == "/backups" && s.backup != nil:
+		s.requireScope(conn, req, ScopeOperator, s.handleRunBackup)
+	case method == "GET" && strings.HasPrefix(path, "/backups/") && s.backup != nil:
+		s.requireScope(conn, req, ScopeAdmin, s.handleGetBackup)
+	case method == "GET" && path == "/snapshot" && s.snapshot != nil:
+		s.requireScopeIf(conn, req, ScopeViewer, s.handleSnapshot)
+	case method == "POST" && path == "/snapshot/baseline" && s.snapshot != nil:
+		s.requireScope(conn, req, ScopeOperator, s.handleResetSnapshot)
+	case method == "GET" && path == "/files" && s.files != nil:
+		s.requireScope(conn, req, ScopeViewer, serveJSON(func() any { return s.files.Folders() }))
+	case method == "GET" && strings.HasPrefix(path, "/files/") && s.files != nil:
+		s.requireScope(conn, req, ScopeViewer, s.handleFiles)
+	case method == "GET" && path == "/report" && s.report != nil:
+		s.requireScopeIf(conn, req, ScopeViewer, s.handleReport)
 	case method == "POST" && path == "/update":
-		s.handleUpdate(conn)
+		s.requireScopeIf(conn, req, ScopeAdmin, s.handleUpdate)
+	case method == "GET" && path == "/update/status" && s.updater != nil:
+		s.requireScopeIf(conn, req, ScopeViewer, serveJSON(func() any { return s.updater.Status() }))
+	case method == "POST" && path == "/updates/channel" && s.updater != nil:
+		s.requireScope(conn, req, ScopeAdmin, s.handleUpdateChannel)
+	case method == "POST" && path == "/updates/skip" && s.updater != nil:
+		s.requireScope(conn, req, ScopeAdmin, s.handleSkipUpdate(s.updater.SkipVersion))
+	case method == "POST" && path == "/updates/unskip" && s.updater != nil:
+		s.requireScope(conn, req, ScopeAdmin, s.handleSkipUpdate(s.updater.UnskipVersion))
+	case method == "POST" && path == "/restart" && s.restart != nil:
+		s.requireScope(conn, req, ScopeOperator, s.handleRestart)
+	case method == "POST" && path == "/identity/renew" && s.renew != nil:
+		s.requireScope(conn, req, ScopeAdmin, s.handleRenewIdentity)
+	case method == "GET" && path == "/support-bundle":
+		s.requireScope(conn, req, ScopeOperator, s.handleSupportBundle)
+	case method == "POST" && path == "/windows-update/pause":
+		s.requireScope(conn, req, ScopeOperator, s.handlePauseUpdates)
+	case method == "POST" && path == "/windows-update/resume":
+		s.requireScope(conn, req, ScopeOperator, s.handleResumeUpdates)
+	case method == "POST" && path == "/pair" && s.pairing != nil:
+		s.handlePair(conn, req)
+	case method == "GET" && path == "/pairings" && s.pairing != nil:
+		s.requireScope(conn, req, ScopeAdmin, serveJSON(func() any { return s.pairing.List() }))
+	case method == "POST" && path == "/pairings/revoke" && s.pairing != nil:
+		s.requireScope(conn, req, ScopeAdmin, s.handleRevokePairing)
+	case method == "GET" && path == "/config" && s.fleet != nil:
+		s.requireScope(conn, req, ScopeAdmin, serveJSON(func() any { return s.fleet.Status() }))
+	case method == "POST" && path == "/config" && s.fleet != nil:
+		s.requireScope(conn, req, ScopeAdmin, s.handlePushConfig)
+	case method == "POST" && path == "/config/rollback" && s.fleet != nil:
+		s.requireScope(conn, req, ScopeAdmin, s.handleRollbackConfig)
 	default:
 		writeResponse(conn, 404, "text/plain", []byte("Not Found"))
 	}
 }
 
-func (s *Server) handleStatus(conn net.Conn) {
+// serveJSON adapts a read-only getter to a handler.
+func serveJSON(get func() any) func(net.Conn, *http.Request) {
+	return func(conn net.Conn, _ *http.Request) {
+		writeJSON(conn, 200, get())
+	}
+}
+
+// handleStatus serves the whole status, or with ?tiers=core,... only those
+// tiers (see metrics.StatusTiers). Every answer carries an ETag; a request
+// whose If-None-Match matches it gets an empty 304.
+func (s *Server) handleStatus(conn net.Conn, req *http.Request) {
 	status := s.collector.CurrentStatus()
 
-	body, err := json.Marshal(status)
+	var body []byte
+	var err error
+	if tiers := req.URL.Query().Get("tiers"); tiers != "" {
+		body, err = status.TierJSON(strings.Split(tiers, ",")...)
+	} else {
+		body, err = json.Marshal(status)
+	}
+	if errors.Is(err, metrics.ErrUnknownTier) {
+		writeResponse(conn, 400, "text/plain", []byte(err.Error()))
+		return
+	}
 	if err != nil {
 		writeResponse(conn, 500, "text/plain", []byte("Internal Server Error"))
 		return
 	}
 
-	writeResponse(conn, 200, "application/json", body)
-
 	// Track poll time for dashboard connection detection
 	s.lastPollTime.Store(time.Now())
+
+	sum := sha256.Sum256(body)
+	etag := fmt.Sprintf(`"%x"`, sum[:8])
+	if req.Header.Get("If-None-Match") == etag {
+		writeResponse(conn, 304, "application/json", nil, "ETag: "+etag)
+		return
+	}
+	writeResponse(conn, 200, "application/json", body, "ETag: "+etag)
+	// The whole status, whatever tiers were asked for, so a support
+	// bundle shows what the machine reported.
+	s.rememberStatus(status)
+}
+
+// handleHealthz returns 200 while the collection loop is fresh and 503 once
+// it has stalled, so watchdogs and load balancers can act on the code alone.
+func (s *Server) handleHealthz(conn net.Conn) {
+	health := s.collector.Health()
+	code := 200
+	if !health.Healthy {
+		code = 503
+	}
+	writeJSON(conn, code, health)
 }
 
-func (s *Server) handleUpdate(conn net.Conn) {
+// handleReport serves the weekly report as HTML, or as JSON with
+// ?format=json.
+func (s *Server) handleReport(conn net.Conn, req *http.Request) {
+	rep := s.report.Build()
+	if req.URL.Query().Get("format") == "json" {
+		writeJSON(conn, 200, rep)
+		return
+	}
+	body, err := report.HTML(rep)
+	if err != nil {
+		writeResponse(conn, 500, "text/plain", []byte(err.Error()))
+		return
+	}
+	writeResponse(conn, 200, "text/html; charset=utf-8", body)
+}
+
+func (s *Server) handleUpdate(conn net.Conn, req *http.Request) {
 	writeResponse(conn, 200, "text/plain", []byte("Update check triggered"))
+	events.Record(events.RemoteAction, events.Info, "Update check requested by %s", caller(conn, req))
 	if s.updater != nil {
 		go s.updater.ForceCheck()
 	}
 }
 
-func writeResponse(conn net.Conn, status int, contentType string, body []byte) {
-	statusText := "OK"
-	switch status {
-	case 400:
-		statusText = "Bad Request"
-	case 404:
-		statusText = "Not Found"
-	case 500:
-		statusText = "Internal Server Error"
+// channelRequest is the body of POST /updates/channel.
+type channelRequest struct {
+	Channel string `json:"channel"` // "stable" or "beta"
+}
+
+// handleUpdateChannel moves the machine to or from the beta channel, as
+// the tray's "Receive beta updates" does.
+func (s *Server) handleUpdateChannel(conn net.Conn, req *http.Request) {
+	var body channelRequest
+	if err := decodeBody(req, &body); err != nil {
+		writeResponse(conn, 400, "text/plain", []byte(err.Error()))
+		return
+	}
+	if err := s.updater.SetChannel(body.Channel, caller(conn, req)); err != nil {
+		writeResponse(conn, 400, "text/plain", []byte(err.Error()))
+		return
+	}
+	writeJSON(conn, 200, channelRequest{Channel: s.updater.Channel()})
+}
+
+// skipRequest is the body of POST /updates/skip and /updates/unskip.
+type skipRequest struct {
+	Version string `json:"version"` // e.g. "v1.4.2"
+}
+
+// handleSkipUpdate skips or unskips a release version with apply and
+// answers with the versions now skipped.
+func (s *Server) handleSkipUpdate(apply func(version, by string) error) func(net.Conn, *http.Request) {
+	return func(conn net.Conn, req *http.Request) {
+		var body skipRequest
+		if err := decodeBody(req, &body); err != nil {
+			writeResponse(conn, 400, "text/plain", []byte(err.Error()))
+			return
+		}
+		if err := apply(body.Version, caller(conn, req)); err != nil {
+			writeResponse(conn, 400, "text/plain", []byte(err.Error()))
+			return
+		}
+		writeJSON(conn, 200, s.updater.Skipped())
+	}
+}
+
+// handleRestart answers before relaunching so the caller sees the 202
+// rather than a dropped connection.
+func (s *Server) handleRestart(conn net.Conn, req *http.Request) {
+	events.Record(events.RemoteAction, events.Info, "Restart requested by %s", caller(conn, req))
+	writeResponse(conn, 202, "text/plain", []byte("Restarting"))
+	go func() {
+		time.Sleep(500 * time.Millisecond)
+		if err := s.restart(); err != nil {
+			events.Record(events.Lifecycle, events.Error, "Restart failed: %v", err)
+		}
+	}()
+}
+
+// pauseRequest is the body of POST /windows-update/pause. Either Until
+// (RFC 3339) or Hours must be set.
+type pauseRequest struct {
+	Until string  `json:"until"`
+	Hours float64 `json:"hours"`
+}
+
+func (s *Server) handlePauseUpdates(conn net.Conn, req *http.Request) {
+	var body pauseRequest
+	if err := decodeBody(req, &body); err != nil {
+		writeResponse(conn, 400, "text/plain", []byte(err.Error()))
+		return
+	}
+
+	var until time.Time
+	switch {
+	case body.Until != "":
+		t, err := time.Parse(time.RFC3339, body.Until)
+		if err != nil {
+			writeResponse(conn, 400, "text/plain", []byte("until must be RFC 3339"))
+			return
+		}
+		until = t
+	case body.Hours > 0:
+		until = time.Now().Add(time.Duration(body.Hours * float64(time.Hour)))
+	default:
+		writeResponse(conn, 400, "text/plain", []byte("until or hours is required"))
+		return
+	}
+
+	if err := winupdate.Pause(until); err != nil {
+		events.Record(events.RemoteAction, events.Error, "Windows Update pause from %s failed: %v", caller(conn, req), err)
+		writeResponse(conn, 500, "text/plain", []byte(err.Error()))
+		return
+	}
+	events.Record(events.RemoteAction, events.Info, "Windows Update paused until %s by %s", until.Format(time.RFC3339), caller(conn, req))
+	writeJSON(conn, 200, winupdate.Read())
+}
+
+func (s *Server) handleResumeUpdates(conn net.Conn, req *http.Request) {
+	if err := winupdate.Resume(); err != nil {
+		events.Record(events.RemoteAction, events.Error, "Windows Update resume from %s failed: %v", caller(conn, req), err)
+		writeResponse(conn, 500, "text/plain", []byte(err.Error()))
+		return
+	}
+	events.Record(events.RemoteAction, events.Info, "Windows Update resumed by %s", caller(conn, req))
+	writeJSON(conn, 200, winupdate.Read())
+}
+
+// decodeBody unmarshals a JSON request body into v. An empty body leaves v unchanged.
+func decodeBody(req *http.Request, v any) error {
+	data, err := io.ReadAll(io.LimitReader(req.Body, maxBodySize))
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("invalid JSON body: %w", err)
 	}
+	return nil
+}
 
+// writeAttachment sends body as a download named filename.
+func writeAttachment(conn net.Conn, contentType, filename string, body []byte) {
+	header := fmt.Sprintf(
+		"HTTP/1.1 200 OK\r\nContent-Type: %s\r\nContent-Disposition: attachment; filename=%q\r\nContent-Length: %d\r\nConnection: close\r\n\r\n",
+		contentType, filename, len(body),
+	)
+	conn.SetDeadline(time.Now().Add(30 * time.Second))
+	conn.Write([]byte(header))
+	conn.Write(body)
+}
+
+func writeJSON(conn net.Conn, status int, v any) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		writeResponse(conn, 500, "text/plain", []byte("Internal Server Error"))
+		return
+	}
+	writeResponse(conn, status, "application/json", body)
+}
+
+// writeResponse writes a complete response. Extra headers are whole
+// lines without the line break, e.g. "ETag: \"1a2b\"".
+func writeResponse(conn net.Conn, status int, contentType string, body []byte, extra ...string) {
+	var headers strings.Builder
+	for _, h := range extra {
+		headers.WriteString(h + "\r\n")
+	}
 	header := fmt.Sprintf(
-		"HTTP/1.1 %d %s\r\nContent-Type: %s\r\nContent-Length: %d\r\nConnection: close\r\n\r\n",
-		status, statusText, contentType, len(body),
+		"HTTP/1.1 %d %s\r\nContent-Type: %s\r\nContent-Length: %d\r\n%sConnection: close\r\n\r\n",
+		status, http.StatusText(status), contentType, len(body), headers.String(),
 	)
 
 	conn.Write([]byte(header))
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/history"
+)
+
+// handleHistoryExport serves GET /history/export. Query parameters: from
+// and to (RFC 3339, default the last 24 hours), fields (comma-separated,
+// default all), and format ("csv", the default, or "jsonl").
+func (s *Server) handleHistoryExport(conn net.Conn, req *http.Request) {
+	q := req.URL.Query()
+	to := time.Now()
+	from := to.Add(-24 * time.Hour)
+	for name, t := range map[string]*time.Time{"from": &from, "to": &to} {
+		if v := q.Get(name); v != "" {
+			parsed, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				writeResponse(conn, 400, "text/plain", []byte(name+" must be RFC 3339"))
+				return
+			}
+			*t = parsed
+		}
+	}
+	fields, err := history.ParseFields(q.Get("fields"))
+	if err != nil {
+		writeResponse(conn, 400, "text/plain", []byte(err.Error()))
+		return
+	}
+	format := q.Get("format")
+	if format == "" {
+		format = "csv"
+	}
+
+	samples, err := s.history.Samples(from, to)
+	if err != nil {
+		writeResponse(conn, 500, "text/plain", []byte(err.Error()))
+		return
+	}
+	var buf bytes.Buffer
+	if err := history.Export(&buf, samples, fields, format); err != nil {
+		writeResponse(conn, 400, "text/plain", []byte(err.Error()))
+		return
+	}
+
+	contentType := "text/csv"
+	if format == "jsonl" {
+		contentType = "application/x-ndjson"
+	}
+	name := fmt.Sprintf("history-%s-%s.%s", from.Format("20060102"), to.Format("20060102"), format)
+	writeAttachment(conn, contentType, name, buf.Bytes())
+}
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/events"
+)
+
+type renewRequest struct {
+	// UUID is the hardware UUID the dashboard saw duplicated; it must
+	// still be this machine's, so a retried request doesn't renew twice.
+	UUID string `json:"uuid"`
+}
+
+// handleRenewIdentity gives the machine a new hardware UUID when the
+// dashboard finds two machines reporting the same one, then restarts the
+// agent so every advertisement carries it. It answers before restarting.
+func (s *Server) handleRenewIdentity(conn net.Conn, req *http.Request) {
+	var body renewRequest
+	if err := decodeBody(req, &body); err != nil {
+		writeResponse(conn, 400, "text/plain", []byte(err.Error()))
+		return
+	}
+	current := s.collector.CurrentStatus().HardwareUUID
+	if !strings.EqualFold(body.UUID, current) {
+		writeResponse(conn, 409, "text/plain", []byte("Hardware UUID is "+current))
+		return
+	}
+	reason := fmt.Sprintf("%s reported another machine with hardware UUID %s", caller(conn, req), current)
+	events.Record(events.RemoteAction, events.Warning, "New identity requested: %s", reason)
+	writeResponse(conn, 202, "text/plain", []byte("Renewing identity"))
+	go func() {
+		time.Sleep(500 * time.Millisecond)
+		if err := s.renew(reason); err != nil {
+			events.Record(events.Lifecycle, events.Error, "New identity failed: %v", err)
+		}
+	}()
+}
@@ -0,0 +1,24 @@
+package server
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/incident"
+)
+
+// handleIncident serves GET /incidents/{id}: one incident with its
+// per-second samples.
+func (s *Server) handleIncident(conn net.Conn, req *http.Request) {
+	inc, err := s.incidents.Get(strings.TrimPrefix(req.URL.Path, "/incidents/"))
+	switch {
+	case errors.Is(err, incident.ErrNotFound):
+		writeResponse(conn, 404, "text/plain", []byte(err.Error()))
+	case err != nil:
+		writeResponse(conn, 500, "text/plain", []byte(err.Error()))
+	default:
+		writeJSON(conn, 200, inc)
+	}
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/diag"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/instances"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/pairing"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/peerclock"
+)
+
+// NewInstance creates a server for a logical instance on its own port. It
+// answers GET /status with the instance's status and GET /time; tokens
+// and pairings are the host agent's. Remote actions go to the machine's
+// own agent, so every other endpoint is 404.
+func NewInstance(inst *instances.Instance, cfg *config.Config, pairer *pairing.Manager) *Server {
+	return &Server{
+		cfg:       cfg,
+		pairing:   pairer,
+		instance:  inst,
+		fixedPort: inst.Port,
+		portReady: make(chan struct{}),
+	}
+}
+
+func (s *Server) handleInstance(conn net.Conn, req *http.Request) {
+	switch {
+	case req.Method == "GET" && req.URL.Path == "/status":
+		s.requireScopeIf(conn, req, ScopeViewer, s.handleInstanceStatus)
+	case req.Method == "GET" && req.URL.Path == "/time":
+		writeJSON(conn, 200, peerclock.Now())
+	default:
+		writeResponse(conn, 404, "text/plain", []byte("Not Found"))
+	}
+}
+
+// handleInstanceStatus answers 502 when the instance's source doesn't, so
+// the dashboard shows the machine offline rather than stale.
+func (s *Server) handleInstanceStatus(conn net.Conn, req *http.Request) {
+	body, err := s.instance.Status(req.Context())
+	if err != nil {
+		diag.Logf(diag.LevelDebug, "Instance %s: %v", s.instance.Name, err)
+		writeResponse(conn, 502, "text/plain", []byte(err.Error()))
+		return
+	}
+	writeResponse(conn, 200, "application/json", body)
+}
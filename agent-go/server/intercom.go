@@ -0,0 +1,63 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/i18n"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/intercom"
+)
+
+// maxMessageTimeout caps how long a message stays up unacknowledged.
+const maxMessageTimeout = 24 * time.Hour
+
+// notifyRequest is the body of POST /notify. From names the sender in the
+// title, e.g. "Director"; TimeoutMinutes defaults to an hour.
+type notifyRequest struct {
+	Text           string `json:"text"`
+	From           string `json:"from"`
+	TimeoutMinutes int    `json:"timeoutMinutes"`
+}
+
+// handleNotify shows a message on the machine's screen and returns it,
+// with its state, once shown.
+func (s *Server) handleNotify(conn net.Conn, req *http.Request) {
+	var body notifyRequest
+	if err := decodeBody(req, &body); err != nil {
+		writeResponse(conn, 400, "text/plain", []byte(err.Error()))
+		return
+	}
+	body.Text = strings.TrimSpace(body.Text)
+	if body.Text == "" || utf8.RuneCountInString(body.Text) > intercom.MaxText {
+		writeResponse(conn, 400, "text/plain", []byte(fmt.Sprintf("text must be 1-%d characters", intercom.MaxText)))
+		return
+	}
+	timeout := time.Duration(body.TimeoutMinutes) * time.Minute
+	if timeout == 0 {
+		timeout = intercom.DefaultTimeout
+	}
+	if timeout < 0 || timeout > maxMessageTimeout {
+		writeResponse(conn, 400, "text/plain", []byte("timeoutMinutes must be between 1 and 1440"))
+		return
+	}
+	title := i18n.T("intercom.title.dashboard")
+	if body.From != "" {
+		title = i18n.T("intercom.title", body.From)
+	}
+	writeJSON(conn, 200, s.intercom.Send(title, body.Text, caller(conn, req), timeout))
+}
+
+// handleMessage serves GET /notify/{id}: one message's delivery state.
+func (s *Server) handleMessage(conn net.Conn, req *http.Request) {
+	msg, err := s.intercom.Get(strings.TrimPrefix(req.URL.Path, "/notify/"))
+	if errors.Is(err, intercom.ErrNotFound) {
+		writeResponse(conn, 404, "text/plain", []byte(err.Error()))
+		return
+	}
+	writeJSON(conn, 200, msg)
+}
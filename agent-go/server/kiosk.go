@@ -0,0 +1,16 @@
+package server
+
+import (
+	"net"
+	"net/http"
+)
+
+// handleRelaunchKiosk restarts the signage player and returns its status.
+func (s *Server) handleRelaunchKiosk(conn net.Conn, req *http.Request) {
+	status, err := s.kiosk.Relaunch(caller(conn, req))
+	if err != nil {
+		writeResponse(conn, 500, "text/plain", []byte(err.Error()))
+		return
+	}
+	writeJSON(conn, 200, status)
+}
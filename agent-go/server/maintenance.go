@@ -0,0 +1,39 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// maxMaintenance caps a window so a forgotten one can't silence a machine
+// indefinitely.
+const maxMaintenance = 7 * 24 * time.Hour
+
+// maintenanceRequest is the body of POST /maintenance.
+type maintenanceRequest struct {
+	Minutes int    `json:"minutes"`
+	Reason  string `json:"reason"`
+}
+
+func (s *Server) handleStartMaintenance(conn net.Conn, req *http.Request) {
+	var body maintenanceRequest
+	if err := decodeBody(req, &body); err != nil {
+		writeResponse(conn, 400, "text/plain", []byte(err.Error()))
+		return
+	}
+	d := time.Duration(body.Minutes) * time.Minute
+	if d <= 0 || d > maxMaintenance {
+		writeResponse(conn, 400, "text/plain", []byte("minutes must be between 1 and 10080"))
+		return
+	}
+	writeJSON(conn, 200, s.maintenance.Start(d, body.Reason, caller(conn, req)))
+}
+
+func (s *Server) handleEndMaintenance(conn net.Conn, req *http.Request) {
+	if !s.maintenance.End(caller(conn, req)) {
+		writeResponse(conn, 404, "text/plain", []byte("Not in maintenance"))
+		return
+	}
+	writeResponse(conn, 200, "text/plain", []byte("Maintenance ended"))
+}
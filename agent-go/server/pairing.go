@@ -0,0 +1,67 @@
+package server
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/pairing"
+)
+
+// pairRequest is the body of POST /pair.
+type pairRequest struct {
+	Code string `json:"code"`
+	Name string `json:"name"` // dashboard name, shown in GET /pairings
+}
+
+// pairResponse carries the issued token; the dashboard sends it as a
+// bearer token from then on.
+type pairResponse struct {
+	Token        string `json:"token"`
+	Scope        string `json:"scope"`
+	HardwareUUID string `json:"hardwareUUID"`
+}
+
+func (s *Server) handlePair(conn net.Conn, req *http.Request) {
+	var body pairRequest
+	if err := decodeBody(req, &body); err != nil {
+		writeResponse(conn, 400, "text/plain", []byte(err.Error()))
+		return
+	}
+	body.Name = strings.TrimSpace(body.Name)
+	if body.Code == "" || body.Name == "" {
+		writeResponse(conn, 400, "text/plain", []byte("code and name are required"))
+		return
+	}
+
+	token, scope, err := s.pairing.Pair(body.Code, body.Name)
+	switch {
+	case errors.Is(err, pairing.ErrNotOpen):
+		writeResponse(conn, 409, "text/plain", []byte(err.Error()))
+	case errors.Is(err, pairing.ErrWrongCode):
+		writeResponse(conn, 401, "text/plain", []byte(err.Error()))
+	case err != nil:
+		writeResponse(conn, 500, "text/plain", []byte(err.Error()))
+	default:
+		writeJSON(conn, 200, pairResponse{Token: token, Scope: scope, HardwareUUID: s.collector.CurrentStatus().HardwareUUID})
+	}
+}
+
+// revokeRequest is the body of POST /pairings/revoke.
+type revokeRequest struct {
+	Name string `json:"name"`
+}
+
+func (s *Server) handleRevokePairing(conn net.Conn, req *http.Request) {
+	var body revokeRequest
+	if err := decodeBody(req, &body); err != nil {
+		writeResponse(conn, 400, "text/plain", []byte(err.Error()))
+		return
+	}
+	if !s.pairing.Revoke(body.Name) {
+		writeResponse(conn, 404, "text/plain", []byte("No such dashboard"))
+		return
+	}
+	writeJSON(conn, 200, s.pairing.List())
+}
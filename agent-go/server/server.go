@@ -1,6 +1,8 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net"
@@ -8,7 +10,22 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/alerts"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/backup"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/display"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/files"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/fleet"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/history"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/incident"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/instances"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/intercom"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/kiosk"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/maintenance"
 	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/metrics"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/pairing"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/report"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/snapshot"
 	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/update"
 )
 
@@ -17,24 +34,94 @@ const (
 	portRetries = 10
 )
 
+// Deps are the subsystems the server exposes. Collector and Config are
+// required; endpoints backed by a nil subsystem return 404. Restart
+// relaunches the agent process and does not return on success; so does
+// RenewIdentity, after giving the machine a new hardware UUID. A non-zero
+// Port is bound exactly instead of searching from the default; otherwise a
+// non-zero PreferredPort (usually last run's) is tried before the search.
+type Deps struct {
+	Port          uint16
+	PreferredPort uint16
+	Collector     *metrics.Collector
+	Updater       *update.Updater
+	Config        *config.Config
+	Availability  *history.Availability
+	History       *history.Recorder
+	Alerts        *alerts.Engine
+	Pairing       *pairing.Manager
+	Fleet         *fleet.Manager
+	Maintenance   *maintenance.Mode
+	Report        *report.Reporter
+	Displays      *display.Monitor
+	Kiosk         *kiosk.Monitor
+	Intercom      *intercom.Intercom
+	Incidents     *incident.Recorder
+	Backup        *backup.Manager
+	Snapshot      *snapshot.Tracker
+	Files         *files.Browser
+	Restart       func() error
+	RenewIdentity func(reason string) error
+}
+
 // Server is a lightweight HTTP server that exposes system metrics.
 type Server struct {
-	collector *metrics.Collector
-	updater   *update.Updater
-	listener  net.Listener
-	port      uint16
-	portReady chan struct{}
+	collector    *metrics.Collector
+	updater      *update.Updater
+	cfg          *config.Config
+	availability *history.Availability
+	history      *history.Recorder
+	alerts       *alerts.Engine
+	pairing      *pairing.Manager
+	fleet        *fleet.Manager
+	maintenance  *maintenance.Mode
+	report       *report.Reporter
+	displays     *display.Monitor
+	kiosk        *kiosk.Monitor
+	intercom     *intercom.Intercom
+	incidents    *incident.Recorder
+	backup       *backup.Manager
+	snapshot     *snapshot.Tracker
+	files        *files.Browser
+	restart      func() error
+	renew        func(reason string) error
+	instance     *instances.Instance // set for NewInstance servers
+	fixedPort    uint16
+	preferred    uint16
+	listener     net.Listener
+	port         uint16
+	portReady    chan struct{}
 
 	lastPollTime atomic.Value // stores time.Time
 	mu           sync.RWMutex
+	recentStatus []servedStatus
 }
 
-// New creates a Server backed by the given metrics collector and updater.
-func New(collector *metrics.Collector, updater *update.Updater) *Server {
+// New creates a Server backed by the given subsystems.
+func New(deps Deps) *Server {
 	return &Server{
-		collector: collector,
-		updater:   updater,
-		portReady: make(chan struct{}),
+		collector:    deps.Collector,
+		updater:      deps.Updater,
+		cfg:          deps.Config,
+		availability: deps.Availability,
+		history:      deps.History,
+		alerts:       deps.Alerts,
+		pairing:      deps.Pairing,
+		fleet:        deps.Fleet,
+		maintenance:  deps.Maintenance,
+		report:       deps.Report,
+		displays:     deps.Displays,
+		kiosk:        deps.Kiosk,
+		intercom:     deps.Intercom,
+		incidents:    deps.Incidents,
+		backup:       deps.Backup,
+		snapshot:     deps.Snapshot,
+		files:        deps.Files,
+		restart:      deps.Restart,
+		renew:        deps.RenewIdentity,
+		fixedPort:    deps.Port,
+		preferred:    deps.PreferredPort,
+		portReady:    make(chan struct{}),
 	}
 }
 
@@ -56,13 +143,46 @@ func (s *Server) DashboardConnected() bool {
 	return time.Since(t) < 15*time.Second
 }
 
-// ListenAndServe binds to a TCP port and accepts connections. Blocks forever.
-func (s *Server) ListenAndServe() error {
-	var listener net.Listener
+// NewWithListener creates a Server that accepts on l instead of binding a
+// port itself, e.g. an ephemeral listener in an end-to-end test.
+func NewWithListener(deps Deps, l net.Listener) *Server {
+	s := New(deps)
+	s.listener = l
+	return s
+}
+
+// ListenAndServe binds to a TCP port (unless the server was created with a
+// listener) and accepts connections. Blocks until Close or until ctx is
+// cancelled.
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	listener := s.listener
 	var boundPort uint16
+	if listener != nil {
+		if tcp, ok := listener.Addr().(*net.TCPAddr); ok {
+			boundPort = uint16(tcp.Port)
+		}
+	}
+
+	if listener == nil && s.fixedPort != 0 {
+		l, err := net.Listen("tcp", fmt.Sprintf(":%d", s.fixedPort))
+		if err != nil {
+			close(s.portReady)
+			return fmt.Errorf("failed to bind port %d: %w", s.fixedPort, err)
+		}
+		listener = l
+		boundPort = s.fixedPort
+	}
+
+	// Keeping last run's port keeps firewall rules and bookmarks valid
+	if listener == nil && s.preferred != 0 {
+		if l, err := net.Listen("tcp", fmt.Sprintf(":%d", s.preferred)); err == nil {
+			listener = l
+			boundPort = s.preferred
+		}
+	}
 
 	// Try fixed ports first (49990..50000), then fall back to OS-assigned
-	for i := uint16(0); i <= portRetries; i++ {
+	for i := uint16(0); listener == nil && i <= portRetries; i++ {
 		port := defaultPort + i
 		l, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
 		if err == nil {
@@ -89,12 +209,27 @@ func (s *Server) ListenAndServe() error {
 	close(s.portReady)
 
 	log.Printf("Listening on port %d", boundPort)
+	stop := context.AfterFunc(ctx, func() { listener.Close() })
+	defer stop()
 
 	for {
 		conn, err := listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		}
 		if err != nil {
 			continue
 		}
 		go s.handleConnection(conn)
 	}
 }
+
+// Close stops accepting connections; ListenAndServe returns.
+func (s *Server) Close() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Close()
+}
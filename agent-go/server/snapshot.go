@@ -0,0 +1,27 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// handleSnapshot serves GET /snapshot: the latest settings, their drift
+// from the baseline, and the change log, from ?since= (RFC 3339) when
+// given.
+func (s *Server) handleSnapshot(conn net.Conn, req *http.Request) {
+	var since time.Time
+	if v := req.URL.Query().Get("since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeResponse(conn, 400, "text/plain", []byte("since must be RFC 3339"))
+			return
+		}
+		since = t
+	}
+	writeJSON(conn, 200, s.snapshot.Report(since))
+}
+
+func (s *Server) handleResetSnapshot(conn net.Conn, req *http.Request) {
+	writeJSON(conn, 200, s.snapshot.ResetBaseline(caller(conn, req)))
+}
@@ -0,0 +1,254 @@
+// Package snapshot records the machine's monitored settings every 15
+// minutes, keeps a log of what changed and when, and compares them with a
+// stored baseline. "What changed since last Sunday" is otherwise the
+// question nobody can answer.
+package snapshot
+
+import (
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/events"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/metrics"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/state"
+)
+
+const (
+	stateKey      = "settingsSnapshot"
+	checkInterval = 15 * time.Minute
+	// firstDelay lets the collector read the slow probes (power plan,
+	// displays) before the first snapshot.
+	firstDelay = time.Minute
+	maxChanges = 500
+)
+
+// unreadable are the groups of settings whose probes can fail outright (a
+// WMI timeout, the audio service restarting). A group that reads empty
+// keeps its last values rather than logging every setting as removed.
+var unreadable = []string{"power.", "audio.", "service.", "firewall."}
+
+// Change is a setting that changed between two snapshots. An empty value
+// means the setting didn't exist.
+type Change struct {
+	Time time.Time `json:"time"`
+	Key  string    `json:"key"`
+	From string    `json:"from"`
+	To   string    `json:"to"`
+}
+
+// Report is served by GET /snapshot.
+type Report struct {
+	TakenAt    time.Time              `json:"takenAt"`
+	Settings   map[string]string      `json:"settings"`
+	BaselineAt time.Time              `json:"baselineAt"`
+	Drift      []metrics.SettingDrift `json:"drift"`
+	Changes    []Change               `json:"changes"` // oldest first
+}
+
+// saved is what persists across restarts.
+type saved struct {
+	TakenAt    time.Time         `json:"takenAt"`
+	Settings   map[string]string `json:"settings"`
+	BaselineAt time.Time         `json:"baselineAt"`
+	Baseline   map[string]string `json:"baseline"`
+	Changes    []Change          `json:"changes"`
+}
+
+// Tracker takes the snapshots. Safe for concurrent use.
+type Tracker struct {
+	cfg       config.SnapshotConfig
+	collector *metrics.Collector
+	store     *state.Store
+
+	mu  sync.Mutex
+	rec saved
+}
+
+// New restores the last snapshot. It returns nil when disabled.
+func New(cfg config.SnapshotConfig, collector *metrics.Collector, store *state.Store) *Tracker {
+	if cfg.Disabled {
+		return nil
+	}
+	t := &Tracker{cfg: cfg, collector: collector, store: store}
+	store.Get(stateKey, &t.rec)
+	dropRetired(t.rec.Baseline)
+	dropRetired(t.rec.Settings)
+	return t
+}
+
+// retired are settings earlier versions recorded that change in normal
+// use, so they would drift forever against an old baseline.
+var retired = []string{"audio.volumePercent", "audio.muted"}
+
+// dropRetired removes retired settings, and the addresses of interfaces
+// not statically addressed, from a snapshot taken by an earlier version.
+func dropRetired(settings map[string]string) {
+	for _, key := range retired {
+		delete(settings, key)
+	}
+	for key := range settings {
+		if iface, ok := strings.CutSuffix(key, ".address"); ok && strings.HasPrefix(key, "network.") &&
+			settings[iface+".method"] != metrics.AddressStatic {
+			delete(settings, key)
+		}
+	}
+}
+
+// Run takes a snapshot every 15 minutes. The first becomes the baseline.
+// Blocks forever.
+func (t *Tracker) Run() {
+	time.Sleep(firstDelay)
+	for {
+		t.take()
+		time.Sleep(checkInterval)
+	}
+}
+
+// Report returns the latest snapshot, its drift from the baseline, and the
+// changes logged since since (all when zero).
+func (t *Tracker) Report(since time.Time) Report {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	rep := Report{
+		TakenAt:    t.rec.TakenAt,
+		Settings:   t.rec.Settings,
+		BaselineAt: t.rec.BaselineAt,
+		Drift:      diff(t.rec.Baseline, t.rec.Settings),
+	}
+	for _, c := range t.rec.Changes {
+		if !c.Time.Before(since) {
+			rep.Changes = append(rep.Changes, c)
+		}
+	}
+	return rep
+}
+
+// ResetBaseline makes the current settings the baseline, after a
+// deliberate change. by names who asked, for the event log.
+func (t *Tracker) ResetBaseline(by string) Report {
+	settings := t.read()
+	now := time.Now()
+	t.mu.Lock()
+	t.record(settings, now)
+	t.rec.Baseline, t.rec.BaselineAt = settings, now
+	t.save()
+	t.mu.Unlock()
+	events.Record(events.RemoteAction, events.Info, "Settings baseline reset by %s", by)
+	t.publish()
+	return t.Report(now)
+}
+
+func (t *Tracker) take() {
+	settings := t.read()
+	now := time.Now()
+	t.mu.Lock()
+	t.record(settings, now)
+	if t.rec.Baseline == nil {
+		t.rec.Baseline, t.rec.BaselineAt = settings, now
+	}
+	t.save()
+	t.mu.Unlock()
+	t.publish()
+}
+
+// read takes a snapshot without the ignored keys.
+func (t *Tracker) read() map[string]string {
+	settings := t.collector.Settings(t.cfg.FirewallKeywords)
+	for key := range settings {
+		for _, prefix := range t.cfg.Ignore {
+			if strings.HasPrefix(key, prefix) {
+				delete(settings, key)
+			}
+		}
+	}
+	return settings
+}
+
+// record logs what changed since the last snapshot. Caller holds t.mu.
+func (t *Tracker) record(settings map[string]string, now time.Time) {
+	for _, group := range unreadable {
+		if hasPrefix(settings, group) {
+			continue
+		}
+		for key, value := range t.rec.Settings {
+			if strings.HasPrefix(key, group) {
+				settings[key] = value
+			}
+		}
+	}
+	if t.rec.Settings != nil {
+		drift := diff(t.rec.Settings, settings)
+		for _, d := range drift {
+			t.rec.Changes = append(t.rec.Changes, Change{Time: now, Key: d.Key, From: d.Baseline, To: d.Current})
+		}
+		if len(drift) > 0 {
+			events.Record(events.Lifecycle, events.Info, "Settings: %d changed (%s)", len(drift), Summary(drift))
+		}
+		if n := len(t.rec.Changes); n > maxChanges {
+			t.rec.Changes = t.rec.Changes[n-maxChanges:]
+		}
+	}
+	t.rec.Settings, t.rec.TakenAt = settings, now
+}
+
+// publish reports the drift from the baseline in /status.
+func (t *Tracker) publish() {
+	t.mu.Lock()
+	drift := &metrics.ConfigDrift{
+		BaselineAt: t.rec.BaselineAt,
+		CheckedAt:  t.rec.TakenAt,
+		Drift:      diff(t.rec.Baseline, t.rec.Settings),
+	}
+	t.mu.Unlock()
+	t.collector.SetConfigDrift(drift)
+}
+
+// save persists the snapshots. Caller holds t.mu.
+func (t *Tracker) save() {
+	if err := t.store.Set(stateKey, t.rec); err != nil {
+		log.Printf("State: save settings snapshot failed: %v", err)
+	}
+}
+
+func hasPrefix(settings map[string]string, prefix string) bool {
+	for key := range settings {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// diff lists the settings that differ, sorted by key.
+func diff(from, to map[string]string) []metrics.SettingDrift {
+	var drift []metrics.SettingDrift
+	for key, was := range from {
+		if now, ok := to[key]; !ok || now != was {
+			drift = append(drift, metrics.SettingDrift{Key: key, Baseline: was, Current: now})
+		}
+	}
+	for key, now := range to {
+		if _, ok := from[key]; !ok {
+			drift = append(drift, metrics.SettingDrift{Key: key, Current: now})
+		}
+	}
+	sort.Slice(drift, func(i, j int) bool { return drift[i].Key < drift[j].Key })
+	return drift
+}
+
+// Summary names the first few changed settings, for events and alerts.
+func Summary(drift []metrics.SettingDrift) string {
+	var keys []string
+	for i, d := range drift {
+		if i == 3 {
+			keys = append(keys, "...")
+			break
+		}
+		keys = append(keys, d.Key)
+	}
+	return strings.Join(keys, ", ")
+}
@@ -0,0 +1,193 @@
+package snmp
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// BER tags used by SNMPv1/v2c.
+const (
+	tagInteger     = 0x02
+	tagOctetString = 0x04
+	tagNull        = 0x05
+	tagOID         = 0x06
+	tagSequence    = 0x30
+	tagCounter32   = 0x41
+	tagGauge32     = 0x42
+	tagTimeTicks   = 0x43
+
+	tagNoSuchObject   = 0x80
+	tagNoSuchInstance = 0x81
+	tagEndOfMibView   = 0x82
+
+	pduGet      = 0xa0
+	pduGetNext  = 0xa1
+	pduResponse = 0xa2
+	pduSet      = 0xa3
+	pduGetBulk  = 0xa5
+)
+
+var errMalformed = errors.New("malformed packet")
+
+// OID is an object identifier, e.g. 1.3.6.1.2.1.1.5.0.
+type OID []uint32
+
+// ParseOID parses dotted notation, with or without a leading dot.
+func ParseOID(s string) (OID, error) {
+	parts := strings.Split(strings.TrimPrefix(s, "."), ".")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("OID %q is too short", s)
+	}
+	oid := make(OID, len(parts))
+	for i, p := range parts {
+		n, err := strconv.ParseUint(p, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("OID %q: %w", s, err)
+		}
+		oid[i] = uint32(n)
+	}
+	return oid, nil
+}
+
+func (o OID) String() string {
+	parts := make([]string, len(o))
+	for i, n := range o {
+		parts[i] = strconv.FormatUint(uint64(n), 10)
+	}
+	return strings.Join(parts, ".")
+}
+
+// compare orders OIDs lexicographically, as GETNEXT walks them.
+func (o OID) compare(other OID) int {
+	for i := 0; i < len(o) && i < len(other); i++ {
+		switch {
+		case o[i] < other[i]:
+			return -1
+		case o[i] > other[i]:
+			return 1
+		}
+	}
+	return len(o) - len(other)
+}
+
+// append returns o extended with arcs, without aliasing o.
+func (o OID) append(arcs ...uint32) OID {
+	out := make(OID, 0, len(o)+len(arcs))
+	return append(append(out, o...), arcs...)
+}
+
+// tlv is one decoded element.
+type tlv struct {
+	tag   byte
+	value []byte
+}
+
+// next splits the first element off b.
+func next(b []byte) (tlv, []byte, error) {
+	if len(b) < 2 {
+		return tlv{}, nil, errMalformed
+	}
+	tag, length, rest := b[0], int(b[1]), b[2:]
+	if length&0x80 != 0 {
+		n := length & 0x7f
+		if n == 0 || n > 3 || len(rest) < n {
+			return tlv{}, nil, errMalformed
+		}
+		length = 0
+		for _, c := range rest[:n] {
+			length = length<<8 | int(c)
+		}
+		rest = rest[n:]
+	}
+	if len(rest) < length {
+		return tlv{}, nil, errMalformed
+	}
+	return tlv{tag: tag, value: rest[:length]}, rest[length:], nil
+}
+
+func decodeInt(b []byte) int64 {
+	var n int64
+	for i, c := range b {
+		if i == 0 && c&0x80 != 0 {
+			n = -1
+		}
+		n = n<<8 | int64(c)
+	}
+	return n
+}
+
+func decodeOID(b []byte) (OID, error) {
+	if len(b) == 0 {
+		return nil, errMalformed
+	}
+	oid := OID{uint32(b[0]) / 40, uint32(b[0]) % 40}
+	var n uint32
+	for _, c := range b[1:] {
+		n = n<<7 | uint32(c&0x7f)
+		if c&0x80 == 0 {
+			oid = append(oid, n)
+			n = 0
+		}
+	}
+	return oid, nil
+}
+
+// encode wraps value in a tag and BER length.
+func encode(tag byte, value []byte) []byte {
+	out := []byte{tag}
+	switch n := len(value); {
+	case n < 0x80:
+		out = append(out, byte(n))
+	case n <= 0xff:
+		out = append(out, 0x81, byte(n))
+	default:
+		out = append(out, 0x82, byte(n>>8), byte(n))
+	}
+	return append(out, value...)
+}
+
+func encodeInt(tag byte, n int64) []byte {
+	var b []byte
+	for {
+		b = append([]byte{byte(n)}, b...)
+		n >>= 8
+		if (n == 0 && b[0]&0x80 == 0) || (n == -1 && b[0]&0x80 != 0) {
+			break
+		}
+	}
+	return encode(tag, b)
+}
+
+// encodeUint encodes Counter32, Gauge32, and TimeTicks, which are unsigned.
+func encodeUint(tag byte, n uint32) []byte {
+	return encodeInt(tag, int64(n))
+}
+
+func encodeOID(o OID) []byte {
+	b := []byte{byte(o[0]*40 + o[1])}
+	for _, n := range o[2:] {
+		var arc []byte
+		for {
+			arc = append([]byte{byte(n & 0x7f)}, arc...)
+			n >>= 7
+			if n == 0 {
+				break
+			}
+		}
+		for i := 0; i < len(arc)-1; i++ {
+			arc[i] |= 0x80
+		}
+		b = append(b, arc...)
+	}
+	return encode(tagOID, b)
+}
+
+func sequence(tag byte, parts ...[]byte) []byte {
+	var b []byte
+	for _, p := range parts {
+		b = append(b, p...)
+	}
+	return encode(tag, b)
+}
@@ -0,0 +1,130 @@
+package snmp
+
+import (
+	"math"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/alerts"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/metrics"
+)
+
+// systemOID is MIB-II's system group, which monitoring tools read during
+// discovery.
+var systemOID = OID{1, 3, 6, 1, 2, 1, 1}
+
+// variable is one object instance with its BER-encoded value.
+type variable struct {
+	oid   OID
+	value []byte
+}
+
+// view is the MIB at one moment, sorted by OID. It is rebuilt for each
+// request, so a walk sees live values.
+type view []variable
+
+// buildView lays out the MIB described in AVL-DASHBOARD-MIB.txt under root.
+func buildView(root OID, s metrics.MachineStatus, active []alerts.Alert, started time.Time, siteName string) view {
+	var v view
+	add := func(oid OID, value []byte) { v = append(v, variable{oid, value}) }
+
+	add(systemOID.append(1, 0), str("AVL Dashboard Agent "+s.AgentVersion+" on "+s.OSVersion+" ("+runtime.GOARCH+")"))
+	add(systemOID.append(2, 0), encodeOID(root))
+	add(systemOID.append(3, 0), encodeUint(tagTimeTicks, uint32(time.Since(started)/(10*time.Millisecond))))
+	add(systemOID.append(4, 0), str(""))
+	add(systemOID.append(5, 0), str(s.Hostname))
+	add(systemOID.append(6, 0), str(siteName))
+	add(systemOID.append(7, 0), integer(72)) // application + end-to-end
+
+	var critical int
+	for _, a := range active {
+		if a.Severity == alerts.SeverityCritical {
+			critical++
+		}
+	}
+	site := ""
+	if s.Site != nil {
+		site = s.Site.ID
+	}
+	scalars := root.append(1)
+	add(scalars.append(1, 0), str(s.Hostname))
+	add(scalars.append(2, 0), str(s.AgentVersion))
+	add(scalars.append(3, 0), str(site))
+	add(scalars.append(4, 0), gauge(s.CPUUsagePercent))
+	add(scalars.append(5, 0), integer(int64(math.Round(s.CPUTempCelsius))))
+	add(scalars.append(6, 0), gauge(s.RAMUsagePercent))
+	add(scalars.append(7, 0), gauge(s.NetworkBytesPS))
+	add(scalars.append(8, 0), gauge(s.DiskBytesPS))
+	add(scalars.append(9, 0), gauge(s.UptimeSeconds))
+	add(scalars.append(10, 0), truth(s.AgentHealth == nil || s.AgentHealth.Healthy))
+	add(scalars.append(11, 0), truth(s.Maintenance != nil))
+	add(scalars.append(12, 0), gauge(float64(len(active))))
+	add(scalars.append(13, 0), gauge(float64(critical)))
+
+	volumes := root.append(2, 1)
+	for i, vol := range s.Volumes {
+		idx := uint32(i + 1)
+		add(volumes.append(1, idx), integer(int64(idx)))
+		add(volumes.append(2, idx), str(vol.Mount))
+		add(volumes.append(3, idx), gauge(vol.UsedPercent))
+		add(volumes.append(4, idx), gauge(float64(vol.FreeBytes)/(1<<20)))
+	}
+
+	alertRows := root.append(3, 1)
+	for i, a := range active {
+		idx := uint32(i + 1)
+		add(alertRows.append(1, idx), integer(int64(idx)))
+		add(alertRows.append(2, idx), str(a.Key))
+		add(alertRows.append(3, idx), integer(severityValue(a.Severity)))
+		add(alertRows.append(4, idx), str(a.Message))
+	}
+
+	sort.Slice(v, func(i, j int) bool { return v[i].oid.compare(v[j].oid) < 0 })
+	return v
+}
+
+// get returns the value at exactly oid.
+func (v view) get(oid OID) ([]byte, bool) {
+	i := sort.Search(len(v), func(i int) bool { return v[i].oid.compare(oid) >= 0 })
+	if i < len(v) && v[i].oid.compare(oid) == 0 {
+		return v[i].value, true
+	}
+	return nil, false
+}
+
+// next returns the first variable after oid.
+func (v view) next(oid OID) (variable, bool) {
+	i := sort.Search(len(v), func(i int) bool { return v[i].oid.compare(oid) > 0 })
+	if i < len(v) {
+		return v[i], true
+	}
+	return variable{}, false
+}
+
+func str(s string) []byte { return encode(tagOctetString, []byte(s)) }
+
+func integer(n int64) []byte { return encodeInt(tagInteger, n) }
+
+// gauge rounds f into a Gauge32, clamping to its range.
+func gauge(f float64) []byte {
+	return encodeUint(tagGauge32, uint32(math.Max(0, math.Min(math.Round(f), math.MaxUint32))))
+}
+
+// truth encodes a TruthValue: 1 true, 2 false.
+func truth(b bool) []byte {
+	if b {
+		return integer(1)
+	}
+	return integer(2)
+}
+
+func severityValue(severity string) int64 {
+	switch severity {
+	case alerts.SeverityCritical:
+		return 3
+	case alerts.SeverityWarning:
+		return 2
+	}
+	return 1
+}
@@ -0,0 +1,283 @@
+// Package snmp is a small read-only SNMPv1/v2c agent, so network
+// monitoring (PRTG, LibreNMS) can poll AVL machines alongside switches and
+// UPSes. It answers GET, GETNEXT, and GETBULK for MIB-II's system group and
+// the objects in AVL-DASHBOARD-MIB.txt.
+package snmp
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/alerts"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/metrics"
+)
+
+const (
+	defaultPort = 161
+	// defaultRoot is Net-SNMP's playpen arc for local, unregistered MIBs.
+	// Sites with their own enterprise number can set enterpriseOid.
+	defaultRoot = "1.3.6.1.4.1.8072.9999.9999.1"
+
+	versionV1  = 0
+	versionV2c = 1
+
+	maxBulkVarbinds = 50
+)
+
+// Error statuses.
+const (
+	errNoSuchName  = 2  // v1
+	errReadOnly    = 4  // v1
+	errNotWritable = 17 // v2c
+)
+
+// Agent serves SNMP requests from live status.
+type Agent struct {
+	cfg       config.SNMPConfig
+	root      OID
+	siteName  string
+	collector *metrics.Collector
+	alerts    *alerts.Engine
+	started   time.Time
+}
+
+// New creates an agent for cfg. It returns nil when SNMP is disabled (no
+// community configured) or the enterprise OID is invalid.
+func New(cfg config.SNMPConfig, siteName string, collector *metrics.Collector, engine *alerts.Engine) *Agent {
+	if cfg.Community == "" {
+		return nil
+	}
+	if cfg.Port == 0 {
+		cfg.Port = defaultPort
+	}
+	rootText := cfg.EnterpriseOID
+	if rootText == "" {
+		rootText = defaultRoot
+	}
+	root, err := ParseOID(rootText)
+	if err != nil {
+		log.Printf("SNMP: disabled: %v", err)
+		return nil
+	}
+	return &Agent{cfg: cfg, root: root, siteName: siteName, collector: collector, alerts: engine, started: time.Now()}
+}
+
+// Run answers requests on the configured UDP port. Blocks; returns if the
+// port can't be bound (161 needs root on Linux).
+func (a *Agent) Run() {
+	conn, err := net.ListenPacket("udp", fmt.Sprintf(":%d", a.cfg.Port))
+	if err != nil {
+		log.Printf("SNMP: %v", err)
+		return
+	}
+	defer conn.Close()
+	log.Printf("SNMP: listening on UDP %d", a.cfg.Port)
+
+	buf := make([]byte, 65535)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			log.Printf("SNMP: %v", err)
+			return
+		}
+		if resp := a.handle(buf[:n]); resp != nil {
+			conn.WriteTo(resp, addr)
+		}
+	}
+}
+
+// request is a decoded v1/v2c message.
+type request struct {
+	version   int64
+	community []byte
+	pduType   byte
+	id        int64
+	// nonRepeaters and maxRepetitions for GETBULK; error fields otherwise.
+	field2, field3 int64
+	oids           []OID
+}
+
+// handle answers one packet. Malformed packets and wrong communities get
+// no reply, as with any SNMP agent.
+func (a *Agent) handle(packet []byte) []byte {
+	req, err := decodeRequest(packet)
+	if err != nil {
+		return nil
+	}
+	if req.version != versionV1 && req.version != versionV2c {
+		return nil
+	}
+	if subtle.ConstantTimeCompare(req.community, []byte(a.cfg.Community)) != 1 {
+		return nil
+	}
+
+	var active []alerts.Alert
+	if a.alerts != nil {
+		for _, al := range a.alerts.List() {
+			if al.Active {
+				active = append(active, al)
+			}
+		}
+	}
+	v := buildView(a.root, a.collector.CurrentStatus(), active, a.started, a.siteName)
+
+	var varbinds [][]byte
+	var errStatus, errIndex int64
+	v1Error := func(i int) {
+		errStatus, errIndex = errNoSuchName, int64(i+1)
+	}
+
+	switch req.pduType {
+	case pduGet:
+		for i, oid := range req.oids {
+			value, ok := v.get(oid)
+			if !ok {
+				if req.version == versionV1 {
+					v1Error(i)
+					break
+				}
+				value = encode(tagNoSuchObject, nil)
+			}
+			varbinds = append(varbinds, varbind(oid, value))
+		}
+	case pduGetNext:
+		for i, oid := range req.oids {
+			next, ok := v.next(oid)
+			if !ok {
+				if req.version == versionV1 {
+					v1Error(i)
+					break
+				}
+				next = variable{oid, encode(tagEndOfMibView, nil)}
+			}
+			varbinds = append(varbinds, varbind(next.oid, next.value))
+		}
+	case pduGetBulk:
+		if req.version == versionV1 {
+			return nil
+		}
+		varbinds = v.bulk(req.oids, int(req.field2), int(req.field3))
+	case pduSet:
+		errStatus, errIndex = errNotWritable, 1
+		if req.version == versionV1 {
+			errStatus = errReadOnly
+		}
+	default:
+		return nil
+	}
+
+	if errStatus != 0 {
+		// Errors echo the request's variable bindings.
+		varbinds = nil
+		for _, oid := range req.oids {
+			varbinds = append(varbinds, varbind(oid, encode(tagNull, nil)))
+		}
+	}
+	return sequence(tagSequence,
+		integer(req.version),
+		encode(tagOctetString, req.community),
+		sequence(pduResponse,
+			integer(req.id),
+			integer(errStatus),
+			integer(errIndex),
+			sequence(tagSequence, varbinds...),
+		),
+	)
+}
+
+// bulk answers GETBULK: one GETNEXT for each of the first nonRepeaters
+// OIDs, then up to maxRepetitions for the rest, interleaved.
+func (v view) bulk(oids []OID, nonRepeaters, maxRepetitions int) [][]byte {
+	nonRepeaters = max(0, min(nonRepeaters, len(oids)))
+	var out [][]byte
+	for _, oid := range oids[:nonRepeaters] {
+		next, ok := v.next(oid)
+		if !ok {
+			next = variable{oid, encode(tagEndOfMibView, nil)}
+		}
+		out = append(out, varbind(next.oid, next.value))
+	}
+
+	cursors := append([]OID(nil), oids[nonRepeaters:]...)
+	for r := 0; r < maxRepetitions && len(cursors) > 0; r++ {
+		done := true
+		for i, oid := range cursors {
+			if len(out) >= maxBulkVarbinds {
+				return out
+			}
+			next, ok := v.next(oid)
+			if !ok {
+				out = append(out, varbind(oid, encode(tagEndOfMibView, nil)))
+				continue
+			}
+			done = false
+			cursors[i] = next.oid
+			out = append(out, varbind(next.oid, next.value))
+		}
+		if done {
+			break
+		}
+	}
+	return out
+}
+
+func varbind(oid OID, value []byte) []byte {
+	return sequence(tagSequence, encodeOID(oid), value)
+}
+
+func decodeRequest(packet []byte) (request, error) {
+	var req request
+	msg, _, err := next(packet)
+	if err != nil || msg.tag != tagSequence {
+		return req, errMalformed
+	}
+	version, rest, err := next(msg.value)
+	if err != nil || version.tag != tagInteger {
+		return req, errMalformed
+	}
+	community, rest, err := next(rest)
+	if err != nil || community.tag != tagOctetString {
+		return req, errMalformed
+	}
+	pdu, _, err := next(rest)
+	if err != nil {
+		return req, errMalformed
+	}
+	req.version, req.community, req.pduType = decodeInt(version.value), community.value, pdu.tag
+
+	fields := make([]int64, 3)
+	rest = pdu.value
+	for i := range fields {
+		var f tlv
+		if f, rest, err = next(rest); err != nil || f.tag != tagInteger {
+			return req, errMalformed
+		}
+		fields[i] = decodeInt(f.value)
+	}
+	req.id, req.field2, req.field3 = fields[0], fields[1], fields[2]
+
+	list, _, err := next(rest)
+	if err != nil || list.tag != tagSequence {
+		return req, errMalformed
+	}
+	for rest = list.value; len(rest) > 0; {
+		var vb tlv
+		if vb, rest, err = next(rest); err != nil || vb.tag != tagSequence {
+			return req, errMalformed
+		}
+		name, _, err := next(vb.value)
+		if err != nil || name.tag != tagOID {
+			return req, errMalformed
+		}
+		oid, err := decodeOID(name.value)
+		if err != nil {
+			return req, err
+		}
+		req.oids = append(req.oids, oid)
+	}
+	return req, nil
+}
@@ -0,0 +1,173 @@
+// Package startup verifies that the configured applications are running a
+// few minutes after boot, and starts the ones that aren't. A machine that
+// rebooted overnight for updates otherwise greets Sunday's team with an
+// empty desktop.
+package startup
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/host"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/events"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/launch"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/presence"
+)
+
+const (
+	checkInterval = 30 * time.Second
+	defaultWithin = 5 * time.Minute
+	// maxLaunches bounds the launches per boot, retryInterval spaces them,
+	// so an app that can't start isn't started forever.
+	maxLaunches   = 3
+	retryInterval = 2 * time.Minute
+)
+
+// App states.
+const (
+	StatePending = "pending" // not running, but not due yet
+	StateRunning = "running"
+	StateMissing = "missing" // not running after it was due
+)
+
+// Status is one startup app as reported in /status.
+type Status struct {
+	Process    string     `json:"process"`
+	Due        time.Time  `json:"due"` // boot plus withinMinutes
+	State      string     `json:"state"`
+	Launches   int        `json:"launches,omitempty"` // by the agent this boot
+	LastLaunch *time.Time `json:"lastLaunch,omitempty"`
+	Error      string     `json:"error,omitempty"` // the last launch failed
+}
+
+// Compliant reports whether the app is running or not due yet.
+func (s Status) Compliant() bool {
+	return s.State != StateMissing
+}
+
+// Problem describes a missing app for alerts, or is empty.
+func (s Status) Problem() string {
+	if s.Compliant() {
+		return ""
+	}
+	problem := fmt.Sprintf("%s not running (due by %s)", s.Process, s.Due.Format("15:04"))
+	if s.Error != "" {
+		problem += "; launch failed: " + s.Error
+	}
+	return problem
+}
+
+// app is one configured app and what this boot has seen of it.
+type app struct {
+	cfg    config.StartupApp
+	status Status
+	// seen is set once the app has run this boot. An app that ran and
+	// exited was closed by someone, so it isn't launched again; the kiosk
+	// watchdog is for players that must stay up.
+	seen bool
+}
+
+// Checker checks the startup apps every 30 seconds. Safe for concurrent
+// use.
+type Checker struct {
+	mu   sync.Mutex
+	apps []*app
+}
+
+// New creates a checker for apps. It returns nil when none are
+// configured.
+func New(apps []config.StartupApp) *Checker {
+	if len(apps) == 0 {
+		return nil
+	}
+	boot := time.Now()
+	if b, err := host.BootTime(); err == nil {
+		boot = time.Unix(int64(b), 0)
+	}
+	c := &Checker{}
+	for _, cfg := range apps {
+		within := defaultWithin
+		if cfg.WithinMinutes > 0 {
+			within = time.Duration(cfg.WithinMinutes) * time.Minute
+		}
+		c.apps = append(c.apps, &app{
+			cfg:    cfg,
+			status: Status{Process: cfg.Process, Due: boot.Add(within), State: StatePending},
+		})
+	}
+	return c
+}
+
+// Status returns every app's latest check, or nil when none are
+// configured.
+func (c *Checker) Status() []Status {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	list := make([]Status, 0, len(c.apps))
+	for _, a := range c.apps {
+		list = append(list, a.status)
+	}
+	return list
+}
+
+// Run checks the apps every 30 seconds. Blocks forever.
+func (c *Checker) Run() {
+	for {
+		now := time.Now()
+		for _, a := range c.apps {
+			c.check(a, now)
+		}
+		time.Sleep(checkInterval)
+	}
+}
+
+func (c *Checker) check(a *app, now time.Time) {
+	running := launch.Running(a.cfg.Process)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	prev := a.status.State
+	switch {
+	case running:
+		a.status.State, a.seen = StateRunning, true
+	case now.Before(a.status.Due):
+		a.status.State = StatePending
+	default:
+		a.status.State = StateMissing
+	}
+	if a.status.State == StateMissing && prev != StateMissing {
+		events.Record(events.Lifecycle, events.Warning, "Startup: %s is not running", a.cfg.Process)
+	}
+	if a.status.State != StateMissing || !c.launchDue(a, now) {
+		return
+	}
+
+	a.status.Launches++
+	a.status.LastLaunch = &now
+	a.status.Error = ""
+	if err := launch.Start(a.cfg.Command, a.cfg.Args); err != nil {
+		a.status.Error = err.Error()
+		events.Record(events.Lifecycle, events.Error, "Startup: launching %s failed: %v", a.cfg.Process, err)
+		return
+	}
+	events.Record(events.Lifecycle, events.Info, "Startup: launched %s (attempt %d)", a.cfg.Process, a.status.Launches)
+}
+
+// launchDue reports whether a missing app should be launched now. Launches
+// wait while an operator is at the machine, who may have closed it on
+// purpose.
+func (c *Checker) launchDue(a *app, now time.Time) bool {
+	switch {
+	case !a.cfg.Launch, a.seen, a.status.Launches >= maxLaunches:
+		return false
+	case a.status.LastLaunch != nil && now.Sub(*a.status.LastLaunch) < retryInterval:
+		return false
+	}
+	return !presence.OperatorPresent()
+}
@@ -0,0 +1,116 @@
+// Package state persists small pieces of agent state (counters, staged
+// updates, acknowledgements) across restarts in a single JSON file.
+//
+// Each subsystem owns a top-level key and its own value type; the store only
+// guarantees that every Set is written atomically to disk.
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Store is a JSON document of named sections, safe for concurrent use.
+type Store struct {
+	path string
+	mu   sync.Mutex
+	data map[string]json.RawMessage
+}
+
+// Open loads the store at path. A missing file yields an empty store. A
+// corrupt file is set aside (renamed to .corrupt) rather than blocking
+// startup, since losing counters is better than not monitoring.
+func Open(path string) *Store {
+	s := &Store{path: path, data: make(map[string]json.RawMessage)}
+
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s
+	}
+	if err != nil {
+		log.Printf("State: cannot read %s: %v", path, err)
+		return s
+	}
+	if err := json.Unmarshal(raw, &s.data); err != nil {
+		log.Printf("State: %s is corrupt, starting fresh: %v", path, err)
+		os.Rename(path, path+".corrupt")
+		s.data = make(map[string]json.RawMessage)
+	}
+	return s
+}
+
+// Get decodes the section stored under key into v. Returns false if the
+// key doesn't exist or can't be decoded into v.
+func (s *Store) Get(key string, v any) bool {
+	s.mu.Lock()
+	raw, ok := s.data[key]
+	s.mu.Unlock()
+	if !ok {
+		return false
+	}
+	return json.Unmarshal(raw, v) == nil
+}
+
+// Set stores v under key and writes the store to disk.
+func (s *Store) Set(key string, v any) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[key] = raw
+	return s.save()
+}
+
+// Delete removes key and writes the store to disk.
+func (s *Store) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.data[key]; !ok {
+		return nil
+	}
+	delete(s.data, key)
+	return s.save()
+}
+
+// Incr adds one to the named counter and returns the new value.
+func (s *Store) Incr(counter string) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counters := make(map[string]int64)
+	if raw, ok := s.data["counters"]; ok {
+		json.Unmarshal(raw, &counters)
+	}
+	counters[counter]++
+	raw, _ := json.Marshal(counters)
+	s.data["counters"] = raw
+	if err := s.save(); err != nil {
+		log.Printf("State: save failed: %v", err)
+	}
+	return counters[counter]
+}
+
+// save writes to a temp file and renames it over the store, so a crash
+// mid-write never leaves a truncated file. Caller holds s.mu.
+func (s *Store) save() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	raw, err := json.MarshalIndent(s.data, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
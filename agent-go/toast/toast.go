@@ -0,0 +1,4 @@
+// Package toast shows desktop notifications to the person sitting at the
+// machine. Only Windows has a desktop session to show them in; elsewhere
+// Show is a no-op.
+package toast
@@ -0,0 +1,8 @@
+//go:build linux
+
+package toast
+
+// Show does nothing: the Linux agent runs as a headless service.
+func Show(title, message string) error {
+	return nil
+}
@@ -0,0 +1,8 @@
+//go:build linux
+
+package trayicon
+
+// DarkTaskbar is always false: the Linux agent has no tray.
+func DarkTaskbar() bool {
+	return false
+}
@@ -0,0 +1,19 @@
+//go:build windows
+
+package trayicon
+
+import "golang.org/x/sys/windows/registry"
+
+const personalizeKey = `Software\Microsoft\Windows\CurrentVersion\Themes\Personalize`
+
+// DarkTaskbar reports whether the taskbar uses the dark theme, which is
+// the Windows default when the value is missing.
+func DarkTaskbar() bool {
+	k, err := registry.OpenKey(registry.CURRENT_USER, personalizeKey, registry.QUERY_VALUE)
+	if err != nil {
+		return true
+	}
+	defer k.Close()
+	light, _, err := k.GetIntegerValue("SystemUsesLightTheme")
+	return err != nil || light == 0
+}
@@ -0,0 +1,231 @@
+// Package trayicon renders the tray icon at runtime: the embedded logo
+// with a status badge, outlined on dark taskbars where the dark tile would
+// otherwise disappear.
+package trayicon
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"sync"
+)
+
+// Level is the machine state the badge shows.
+type Level int
+
+const (
+	Healthy Level = iota
+	Warning
+	Critical
+	Maintenance
+)
+
+var badgeColors = map[Level]color.NRGBA{
+	Healthy:     {0x34, 0xC7, 0x59, 0xFF},
+	Warning:     {0xFF, 0xB3, 0x00, 0xFF},
+	Critical:    {0xE5, 0x39, 0x35, 0xFF},
+	Maintenance: {0x42, 0x8B, 0xF5, 0xFF},
+}
+
+var (
+	outlineColor = color.NRGBA{0xC8, 0xC8, 0xC8, 0xFF}
+	darkBar      = color.NRGBA{0x20, 0x20, 0x20, 0xFF}
+	lightBar     = color.NRGBA{0xF3, 0xF3, 0xF3, 0xFF}
+)
+
+// Renderer caches rendered icons, since the tray is refreshed every few
+// seconds but the state rarely changes.
+type Renderer struct {
+	base []image.Image
+
+	mu    sync.Mutex
+	cache map[cacheKey][]byte
+}
+
+type cacheKey struct {
+	level Level
+	dark  bool
+}
+
+// NewRenderer decodes the PNG entries of an .ico file.
+func NewRenderer(ico []byte) (*Renderer, error) {
+	images, err := decodeICO(ico)
+	if err != nil {
+		return nil, err
+	}
+	return &Renderer{base: images, cache: make(map[cacheKey][]byte)}, nil
+}
+
+// Icon returns an .ico for the level, styled for a dark or light taskbar.
+func (r *Renderer) Icon(level Level, dark bool) []byte {
+	key := cacheKey{level, dark}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if ico, ok := r.cache[key]; ok {
+		return ico
+	}
+
+	bar := lightBar
+	if dark {
+		bar = darkBar
+	}
+	frames := make([]image.Image, len(r.base))
+	for i, src := range r.base {
+		img := image.NewNRGBA(src.Bounds())
+		draw.Draw(img, img.Bounds(), src, src.Bounds().Min, draw.Src)
+		if dark {
+			outline(img)
+		}
+		badge(img, badgeColors[level], bar)
+		frames[i] = img
+	}
+	ico := encodeICO(frames)
+	r.cache[key] = ico
+	return ico
+}
+
+// outline draws a light edge on opaque pixels that border transparency.
+func outline(img *image.NRGBA) {
+	b := img.Bounds()
+	opaque := func(x, y int) bool {
+		if !(image.Point{x, y}.In(b)) {
+			return false
+		}
+		return img.NRGBAAt(x, y).A >= 0x80
+	}
+	var edge []image.Point
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if opaque(x, y) && (!opaque(x-1, y) || !opaque(x+1, y) || !opaque(x, y-1) || !opaque(x, y+1)) {
+				edge = append(edge, image.Point{x, y})
+			}
+		}
+	}
+	for _, p := range edge {
+		img.SetNRGBA(p.X, p.Y, outlineColor)
+	}
+}
+
+// badge draws a filled circle in the bottom-right corner with a ring in
+// the taskbar colour separating it from the logo.
+func badge(img *image.NRGBA, fill, ring color.NRGBA) {
+	b := img.Bounds()
+	size := float64(b.Dx())
+	r := size * 0.18
+	ringWidth := math.Max(1, size/16)
+	cx := float64(b.Max.X) - r - ringWidth
+	cy := float64(b.Max.Y) - r - ringWidth
+
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			d := math.Hypot(float64(x)+0.5-cx, float64(y)+0.5-cy)
+			if a := coverage(d, r+ringWidth); a > 0 {
+				blend(img, x, y, ring, a)
+			}
+			if a := coverage(d, r); a > 0 {
+				blend(img, x, y, fill, a)
+			}
+		}
+	}
+}
+
+// coverage anti-aliases a circle edge over one pixel.
+func coverage(dist, radius float64) float64 {
+	return math.Max(0, math.Min(1, radius-dist+0.5))
+}
+
+func blend(img *image.NRGBA, x, y int, c color.NRGBA, a float64) {
+	dst := img.NRGBAAt(x, y)
+	mix := func(s, d uint8) uint8 { return uint8(float64(s)*a + float64(d)*(1-a) + 0.5) }
+	img.SetNRGBA(x, y, color.NRGBA{
+		R: mix(c.R, dst.R),
+		G: mix(c.G, dst.G),
+		B: mix(c.B, dst.B),
+		A: uint8(math.Max(float64(dst.A), a*255)),
+	})
+}
+
+// icoEntry is an ICONDIRENTRY.
+type icoEntry struct {
+	Width, Height uint8
+	Colors        uint8
+	Reserved      uint8
+	Planes        uint16
+	BitCount      uint16
+	Size          uint32
+	Offset        uint32
+}
+
+// decodeICO returns the PNG-encoded images in an .ico. BMP entries are
+// skipped; Vista and later store every size as PNG.
+func decodeICO(data []byte) ([]image.Image, error) {
+	if len(data) < 6 || binary.LittleEndian.Uint16(data[2:]) != 1 {
+		return nil, errors.New("not an icon file")
+	}
+	count := int(binary.LittleEndian.Uint16(data[4:]))
+	var images []image.Image
+	for i := 0; i < count; i++ {
+		var e icoEntry
+		off := 6 + 16*i
+		if off+16 > len(data) {
+			break
+		}
+		binary.Read(bytes.NewReader(data[off:off+16]), binary.LittleEndian, &e)
+		end := int(e.Offset) + int(e.Size)
+		if end > len(data) {
+			continue
+		}
+		img, err := png.Decode(bytes.NewReader(data[e.Offset:end]))
+		if err != nil {
+			continue
+		}
+		images = append(images, img)
+	}
+	if len(images) == 0 {
+		return nil, errors.New("icon has no PNG images")
+	}
+	return images, nil
+}
+
+// encodeICO packs images into an .ico with PNG entries.
+func encodeICO(images []image.Image) []byte {
+	pngs := make([][]byte, len(images))
+	for i, img := range images {
+		var buf bytes.Buffer
+		png.Encode(&buf, img)
+		pngs[i] = buf.Bytes()
+	}
+
+	var out bytes.Buffer
+	binary.Write(&out, binary.LittleEndian, [3]uint16{0, 1, uint16(len(images))})
+	offset := 6 + 16*len(images)
+	for i, img := range images {
+		b := img.Bounds()
+		binary.Write(&out, binary.LittleEndian, icoEntry{
+			Width:    dimension(b.Dx()),
+			Height:   dimension(b.Dy()),
+			Planes:   1,
+			BitCount: 32,
+			Size:     uint32(len(pngs[i])),
+			Offset:   uint32(offset),
+		})
+		offset += len(pngs[i])
+	}
+	for _, p := range pngs {
+		out.Write(p)
+	}
+	return out.Bytes()
+}
+
+// dimension encodes an icon size; 256 is stored as 0.
+func dimension(n int) uint8 {
+	if n >= 256 {
+		return 0
+	}
+	return uint8(n)
+}
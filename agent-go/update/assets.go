@@ -0,0 +1,214 @@
+package update
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/events"
+)
+
+// A release can carry an asset pack, "agent-assets.zip", next to the
+// agent builds: a manifest.json, a config.json layered under the fleet
+// document (check definitions, alert thresholds, integrations), and
+// i18n/<lang>.json message overrides. Re-uploading the pack to a release
+// ships new checks without a new agent version.
+const (
+	manifestFile  = "manifest.json"
+	installedFile = "installed.json"
+	maxPackSize   = 10 << 20
+)
+
+// AssetManifest is a pack's manifest.json.
+type AssetManifest struct {
+	Version string `json:"version"`
+	// MinAgent is the oldest agent version that understands the pack's
+	// config; older agents (and dev builds) skip it.
+	MinAgent string `json:"minAgent,omitempty"`
+}
+
+// InstalledAssets is the pack in config.AssetsDir().
+type InstalledAssets struct {
+	Version   string    `json:"version"`
+	Release   string    `json:"release"`
+	UpdatedAt string    `json:"updatedAt"` // the release asset's, to notice a re-upload
+	AppliedAt time.Time `json:"appliedAt"`
+}
+
+// InstalledPack returns the installed asset pack, or nil.
+func InstalledPack() *InstalledAssets {
+	data, err := os.ReadFile(filepath.Join(config.AssetsDir(), installedFile))
+	if err != nil {
+		return nil
+	}
+	var in InstalledAssets
+	if json.Unmarshal(data, &in) != nil {
+		return nil
+	}
+	return &in
+}
+
+// OnAssets registers a callback run after an asset pack is installed,
+// reporting whether its config layer changed. The running config is not
+// reloaded in place; the callback decides how to pick it up.
+func (u *Updater) OnAssets(fn func(configChanged bool)) {
+	u.onAssets = fn
+}
+
+// findAssetPack returns the pack from the newest release keep accepts
+// that has one.
+func findAssetPack(releases []GitHubRelease, keep func(*GitHubRelease) bool) (*GitHubRelease, *GitHubAsset) {
+	var bestRelease *GitHubRelease
+	var bestAsset *GitHubAsset
+	var bestVersion *SemanticVersion
+	for i := range releases {
+		v := ParseVersion(releases[i].TagName)
+		if v == nil || (bestVersion != nil && !v.GreaterThan(*bestVersion)) || !keep(&releases[i]) {
+			continue
+		}
+		for j := range releases[i].Assets {
+			if strings.EqualFold(releases[i].Assets[j].Name, "agent-assets.zip") {
+				bestRelease, bestAsset, bestVersion = &releases[i], &releases[i].Assets[j], v
+			}
+		}
+	}
+	return bestRelease, bestAsset
+}
+
+// checkAssets installs the asset pack of the newest release the rollout
+// ring allows, unless it is the one installed.
+func (u *Updater) checkAssets(ctx context.Context, releases []GitHubRelease) error {
+	now := time.Now()
+	release, asset := findAssetPack(releases, func(r *GitHubRelease) bool { return u.eligible(r, now) })
+	if asset == nil {
+		return nil
+	}
+	if in := InstalledPack(); in != nil && in.Release == release.TagName && in.UpdatedAt == asset.UpdatedAt {
+		return nil
+	}
+	if asset.Size > maxPackSize {
+		return fmt.Errorf("asset pack is %d bytes", asset.Size)
+	}
+	data, err := u.downloadAsset(ctx, asset.BrowserDownloadURL)
+	if err != nil {
+		events.Record(events.Lifecycle, events.Error, "Asset pack download failed: %v", err)
+		return err
+	}
+	manifest, configChanged, err := u.installAssets(data, InstalledAssets{Release: release.TagName, UpdatedAt: asset.UpdatedAt})
+	if err != nil {
+		events.Record(events.Lifecycle, events.Error, "Asset pack from %s not installed: %v", release.TagName, err)
+		return err
+	}
+	if manifest == nil {
+		return nil
+	}
+	events.Record(events.Lifecycle, events.Info, "Asset pack %s installed from %s", manifest.Version, release.TagName)
+	if u.onAssets != nil {
+		u.onAssets(configChanged)
+	}
+	return nil
+}
+
+// installAssets checks a pack and swaps it in for the installed one. It
+// returns a nil manifest, and installs nothing, for a pack this agent is
+// too old for.
+func (u *Updater) installAssets(data []byte, in InstalledAssets) (*AssetManifest, bool, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, false, err
+	}
+	files := map[string][]byte{}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		name := path.Clean(f.Name)
+		if !allowedAsset(name) {
+			return nil, false, fmt.Errorf("unexpected file %q", f.Name)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, false, err
+		}
+		files[name], err = io.ReadAll(io.LimitReader(rc, maxPackSize))
+		rc.Close()
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	var manifest AssetManifest
+	if err := json.Unmarshal(files[manifestFile], &manifest); err != nil || manifest.Version == "" {
+		return nil, false, errors.New("missing or invalid manifest.json")
+	}
+	if manifest.MinAgent != "" {
+		min, current := ParseVersion(manifest.MinAgent), ParseVersion(u.currentVersion)
+		if min == nil || current == nil || min.GreaterThan(*current) {
+			return nil, false, nil
+		}
+	}
+	if pack, ok := files["config.json"]; ok {
+		doc := config.FleetDocument{Version: manifest.Version, Config: pack}
+		if err := doc.Validate(); err != nil {
+			return nil, false, fmt.Errorf("config.json: %w", err)
+		}
+	}
+	for name, data := range files {
+		var msgs map[string]string
+		if strings.HasPrefix(name, "i18n/") && json.Unmarshal(data, &msgs) != nil {
+			return nil, false, fmt.Errorf("%s is not an object of messages", name)
+		}
+	}
+
+	dir := config.AssetsDir()
+	old, _ := os.ReadFile(filepath.Join(dir, "config.json"))
+	staging := dir + ".new"
+	os.RemoveAll(staging)
+	for name, data := range files {
+		dst := filepath.Join(staging, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return nil, false, err
+		}
+		if err := os.WriteFile(dst, data, 0o644); err != nil {
+			return nil, false, err
+		}
+	}
+	in.Version, in.AppliedAt = manifest.Version, time.Now()
+	record, _ := json.MarshalIndent(in, "", "  ")
+	if err := os.WriteFile(filepath.Join(staging, installedFile), record, 0o644); err != nil {
+		return nil, false, err
+	}
+	os.RemoveAll(dir + ".old")
+	if err := os.Rename(dir, dir+".old"); err != nil && !os.IsNotExist(err) {
+		return nil, false, err
+	}
+	if err := os.Rename(staging, dir); err != nil {
+		os.Rename(dir+".old", dir)
+		return nil, false, err
+	}
+	os.RemoveAll(dir + ".old")
+	return &manifest, !bytes.Equal(old, files["config.json"]), nil
+}
+
+// allowedAsset reports whether a pack may contain name: the manifest, the
+// config layer, and message files.
+func allowedAsset(name string) bool {
+	switch {
+	case name == manifestFile, name == "config.json":
+		return true
+	case strings.HasPrefix(name, "i18n/"):
+		base := strings.TrimPrefix(name, "i18n/")
+		return !strings.Contains(base, "/") && strings.HasSuffix(base, ".json")
+	}
+	return false
+}
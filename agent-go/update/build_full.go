@@ -0,0 +1,6 @@
+//go:build !lite
+
+package update
+
+// lite is set in lite builds, which update only from "-lite" assets.
+const lite = false
@@ -0,0 +1,113 @@
+package update
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/events"
+)
+
+// Pre-releases go only to machines on the beta channel, such as a test
+// bench. updates.channel sets the channel in config; a choice made from the
+// tray or POST /updates/channel is kept in channelFile and overrides it, so
+// opting a machine in needs no config editing. A beta machine takes a
+// pre-release as soon as it is published, whatever its ring.
+const (
+	ChannelStable = "stable"
+	ChannelBeta   = "beta"
+	channelFile   = "update-channel.json"
+)
+
+// channelChoice is a channel set at runtime.
+type channelChoice struct {
+	Channel string    `json:"channel"`
+	By      string    `json:"by"`
+	At      time.Time `json:"at"`
+}
+
+// loadChannel sets the channel from the saved choice, else cfg.
+func (u *Updater) loadChannel(cfg config.UpdatesConfig) {
+	u.channelMu.Lock()
+	defer u.channelMu.Unlock()
+	var saved channelChoice
+	switch {
+	case readJSON(channelFile, &saved) && validChannel(saved.Channel):
+		u.channel = saved.Channel
+	case cfg.Channel != "":
+		u.channel = cfg.Channel
+	}
+}
+
+// Channel returns the agent's update channel.
+func (u *Updater) Channel() string {
+	if u == nil {
+		return ""
+	}
+	u.channelMu.Lock()
+	defer u.channelMu.Unlock()
+	if u.channel == "" {
+		return ChannelStable
+	}
+	return u.channel
+}
+
+// SetChannel moves the agent to channel and keeps the choice across
+// restarts. Joining beta checks for a pre-release at once; leaving it
+// doesn't downgrade, the next stable release replaces the pre-release.
+func (u *Updater) SetChannel(channel, by string) error {
+	if !validChannel(channel) {
+		return fmt.Errorf("unknown update channel %q (want %s or %s)", channel, ChannelStable, ChannelBeta)
+	}
+	u.channelMu.Lock()
+	if channel == u.channel || (channel == ChannelStable && u.channel == "") {
+		u.channelMu.Unlock()
+		return nil
+	}
+	u.channel = channel
+	notify := u.onChannel
+	u.channelMu.Unlock()
+
+	writeJSON(channelFile, channelChoice{Channel: channel, By: by, At: time.Now()})
+	events.Record(events.RemoteAction, events.Info, "Update channel set to %s by %s", channel, by)
+	if notify != nil {
+		notify(channel)
+	}
+	if channel == ChannelBeta {
+		go u.ForceCheck()
+	}
+	return nil
+}
+
+// OnChannel registers a callback run after the channel changes.
+func (u *Updater) OnChannel(fn func(channel string)) {
+	u.channelMu.Lock()
+	defer u.channelMu.Unlock()
+	u.onChannel = fn
+}
+
+// offered reports whether r is on the agent's channel: pre-releases, marked
+// so on GitHub or by a tag like v1.4.0-beta.1, only on beta.
+func (u *Updater) offered(r *GitHubRelease) bool {
+	if !r.isPrerelease() {
+		return true
+	}
+	return u.Channel() == ChannelBeta
+}
+
+// wanted reports whether r is offered and not skipped.
+func (u *Updater) wanted(r *GitHubRelease) bool {
+	return u.offered(r) && !u.isSkipped(r)
+}
+
+func (r *GitHubRelease) isPrerelease() bool {
+	if r.Prerelease {
+		return true
+	}
+	v := ParseVersion(r.TagName)
+	return v != nil && v.Prerelease != ""
+}
+
+func validChannel(channel string) bool {
+	return channel == ChannelStable || channel == ChannelBeta
+}
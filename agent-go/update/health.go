@@ -0,0 +1,306 @@
+package update
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/events"
+)
+
+// An update is recorded as pending before the new binary is applied. The
+// new version confirms it once it has run healthy for confirmAfter; a
+// version that doesn't stay up through maxAttempts starts (the watchdog or
+// systemd restarting it after each crash) is rolled back to the binary it
+// replaced. The outcome is reported in /status and, with
+// updates.reportURL set, POSTed there.
+const (
+	pendingFile   = "update-pending.json"
+	copyErrorFile = "update-copy-error.txt" // written by the trampoline
+	resultFile    = "update-result.json"
+	previousExt   = ".previous"
+	confirmAfter  = 2 * time.Minute
+	maxAttempts   = 3
+	reportTimeout = 15 * time.Second
+)
+
+// Update outcomes.
+const (
+	OutcomeSucceeded  = "succeeded"
+	OutcomeFailed     = "failed"
+	OutcomeRolledBack = "rolledBack"
+)
+
+// Result is the outcome of the latest update.
+type Result struct {
+	From            string    `json:"from"`
+	To              string    `json:"to"`
+	Started         time.Time `json:"started"`
+	Finished        time.Time `json:"finished"`
+	DurationSeconds float64   `json:"durationSeconds"`
+	Outcome         string    `json:"outcome"`
+	Error           string    `json:"error,omitempty"`
+	// Attempts counts starts of the new version before it settled or was
+	// rolled back.
+	Attempts int  `json:"attempts,omitempty"`
+	Reported bool `json:"reported"` // delivered to updates.reportURL
+}
+
+// pending is an update applied but not yet confirmed.
+type pending struct {
+	From     string    `json:"from"`
+	To       string    `json:"to"`
+	Started  time.Time `json:"started"`
+	Attempts int       `json:"attempts"`
+}
+
+// report is what is POSTed to updates.reportURL.
+type report struct {
+	Hostname     string `json:"hostname"`
+	HardwareUUID string `json:"hardwareUUID"`
+	Ring         string `json:"ring"`
+	Result
+}
+
+// SetReporting sets where update outcomes are POSTed; an empty URL
+// reports only in /status.
+func (u *Updater) SetReporting(cfg config.UpdatesConfig) {
+	u.reportURL, u.reportToken = cfg.ReportURL, cfg.ReportToken
+}
+
+// LastUpdate returns the outcome of the latest update, or nil.
+func (u *Updater) LastUpdate() *Result {
+	if u == nil {
+		return nil
+	}
+	u.resultMu.Lock()
+	defer u.resultMu.Unlock()
+	if !u.resultLoaded {
+		var r Result
+		if readJSON(resultFile, &r) {
+			u.result = &r
+		}
+		u.resultLoaded = true
+	}
+	if u.result == nil {
+		return nil
+	}
+	r := *u.result
+	return &r
+}
+
+// setResult stores the latest outcome.
+func (u *Updater) setResult(r Result) {
+	u.resultMu.Lock()
+	defer u.resultMu.Unlock()
+	u.result, u.resultLoaded = &r, true
+	writeJSON(resultFile, r)
+}
+
+// Resume picks up an update applied by the previous process: it counts
+// this start of the new version, rolls back after too many, and otherwise
+// confirms the update once healthy reports true for a check after
+// confirmAfter. Call it early at startup. A rollback doesn't return.
+func (u *Updater) Resume(healthy func() bool) {
+	go u.deliver()
+	var p pending
+	if !readJSON(pendingFile, &p) {
+		return
+	}
+	switch u.currentVersion {
+	case p.To:
+	case p.From:
+		detail := fmt.Sprintf("still running %s after installing %s", p.From, p.To)
+		copyError := filepath.Join(config.DataDir(), copyErrorFile)
+		if data, err := os.ReadFile(copyError); err == nil {
+			detail = strings.TrimSpace(string(data))
+			os.Remove(copyError)
+		}
+		u.finish(p, OutcomeFailed, detail)
+		return
+	default:
+		removeJSON(pendingFile)
+		return
+	}
+
+	p.Attempts++
+	if p.Attempts > maxAttempts {
+		u.finish(p, OutcomeRolledBack, fmt.Sprintf("%s didn't stay up through %d starts", p.To, maxAttempts))
+		if err := rollback(); err != nil {
+			events.Record(events.Lifecycle, events.Error, "Rollback to %s failed: %v", p.From, err)
+		}
+		return
+	}
+	writeJSON(pendingFile, p)
+	go func() {
+		time.Sleep(confirmAfter)
+		for !healthy() {
+			time.Sleep(time.Minute)
+		}
+		u.finish(p, OutcomeSucceeded, "")
+		u.hooksMu.Lock()
+		onInstalled := u.onInstalled
+		u.hooksMu.Unlock()
+		if notes := u.InstalledNotes(); notes != nil && onInstalled != nil {
+			onInstalled(*notes)
+		}
+	}()
+}
+
+// begin records an update about to be applied.
+func (u *Updater) begin(to string, started time.Time) {
+	writeJSON(pendingFile, pending{From: u.currentVersion, To: to, Started: started})
+}
+
+// fail records an update that failed before the new binary ran.
+func (u *Updater) fail(to string, started time.Time, err error) {
+	u.finish(pending{From: u.currentVersion, To: to, Started: started}, OutcomeFailed, err.Error())
+}
+
+// finish stores and reports an update's outcome.
+func (u *Updater) finish(p pending, outcome, detail string) {
+	now := time.Now()
+	r := Result{
+		From: p.From, To: p.To, Started: p.Started, Finished: now,
+		DurationSeconds: now.Sub(p.Started).Seconds(),
+		Outcome:         outcome, Error: detail, Attempts: p.Attempts,
+	}
+	u.setResult(r)
+	removeJSON(pendingFile)
+	if outcome == OutcomeSucceeded {
+		events.Record(events.Lifecycle, events.Info, "Update from %s to %s succeeded in %.0f s", r.From, r.To, r.DurationSeconds)
+	} else {
+		events.Record(events.Lifecycle, events.Error, "Update from %s to %s %s: %s", r.From, r.To, outcome, detail)
+	}
+	go u.deliver()
+}
+
+// deliver POSTs the latest outcome to the report URL unless it already
+// got there. An undelivered outcome is retried at the next start and
+// after each update check.
+func (u *Updater) deliver() {
+	u.reportMu.Lock()
+	defer u.reportMu.Unlock()
+	r := u.LastUpdate()
+	if u.reportURL == "" || r == nil || r.Reported {
+		return
+	}
+	hostname, _ := os.Hostname()
+	body, _ := json.Marshal(report{Hostname: hostname, HardwareUUID: u.hardwareUUID, Ring: u.Ring(), Result: *r})
+	req, err := http.NewRequest("POST", u.reportURL, bytes.NewReader(body))
+	if err != nil {
+		log.Printf("Update report: %v", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if u.reportToken != "" {
+		req.Header.Set("Authorization", "Bearer "+u.reportToken)
+	}
+	resp, err := (&http.Client{Timeout: reportTimeout}).Do(req)
+	if err != nil {
+		log.Printf("Update report: %v", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		log.Printf("Update report: server answered %s", resp.Status)
+		return
+	}
+	// Mark it unless a newer outcome replaced it meanwhile.
+	if cur := u.LastUpdate(); cur != nil && cur.Finished.Equal(r.Finished) {
+		r.Reported = true
+		u.setResult(*r)
+	}
+}
+
+// replaceable reports why the running binary can't be replaced in place,
+// such as a tray agent installed by the MSI into Program Files, which a
+// signed-in user may not write; nil when it can be.
+func replaceable() error {
+	exe, err := currentExecutable()
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(exe), ".update-check-*")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// backupCurrent keeps a copy of the running binary for rollback.
+func backupCurrent() error {
+	exe, err := currentExecutable()
+	if err != nil {
+		return err
+	}
+	src, err := os.Open(exe)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(exe+previousExt, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o755)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
+
+// rollback relaunches with the binary backupCurrent kept. Does not return
+// on success.
+func rollback() error {
+	exe, err := currentExecutable()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(exe + previousExt); err != nil {
+		return err
+	}
+	tempDir, err := os.MkdirTemp("", "avl-agent-rollback-*")
+	if err != nil {
+		return err
+	}
+	return relaunch(tempDir, exe+previousExt)
+}
+
+func currentExecutable() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(exe)
+}
+
+func readJSON(name string, v any) bool {
+	data, err := os.ReadFile(filepath.Join(config.DataDir(), name))
+	return err == nil && json.Unmarshal(data, v) == nil
+}
+
+func writeJSON(name string, v any) {
+	path := filepath.Join(config.DataDir(), name)
+	data, _ := json.MarshalIndent(v, "", "  ")
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err == nil {
+		err = os.WriteFile(path, data, 0o644)
+	}
+	if err != nil {
+		log.Printf("Update: saving %s: %v", name, err)
+	}
+}
+
+func removeJSON(name string) {
+	os.Remove(filepath.Join(config.DataDir(), name))
+}
@@ -0,0 +1,117 @@
+package update
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// The notes of the release being installed are kept in notesFile, so the
+// new version can still show what changed once it is running.
+const (
+	notesFile       = "update-notes.json"
+	maxSummaryLen   = 120
+	summaryEllipsis = "…"
+)
+
+// ReleaseNotes are the notes of a release the agent installed or is
+// installing: the body of its GitHub release, as markdown.
+type ReleaseNotes struct {
+	Version     string    `json:"version"`
+	Name        string    `json:"name,omitempty"`
+	Notes       string    `json:"notes"`
+	URL         string    `json:"url,omitempty"` // the release page
+	PublishedAt time.Time `json:"publishedAt,omitempty"`
+	StagedAt    time.Time `json:"stagedAt"` // when the agent began installing it
+}
+
+// Status is served at GET /update/status.
+type Status struct {
+	CurrentVersion string `json:"currentVersion"`
+	Channel        string `json:"channel"`
+	Ring           string `json:"ring"`
+	// Staged is the update being downloaded, or applied but not yet
+	// confirmed.
+	Staged *ReleaseNotes `json:"staged,omitempty"`
+	// Installed is the running version's notes, when it was installed by
+	// an update.
+	Installed  *ReleaseNotes `json:"installed,omitempty"`
+	LastUpdate *Result       `json:"lastUpdate,omitempty"`
+	Skipped    []Skip        `json:"skipped,omitempty"`
+}
+
+// OnInstalled registers a callback run once an update is confirmed, with
+// the running version's notes.
+func (u *Updater) OnInstalled(fn func(notes ReleaseNotes)) {
+	u.hooksMu.Lock()
+	defer u.hooksMu.Unlock()
+	u.onInstalled = fn
+}
+
+// Status reports the running version, the update in progress, what the
+// last update changed, and the skipped versions.
+func (u *Updater) Status() Status {
+	s := Status{
+		CurrentVersion: u.currentVersion,
+		Channel:        u.Channel(),
+		Ring:           u.Ring(),
+		LastUpdate:     u.LastUpdate(),
+		Skipped:        u.Skipped(),
+	}
+	var notes ReleaseNotes
+	if !readJSON(notesFile, &notes) {
+		return s
+	}
+	var p pending
+	last := s.LastUpdate
+	switch {
+	case readJSON(pendingFile, &p) && p.To == notes.Version:
+		s.Staged = &notes
+	case notes.Version == u.currentVersion:
+		s.Installed = &notes
+	case last == nil || last.To != notes.Version || last.Finished.Before(notes.StagedAt):
+		// Installing now: no outcome since it was staged.
+		s.Staged = &notes
+	}
+	return s
+}
+
+// InstalledNotes returns the running version's notes, or nil when it
+// wasn't installed by an update.
+func (u *Updater) InstalledNotes() *ReleaseNotes {
+	var notes ReleaseNotes
+	if !readJSON(notesFile, &notes) || notes.Version != u.currentVersion {
+		return nil
+	}
+	return &notes
+}
+
+// stageNotes keeps the notes of a release about to be installed.
+func stageNotes(r *GitHubRelease, v *SemanticVersion) ReleaseNotes {
+	notes := ReleaseNotes{
+		Version:     v.String(),
+		Name:        r.Name,
+		Notes:       strings.TrimSpace(r.Body),
+		URL:         r.HTMLURL,
+		PublishedAt: r.PublishedAt,
+		StagedAt:    time.Now(),
+	}
+	writeJSON(notesFile, notes)
+	return notes
+}
+
+// Summary returns the first line of the notes with markdown marks trimmed,
+// short enough for a notification; "" without notes.
+func (n ReleaseNotes) Summary() string {
+	for _, line := range strings.Split(n.Notes, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "#*->_ "))
+		if line == "" || strings.HasPrefix(strings.ToLower(line), "rollout:") {
+			continue
+		}
+		if utf8.RuneCountInString(line) > maxSummaryLen {
+			line = string([]rune(line)[:maxSummaryLen-1]) + summaryEllipsis
+		}
+		return line
+	}
+	return ""
+}
@@ -0,0 +1,105 @@
+package update
+
+import (
+	"bufio"
+	"hash/fnv"
+	"strings"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/events"
+)
+
+// Releases roll out in two rings. Canary machines install a release as
+// soon as it is published; stable machines hold it until its notes carry
+// a "Rollout: stable" line or it has been out StableAfterHours. A
+// "Rollout: halt" line stops both rings, for a release the canaries
+// showed to be bad.
+const (
+	RingCanary = "canary"
+	RingStable = "stable"
+)
+
+// SetRollout places the agent in a ring: the configured one, else
+// canary for CanaryPercent of machines chosen by hardware UUID. Without
+// either, rollout is off and every release installs at once, as before
+// rings existed. It also picks the update channel; see Channel.
+func (u *Updater) SetRollout(cfg config.UpdatesConfig, hardwareUUID string) {
+	u.ring = RolloutRing(cfg, hardwareUUID)
+	u.loadChannel(cfg)
+	u.hardwareUUID = hardwareUUID
+	u.stableAfter = time.Duration(cfg.StableAfterHours) * time.Hour
+}
+
+// RolloutRing returns the ring cfg puts the machine in.
+func RolloutRing(cfg config.UpdatesConfig, hardwareUUID string) string {
+	switch {
+	case cfg.Ring != "":
+		return cfg.Ring
+	case cfg.CanaryPercent > 0:
+		h := fnv.New32a()
+		h.Write([]byte(strings.ToUpper(hardwareUUID)))
+		if int(h.Sum32()%100) < cfg.CanaryPercent {
+			return RingCanary
+		}
+		return RingStable
+	}
+	return RingCanary
+}
+
+// Ring returns the agent's rollout ring.
+func (u *Updater) Ring() string {
+	if u.ring == "" {
+		return RingCanary
+	}
+	return u.ring
+}
+
+// eligible reports whether this agent's channel and ring may install r
+// now, and it wasn't skipped.
+func (u *Updater) eligible(r *GitHubRelease, now time.Time) bool {
+	if !u.wanted(r) {
+		return false
+	}
+	switch rolloutMarker(r.Body) {
+	case "halt":
+		return false
+	case "stable":
+		return true
+	}
+	if r.isPrerelease() {
+		return true
+	}
+	if u.Ring() == RingCanary {
+		return true
+	}
+	return u.stableAfter > 0 && !r.PublishedAt.IsZero() && now.Sub(r.PublishedAt) >= u.stableAfter
+}
+
+// noteHeld records, once per release, that a newer release is waiting to
+// be marked stable.
+func (u *Updater) noteHeld(releases []GitHubRelease, installing *SemanticVersion) {
+	newest, v := newestRelease(releases, u.wanted)
+	if newest == nil || !u.isNewer(v) || (installing != nil && !v.GreaterThan(*installing)) || u.held == newest.TagName {
+		return
+	}
+	u.held = newest.TagName
+	if rolloutMarker(newest.Body) == "halt" {
+		events.Record(events.Lifecycle, events.Warning, "Release %s is halted; not installing it", newest.TagName)
+		return
+	}
+	events.Record(events.Lifecycle, events.Info, "Holding release %s until it is marked stable (%s ring)", newest.TagName, u.Ring())
+}
+
+// rolloutMarker returns the value of a "Rollout: <value>" line in release
+// notes, lowercased, or "".
+func rolloutMarker(notes string) string {
+	scanner := bufio.NewScanner(strings.NewReader(notes))
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.Trim(strings.TrimSpace(scanner.Text()), "*_`>-"))
+		if key, value, ok := strings.Cut(line, ":"); ok && strings.EqualFold(strings.TrimSpace(key), "rollout") {
+			return strings.ToLower(strings.Trim(strings.TrimSpace(value), "*_`"))
+		}
+	}
+	return ""
+}
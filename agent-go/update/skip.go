@@ -0,0 +1,148 @@
+package update
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/events"
+)
+
+// A release with a known problem can be skipped from the tray or POST
+// /updates/skip: the updater passes over it and takes the next release.
+// Skips are kept in skippedFile until undone. A release a check finds is
+// the candidate for candidateGrace before a later check installs it, so
+// there is time to skip it.
+const (
+	skippedFile    = "update-skipped.json"
+	candidateGrace = 10 * time.Minute
+)
+
+// Skip is a release version the updater won't install.
+type Skip struct {
+	Version string    `json:"version"`
+	By      string    `json:"by"`
+	At      time.Time `json:"at"`
+}
+
+// Skipped returns the skipped versions, oldest skip first.
+func (u *Updater) Skipped() []Skip {
+	u.skipMu.Lock()
+	defer u.skipMu.Unlock()
+	u.loadSkipped()
+	return u.sortedSkips()
+}
+
+// SkipVersion stops the updater installing version, e.g. "v1.4.2".
+func (u *Updater) SkipVersion(version, by string) error {
+	v := ParseVersion(version)
+	if v == nil {
+		return fmt.Errorf("%q is not a version", version)
+	}
+	u.skipMu.Lock()
+	u.loadSkipped()
+	if _, ok := u.skipped[v.String()]; ok {
+		u.skipMu.Unlock()
+		return nil
+	}
+	u.skipped[v.String()] = Skip{Version: v.String(), By: by, At: time.Now()}
+	if u.candidate == v.String() {
+		u.candidate = ""
+	}
+	u.saveSkipped()
+	u.skipMu.Unlock()
+	events.Record(events.RemoteAction, events.Info, "Update %s skipped by %s", v, by)
+	return nil
+}
+
+// UnskipVersion lets the updater install version again.
+func (u *Updater) UnskipVersion(version, by string) error {
+	v := ParseVersion(version)
+	if v == nil {
+		return fmt.Errorf("%q is not a version", version)
+	}
+	u.skipMu.Lock()
+	u.loadSkipped()
+	if _, ok := u.skipped[v.String()]; !ok {
+		u.skipMu.Unlock()
+		return nil
+	}
+	delete(u.skipped, v.String())
+	u.saveSkipped()
+	u.skipMu.Unlock()
+	events.Record(events.RemoteAction, events.Info, "Update %s no longer skipped, by %s", v, by)
+	return nil
+}
+
+// Candidate returns the update the last check found to install, or "":
+// what the tray offers to skip before it is installed.
+func (u *Updater) Candidate() string {
+	u.skipMu.Lock()
+	defer u.skipMu.Unlock()
+	return u.candidate
+}
+
+// offerCandidate records the update a check found and reports whether
+// it may be installed: it has been the candidate for candidateGrace, or
+// now is set.
+func (u *Updater) offerCandidate(version string, now bool) bool {
+	u.skipMu.Lock()
+	if version == u.candidate {
+		ready := now || time.Since(u.candidateAt) >= candidateGrace
+		u.skipMu.Unlock()
+		return ready
+	}
+	u.candidate, u.candidateAt = version, time.Now()
+	u.skipMu.Unlock()
+	if !now {
+		events.Record(events.Lifecycle, events.Info, "Update %s found; installing it in %d minutes unless it is skipped", version, int(candidateGrace.Minutes()))
+	}
+	return now
+}
+
+// clearCandidate records that the last check found nothing to install.
+func (u *Updater) clearCandidate() {
+	u.skipMu.Lock()
+	defer u.skipMu.Unlock()
+	u.candidate = ""
+}
+
+// isSkipped reports whether r's version was skipped.
+func (u *Updater) isSkipped(r *GitHubRelease) bool {
+	v := ParseVersion(r.TagName)
+	if v == nil {
+		return false
+	}
+	u.skipMu.Lock()
+	defer u.skipMu.Unlock()
+	u.loadSkipped()
+	_, ok := u.skipped[v.String()]
+	return ok
+}
+
+// loadSkipped reads skippedFile once. Call with skipMu held.
+func (u *Updater) loadSkipped() {
+	if u.skipped != nil {
+		return
+	}
+	u.skipped = map[string]Skip{}
+	var list []Skip
+	readJSON(skippedFile, &list)
+	for _, s := range list {
+		u.skipped[s.Version] = s
+	}
+}
+
+// saveSkipped writes skippedFile. Call with skipMu held.
+func (u *Updater) saveSkipped() {
+	writeJSON(skippedFile, u.sortedSkips())
+}
+
+func (u *Updater) sortedSkips() []Skip {
+	list := make([]Skip, 0, len(u.skipped))
+	for _, s := range u.skipped {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].At.Before(list[j].At) })
+	return list
+}
@@ -1,28 +1,41 @@
 package update
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"os"
+	"runtime"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/diag"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/events"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/schedule"
 )
 
 const (
 	owner         = "NorthwoodsCommunityChurch"
 	repo          = "AVL-Dashboard"
 	checkInterval = 30 * time.Minute
+	maxBackoff    = 4 * time.Hour
 	cacheDuration = 15 * time.Minute
 )
 
 // GitHubRelease represents a release from the GitHub API.
 type GitHubRelease struct {
-	TagName    string        `json:"tag_name"`
-	Name       string        `json:"name"`
-	Prerelease bool          `json:"prerelease"`
-	Assets     []GitHubAsset `json:"assets"`
+	TagName    string `json:"tag_name"`
+	Name       string `json:"name"`
+	Prerelease bool   `json:"prerelease"`
+	Body       string `json:"body"` // release notes
+	HTMLURL    string `json:"html_url"`
+	// PublishedAt is zero for a draft.
+	PublishedAt time.Time     `json:"published_at"`
+	Assets      []GitHubAsset `json:"assets"`
 }
 
 // GitHubAsset represents a downloadable file attached to a release.
@@ -30,12 +43,60 @@ type GitHubAsset struct {
 	Name               string `json:"name"`
 	BrowserDownloadURL string `json:"browser_download_url"`
 	Size               int    `json:"size"`
+	UpdatedAt          string `json:"updated_at"`
 }
 
 // Updater checks GitHub for new agent releases and applies them.
 type Updater struct {
 	currentVersion string
 	lastCheck      time.Time
+	hooksMu        sync.Mutex // guards onUpdate and onInstalled
+	onUpdate       func(from string, to ReleaseNotes)
+	onInstalled    func(notes ReleaseNotes)
+	onAssets       func(configChanged bool)
+	ring           string        // see SetRollout
+	stableAfter    time.Duration // see SetRollout
+	held           string        // release last reported as held
+	unwritable     bool          // reported that the binary can't be replaced
+	hardwareUUID   string
+	reportURL      string
+	reportToken    string
+	reportMu       sync.Mutex // one delivery at a time
+	resultMu       sync.Mutex
+	result         *Result // see LastUpdate
+	resultLoaded   bool
+	channelMu      sync.Mutex
+	channel        string // see Channel
+	onChannel      func(channel string)
+	skipMu         sync.Mutex
+	skipped        map[string]Skip // see Skipped
+	candidate      string          // see Candidate
+	candidateAt    time.Time       // when candidate was found
+}
+
+// beforeExit are run by exit; see BeforeExit.
+var (
+	beforeExitMu sync.Mutex
+	beforeExit   []func()
+)
+
+// BeforeExit registers fn to run before the process exits to relaunch, for
+// an update, a restart, or a rollback, so state kept in memory is saved.
+func BeforeExit(fn func()) {
+	beforeExitMu.Lock()
+	defer beforeExitMu.Unlock()
+	beforeExit = append(beforeExit, fn)
+}
+
+// exit runs the BeforeExit functions and exits.
+func exit() {
+	beforeExitMu.Lock()
+	fns := beforeExit
+	beforeExitMu.Unlock()
+	for _, fn := range fns {
+		fn()
+	}
+	os.Exit(0)
 }
 
 // NewUpdater creates an Updater for the given current version.
@@ -43,43 +104,139 @@ func NewUpdater(version string) *Updater {
 	return &Updater{currentVersion: version}
 }
 
-// StartPeriodicChecks runs update checks on a schedule. Blocks forever.
-func (u *Updater) StartPeriodicChecks() {
-	// Initial check after short delay
-	time.Sleep(5 * time.Second)
-	u.checkAndUpdate()
+// OnUpdate registers a callback run when an update is about to be
+// downloaded and applied, with the new release's notes.
+func (u *Updater) OnUpdate(fn func(from string, to ReleaseNotes)) {
+	u.hooksMu.Lock()
+	defer u.hooksMu.Unlock()
+	u.onUpdate = fn
+}
 
-	ticker := time.NewTicker(checkInterval)
-	defer ticker.Stop()
-	for range ticker.C {
-		u.checkAndUpdate()
-	}
+// StartPeriodicChecks runs update checks on a schedule until ctx is
+// cancelled, which also abandons a check or download in progress. Checks
+// are jittered so agents installed together don't poll GitHub together,
+// and back off while GitHub or the download keeps failing.
+func (u *Updater) StartPeriodicChecks(ctx context.Context) {
+	schedule.Loop(ctx, schedule.Policy{
+		First:      10 * time.Second,
+		Interval:   checkInterval,
+		Jitter:     0.2,
+		MaxBackoff: maxBackoff,
+	}, func() error {
+		return u.checkAndUpdate(ctx, false)
+	})
 }
 
-// ForceCheck clears the cache and checks immediately.
+// ForceCheck clears the cache and checks immediately. Someone asked for
+// it, so an update it finds is installed at once, without the grace
+// period a scheduled check gives for skipping it.
 func (u *Updater) ForceCheck() {
 	u.lastCheck = time.Time{}
-	u.checkAndUpdate()
+	u.checkAndUpdate(context.Background(), true)
 }
 
-func (u *Updater) checkAndUpdate() {
+// checkAndUpdate returns an error if the check, download, or install
+// failed, so the schedule can back off. Without an agent update to
+// install, it installs a new asset pack instead. forced installs an
+// update without its grace period.
+func (u *Updater) checkAndUpdate(ctx context.Context, forced bool) error {
 	if !u.lastCheck.IsZero() && time.Since(u.lastCheck) < cacheDuration {
-		return
+		return nil
 	}
 
-	releases, err := u.fetchReleases()
+	releases, err := u.fetchReleases(ctx)
 	if err != nil {
 		log.Printf("Update check failed: %v", err)
-		return
+		return err
 	}
 	u.lastCheck = time.Now()
+	go u.deliver()
+
+	now := time.Now()
+	bestRelease, bestVersion := newestRelease(releases, func(r *GitHubRelease) bool { return u.eligible(r, now) })
+	u.noteHeld(releases, bestVersion)
+	if bestRelease == nil || !u.isNewer(bestVersion) {
+		u.clearCandidate()
+		return u.checkAssets(ctx, releases)
+	}
+
+	// Find platform-specific agent asset
+	targetAsset := findAgentAsset(bestRelease.Assets)
+	if targetAsset == nil {
+		u.clearCandidate()
+		return u.checkAssets(ctx, releases)
+	}
+	// A version rolled back here isn't installed again.
+	if last := u.LastUpdate(); last != nil && last.Outcome == OutcomeRolledBack && last.To == bestVersion.String() {
+		u.clearCandidate()
+		return u.checkAssets(ctx, releases)
+	}
+	// Installed where this process can't write, updates come from
+	// whoever installed it (the MSI, or the service).
+	if err := replaceable(); err != nil {
+		if !u.unwritable {
+			u.unwritable = true
+			events.Record(events.Lifecycle, events.Warning, "Not installing %s: can't replace the agent binary: %v", bestVersion, err)
+		}
+		u.clearCandidate()
+		return u.checkAssets(ctx, releases)
+	}
+	// Offered first, so it can still be skipped; installed by a check
+	// once the grace period is over, or by a forced check at once.
+	if !u.offerCandidate(bestVersion.String(), forced) {
+		return u.checkAssets(ctx, releases)
+	}
+
+	events.Record(events.Lifecycle, events.Info, "Updating from %s to %s...", u.currentVersion, bestVersion)
+	notes := stageNotes(bestRelease, bestVersion)
+	u.hooksMu.Lock()
+	onUpdate := u.onUpdate
+	u.hooksMu.Unlock()
+	if onUpdate != nil {
+		onUpdate(u.currentVersion, notes)
+	}
+	started := time.Now()
+	zipData, err := u.downloadAsset(ctx, targetAsset.BrowserDownloadURL)
+	if err != nil {
+		u.fail(bestVersion.String(), started, fmt.Errorf("download: %w", err))
+		return err
+	}
+
+	if err := backupCurrent(); err != nil {
+		log.Printf("Update: keeping the current binary for rollback: %v", err)
+	}
+	u.begin(bestVersion.String(), started)
+	if err := u.applyUpdate(zipData); err != nil {
+		u.fail(bestVersion.String(), started, fmt.Errorf("apply: %w", err))
+		return err
+	}
+	return nil
+}
+
+// Latest reports the newest release this agent's rollout ring may install
+// and whether it would be: newer than the running version, with an agent
+// build for this platform. It only checks; nothing is downloaded.
+func (u *Updater) Latest() (version string, available bool, err error) {
+	releases, err := u.fetchReleases(context.Background())
+	if err != nil {
+		return "", false, err
+	}
+	now := time.Now()
+	best, bestVersion := newestRelease(releases, func(r *GitHubRelease) bool { return u.eligible(r, now) })
+	if best == nil {
+		return "", false, nil
+	}
+	return bestVersion.String(), u.isNewer(bestVersion) && findAgentAsset(best.Assets) != nil, nil
+}
 
-	// Find the newest version across all releases
+// newestRelease returns the release with the highest version tag among
+// those keep accepts; a nil keep accepts all.
+func newestRelease(releases []GitHubRelease, keep func(*GitHubRelease) bool) (*GitHubRelease, *SemanticVersion) {
 	var bestRelease *GitHubRelease
 	var bestVersion *SemanticVersion
 	for i := range releases {
 		v := ParseVersion(releases[i].TagName)
-		if v == nil {
+		if v == nil || (keep != nil && !keep(&releases[i])) {
 			continue
 		}
 		if bestVersion == nil || v.GreaterThan(*bestVersion) {
@@ -87,37 +244,19 @@ func (u *Updater) checkAndUpdate() {
 			bestVersion = v
 		}
 	}
+	return bestRelease, bestVersion
+}
 
-	if bestRelease == nil || bestVersion == nil {
-		return
-	}
-
+// isNewer reports whether v is newer than the running version. A dev
+// build never updates.
+func (u *Updater) isNewer(v *SemanticVersion) bool {
 	current := ParseVersion(u.currentVersion)
-	if current == nil || !bestVersion.GreaterThan(*current) {
-		return
-	}
-
-	// Find platform-specific agent asset
-	targetAsset := findAgentAsset(bestRelease.Assets)
-	if targetAsset == nil {
-		return
-	}
-
-	log.Printf("Updating from %s to %s...", u.currentVersion, bestVersion)
-	zipData, err := u.downloadAsset(targetAsset.BrowserDownloadURL)
-	if err != nil {
-		log.Printf("Download failed: %v", err)
-		return
-	}
-
-	if err := u.applyUpdate(zipData); err != nil {
-		log.Printf("Update apply failed: %v", err)
-	}
+	return current != nil && v.GreaterThan(*current)
 }
 
-func (u *Updater) fetchReleases() ([]GitHubRelease, error) {
+func (u *Updater) fetchReleases(ctx context.Context) ([]GitHubRelease, error) {
 	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/releases", owner, repo)
-	req, _ := http.NewRequest("GET", url, nil)
+	req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
 	req.Header.Set("Accept", "application/vnd.github+json")
 
 	client := &http.Client{Timeout: 15 * time.Second}
@@ -138,9 +277,13 @@ func (u *Updater) fetchReleases() ([]GitHubRelease, error) {
 	return releases, nil
 }
 
-func (u *Updater) downloadAsset(url string) ([]byte, error) {
+func (u *Updater) downloadAsset(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
 	client := &http.Client{Timeout: 120 * time.Second}
-	resp, err := client.Get(url)
+	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -153,10 +296,28 @@ func (u *Updater) downloadAsset(url string) ([]byte, error) {
 	return io.ReadAll(resp.Body)
 }
 
-// matchesAgentAsset checks if an asset name matches a platform keyword.
-func matchesAgentAsset(name, platform string) bool {
+// agentAsset returns the agent zip for platform and the machine's native
+// architecture, else the running build's. An amd64 agent emulated on
+// Windows on ARM moves to the arm64 build this way.
+func agentAsset(assets []GitHubAsset, platform string) *GitHubAsset {
+	for _, arch := range []string{diag.NativeArch(), runtime.GOARCH} {
+		for i := range assets {
+			if matchesAgentAsset(assets[i].Name, platform, arch) {
+				return &assets[i]
+			}
+		}
+	}
+	return nil
+}
+
+// matchesAgentAsset checks if an asset name matches a platform keyword,
+// an architecture, and this build's flavor: a lite agent stays lite and a
+// full one full.
+func matchesAgentAsset(name, platform, arch string) bool {
 	lower := strings.ToLower(name)
 	return strings.Contains(lower, platform) &&
+		strings.Contains(lower, "-"+arch) &&
 		strings.Contains(lower, "agent") &&
+		strings.Contains(lower, "-lite") == lite &&
 		strings.HasSuffix(lower, ".zip")
 }
@@ -12,30 +12,18 @@ import (
 	"path/filepath"
 	"strings"
 	"syscall"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/watchdog"
 )
 
 // findAgentAsset returns the Linux agent zip from a release's assets.
 func findAgentAsset(assets []GitHubAsset) *GitHubAsset {
-	for i := range assets {
-		if matchesAgentAsset(assets[i].Name, "linux") {
-			return &assets[i]
-		}
-	}
-	return nil
+	return agentAsset(assets, "linux")
 }
 
-// applyUpdate extracts the new binary from the zip, writes a shell trampoline
-// that replaces the running binary and restarts the systemd service.
+// applyUpdate extracts the new binary from the zip and relaunches through a
+// trampoline that replaces the running binary and restarts the service.
 func (u *Updater) applyUpdate(zipData []byte) error {
-	currentExe, err := os.Executable()
-	if err != nil {
-		return err
-	}
-	currentExe, err = filepath.EvalSymlinks(currentExe)
-	if err != nil {
-		return err
-	}
-
 	tempDir, err := os.MkdirTemp("", "avl-agent-update-*")
 	if err != nil {
 		return err
@@ -74,16 +62,58 @@ func (u *Updater) applyUpdate(zipData []byte) error {
 		return fmt.Errorf("no binary found in update zip")
 	}
 
+	return relaunch(tempDir, newBinPath)
+}
+
+// Restart relaunches the agent. Under systemd (Restart=always) exiting is
+// enough; otherwise the same trampoline an update uses starts the binary
+// again. Does not return on success.
+func Restart() error {
+	if os.Getenv("INVOCATION_ID") != "" {
+		exit()
+	}
+	tempDir, err := os.MkdirTemp("", "avl-agent-restart-*")
+	if err != nil {
+		return err
+	}
+	return relaunch(tempDir, "")
+}
+
+// relaunch writes a shell trampoline into tempDir that waits for this
+// process to exit, copies newBinPath over the running binary if set,
+// restarts the agent, and removes tempDir. Then it terminates this process.
+func relaunch(tempDir, newBinPath string) error {
+	currentExe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	currentExe, err = filepath.EvalSymlinks(currentExe)
+	if err != nil {
+		return err
+	}
+
+	var replace string
+	if newBinPath != "" {
+		replace = fmt.Sprintf("cp -f %q %q\nchmod +x %q\n", newBinPath, currentExe, currentExe)
+	}
+	args := make([]string, 0, len(os.Args))
+	for _, arg := range os.Args[1:] {
+		args = append(args, fmt.Sprintf("%q", arg))
+	}
+
 	// Write shell trampoline that waits for this process to exit,
 	// replaces the binary, restarts the service, and cleans up.
-	scriptPath := filepath.Join(tempDir, "update.sh")
+	// Outside systemd the binary is started directly in its own session.
+	scriptPath := filepath.Join(tempDir, "relaunch.sh")
 	scriptContent := fmt.Sprintf(`#!/bin/bash
-sleep 2
-cp -f "%s" "%s"
-chmod +x "%s"
-systemctl restart dashboard-agent 2>/dev/null || true
-rm -rf "%s"
-`, newBinPath, currentExe, currentExe, tempDir)
+while kill -0 %d 2>/dev/null; do sleep 1; done
+%sif [ -n "$INVOCATION_ID" ]; then
+    systemctl restart dashboard-agent 2>/dev/null || true
+else
+    setsid %q %s </dev/null >/dev/null 2>&1 &
+fi
+rm -rf %q
+`, os.Getpid(), replace, currentExe, strings.Join(args, " "), tempDir)
 
 	if err := os.WriteFile(scriptPath, []byte(scriptContent), 0755); err != nil {
 		return err
@@ -92,10 +122,11 @@ rm -rf "%s"
 	// Launch trampoline detached (new session so it survives our exit)
 	cmd := exec.Command("bash", scriptPath)
 	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	cmd.Env = watchdog.UnsupervisedEnv()
 	if err := cmd.Start(); err != nil {
 		return err
 	}
 
-	os.Exit(0)
+	exit()
 	return nil // unreachable
 }
@@ -11,30 +11,19 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/config"
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/watchdog"
 )
 
 // findAgentAsset returns the Windows agent zip from a release's assets.
 func findAgentAsset(assets []GitHubAsset) *GitHubAsset {
-	for i := range assets {
-		if matchesAgentAsset(assets[i].Name, "windows") {
-			return &assets[i]
-		}
-	}
-	return nil
+	return agentAsset(assets, "windows")
 }
 
-// applyUpdate extracts the new exe from the zip, writes a batch trampoline
-// that replaces the running binary after exit, then terminates this process.
+// applyUpdate extracts the new exe from the zip and relaunches through a
+// trampoline that replaces the running binary after exit.
 func (u *Updater) applyUpdate(zipData []byte) error {
-	currentExe, err := os.Executable()
-	if err != nil {
-		return err
-	}
-	currentExe, err = filepath.EvalSymlinks(currentExe)
-	if err != nil {
-		return err
-	}
-
 	tempDir, err := os.MkdirTemp("", "avl-agent-update-*")
 	if err != nil {
 		return err
@@ -70,29 +59,93 @@ func (u *Updater) applyUpdate(zipData []byte) error {
 		return fmt.Errorf("no .exe found in update zip")
 	}
 
-	pid := os.Getpid()
-	batPath := filepath.Join(tempDir, "update.bat")
-	batContent := fmt.Sprintf(`@echo off
-:waitloop
+	return relaunch(tempDir, newExePath)
+}
+
+// Restart relaunches the agent through the same trampoline an update uses,
+// without replacing the binary. Does not return on success.
+func Restart() error {
+	tempDir, err := os.MkdirTemp("", "avl-agent-restart-*")
+	if err != nil {
+		return err
+	}
+	return relaunch(tempDir, "")
+}
+
+// relaunch writes a batch trampoline into tempDir that waits for this
+// process (and the watchdog supervisor running from the same exe) to exit,
+// copies newExePath over the running binary if set, starts the agent
+// again (through the service manager when running as a service), and
+// removes tempDir. Then it terminates this process.
+func relaunch(tempDir, newExePath string) error {
+	currentExe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	currentExe, err = filepath.EvalSymlinks(currentExe)
+	if err != nil {
+		return err
+	}
+
+	pids := []int{os.Getpid()}
+	if watchdog.Supervised() {
+		pids = append(pids, os.Getppid())
+	}
+	var waits strings.Builder
+	for i, pid := range pids {
+		fmt.Fprintf(&waits, `:waitloop%d
 tasklist /FI "PID eq %d" 2>NUL | find /I "%d" >NUL
 if not errorlevel 1 (
     timeout /t 1 /nobreak >NUL
-    goto waitloop
+    goto waitloop%d
 )
-copy /Y "%s" "%s"
-start "" "%s"
-rmdir /S /Q "%s"
-`, pid, pid, newExePath, currentExe, currentExe, tempDir)
+`, i, pid, pid, i)
+	}
+
+	// The copy is retried while antivirus or a slow exit still holds the
+	// exe; if it never succeeds, the old binary starts again and reads
+	// the error from copyErrorFile.
+	var replace string
+	if newExePath != "" {
+		replace = fmt.Sprintf(`set tries=0
+:copyloop
+copy /Y "%[1]s" "%[2]s" >NUL && goto copied
+set /a tries+=1
+if %%tries%% GEQ 5 goto copyfailed
+timeout /t 2 /nobreak >NUL
+goto copyloop
+:copyfailed
+echo Could not replace "%[2]s" > "%[3]s"
+:copied
+`, newExePath, currentExe, filepath.Join(config.DataDir(), copyErrorFile))
+	}
+	var args strings.Builder
+	for _, arg := range os.Args[1:] {
+		fmt.Fprintf(&args, ` "%s"`, arg)
+	}
+
+	// A service is started through the service manager so it comes back
+	// as a service, not as a process in the trampoline's session.
+	start := fmt.Sprintf("start \"\" \"%s\"%s\n", currentExe, args.String())
+	if watchdog.RunningAsService() {
+		start = fmt.Sprintf("net start %s\n", watchdog.ServiceName)
+	}
+
+	batPath := filepath.Join(tempDir, "relaunch.bat")
+	batContent := fmt.Sprintf(`@echo off
+%s%s%srmdir /S /Q "%s"
+`, waits.String(), replace, start, tempDir)
 
 	if err := os.WriteFile(batPath, []byte(batContent), 0755); err != nil {
 		return err
 	}
 
 	cmd := exec.Command("cmd.exe", "/C", "start", "/B", batPath)
+	cmd.Env = watchdog.UnsupervisedEnv()
 	if err := cmd.Start(); err != nil {
 		return err
 	}
 
-	os.Exit(0)
+	exit()
 	return nil // unreachable
 }
@@ -0,0 +1,39 @@
+//go:build linux
+
+package watchdog
+
+import (
+	"net"
+	"os"
+	"time"
+)
+
+// Notify sends a state string (e.g. "READY=1") to systemd. No-op when not
+// running under a Type=notify unit.
+func Notify(state string) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	conn.Write([]byte(state))
+}
+
+// PingSystemd tells systemd the agent is ready, then keeps petting the
+// systemd watchdog for as long as /healthz answers. If the agent hangs, the
+// pings stop and systemd restarts the unit (see WatchdogSec in the unit
+// file). Blocks forever.
+func PingSystemd(port uint16) {
+	Notify("READY=1")
+	ticker := time.NewTicker(probeInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if Probe(port) {
+			Notify("WATCHDOG=1")
+		}
+	}
+}
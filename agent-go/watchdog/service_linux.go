@@ -0,0 +1,9 @@
+//go:build linux
+
+package watchdog
+
+// RunningAsService is false: under systemd the agent runs as a plain
+// process.
+func RunningAsService() bool {
+	return false
+}
@@ -0,0 +1,53 @@
+//go:build windows
+
+package watchdog
+
+import (
+	"sync"
+
+	"golang.org/x/sys/windows/svc"
+)
+
+// ServiceName is the Windows service the installer registers. As a
+// service the agent runs headless and the service manager's recovery
+// actions replace the supervisor process.
+const ServiceName = "AVLDashboardAgent"
+
+var serviceCheck = sync.OnceValue(func() bool {
+	ok, _ := svc.IsWindowsService()
+	return ok
+})
+
+// RunningAsService reports whether the service manager started this process.
+func RunningAsService() bool {
+	return serviceCheck()
+}
+
+// RunService reports the service as running once start returns, and calls
+// stop when the service manager asks the agent to stop or the machine
+// shuts down. Blocks until then.
+func RunService(start, stop func()) error {
+	return svc.Run(ServiceName, &service{start: start, stop: stop})
+}
+
+type service struct {
+	start, stop func()
+}
+
+func (s *service) Execute(_ []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	status <- svc.Status{State: svc.StartPending}
+	s.start()
+	status <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+
+	for req := range requests {
+		switch req.Cmd {
+		case svc.Interrogate:
+			status <- req.CurrentStatus
+		case svc.Stop, svc.Shutdown:
+			status <- svc.Status{State: svc.StopPending}
+			s.stop()
+			return false, 0
+		}
+	}
+	return false, 0
+}
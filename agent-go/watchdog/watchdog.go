@@ -0,0 +1,174 @@
+// Package watchdog keeps the agent alive: it restarts the agent when the
+// process exits unexpectedly or /healthz stops answering, backing off when
+// the agent is crash-looping.
+package watchdog
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/NorthwoodsCommunityChurch/AVL-Dashboard/agent-go/events"
+)
+
+// ChildEnv is set in the environment of a supervised agent process.
+const ChildEnv = "AVL_AGENT_SUPERVISED"
+
+const (
+	probeInterval  = 15 * time.Second
+	probeTimeout   = 5 * time.Second
+	maxProbeFails  = 4 // one minute of failed probes before a restart
+	minBackoff     = 2 * time.Second
+	maxBackoff     = 5 * time.Minute
+	stableAfter    = 10 * time.Minute // uptime that resets the backoff
+	portLinePrefix = "port="
+	startupGrace   = 60 * time.Second
+)
+
+// Supervised reports whether this process was started by the watchdog.
+func Supervised() bool {
+	return os.Getenv(ChildEnv) != ""
+}
+
+// UnsupervisedEnv returns this process's environment without ChildEnv,
+// for relaunching the agent: the supervisor has exited with it, so the
+// new process must start its own.
+func UnsupervisedEnv() []string {
+	env := os.Environ()
+	return slices.DeleteFunc(env, func(kv string) bool {
+		return strings.HasPrefix(kv, ChildEnv+"=")
+	})
+}
+
+// ReportPort tells the supervising watchdog which port to probe. No-op when
+// the agent isn't supervised.
+func ReportPort(port uint16) {
+	if Supervised() {
+		fmt.Fprintf(os.Stdout, "%s%d\n", portLinePrefix, port)
+	}
+}
+
+// Probe returns true if the agent on 127.0.0.1:port answers /healthz with 200.
+func Probe(port uint16) bool {
+	client := &http.Client{Timeout: probeTimeout}
+	resp, err := client.Get(fmt.Sprintf("http://127.0.0.1:%d/healthz", port))
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
+
+// Supervise runs the current executable as a child with args and restarts it
+// until it exits cleanly (status 0: tray Quit or self-update). Returns the
+// exit code the supervisor should use.
+func Supervise(args []string) int {
+	exe, err := os.Executable()
+	if err != nil {
+		log.Printf("Watchdog: cannot locate executable: %v", err)
+		return 1
+	}
+
+	backoff := minBackoff
+	for {
+		started := time.Now()
+		code, err := runChild(exe, args)
+		if err != nil {
+			log.Printf("Watchdog: failed to start agent: %v", err)
+		} else if code == 0 {
+			log.Println("Watchdog: agent exited cleanly, stopping")
+			return 0
+		} else {
+			events.Record(events.Lifecycle, events.Warning, "Watchdog: agent exited with status %d after %s", code, time.Since(started).Round(time.Second))
+		}
+
+		if time.Since(started) > stableAfter {
+			backoff = minBackoff
+		}
+		log.Printf("Watchdog: restarting agent in %s", backoff)
+		time.Sleep(backoff)
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// runChild starts one agent process, probes it once it reports its port,
+// and kills it if it stops answering. Returns the child's exit code.
+func runChild(exe string, args []string) (int, error) {
+	cmd := exec.Command(exe, args...)
+	cmd.Env = append(os.Environ(), ChildEnv+"=1")
+	cmd.Stderr = os.Stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return -1, err
+	}
+	if err := cmd.Start(); err != nil {
+		return -1, err
+	}
+
+	var port atomic.Uint32
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			if v, ok := strings.CutPrefix(scanner.Text(), portLinePrefix); ok {
+				if p, err := strconv.ParseUint(v, 10, 16); err == nil {
+					port.Store(uint32(p))
+				}
+			}
+		}
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(probeInterval)
+		defer ticker.Stop()
+		started := time.Now()
+		fails := 0
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			p := uint16(port.Load())
+			if p == 0 {
+				if time.Since(started) > startupGrace {
+					events.Record(events.Lifecycle, events.Error, "Watchdog: agent never reported a port, killing it")
+					cmd.Process.Kill()
+					return
+				}
+				continue
+			}
+			if Probe(p) {
+				fails = 0
+				continue
+			}
+			fails++
+			if fails >= maxProbeFails {
+				events.Record(events.Lifecycle, events.Error, "Watchdog: /healthz failed %d times, killing hung agent", fails)
+				cmd.Process.Kill()
+				return
+			}
+		}
+	}()
+
+	err = cmd.Wait()
+	close(done)
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return -1, err
+	}
+	return 0, nil
+}
@@ -0,0 +1,24 @@
+// Package winupdate reports Windows Update scheduling policy and lets the
+// agent pause updates so no forced restart lands during a service.
+package winupdate
+
+import (
+	"errors"
+	"time"
+)
+
+// MaxPause is the longest pause Windows Update accepts in one request.
+const MaxPause = 35 * 24 * time.Hour
+
+// ErrUnsupported is returned on platforms without Windows Update.
+var ErrUnsupported = errors.New("windows update control is not supported on this platform")
+
+// Status describes when Windows Update may install and restart.
+type Status struct {
+	ActiveHoursStart    int    `json:"activeHoursStart"` // hour of day, 0-23
+	ActiveHoursEnd      int    `json:"activeHoursEnd"`
+	PausedUntil         string `json:"pausedUntil,omitempty"` // RFC 3339, empty if not paused
+	FeatureDeferralDays int    `json:"featureDeferralDays"`
+	QualityDeferralDays int    `json:"qualityDeferralDays"`
+	RebootRequired      bool   `json:"rebootRequired"`
+}
@@ -0,0 +1,20 @@
+//go:build linux
+
+package winupdate
+
+import "time"
+
+// Read returns nil; there is no Windows Update on Linux.
+func Read() *Status {
+	return nil
+}
+
+// Pause is unsupported on Linux.
+func Pause(until time.Time) error {
+	return ErrUnsupported
+}
+
+// Resume is unsupported on Linux.
+func Resume() error {
+	return ErrUnsupported
+}
@@ -0,0 +1,105 @@
+//go:build windows
+
+package winupdate
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+const (
+	uxSettingsKey     = `SOFTWARE\Microsoft\WindowsUpdate\UX\Settings`
+	policyKey         = `SOFTWARE\Policies\Microsoft\Windows\WindowsUpdate`
+	rebootRequiredKey = `SOFTWARE\Microsoft\Windows\CurrentVersion\WindowsUpdate\Auto Update\RebootRequired`
+)
+
+// pauseValues are the UX settings the Settings app writes for "Pause updates".
+var pauseValues = [][2]string{
+	{"PauseUpdatesStartTime", "PauseUpdatesExpiryTime"},
+	{"PauseFeatureUpdatesStartTime", "PauseFeatureUpdatesEndTime"},
+	{"PauseQualityUpdatesStartTime", "PauseQualityUpdatesEndTime"},
+}
+
+// Read returns the current Windows Update schedule, or nil if the settings
+// key is missing (e.g. Windows Server without the UX components).
+func Read() *Status {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, uxSettingsKey, registry.QUERY_VALUE)
+	if err != nil {
+		return nil
+	}
+	defer k.Close()
+
+	s := &Status{}
+	if v, _, err := k.GetIntegerValue("ActiveHoursStart"); err == nil {
+		s.ActiveHoursStart = int(v)
+	}
+	if v, _, err := k.GetIntegerValue("ActiveHoursEnd"); err == nil {
+		s.ActiveHoursEnd = int(v)
+	}
+	if v, _, err := k.GetStringValue("PauseUpdatesExpiryTime"); err == nil {
+		if until, err := time.Parse(time.RFC3339, v); err == nil && until.After(time.Now()) {
+			s.PausedUntil = until.Format(time.RFC3339)
+		}
+	}
+
+	if p, err := registry.OpenKey(registry.LOCAL_MACHINE, policyKey, registry.QUERY_VALUE); err == nil {
+		if v, _, err := p.GetIntegerValue("DeferFeatureUpdatesPeriodInDays"); err == nil {
+			s.FeatureDeferralDays = int(v)
+		}
+		if v, _, err := p.GetIntegerValue("DeferQualityUpdatesPeriodInDays"); err == nil {
+			s.QualityDeferralDays = int(v)
+		}
+		p.Close()
+	}
+
+	if r, err := registry.OpenKey(registry.LOCAL_MACHINE, rebootRequiredKey, registry.QUERY_VALUE); err == nil {
+		s.RebootRequired = true
+		r.Close()
+	}
+	return s
+}
+
+// Pause suspends feature and quality updates until the given time.
+// Requires the agent to run elevated.
+func Pause(until time.Time) error {
+	if d := time.Until(until); d <= 0 || d > MaxPause {
+		return fmt.Errorf("pause must end within %d days", int(MaxPause.Hours()/24))
+	}
+	k, _, err := registry.CreateKey(registry.LOCAL_MACHINE, uxSettingsKey, registry.SET_VALUE)
+	if err != nil {
+		return err
+	}
+	defer k.Close()
+
+	start := time.Now().UTC().Format(time.RFC3339)
+	end := until.UTC().Format(time.RFC3339)
+	for _, pair := range pauseValues {
+		if err := k.SetStringValue(pair[0], start); err != nil {
+			return err
+		}
+		if err := k.SetStringValue(pair[1], end); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Resume clears any pause so updates install normally again.
+func Resume() error {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, uxSettingsKey, registry.SET_VALUE)
+	if err != nil {
+		return err
+	}
+	defer k.Close()
+
+	for _, pair := range pauseValues {
+		for _, name := range pair {
+			if err := k.DeleteValue(name); err != nil && err != registry.ErrNotExist {
+				return err
+			}
+		}
+	}
+	return nil
+}